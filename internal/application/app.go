@@ -5,15 +5,24 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"lesson1/internal/cli"
 	"lesson1/internal/compute"
 	"lesson1/internal/config"
+	"lesson1/internal/database/compression"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/notify"
 	"lesson1/internal/database/storage"
 	"lesson1/internal/database/storage/engine"
+	"lesson1/internal/database/storage/lsm"
+	"lesson1/internal/database/storage/wal"
 	"lesson1/internal/lib/logger/slogdiscard"
 	"lesson1/internal/lib/logger/slogpretty"
+	"lesson1/internal/network"
+	"lesson1/internal/pubsub"
+	"lesson1/internal/replication"
 )
 
 type App struct{}
@@ -28,6 +37,17 @@ const (
 	envProd  = "prod"
 )
 
+const (
+	engineTypeInMemory = "in_memory"
+	engineTypeOrdered  = "ordered"
+	engineTypeLSM      = "lsm"
+)
+
+const (
+	replicaTypeMaster  = "master"
+	replicaTypeReplica = "replica"
+)
+
 func (a *App) Run() {
 	rootCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -38,42 +58,295 @@ func (a *App) Run() {
 	log.Info("starting service", slog.String("env", cfg.Env))
 	log.Debug("debug message are enabled")
 
-	engine := engine.NewEngine(log)
-	storage := storage.NewStorage(log, engine)
+	isReplica := cfg.Replication.ReplicaType == replicaTypeReplica
+
+	policy, err := hashtable.ParseEvictionPolicy(cfg.Engine.EvictionPolicy)
+	if err != nil {
+		log.Error("invalid engine config", slog.Any("error", err))
+		return
+	}
+
+	codec, err := compression.ParseCodec(cfg.Compression.Codec)
+	if err != nil {
+		log.Error("invalid compression config", slog.Any("error", err))
+		return
+	}
+	compressor := compression.NewCompressor(codec, cfg.Compression.Threshold)
+
+	engineOpts := []engine.Option{
+		engine.WithShardCount(cfg.Engine.ShardCount),
+		engine.WithMaxMemory(cfg.Engine.MaxMemory, policy),
+		engine.WithCompression(compressor),
+	}
+	lsmOpts := []lsm.Option{
+		lsm.WithMemtableSize(cfg.Engine.LSM.MemtableSize),
+		lsm.WithTableSize(cfg.Engine.LSM.TableSize),
+		lsm.WithLevel0Tables(cfg.Engine.LSM.Level0Tables),
+		lsm.WithCompression(compressor),
+	}
+	switch cfg.Engine.Type {
+	case engineTypeInMemory, engineTypeLSM:
+	case engineTypeOrdered:
+		engineOpts = append(engineOpts, engine.WithOrderedIndex())
+	default:
+		log.Error("invalid engine config", slog.String("type", cfg.Engine.Type))
+		return
+	}
+
+	overflow, err := pubsub.ParseOverflowPolicy(cfg.PubSub.OverflowPolicy)
+	if err != nil {
+		log.Error("invalid pubsub config", slog.Any("error", err))
+		return
+	}
+	broker := pubsub.NewBroker(pubsub.WithBufferSize(cfg.PubSub.BufferSize), pubsub.WithOverflowPolicy(overflow))
+
+	var notifier *notify.Notifier
+	if cfg.PubSub.KeyspaceEvents {
+		notifier = notify.NewNotifier(notify.WithBroker(broker))
+		engineOpts = append(engineOpts, engine.WithListener(notifier.Notify))
+		lsmOpts = append(lsmOpts, lsm.WithListener(notifier.Notify))
+	}
+
+	var (
+		eng     *engine.Engine
+		tree    *lsm.Engine
+		backend interface {
+			storage.CommandStorage
+			storage.QueryStorage
+		}
+	)
+	if cfg.Engine.Type == engineTypeLSM {
+		var err error
+		tree, err = lsm.Open(log, cfg.Engine.LSM.Directory, lsmOpts...)
+		if err != nil {
+			log.Error("lsm open failed", slog.Any("error", err))
+			return
+		}
+		defer func() {
+			if err := tree.Close(); err != nil {
+				log.Error("lsm close failed", slog.Any("error", err))
+			}
+		}()
+		backend = tree
+	} else {
+		eng = engine.NewEngine(log, engineOpts...)
+		backend = eng
+	}
+
+	var (
+		storageOpts   []storage.Option
+		writeAheadLog *wal.WAL
+	)
+
+	switch {
+	case isReplica:
+		// a replica rebuilds its state from the master log on every start
+		storageOpts = append(storageOpts, storage.WithReadOnly())
+		if tree != nil {
+			if err := tree.Flush(rootCtx); err != nil {
+				log.Error("lsm flush failed", slog.Any("error", err))
+				return
+			}
+		}
+	case cfg.WAL.DataDirectory != "":
+		corruption, err := wal.ParseCorruptionPolicy(cfg.WAL.CorruptionPolicy)
+		if err != nil {
+			log.Error("invalid wal config", slog.Any("error", err))
+			return
+		}
+		writeAheadLog, err = wal.New(log, cfg.WAL.DataDirectory,
+			wal.WithMaxSegmentSize(cfg.WAL.MaxSegmentSize),
+			wal.WithBatchSize(cfg.WAL.FlushingBatchSize),
+			wal.WithBatchTimeout(cfg.WAL.FlushingBatchTimeout),
+			wal.WithCorruptionPolicy(corruption),
+			wal.WithCompression(compressor),
+		)
+		if err != nil {
+			log.Error("wal open failed", slog.Any("error", err))
+			return
+		}
+		defer func() {
+			if err := writeAheadLog.Close(); err != nil {
+				log.Error("wal close failed", slog.Any("error", err))
+			}
+		}()
+		storageOpts = append(storageOpts, storage.WithWAL(writeAheadLog))
+		if tree != nil {
+			storageOpts = append(storageOpts, storage.WithCheckpointer(tree))
+		}
+	}
+	if eng != nil {
+		storageOpts = append(storageOpts, storage.WithEvictor(eng))
+	}
+
+	store := storage.NewStorage(log, backend, storageOpts...)
+	if err := store.Recover(rootCtx); err != nil {
+		log.Error("recovery failed", slog.Any("error", err))
+		return
+	}
+
+	validation, err := validationPolicy(cfg.Validation)
+	if err != nil {
+		log.Error("invalid validation config", slog.Any("error", err))
+		return
+	}
+
+	// every cli or network client gets its own transaction session
+	newSession := compute.NewSessionContext
+	compute := compute.NewCompute(log, store, compute.WithPolicy(validation), compute.WithBroker(broker))
+
+	var (
+		cliDone  <-chan struct{}
+		cliErr   <-chan error
+		services []<-chan error
+	)
+
+	if cfg.Cli.Enabled {
+		cli := cli.NewCli(log, compute, cli.WithSessionContext(newSession))
+		cliCtx, errCh := cli.Start(rootCtx)
+		cliDone, cliErr = cliCtx.Done(), errCh
+	}
+
+	if notifier != nil {
+		_, errCh := notifier.Start(rootCtx)
+		services = append(services, errCh)
+	}
 
-	_ = engine
-	_ = storage
+	// the lsm engine drops expired keys when it compacts them
+	if eng != nil {
+		sweeper := engine.NewSweeper(log, eng, cfg.Engine.ExpirationSweepInterval, cfg.Engine.ExpirationSampleSize)
+		_, sweeperErr := sweeper.Start(rootCtx)
+		services = append(services, sweeperErr)
+	}
 
-	compute := compute.NewCompute(log, storage)
+	// the tables of the lsm engine hold its state, so the WAL is cut at their
+	// checkpoint instead of being snapshotted; a master keeps its whole log,
+	// as it has no snapshot to serve the replicas that miss its start
+	isMaster := !isReplica && cfg.Replication.MasterAddress != ""
+	switch {
+	case writeAheadLog == nil || cfg.WAL.SnapshotInterval <= 0:
+	case eng != nil:
+		snapshotter := storage.NewSnapshotter(log, store, cfg.WAL.SnapshotInterval)
+		_, errCh := snapshotter.Start(rootCtx)
+		services = append(services, errCh)
+	case !isMaster:
+		truncator := storage.NewLogTruncator(log, store, cfg.WAL.SnapshotInterval)
+		_, errCh := truncator.Start(rootCtx)
+		services = append(services, errCh)
+	}
 
-	cli := cli.NewCli(log, compute)
-	cliCtx, cliErr := cli.Start(rootCtx)
+	if cfg.Network.Address != "" {
+		opts := append(networkOptions(cfg.Network), network.WithSessionContext(newSession))
+		server := network.NewServer(log, compute, cfg.Network.Address, opts...)
+		_, errCh := server.Start(rootCtx)
+		services = append(services, errCh)
+	}
+
+	switch {
+	case isReplica && cfg.Replication.MasterAddress != "":
+		replica := replication.NewReplica(log, store, cfg.Replication.MasterAddress, cfg.Replication.SyncInterval)
+		_, errCh := replica.Start(rootCtx)
+		services = append(services, errCh)
+	case !isReplica && cfg.Replication.MasterAddress != "" && writeAheadLog != nil:
+		master := replication.NewMaster(log, writeAheadLog)
+		server := network.NewServer(log, master, cfg.Replication.MasterAddress, networkOptions(cfg.Network)...)
+		_, errCh := server.Start(rootCtx)
+		services = append(services, errCh)
+	case !isReplica && cfg.Replication.MasterAddress != "":
+		log.Warn("replication master requires the wal, replication disabled")
+	}
 
-	// _ = compute
+	serviceErr := mergeErrors(services...)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	waitForShutdown(cliCtx, cancel, log, cliErr, stop)
+	waitForShutdown(cancel, log, cliDone, cliErr, serviceErr, stop)
+
+	// every service closes its error channel only after it has fully stopped
+	if serviceErr != nil {
+		for err := range serviceErr {
+			log.Error("service error", slog.Any("error", err))
+		}
+	}
 	log.Info("service stoped")
 }
 
+func networkOptions(cfg config.Network) []network.Option {
+	return []network.Option{
+		network.WithMaxConnections(cfg.MaxConnections),
+		network.WithIdleTimeout(cfg.IdleTimeout),
+		network.WithMaxMessageSize(cfg.MaxMessageSize),
+	}
+}
+
+func validationPolicy(cfg config.Validation) (compute.Policy, error) {
+	classes, err := compute.ParseCharacterClasses(cfg.CharacterClasses)
+	if err != nil {
+		return compute.Policy{}, err
+	}
+
+	return compute.Policy{
+		Classes:        classes,
+		Punctuation:    cfg.Punctuation,
+		RestrictQuoted: cfg.RestrictQuoted,
+		MaxKeyLength:   cfg.MaxKeyLength,
+		MaxValueLength: cfg.MaxValueLength,
+		MaxTokens:      cfg.MaxTokens,
+	}, nil
+}
+
+// mergeErrors fans several service error channels into one that is closed
+// after all of them are closed. Without services it returns nil, which never
+// becomes ready in a select.
+func mergeErrors(chans ...<-chan error) <-chan error {
+	if len(chans) == 0 {
+		return nil
+	}
+
+	merged := make(chan error, len(chans))
+
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for err := range ch {
+				merged <- err
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged
+}
+
 func waitForShutdown(
-	cliCtx context.Context,
 	cancel context.CancelFunc,
 	log *slog.Logger,
+	cliDone <-chan struct{},
 	cliErr <-chan error,
+	serviceErr <-chan error,
 	stop <-chan os.Signal,
 ) {
+	defer cancel()
+
 	select {
 	case err := <-cliErr:
 		if err != nil {
 			log.Error("cli error", slog.Any("error", err))
 		}
-	case <-cliCtx.Done():
+	case <-cliDone:
+	case err, ok := <-serviceErr:
+		if ok && err != nil {
+			log.Error("service error", slog.Any("error", err))
+		}
 	case sig := <-stop:
 		log.Error("shutting down application ", slog.String("signal", sig.String()))
-		cancel()
 	}
 }
 
@@ -10,19 +10,42 @@ import (
 )
 
 type Cli struct {
-	log        *slog.Logger
-	cliHandler CommandHandler
+	log            *slog.Logger
+	cliHandler     CommandHandler
+	sessionContext func(ctx context.Context) context.Context
 }
 
 type CommandHandler interface {
 	ComputeHandler(ctx context.Context, raw string) (string, error)
 }
 
-func NewCli(log *slog.Logger, handler CommandHandler) *Cli {
-	return &Cli{
+// Pusher is implemented by handlers that send lines outside of the replies
+// to commands, such as pub/sub messages. Pushes returns the lines pending
+// for the session of ctx, nil when there are none to wait for.
+type Pusher interface {
+	Pushes(ctx context.Context) <-chan string
+}
+
+type Option func(*Cli)
+
+// WithSessionContext derives the context of the stdin session, which lets the
+// handler keep state such as an open transaction between commands.
+func WithSessionContext(fn func(ctx context.Context) context.Context) Option {
+	return func(cli *Cli) {
+		cli.sessionContext = fn
+	}
+}
+
+func NewCli(log *slog.Logger, handler CommandHandler, opts ...Option) *Cli {
+	cli := &Cli{
 		log:        log,
 		cliHandler: handler,
 	}
+	for _, opt := range opts {
+		opt(cli)
+	}
+
+	return cli
 }
 
 func (cli *Cli) Start(parent context.Context) (context.Context, <-chan error) {
@@ -36,6 +59,14 @@ func (cli *Cli) Start(parent context.Context) (context.Context, <-chan error) {
 		_ = os.Stdin.Close()
 	}()
 
+	sessionCtx := ctx
+	if cli.sessionContext != nil {
+		sessionCtx = cli.sessionContext(ctx)
+	}
+
+	pusher, _ := cli.cliHandler.(Pusher)
+	var printing <-chan string
+
 	go func() {
 		defer close(errCh)
 		defer cancel()
@@ -67,7 +98,7 @@ func (cli *Cli) Start(parent context.Context) (context.Context, <-chan error) {
 				return
 			}
 
-			result, err := cli.cliHandler.ComputeHandler(ctx, line)
+			result, err := cli.cliHandler.ComputeHandler(sessionCtx, line)
 			if err != nil {
 				fmt.Fprintln(os.Stdout, err)
 				fmt.Fprint(os.Stdout, "> ")
@@ -76,9 +107,31 @@ func (cli *Cli) Start(parent context.Context) (context.Context, <-chan error) {
 
 			fmt.Fprintln(os.Stdout, result)
 
+			// pushes are printed as they come while the next command is typed
+			if pusher != nil {
+				if pushes := pusher.Pushes(sessionCtx); pushes != nil && pushes != printing {
+					printing = pushes
+					go printPushes(ctx, pushes)
+				}
+			}
+
 			fmt.Fprint(os.Stdout, "> ")
 		}
 	}()
 
 	return ctx, errCh
 }
+
+func printPushes(ctx context.Context, pushes <-chan string) {
+	for {
+		select {
+		case line, ok := <-pushes:
+			if !ok {
+				return
+			}
+			fmt.Fprintln(os.Stdout, line)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -1,9 +1,83 @@
 package command
 
 const (
-	CommandSet = "SET"
-	CommandGet = "GET"
-	CommandDel = "DEL"
+	CommandSet           = "SET"
+	CommandGet           = "GET"
+	CommandDel           = "DEL"
+	CommandExpire        = "EXPIRE"
+	CommandTTL           = "TTL"
+	CommandPersist       = "PERSIST"
+	CommandStats         = "STATS"
+	CommandMSet          = "MSET"
+	CommandMGet          = "MGET"
+	CommandMDel          = "MDEL"
+	CommandSetGet        = "SETGET"
+	CommandCAS           = "CAS"
+	CommandIncr          = "INCR"
+	CommandDecr          = "DECR"
+	CommandIncrBy        = "INCRBY"
+	CommandDecrBy        = "DECRBY"
+	CommandIncrByFloat   = "INCRBYFLOAT"
+	CommandScan          = "SCAN"
+	CommandKeys          = "KEYS"
+	CommandCount         = "COUNT"
+	CommandHSet          = "HSET"
+	CommandHGet          = "HGET"
+	CommandHDel          = "HDEL"
+	CommandHGetAll       = "HGETALL"
+	CommandHLen          = "HLEN"
+	CommandHExists       = "HEXISTS"
+	CommandLPush         = "LPUSH"
+	CommandRPush         = "RPUSH"
+	CommandLPop          = "LPOP"
+	CommandRPop          = "RPOP"
+	CommandLRange        = "LRANGE"
+	CommandLLen          = "LLEN"
+	CommandBLPop         = "BLPOP"
+	CommandBRPop         = "BRPOP"
+	CommandSAdd          = "SADD"
+	CommandSRem          = "SREM"
+	CommandSMembers      = "SMEMBERS"
+	CommandSIsMember     = "SISMEMBER"
+	CommandSInter        = "SINTER"
+	CommandSUnion        = "SUNION"
+	CommandZAdd          = "ZADD"
+	CommandZRem          = "ZREM"
+	CommandZScore        = "ZSCORE"
+	CommandZRange        = "ZRANGE"
+	CommandZRangeByScore = "ZRANGEBYSCORE"
+	CommandZRank         = "ZRANK"
+	CommandPublish       = "PUBLISH"
+	CommandSubscribe     = "SUBSCRIBE"
+	CommandPSubscribe    = "PSUBSCRIBE"
+	CommandUnsubscribe   = "UNSUBSCRIBE"
+	CommandPUnsubscribe  = "PUNSUBSCRIBE"
+	CommandMulti         = "MULTI"
+	CommandExec          = "EXEC"
+	CommandDiscard       = "DISCARD"
+	CommandWatch         = "WATCH"
+	CommandUnwatch       = "UNWATCH"
+
+	// CommandPExpireAt is only written to the WAL: it carries the absolute
+	// deadline computed when EXPIRE was executed.
+	CommandPExpireAt = "PEXPIREAT"
+
+	// ErrorPrefix starts every reply that reports a failed command.
+	ErrorPrefix = "ERROR "
+
+	OptionEX = "EX"
+	OptionNX = "NX"
+	OptionXX = "XX"
+	// OptionLimit caps the number of keys a range SCAN returns; OptionMatch
+	// and OptionCount filter and size the steps of a cursor SCAN.
+	OptionLimit = "LIMIT"
+	OptionMatch = "MATCH"
+	OptionCount = "COUNT"
+	// OptionWithScores adds the scores to the members ZRANGE and
+	// ZRANGEBYSCORE return.
+	OptionWithScores = "WITHSCORES"
+	// OptionPXAT marks an absolute deadline in unix milliseconds in a logged SET.
+	OptionPXAT = "PXAT"
 )
 
 var (
@@ -12,7 +86,78 @@ var (
 	LetterRangeUpper = [2]rune{'A', 'Z'}
 	DigitRange       = [2]rune{'0', '9'}
 
-	CommandSetQ = 2
-	CommandGetQ = 1
-	CommandDelQ = 1
+	CommandSetQ     = 2
+	CommandSetExQ   = 4
+	CommandGetQ     = 1
+	CommandDelQ     = 1
+	CommandExpireQ  = 2
+	CommandTTLQ     = 1
+	CommandPersistQ = 1
+	CommandStatsQ   = 0
+	CommandSetGetQ  = 2
+	CommandCASQ     = 3
+	CommandIncrQ    = 1
+	CommandIncrByQ  = 2
+
+	// SCAN start end takes LIMIT n optionally
+	CommandScanQ      = 2
+	CommandScanLimitQ = 4
+	CommandKeysQ      = 1
+	CommandCountQ     = 1
+	// DefaultScanLimit is the page size of a SCAN without LIMIT.
+	DefaultScanLimit = 1000
+
+	// SCAN cursor takes MATCH pattern and COUNT n optionally
+	CommandScanCursorMaxQ = 5
+	// DefaultScanCount is the step size of a cursor SCAN without COUNT.
+	DefaultScanCount = 10
+
+	// minimum quantities; MSET takes key value pairs
+	CommandMSetMinQ = 2
+	CommandMGetMinQ = 1
+	CommandMDelMinQ = 1
+
+	// HSET key takes field value pairs, HDEL key takes fields
+	CommandHSetMinQ = 3
+	CommandHDelMinQ = 2
+	CommandHGetQ    = 2
+	CommandHGetAllQ = 1
+	CommandHLenQ    = 1
+	CommandHExistsQ = 2
+
+	// LPUSH and RPUSH take a key and values, BLPOP and BRPOP keys and a
+	// timeout in seconds
+	CommandPushMinQ = 2
+	CommandPopQ     = 1
+	CommandLRangeQ  = 3
+	CommandLLenQ    = 1
+	CommandBPopMinQ = 2
+
+	// SADD, SREM and ZREM take a key and members, ZADD a key and score
+	// member pairs; ZRANGE key start stop and ZRANGEBYSCORE key min max take
+	// WITHSCORES and, by score, LIMIT n optionally
+	CommandSAddMinQ          = 2
+	CommandSRemMinQ          = 2
+	CommandSMembersQ         = 1
+	CommandSIsMemberQ        = 2
+	CommandSInterMinQ        = 1
+	CommandSUnionMinQ        = 1
+	CommandZAddMinQ          = 3
+	CommandZRemMinQ          = 2
+	CommandZScoreQ           = 2
+	CommandZRankQ            = 2
+	CommandZRangeQ           = 3
+	CommandZRangeByScoreMinQ = 3
+	CommandZRangeByScoreMaxQ = 6
+
+	// SUBSCRIBE and PSUBSCRIBE take channels and patterns, UNSUBSCRIBE and
+	// PUNSUBSCRIBE without any drop every subscription of their kind
+	CommandPublishQ      = 2
+	CommandSubscribeMinQ = 1
+
+	CommandMultiQ    = 0
+	CommandExecQ     = 0
+	CommandDiscardQ  = 0
+	CommandWatchMinQ = 1
+	CommandUnwatchQ  = 0
 )
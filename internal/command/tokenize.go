@@ -0,0 +1,253 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+	ErrInvalidEscape     = errors.New("invalid escape sequence")
+	ErrUnexpectedQuote   = errors.New("unexpected quote")
+	ErrMissingSeparator  = errors.New("missing space after closing quote")
+)
+
+// SyntaxError reports where tokenizing failed. Offset is the byte offset in
+// the raw command of the character that caused the error.
+type SyntaxError struct {
+	Offset int
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Token is one argument of a command. Quoted tokens may contain any byte and
+// are not subject to the bare argument alphabet.
+type Token struct {
+	Text   string
+	Offset int
+	Quoted bool
+}
+
+// Tokenize splits a command into whitespace separated tokens. A token is
+// either a bare word or a quoted string:
+//
+//   - "..." supports the escapes \n \r \t \0 \\ \" \' and \xNN;
+//   - '...' is taken literally except for \' and \\;
+//   - a pair of quotes with nothing between them is an empty argument.
+//
+// A quote may only open a token and a closing quote must be followed by a
+// space or the end of the command.
+func Tokenize(raw string) ([]Token, error) {
+	var tokens []Token
+
+	for i := 0; i < len(raw); {
+		if isSpace(raw[i]) {
+			i++
+			continue
+		}
+
+		var (
+			token Token
+			err   error
+		)
+		switch raw[i] {
+		case '"':
+			token, i, err = scanDoubleQuoted(raw, i)
+		case '\'':
+			token, i, err = scanSingleQuoted(raw, i)
+		default:
+			token, i, err = scanBare(raw, i)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Quoted && i < len(raw) && !isSpace(raw[i]) {
+			return nil, &SyntaxError{Offset: i, Err: ErrMissingSeparator}
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func scanBare(raw string, start int) (Token, int, error) {
+	i := start
+	for i < len(raw) && !isSpace(raw[i]) {
+		if raw[i] == '"' || raw[i] == '\'' {
+			return Token{}, 0, &SyntaxError{Offset: i, Err: ErrUnexpectedQuote}
+		}
+		i++
+	}
+	return Token{Text: raw[start:i], Offset: start}, i, nil
+}
+
+func scanDoubleQuoted(raw string, start int) (Token, int, error) {
+	var text strings.Builder
+
+	for i := start + 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '"':
+			return Token{Text: text.String(), Offset: start, Quoted: true}, i + 1, nil
+		case '\\':
+			if i+1 >= len(raw) {
+				return Token{}, 0, &SyntaxError{Offset: start, Err: ErrUnterminatedQuote}
+			}
+
+			b, width, ok := unescape(raw[i+1:])
+			if !ok {
+				return Token{}, 0, &SyntaxError{Offset: i, Err: ErrInvalidEscape}
+			}
+			text.WriteByte(b)
+			i += width
+		default:
+			text.WriteByte(raw[i])
+		}
+	}
+
+	return Token{}, 0, &SyntaxError{Offset: start, Err: ErrUnterminatedQuote}
+}
+
+func scanSingleQuoted(raw string, start int) (Token, int, error) {
+	var text strings.Builder
+
+	for i := start + 1; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\'':
+			return Token{Text: text.String(), Offset: start, Quoted: true}, i + 1, nil
+		case raw[i] == '\\' && i+1 < len(raw) && (raw[i+1] == '\'' || raw[i+1] == '\\'):
+			text.WriteByte(raw[i+1])
+			i++
+		default:
+			text.WriteByte(raw[i])
+		}
+	}
+
+	return Token{}, 0, &SyntaxError{Offset: start, Err: ErrUnterminatedQuote}
+}
+
+// unescape decodes the escape sequence following a backslash and returns the
+// decoded byte and the number of bytes consumed.
+func unescape(s string) (byte, int, bool) {
+	switch s[0] {
+	case 'n':
+		return '\n', 1, true
+	case 'r':
+		return '\r', 1, true
+	case 't':
+		return '\t', 1, true
+	case '0':
+		return 0, 1, true
+	case '\\', '"', '\'':
+		return s[0], 1, true
+	case 'x':
+		if len(s) < 3 {
+			return 0, 0, false
+		}
+		hi, okHi := unhex(s[1])
+		lo, okLo := unhex(s[2])
+		if !okHi || !okLo {
+			return 0, 0, false
+		}
+		return hi<<4 | lo, 3, true
+	default:
+		return 0, 0, false
+	}
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// Quote returns s as a double-quoted token that Tokenize decodes back to s.
+func Quote(s string) string {
+	const hexDigits = "0123456789abcdef"
+
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == utf8.RuneError && width == 1, !unicode.IsPrint(r):
+			for _, c := range []byte(s[i : i+width]) {
+				b.WriteString(`\x`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xf])
+			}
+		default:
+			b.WriteString(s[i : i+width])
+		}
+		i += width
+	}
+
+	b.WriteByte('"')
+	return b.String()
+}
+
+// QuoteIfNeeded returns s unchanged when it reads back as a single bare token
+// and Quote(s) otherwise. Responses use it so that plain values stay plain
+// while values with spaces, quotes or control characters keep the protocol
+// one line per message.
+func QuoteIfNeeded(s string) string {
+	if s == "" || s[0] == '"' || s[0] == '\'' {
+		return Quote(s)
+	}
+
+	for _, r := range s {
+		if r == '"' || r == '\'' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return Quote(s)
+		}
+	}
+	return s
+}
+
+// Unquote decodes a value written by QuoteIfNeeded.
+func Unquote(s string) (string, error) {
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return s, nil
+	}
+
+	tokens, err := Tokenize(s)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) != 1 {
+		return "", &SyntaxError{Offset: len(s), Err: ErrMissingSeparator}
+	}
+	return tokens[0].Text, nil
+}
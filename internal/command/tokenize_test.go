@@ -0,0 +1,176 @@
+package command_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/command"
+)
+
+func texts(tokens []command.Token) []string {
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, token.Text)
+	}
+	return result
+}
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{name: "empty", raw: "", want: []string{}},
+		{name: "spaces only", raw: " \t ", want: []string{}},
+		{name: "bare words", raw: "  SET key   value ", want: []string{"SET", "key", "value"}},
+		{name: "double quoted", raw: `SET k "hello world"`, want: []string{"SET", "k", "hello world"}},
+		{name: "single quoted", raw: `SET k 'it''s'`, want: nil},
+		{name: "empty strings", raw: `SET "" ''`, want: []string{"SET", "", ""}},
+		{name: "json", raw: `SET doc "{\"a\": [1, 2]}"`, want: []string{"SET", "doc", `{"a": [1, 2]}`}},
+		{name: "escapes", raw: `"a\nb\r\t\0\\\"\'"`, want: []string{"a\nb\r\t\x00\\\"'"}},
+		{name: "hex escapes", raw: `"\x41\x7a\xFF"`, want: []string{"Az\xff"}},
+		{name: "single quotes are literal", raw: `'a\nb "c"'`, want: []string{`a\nb "c"`}},
+		{name: "single quote escapes", raw: `'it\'s \\ ok'`, want: []string{`it's \ ok`}},
+		{name: "utf8", raw: `SET ключ "значение ✓"`, want: []string{"SET", "ключ", "значение ✓"}},
+		{name: "bare backslash", raw: `a\b`, want: []string{`a\b`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens, err := command.Tokenize(tt.raw)
+			if tt.want == nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, texts(tokens))
+		})
+	}
+}
+
+func TestTokenizeOffsets(t *testing.T) {
+	t.Parallel()
+
+	tokens, err := command.Tokenize(`SET  "k v"  x`)
+	require.NoError(t, err)
+	require.Len(t, tokens, 3)
+
+	assert.Equal(t, command.Token{Text: "SET", Offset: 0}, tokens[0])
+	assert.Equal(t, command.Token{Text: "k v", Offset: 5, Quoted: true}, tokens[1])
+	assert.Equal(t, command.Token{Text: "x", Offset: 12}, tokens[2])
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		raw        string
+		wantErr    error
+		wantOffset int
+	}{
+		{name: "unterminated double", raw: `SET k "abc`, wantErr: command.ErrUnterminatedQuote, wantOffset: 6},
+		{name: "unterminated single", raw: `SET k 'abc`, wantErr: command.ErrUnterminatedQuote, wantOffset: 6},
+		{name: "trailing backslash", raw: `SET k "abc\`, wantErr: command.ErrUnterminatedQuote, wantOffset: 6},
+		{name: "unknown escape", raw: `SET k "a\qb"`, wantErr: command.ErrInvalidEscape, wantOffset: 8},
+		{name: "short hex escape", raw: `SET k "\x4"`, wantErr: command.ErrInvalidEscape, wantOffset: 7},
+		{name: "bad hex escape", raw: `SET k "\xZZ"`, wantErr: command.ErrInvalidEscape, wantOffset: 7},
+		{name: "quote inside word", raw: `SET ab"c"`, wantErr: command.ErrUnexpectedQuote, wantOffset: 6},
+		{name: "text after quote", raw: `SET "a"b`, wantErr: command.ErrMissingSeparator, wantOffset: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := command.Tokenize(tt.raw)
+			require.ErrorIs(t, err, tt.wantErr)
+
+			var syntaxErr *command.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.wantOffset, syntaxErr.Offset)
+		})
+	}
+}
+
+func TestQuoteIfNeeded(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "plain", want: "plain"},
+		{value: "http://example.com/?q=1", want: "http://example.com/?q=1"},
+		{value: "ключ", want: "ключ"},
+		{value: "", want: `""`},
+		{value: "a b", want: `"a b"`},
+		{value: `say "hi"`, want: `"say \"hi\""`},
+		{value: "line\nbreak", want: `"line\nbreak"`},
+		{value: "\x00\xff", want: `"\x00\xff"`},
+		{value: `back\slash`, want: `"back\\slash"`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, command.QuoteIfNeeded(tt.value), tt.value)
+
+		got, err := command.Unquote(command.QuoteIfNeeded(tt.value))
+		require.NoError(t, err)
+		assert.Equal(t, tt.value, got)
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	for _, seed := range []string{
+		"", "SET k v", `SET k "a b"`, `'x\'y'`, `"\x41\n"`, `"unterminated`, `a"b`, `"a"b`, "\t\"\\\"", "SET \xff\xfe",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		tokens, err := command.Tokenize(raw)
+		if err != nil {
+			var syntaxErr *command.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			require.GreaterOrEqual(t, syntaxErr.Offset, 0)
+			require.LessOrEqual(t, syntaxErr.Offset, len(raw))
+			return
+		}
+
+		quoted := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			require.GreaterOrEqual(t, token.Offset, 0)
+			require.Less(t, token.Offset, len(raw))
+			quoted = append(quoted, command.Quote(token.Text))
+		}
+
+		again, err := command.Tokenize(strings.Join(quoted, " "))
+		require.NoError(t, err)
+		require.Equal(t, texts(tokens), texts(again))
+	})
+}
+
+func FuzzQuote(f *testing.F) {
+	for _, seed := range []string{"", "plain", "a b", `"`, `\`, "\n\x00\xff", "значение"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		tokens, err := command.Tokenize(command.Quote(value))
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		require.Equal(t, value, tokens[0].Text)
+
+		got, err := command.Unquote(command.QuoteIfNeeded(value))
+		require.NoError(t, err)
+		require.Equal(t, value, got)
+	})
+}
@@ -5,10 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"lesson1/internal/command"
 	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
+	"lesson1/internal/pubsub"
 )
 
 var (
@@ -24,41 +30,150 @@ var (
 
 type CommandCompute interface {
 	Set(ctx context.Context, key, value string) error
+	SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error
+	SetIf(ctx context.Context, key, value string, ttl time.Duration, cond kv.Condition) (kv.Lookup, error)
 	Del(ctx context.Context, key string) error
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	Persist(ctx context.Context, key string) error
+	IncrBy(ctx context.Context, key string, delta int64) (int64, error)
+	IncrByFloat(ctx context.Context, key string, delta float64) (string, error)
+	MSet(ctx context.Context, pairs []kv.Pair) error
+	MDel(ctx context.Context, keys []string) (int, error)
+	HSet(ctx context.Context, key string, fields []kv.Pair) (int, error)
+	HDel(ctx context.Context, key string, fields []string) (int, error)
+	Push(ctx context.Context, key string, values []string, end kv.End) (int, error)
+	Pop(ctx context.Context, key string, end kv.End) (string, error)
+	BPop(ctx context.Context, keys []string, end kv.End, timeout time.Duration) (kv.Pair, error)
+	SAdd(ctx context.Context, key string, members []string) (int, error)
+	SRem(ctx context.Context, key string, members []string) (int, error)
+	ZAdd(ctx context.Context, key string, members []kv.ScoredMember) (int, error)
+	ZRem(ctx context.Context, key string, members []string) (int, error)
+	Exec(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type QueryCompute interface {
 	Get(ctx context.Context, key string) (string, error)
+	MGet(ctx context.Context, keys []string) ([]kv.Lookup, error)
+	Version(ctx context.Context, key string) (uint64, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Stats(ctx context.Context) (stats.Stats, error)
+	Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error)
+	Range(ctx context.Context, start, end string, limit int) ([]string, string, error)
+	CountPrefix(ctx context.Context, prefix string) (int, error)
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) ([]kv.Pair, error)
+	HLen(ctx context.Context, key string) (int, error)
+	HExists(ctx context.Context, key, field string) (bool, error)
+	LRange(ctx context.Context, key string, start, stop int) ([]string, error)
+	LLen(ctx context.Context, key string) (int, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SInter(ctx context.Context, keys []string) ([]string, error)
+	SUnion(ctx context.Context, keys []string) ([]string, error)
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	ZRank(ctx context.Context, key, member string) (int, error)
+	ZRange(ctx context.Context, key string, start, stop int) ([]kv.ScoredMember, error)
+	ZRangeByScore(ctx context.Context, key string, lower, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error)
 }
 
 type Compute struct {
 	log            *slog.Logger
 	commandCompute CommandCompute
 	queryCompute   QueryCompute
+	policy         Policy
+	broker         *pubsub.Broker
+}
+
+type Option func(*Compute)
+
+// WithBroker shares a pub/sub broker with other publishers in place of a
+// broker of the Compute's own.
+func WithBroker(broker *pubsub.Broker) Option {
+	return func(c *Compute) {
+		if broker != nil {
+			c.broker = broker
+		}
+	}
+}
+
+// WithPolicy replaces DefaultPolicy as the argument validation policy.
+func WithPolicy(policy Policy) Option {
+	return func(c *Compute) {
+		c.policy = policy
+	}
 }
 
 func NewCompute(log *slog.Logger, cmd interface {
 	CommandCompute
 	QueryCompute
-},
+}, opts ...Option,
 ) *Compute {
-	return &Compute{
+	c := &Compute{
 		log:            log,
 		commandCompute: cmd,
 		queryCompute:   cmd,
+		policy:         DefaultPolicy(),
+		broker:         pubsub.NewBroker(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
 func (c *Compute) ComputeHandler(ctx context.Context, raw string) (string, error) {
 	const op = "compute.ComputeHandler"
 
+	session := sessionFrom(ctx)
+
 	tokens, err := c.ParseAndValidate(ctx, raw)
 	if err != nil {
+		if session != nil && session.multi {
+			session.aborted = true
+		}
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
 	c.log.Info("command start", slog.String("cmd", tokens[0]))
 
+	if isTransactionCommand(tokens[0]) {
+		if session == nil {
+			return "", fmt.Errorf("%s: %w", op, ErrNoSession)
+		}
+		return c.handleTransaction(ctx, session, tokens)
+	}
+	if session != nil && session.multi {
+		return c.queue(session, tokens)
+	}
+	if isSubscriptionCommand(tokens[0]) {
+		if session == nil {
+			return "", fmt.Errorf("%s: %w", op, ErrNoSession)
+		}
+		return c.handleSubscription(ctx, session, tokens)
+	}
+
+	return c.execute(ctx, tokens)
+}
+
+func (c *Compute) handleTransaction(ctx context.Context, session *Session, tokens []string) (string, error) {
+	switch tokens[0] {
+	case command.CommandMulti:
+		return c.handleMulti(session, tokens)
+	case command.CommandExec:
+		return c.handleExec(ctx, session, tokens)
+	case command.CommandDiscard:
+		return c.handleDiscard(session, tokens)
+	case command.CommandWatch:
+		return c.handleWatch(ctx, session, tokens)
+	default:
+		return c.handleUnwatch(session, tokens)
+	}
+}
+
+func (c *Compute) execute(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.execute"
+
 	switch tokens[0] {
 	case command.CommandSet:
 		return c.handleSet(ctx, tokens)
@@ -66,6 +181,80 @@ func (c *Compute) ComputeHandler(ctx context.Context, raw string) (string, error
 		return c.handleGet(ctx, tokens)
 	case command.CommandDel:
 		return c.handleDel(ctx, tokens)
+	case command.CommandExpire:
+		return c.handleExpire(ctx, tokens)
+	case command.CommandTTL:
+		return c.handleTTL(ctx, tokens)
+	case command.CommandPersist:
+		return c.handlePersist(ctx, tokens)
+	case command.CommandStats:
+		return c.handleStats(ctx, tokens)
+	case command.CommandMSet:
+		return c.handleMSet(ctx, tokens)
+	case command.CommandMGet:
+		return c.handleMGet(ctx, tokens)
+	case command.CommandMDel:
+		return c.handleMDel(ctx, tokens)
+	case command.CommandSetGet:
+		return c.handleSetGet(ctx, tokens)
+	case command.CommandCAS:
+		return c.handleCAS(ctx, tokens)
+	case command.CommandIncr, command.CommandDecr, command.CommandIncrBy, command.CommandDecrBy:
+		return c.handleIncr(ctx, tokens)
+	case command.CommandIncrByFloat:
+		return c.handleIncrByFloat(ctx, tokens)
+	case command.CommandScan:
+		return c.handleScan(ctx, tokens)
+	case command.CommandKeys:
+		return c.handleKeys(ctx, tokens)
+	case command.CommandCount:
+		return c.handleCount(ctx, tokens)
+	case command.CommandHSet:
+		return c.handleHSet(ctx, tokens)
+	case command.CommandHGet:
+		return c.handleHGet(ctx, tokens)
+	case command.CommandHDel:
+		return c.handleHDel(ctx, tokens)
+	case command.CommandHGetAll:
+		return c.handleHGetAll(ctx, tokens)
+	case command.CommandHLen:
+		return c.handleHLen(ctx, tokens)
+	case command.CommandHExists:
+		return c.handleHExists(ctx, tokens)
+	case command.CommandLPush, command.CommandRPush:
+		return c.handlePush(ctx, tokens)
+	case command.CommandLPop, command.CommandRPop:
+		return c.handlePop(ctx, tokens)
+	case command.CommandBLPop, command.CommandBRPop:
+		return c.handleBPop(ctx, tokens)
+	case command.CommandLRange:
+		return c.handleLRange(ctx, tokens)
+	case command.CommandLLen:
+		return c.handleLLen(ctx, tokens)
+	case command.CommandSAdd:
+		return c.handleSAdd(ctx, tokens)
+	case command.CommandSRem:
+		return c.handleSRem(ctx, tokens)
+	case command.CommandSMembers:
+		return c.handleSMembers(ctx, tokens)
+	case command.CommandSIsMember:
+		return c.handleSIsMember(ctx, tokens)
+	case command.CommandSInter, command.CommandSUnion:
+		return c.handleSCombine(ctx, tokens)
+	case command.CommandZAdd:
+		return c.handleZAdd(ctx, tokens)
+	case command.CommandZRem:
+		return c.handleZRem(ctx, tokens)
+	case command.CommandZScore:
+		return c.handleZScore(ctx, tokens)
+	case command.CommandZRank:
+		return c.handleZRank(ctx, tokens)
+	case command.CommandZRange:
+		return c.handleZRange(ctx, tokens)
+	case command.CommandZRangeByScore:
+		return c.handleZRangeByScore(ctx, tokens)
+	case command.CommandPublish:
+		return c.handlePublish(ctx, tokens)
 	default:
 		c.log.Info("invalid command")
 
@@ -73,52 +262,217 @@ func (c *Compute) ComputeHandler(ctx context.Context, raw string) (string, error
 	}
 }
 
+// ParseAndValidate tokenizes a command and checks it against the validation
+// policy.
 func (c *Compute) ParseAndValidate(_ context.Context, raw string) ([]string, error) {
 	const op = "compute.parse"
 
-	tokens := strings.Fields(strings.TrimSpace(raw))
+	parsed, err := command.Tokenize(raw)
+	if err != nil {
+		c.log.Info("invalid syntax of command", slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidSyntaxArg, err)
+	}
 
-	if len(tokens) == 0 {
+	if len(parsed) == 0 {
 		return nil, fmt.Errorf("%s: %w", op, ErrEmptyCommand)
 	}
 
-	ok := ValidateCommand(tokens[0])
+	ok := ValidateCommand(parsed[0].Text)
 
 	if !ok {
-		c.log.Info("invalid syntax of command", slog.String(" ", tokens[0]))
+		c.log.Info("invalid syntax of command", slog.String(" ", parsed[0].Text))
 		return nil, fmt.Errorf("%s: %w", op, ErrInvalidSyntaxCommand)
 	}
 
-	tokens[0] = strings.ToUpper(tokens[0])
+	tokens := make([]string, 0, len(parsed))
+	tokens = append(tokens, strings.ToUpper(parsed[0].Text))
 
-	for _, token := range tokens {
-		ok := ValidateArgument(token)
-		if !ok {
-			c.log.Info("invalid syntax of argument", slog.String(" ", token))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidSyntaxArg)
+	for _, token := range parsed[1:] {
+		if err := c.policy.checkToken(token); err != nil {
+			c.log.Info("invalid syntax of argument", slog.String(" ", token.Text))
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		tokens = append(tokens, token.Text)
+	}
+
+	if err := c.policy.checkLengths(tokens); err != nil {
+		c.log.Info("command exceeds limits", slog.String("cmd", tokens[0]), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return tokens, nil
 }
 
+// handleSet parses SET key value [EX seconds] [NX|XX]. A SET whose NX or XX
+// condition fails is answered with NOT_SET.
 func (c *Compute) handleSet(ctx context.Context, tokens []string) (string, error) {
 	const op = "compute.set"
 
-	if len(tokens)-1 != command.CommandSetQ {
-		c.log.Info("must be two arguments")
+	if len(tokens)-1 < command.CommandSetQ {
+		c.log.Info("must be two arguments followed by options")
 		return "", ErrInvalidQuantity
 	}
+	key, value := tokens[1], tokens[2]
+
+	var (
+		ttl  time.Duration
+		cond kv.Condition
+	)
+	for i := 3; i < len(tokens); i++ {
+		option := strings.ToUpper(tokens[i])
+		switch {
+		case option == command.OptionEX && ttl == 0:
+			if i+1 >= len(tokens) {
+				c.log.Info("EX must be followed by seconds")
+				return "", ErrInvalidQuantity
+			}
+			i++
+
+			var err error
+			if ttl, err = parseSeconds(tokens[i]); err != nil {
+				return "", fmt.Errorf("%s: %w", op, err)
+			}
+		case option == command.OptionNX && cond.Kind == kv.Always:
+			cond.Kind = kv.IfAbsent
+		case option == command.OptionXX && cond.Kind == kv.Always:
+			cond.Kind = kv.IfPresent
+		default:
+			c.log.Info("unknown or repeated set option", slog.String("option", tokens[i]))
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+		}
+	}
+
+	var err error
+	switch {
+	case cond.Kind != kv.Always:
+		_, err = c.commandCompute.SetIf(ctx, key, value, ttl, cond)
+		if errors.Is(err, dberrors.ErrConditionFailed) {
+			return "NOT_SET", nil
+		}
+	case ttl > 0:
+		err = c.commandCompute.SetWithTTL(ctx, key, value, ttl)
+	default:
+		err = c.commandCompute.Set(ctx, key, value)
+	}
 
-	err := c.commandCompute.Set(ctx, tokens[1], tokens[2])
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "OK", nil
+}
+
+// handleSetGet stores the value and answers the previous one, or NOT_FOUND
+// when the key did not exist.
+func (c *Compute) handleSetGet(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.setget"
+
+	if len(tokens)-1 != command.CommandSetGetQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	previous, err := c.commandCompute.SetIf(ctx, tokens[1], tokens[2], 0, kv.Condition{Kind: kv.Swap})
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	if !previous.Found {
+		return "NOT_FOUND", nil
+	}
+	return "VALUE " + command.QuoteIfNeeded(previous.Value), nil
+}
+
+// handleCAS replaces the value of a key only if it currently equals the
+// expected one. It answers OK, NOT_FOUND for a missing key or MISMATCH.
+func (c *Compute) handleCAS(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.cas"
+
+	if len(tokens)-1 != command.CommandCASQ {
+		c.log.Info("must be three arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	previous, err := c.commandCompute.SetIf(ctx, tokens[1], tokens[3], 0, kv.Condition{Kind: kv.IfEquals, Expected: tokens[2]})
+	switch {
+	case errors.Is(err, dberrors.ErrConditionFailed) && !previous.Found:
+		return "NOT_FOUND", nil
+	case errors.Is(err, dberrors.ErrConditionFailed):
+		return "MISMATCH", nil
+	case err != nil:
+		return c.writeErrorResponse(op, err)
 	}
 
 	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
 	return "OK", nil
 }
 
+// handleIncr parses INCR key, DECR key, INCRBY key delta and DECRBY key
+// delta and answers the new value of the counter. A negative delta contains
+// a minus sign, so it has to be quoted unless the validation policy allows
+// that symbol.
+func (c *Compute) handleIncr(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.incr"
+
+	var delta int64 = 1
+	switch tokens[0] {
+	case command.CommandIncr, command.CommandDecr:
+		if len(tokens)-1 != command.CommandIncrQ {
+			c.log.Info("must be one argument")
+			return "", fmt.Errorf("%w", ErrInvalidQuantity)
+		}
+	default:
+		if len(tokens)-1 != command.CommandIncrByQ {
+			c.log.Info("must be two arguments")
+			return "", fmt.Errorf("%w", ErrInvalidQuantity)
+		}
+		var err error
+		if delta, err = strconv.ParseInt(tokens[2], 10, 64); err != nil {
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+		}
+	}
+
+	if tokens[0] == command.CommandDecr || tokens[0] == command.CommandDecrBy {
+		if delta == math.MinInt64 {
+			return "", dberrors.ErrOverflow
+		}
+		delta = -delta
+	}
+
+	result, err := c.commandCompute.IncrBy(ctx, tokens[1], delta)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "VALUE " + strconv.FormatInt(result, 10), nil
+}
+
+// handleIncrByFloat parses INCRBYFLOAT key delta and answers the new value.
+func (c *Compute) handleIncrByFloat(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.incrbyfloat"
+
+	if len(tokens)-1 != command.CommandIncrByQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	delta, err := strconv.ParseFloat(tokens[2], 64)
+	if err != nil || math.IsInf(delta, 0) || math.IsNaN(delta) {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+	}
+
+	result, err := c.commandCompute.IncrByFloat(ctx, tokens[1], delta)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "VALUE " + result, nil
+}
+
 // our mocked QueryService method
 func (c *Compute) handleGet(ctx context.Context, tokens []string) (string, error) {
 	const op = "compute.get"
@@ -130,14 +484,11 @@ func (c *Compute) handleGet(ctx context.Context, tokens []string) (string, error
 
 	result, err := c.queryCompute.Get(ctx, tokens[1])
 	if err != nil {
-		if errors.Is(err, dberrors.ErrNotFound) {
-			return "NOT_FOUND", nil
-		}
-		return "", fmt.Errorf("%s: %w", op, err)
+		return c.writeErrorResponse(op, err)
 	}
 
 	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
-	return "VALUE " + result, nil
+	return "VALUE " + command.QuoteIfNeeded(result), nil
 }
 
 func (c *Compute) handleDel(ctx context.Context, tokens []string) (string, error) {
@@ -153,9 +504,206 @@ func (c *Compute) handleDel(ctx context.Context, tokens []string) (string, error
 		if errors.Is(err, dberrors.ErrNotFound) {
 			return "NOT_FOUND", nil
 		}
+		if errors.Is(err, dberrors.ErrReadOnly) {
+			return "", dberrors.ErrReadOnly
+		}
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
 	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
 	return "DELETED", nil
 }
+
+func (c *Compute) handleExpire(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.expire"
+
+	if len(tokens)-1 != command.CommandExpireQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	ttl, err := parseSeconds(tokens[2])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = c.commandCompute.Expire(ctx, tokens[1], ttl)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "OK", nil
+}
+
+func (c *Compute) handleTTL(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.ttl"
+
+	if len(tokens)-1 != command.CommandTTLQ {
+		c.log.Info("must be one arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	ttl, err := c.queryCompute.TTL(ctx, tokens[1])
+	if err != nil {
+		if errors.Is(err, dberrors.ErrNotFound) {
+			return "NOT_FOUND", nil
+		}
+		if errors.Is(err, dberrors.ErrNoExpiry) {
+			return "NO_EXPIRY", nil
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// round up so that a key reported with "TTL 1" is still alive
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "TTL " + strconv.FormatInt(seconds, 10), nil
+}
+
+func (c *Compute) handlePersist(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.persist"
+
+	if len(tokens)-1 != command.CommandPersistQ {
+		c.log.Info("must be one arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	err := c.commandCompute.Persist(ctx, tokens[1])
+	if err != nil {
+		if errors.Is(err, dberrors.ErrNoExpiry) {
+			return "NO_EXPIRY", nil
+		}
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "OK", nil
+}
+
+func (c *Compute) handleStats(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.stats"
+
+	if len(tokens)-1 != command.CommandStatsQ {
+		c.log.Info("must be no arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	result, err := c.queryCompute.Stats(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]))
+	return "STATS " + result.String(), nil
+}
+
+func (c *Compute) handleMSet(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.mset"
+
+	args := tokens[1:]
+	if len(args) < command.CommandMSetMinQ || len(args)%2 != 0 {
+		c.log.Info("must be key value pairs")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	pairs := make([]kv.Pair, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		pairs = append(pairs, kv.Pair{Key: args[i], Value: args[i+1]})
+	}
+
+	err := c.commandCompute.MSet(ctx, pairs)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("keys", len(pairs)))
+	return "OK", nil
+}
+
+// handleMGet answers "VALUES" followed by one token per key: the quoted value,
+// or a bare NOT_FOUND for a missing key.
+func (c *Compute) handleMGet(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.mget"
+
+	if len(tokens)-1 < command.CommandMGetMinQ {
+		c.log.Info("must be at least one argument")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	results, err := c.queryCompute.MGet(ctx, tokens[1:])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("VALUES")
+	for _, result := range results {
+		b.WriteByte(' ')
+		if result.Found {
+			b.WriteString(command.Quote(result.Value))
+		} else {
+			b.WriteString("NOT_FOUND")
+		}
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("keys", len(results)))
+	return b.String(), nil
+}
+
+func (c *Compute) handleMDel(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.mdel"
+
+	if len(tokens)-1 < command.CommandMDelMinQ {
+		c.log.Info("must be at least one argument")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	deleted, err := c.commandCompute.MDel(ctx, tokens[1:])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("deleted", deleted))
+	return "DELETED " + strconv.Itoa(deleted), nil
+}
+
+// writeErrorResponse maps the errors shared by all key mutations to a
+// response: a missing key is a regular reply, while a replica refusing the
+// write, a full store, a value a counter cannot use, a key of the wrong
+// type, a range query on an unordered engine and a stale scan cursor are
+// reported as they are.
+func (c *Compute) writeErrorResponse(op string, err error) (string, error) {
+	switch {
+	case errors.Is(err, dberrors.ErrNotFound):
+		return "NOT_FOUND", nil
+	case errors.Is(err, dberrors.ErrReadOnly):
+		return "", dberrors.ErrReadOnly
+	case errors.Is(err, dberrors.ErrOutOfMemory):
+		return "", dberrors.ErrOutOfMemory
+	case errors.Is(err, dberrors.ErrNotInteger):
+		return "", dberrors.ErrNotInteger
+	case errors.Is(err, dberrors.ErrNotFloat):
+		return "", dberrors.ErrNotFloat
+	case errors.Is(err, dberrors.ErrOverflow):
+		return "", dberrors.ErrOverflow
+	case errors.Is(err, dberrors.ErrWrongType):
+		return "", dberrors.ErrWrongType
+	case errors.Is(err, dberrors.ErrNotOrdered):
+		return "", dberrors.ErrNotOrdered
+	case errors.Is(err, dberrors.ErrInvalidCursor):
+		return "", dberrors.ErrInvalidCursor
+	default:
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+// parseSeconds parses a strictly positive number of seconds.
+func parseSeconds(raw string) (time.Duration, error) {
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seconds <= 0 || seconds > int64(math.MaxInt64/time.Second) {
+		return 0, ErrInvalidArg
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"lesson1/internal/command"
 	compute "lesson1/internal/compute"
 	computemocks "lesson1/internal/compute/mocks"
 	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
 	"lesson1/internal/lib/logger/slogdiscard"
 )
 
@@ -65,6 +70,14 @@ func TestComputeHandler(t *testing.T) {
 			},
 			wantErr: errSetFailed,
 		},
+		{
+			name:  "set read only",
+			input: "SET key value",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Set(ctx, "key", "value").Return(dberrors.ErrReadOnly)
+			},
+			wantErr: dberrors.ErrReadOnly,
+		},
 		{
 			name:  "get ok",
 			input: "GET key",
@@ -113,6 +126,891 @@ func TestComputeHandler(t *testing.T) {
 			},
 			wantErr: errDelFailed,
 		},
+		{
+			name:  "set with ttl",
+			input: "SET key value EX 10",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetWithTTL(ctx, "key", "value", 10*time.Second).Return(nil)
+			},
+			want: "OK",
+		},
+		{
+			name:  "set with lowercase ex option",
+			input: "SET key value ex 1",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetWithTTL(ctx, "key", "value", time.Second).Return(nil)
+			},
+			want: "OK",
+		},
+		{
+			name:    "set with unknown option",
+			input:   "SET key value PX 10",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "set with zero ttl",
+			input:   "SET key value EX 0",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "set with non numeric ttl",
+			input:   "SET key value EX ten",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "set with missing ttl",
+			input:   "SET key value EX",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "expire ok",
+			input: "EXPIRE key 60",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Expire(ctx, "key", time.Minute).Return(nil)
+			},
+			want: "OK",
+		},
+		{
+			name:  "expire not found",
+			input: "EXPIRE key 60",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Expire(ctx, "key", time.Minute).Return(dberrors.ErrNotFound)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:    "expire negative",
+			input:   "EXPIRE key -1",
+			wantErr: compute.ErrInvalidSyntaxArg,
+		},
+		{
+			name:    "invalid quantity expire",
+			input:   "EXPIRE key",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "ttl ok rounds up",
+			input: "TTL key",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().TTL(ctx, "key").Return(9*time.Second+time.Millisecond, nil)
+			},
+			want: "TTL 10",
+		},
+		{
+			name:  "ttl no expiry",
+			input: "TTL key",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().TTL(ctx, "key").Return(0, dberrors.ErrNoExpiry)
+			},
+			want: "NO_EXPIRY",
+		},
+		{
+			name:  "ttl not found",
+			input: "TTL key",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().TTL(ctx, "key").Return(0, dberrors.ErrNotFound)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:  "persist ok",
+			input: "PERSIST key",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Persist(ctx, "key").Return(nil)
+			},
+			want: "OK",
+		},
+		{
+			name:  "persist no expiry",
+			input: "PERSIST key",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Persist(ctx, "key").Return(dberrors.ErrNoExpiry)
+			},
+			want: "NO_EXPIRY",
+		},
+		{
+			name:  "persist read only",
+			input: "PERSIST key",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Persist(ctx, "key").Return(dberrors.ErrReadOnly)
+			},
+			wantErr: dberrors.ErrReadOnly,
+		},
+		{
+			name:  "set out of memory",
+			input: "SET key value",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Set(ctx, "key", "value").Return(dberrors.ErrOutOfMemory)
+			},
+			wantErr: dberrors.ErrOutOfMemory,
+		},
+		{
+			name:  "stats ok",
+			input: "STATS",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Stats(ctx).Return(stats.Stats{
+					Keys:           2,
+					UsedMemory:     140,
+					MaxMemory:      1024,
+					EvictionPolicy: "allkeys-lru",
+					EvictedKeys:    3,
+					ExpiredKeys:    1,
+					MemorySaved:    512,
+					DiskSaved:      2048,
+				}, nil)
+			},
+			want: "STATS keys=2 used_memory=140 max_memory=1024 eviction_policy=allkeys-lru evicted_keys=3 expired_keys=1 memory_saved=512 disk_saved=2048",
+		},
+		{
+			name:  "set nx ok",
+			input: "SET lock owner NX EX 10",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "lock", "owner", 10*time.Second, kv.Condition{Kind: kv.IfAbsent}).Return(kv.Lookup{}, nil)
+			},
+			want: "OK",
+		},
+		{
+			name:  "set nx not set",
+			input: "SET lock owner nx",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "lock", "owner", time.Duration(0), kv.Condition{Kind: kv.IfAbsent}).
+					Return(kv.Lookup{Value: "other", Found: true}, dberrors.ErrConditionFailed)
+			},
+			want: "NOT_SET",
+		},
+		{
+			name:  "set xx not set",
+			input: "SET key value XX",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "key", "value", time.Duration(0), kv.Condition{Kind: kv.IfPresent}).
+					Return(kv.Lookup{}, dberrors.ErrConditionFailed)
+			},
+			want: "NOT_SET",
+		},
+		{
+			name:    "set nx and xx",
+			input:   "SET key value NX XX",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "set repeated ex",
+			input:   "SET key value EX 1 EX 2",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:  "setget previous",
+			input: "SETGET key new",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "key", "new", time.Duration(0), kv.Condition{Kind: kv.Swap}).
+					Return(kv.Lookup{Value: "old value", Found: true}, nil)
+			},
+			want: `VALUE "old value"`,
+		},
+		{
+			name:  "setget no previous",
+			input: "SETGET key new",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "key", "new", time.Duration(0), kv.Condition{Kind: kv.Swap}).Return(kv.Lookup{}, nil)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:    "setget invalid quantity",
+			input:   "SETGET key",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "cas ok",
+			input: "CAS key old new",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "key", "new", time.Duration(0), kv.Condition{Kind: kv.IfEquals, Expected: "old"}).
+					Return(kv.Lookup{Value: "old", Found: true}, nil)
+			},
+			want: "OK",
+		},
+		{
+			name:  "cas mismatch",
+			input: "CAS key old new",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "key", "new", time.Duration(0), kv.Condition{Kind: kv.IfEquals, Expected: "old"}).
+					Return(kv.Lookup{Value: "other", Found: true}, dberrors.ErrConditionFailed)
+			},
+			want: "MISMATCH",
+		},
+		{
+			name:  "cas not found",
+			input: "CAS key old new",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "key", "new", time.Duration(0), kv.Condition{Kind: kv.IfEquals, Expected: "old"}).
+					Return(kv.Lookup{}, dberrors.ErrConditionFailed)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:  "cas read only",
+			input: "CAS key old new",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SetIf(ctx, "key", "new", time.Duration(0), kv.Condition{Kind: kv.IfEquals, Expected: "old"}).
+					Return(kv.Lookup{}, dberrors.ErrReadOnly)
+			},
+			wantErr: dberrors.ErrReadOnly,
+		},
+		{
+			name:    "cas invalid quantity",
+			input:   "CAS key old",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "scan default limit",
+			input: `SCAN user/ ""`,
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Range(ctx, "user/", "", command.DefaultScanLimit).Return([]string{"user/1", "user 2"}, "", nil)
+			},
+			want: `KEYS "user/1" "user 2"`,
+		},
+		{
+			name:  "scan next page",
+			input: "SCAN a z LIMIT 2",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Range(ctx, "a", "z", 2).Return([]string{"a", "b"}, "c", nil)
+			},
+			want: `KEYS "a" "b" NEXT "c"`,
+		},
+		{
+			name:    "scan invalid limit",
+			input:   "SCAN a z LIMIT 0",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "scan unknown option",
+			input:   "SCAN a z TOP 2",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "scan invalid quantity",
+			input:   "SCAN a z LIMIT 1 x y",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "scan unordered engine",
+			input: "SCAN a z",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Range(ctx, "a", "z", command.DefaultScanLimit).Return(nil, "", dberrors.ErrNotOrdered)
+			},
+			wantErr: dberrors.ErrNotOrdered,
+		},
+		{
+			name:  "scan cursor",
+			input: "SCAN 0",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Scan(ctx, uint64(0), command.DefaultScanCount).Return([]string{"b", "a"}, 17, nil)
+			},
+			want: `CURSOR 17 "b" "a"`,
+		},
+		{
+			name:  "scan cursor match and count",
+			input: "SCAN 17 COUNT 100 MATCH user*",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Scan(ctx, uint64(17), 100).Return([]string{"user1", "order1", "user2"}, 0, nil)
+			},
+			want: `CURSOR 0 "user1" "user2"`,
+		},
+		{
+			name:    "scan cursor not a number",
+			input:   "SCAN abc",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "scan cursor repeated option",
+			input:   "SCAN 0 COUNT 1 COUNT 2",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "scan cursor unknown option",
+			input:   "SCAN 0 LIMIT 1",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:  "scan cursor lower case options",
+			input: "SCAN 17 count 100 Match user*",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Scan(ctx, uint64(17), 100).Return([]string{"user1", "order1"}, 0, nil)
+			},
+			want: `CURSOR 0 "user1"`,
+		},
+		{
+			name:    "scan cursor option without value",
+			input:   "SCAN 0 COUNT",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:    "scan cursor option without value after match",
+			input:   "SCAN 0 MATCH a* COUNT",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "scan numeric range",
+			input: "SCAN 10 20",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Range(ctx, "10", "20", command.DefaultScanLimit).Return([]string{"15"}, "", nil)
+			},
+			want: `KEYS "15"`,
+		},
+		{
+			name:  "scan range lower case limit",
+			input: "SCAN a z limit 2",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Range(ctx, "a", "z", 2).Return([]string{"a", "b"}, "", nil)
+			},
+			want: `KEYS "a" "b"`,
+		},
+		{
+			name:  "scan stale cursor",
+			input: "SCAN 999999",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Scan(ctx, uint64(999999), command.DefaultScanCount).Return(nil, 0, dberrors.ErrInvalidCursor)
+			},
+			wantErr: dberrors.ErrInvalidCursor,
+		},
+		{
+			name:  "keys prefix",
+			input: "KEYS user*",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Range(ctx, "user", "uses", 0).Return([]string{"user", "users"}, "", nil)
+			},
+			want: `KEYS "user" "users"`,
+		},
+		{
+			name:  "keys exact",
+			input: "KEYS user",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Range(ctx, "user", "user\x00", 0).Return(nil, "", nil)
+			},
+			want: "KEYS",
+		},
+		{
+			name:    "keys inner star",
+			input:   "KEYS u*r*",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:  "count prefix",
+			input: "COUNT user",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().CountPrefix(ctx, "user").Return(42, nil)
+			},
+			want: "COUNT 42",
+		},
+		{
+			name:  "hset",
+			input: "HSET user name ann age 30",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().HSet(ctx, "user", []kv.Pair{{Key: "name", Value: "ann"}, {Key: "age", Value: "30"}}).Return(2, nil)
+			},
+			want: "ADDED 2",
+		},
+		{
+			name:    "hset missing value",
+			input:   "HSET user name ann age",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "hset on a string",
+			input: "HSET name f v",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().HSet(ctx, "name", []kv.Pair{{Key: "f", Value: "v"}}).Return(0, dberrors.ErrWrongType)
+			},
+			wantErr: dberrors.ErrWrongType,
+		},
+		{
+			name:  "hget",
+			input: "HGET user name",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().HGet(ctx, "user", "name").Return("ann lee", nil)
+			},
+			want: `VALUE "ann lee"`,
+		},
+		{
+			name:  "hget missing",
+			input: "HGET user city",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().HGet(ctx, "user", "city").Return("", dberrors.ErrNotFound)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:  "hdel",
+			input: "HDEL user name city",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().HDel(ctx, "user", []string{"name", "city"}).Return(1, nil)
+			},
+			want: "DELETED 1",
+		},
+		{
+			name:    "hdel without fields",
+			input:   "HDEL user",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "hgetall",
+			input: "HGETALL user",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().HGetAll(ctx, "user").Return([]kv.Pair{{Key: "age", Value: "30"}, {Key: "name", Value: "ann"}}, nil)
+			},
+			want: `FIELDS "age" "30" "name" "ann"`,
+		},
+		{
+			name:  "hgetall missing",
+			input: "HGETALL user",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().HGetAll(ctx, "user").Return(nil, nil)
+			},
+			want: "FIELDS",
+		},
+		{
+			name:  "hlen",
+			input: "HLEN user",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().HLen(ctx, "user").Return(2, nil)
+			},
+			want: "COUNT 2",
+		},
+		{
+			name:  "hexists",
+			input: "HEXISTS user name",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().HExists(ctx, "user", "name").Return(true, nil)
+			},
+			want: "EXISTS",
+		},
+		{
+			name:  "hexists missing",
+			input: "HEXISTS user city",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().HExists(ctx, "user", "city").Return(false, nil)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:  "get on a hash",
+			input: "GET user",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Get(ctx, "user").Return("", dberrors.ErrWrongType)
+			},
+			wantErr: dberrors.ErrWrongType,
+		},
+		{
+			name:  "lpush",
+			input: "LPUSH jobs a b",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Push(ctx, "jobs", []string{"a", "b"}, kv.Left).Return(2, nil)
+			},
+			want: "COUNT 2",
+		},
+		{
+			name:  "rpush",
+			input: "RPUSH jobs a",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Push(ctx, "jobs", []string{"a"}, kv.Right).Return(3, nil)
+			},
+			want: "COUNT 3",
+		},
+		{
+			name:    "rpush without values",
+			input:   "RPUSH jobs",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "lpop",
+			input: "LPOP jobs",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Pop(ctx, "jobs", kv.Left).Return("a b", nil)
+			},
+			want: `VALUE "a b"`,
+		},
+		{
+			name:  "rpop empty",
+			input: "RPOP jobs",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Pop(ctx, "jobs", kv.Right).Return("", dberrors.ErrNotFound)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:  "blpop",
+			input: "BLPOP first second 1.5",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().BPop(ctx, []string{"first", "second"}, kv.Left, 1500*time.Millisecond).
+					Return(kv.Pair{Key: "second", Value: "job"}, nil)
+			},
+			want: `POPPED "second" "job"`,
+		},
+		{
+			name:  "brpop timeout",
+			input: "BRPOP jobs 0",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().BPop(ctx, []string{"jobs"}, kv.Right, time.Duration(0)).Return(kv.Pair{}, dberrors.ErrNotFound)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:    "blpop negative timeout",
+			input:   `BLPOP jobs "-1"`,
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "blpop without timeout",
+			input:   "BLPOP jobs",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "lrange",
+			input: `LRANGE jobs 0 "-1"`,
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().LRange(ctx, "jobs", 0, -1).Return([]string{"a", "b"}, nil)
+			},
+			want: `ITEMS "a" "b"`,
+		},
+		{
+			name:    "lrange invalid index",
+			input:   "LRANGE jobs 0 x",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:  "llen",
+			input: "LLEN jobs",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().LLen(ctx, "jobs").Return(4, nil)
+			},
+			want: "COUNT 4",
+		},
+		{
+			name:  "sadd",
+			input: "SADD tags go db",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SAdd(ctx, "tags", []string{"go", "db"}).Return(2, nil)
+			},
+			want: "ADDED 2",
+		},
+		{
+			name:    "sadd without members",
+			input:   "SADD tags",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "srem",
+			input: "SREM tags go",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().SRem(ctx, "tags", []string{"go"}).Return(1, nil)
+			},
+			want: "DELETED 1",
+		},
+		{
+			name:  "smembers",
+			input: "SMEMBERS tags",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().SMembers(ctx, "tags").Return([]string{"db", "go lang"}, nil)
+			},
+			want: `MEMBERS "db" "go lang"`,
+		},
+		{
+			name:  "smembers wrong type",
+			input: "SMEMBERS name",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().SMembers(ctx, "name").Return(nil, dberrors.ErrWrongType)
+			},
+			wantErr: dberrors.ErrWrongType,
+		},
+		{
+			name:  "sismember",
+			input: "SISMEMBER tags go",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().SIsMember(ctx, "tags", "go").Return(true, nil)
+			},
+			want: "EXISTS",
+		},
+		{
+			name:  "sismember missing",
+			input: "SISMEMBER tags rust",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().SIsMember(ctx, "tags", "rust").Return(false, nil)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:  "sinter",
+			input: "SINTER a b",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().SInter(ctx, []string{"a", "b"}).Return([]string{"x"}, nil)
+			},
+			want: `MEMBERS "x"`,
+		},
+		{
+			name:  "sunion empty",
+			input: "SUNION a b",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().SUnion(ctx, []string{"a", "b"}).Return(nil, nil)
+			},
+			want: "MEMBERS",
+		},
+		{
+			name:  "zadd",
+			input: `ZADD board 1.5 ann "-2" bob "+inf" cid`,
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().ZAdd(ctx, "board", []kv.ScoredMember{
+					{Member: "ann", Score: 1.5}, {Member: "bob", Score: -2}, {Member: "cid", Score: math.Inf(1)},
+				}).Return(3, nil)
+			},
+			want: "ADDED 3",
+		},
+		{
+			name:    "zadd invalid score",
+			input:   "ZADD board high ann",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "zadd nan score",
+			input:   "ZADD board nan ann",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "zadd score without member",
+			input:   "ZADD board 1 ann 2",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "zrem",
+			input: "ZREM board ann bob",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().ZRem(ctx, "board", []string{"ann", "bob"}).Return(1, nil)
+			},
+			want: "DELETED 1",
+		},
+		{
+			name:  "zscore",
+			input: "ZSCORE board ann",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().ZScore(ctx, "board", "ann").Return(1.5, nil)
+			},
+			want: "SCORE 1.5",
+		},
+		{
+			name:  "zscore missing",
+			input: "ZSCORE board zed",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().ZScore(ctx, "board", "zed").Return(0, dberrors.ErrNotFound)
+			},
+			want: "NOT_FOUND",
+		},
+		{
+			name:  "zrank",
+			input: "ZRANK board ann",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().ZRank(ctx, "board", "ann").Return(2, nil)
+			},
+			want: "RANK 2",
+		},
+		{
+			name:  "zrange",
+			input: `ZRANGE board 0 "-1"`,
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().ZRange(ctx, "board", 0, -1).
+					Return([]kv.ScoredMember{{Member: "bob", Score: -2}, {Member: "ann", Score: 1.5}}, nil)
+			},
+			want: `MEMBERS "bob" "ann"`,
+		},
+		{
+			name:  "zrange withscores",
+			input: "ZRANGE board 0 1 WITHSCORES",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().ZRange(ctx, "board", 0, 1).
+					Return([]kv.ScoredMember{{Member: "bob", Score: -2}, {Member: "ann", Score: 1.5}}, nil)
+			},
+			want: `MEMBERS "bob" "-2" "ann" "1.5"`,
+		},
+		{
+			name:    "zrange unknown option",
+			input:   "ZRANGE board 0 1 REV",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "zrangebyscore",
+			input: `ZRANGEBYSCORE board "(1" "+inf" WITHSCORES LIMIT 2`,
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().ZRangeByScore(ctx, "board",
+					kv.ScoreBound{Score: 1, Exclusive: true}, kv.ScoreBound{Score: math.Inf(1)}, 2).
+					Return([]kv.ScoredMember{{Member: "ann", Score: 1.5}}, nil)
+			},
+			want: `MEMBERS "ann" "1.5"`,
+		},
+		{
+			name:  "zrangebyscore unbounded below",
+			input: `ZRANGEBYSCORE board "-inf" "(5"`,
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().ZRangeByScore(ctx, "board",
+					kv.ScoreBound{Score: math.Inf(-1)}, kv.ScoreBound{Score: 5, Exclusive: true}, 0).
+					Return(nil, nil)
+			},
+			want: "MEMBERS",
+		},
+		{
+			name:    "zrangebyscore invalid bound",
+			input:   "ZRANGEBYSCORE board low 5",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "zrangebyscore invalid limit",
+			input:   "ZRANGEBYSCORE board 0 5 LIMIT 0",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:    "zrangebyscore repeated option",
+			input:   "ZRANGEBYSCORE board 0 5 WITHSCORES WITHSCORES",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:  "incr",
+			input: "INCR counter",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrBy(ctx, "counter", int64(1)).Return(1, nil)
+			},
+			want: "VALUE 1",
+		},
+		{
+			name:  "decr",
+			input: "DECR counter",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrBy(ctx, "counter", int64(-1)).Return(-1, nil)
+			},
+			want: "VALUE -1",
+		},
+		{
+			name:  "incrby quoted negative delta",
+			input: `INCRBY counter "-10"`,
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrBy(ctx, "counter", int64(-10)).Return(-5, nil)
+			},
+			want: "VALUE -5",
+		},
+		{
+			name:  "decrby",
+			input: "DECRBY counter 3",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrBy(ctx, "counter", int64(-3)).Return(7, nil)
+			},
+			want: "VALUE 7",
+		},
+		{
+			name:    "decrby min int64 overflows",
+			input:   "DECRBY counter \"-9223372036854775808\"",
+			wantErr: dberrors.ErrOverflow,
+		},
+		{
+			name:    "incrby not an integer delta",
+			input:   "INCRBY counter 1.5",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:  "incr not an integer value",
+			input: "INCR name",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrBy(ctx, "name", int64(1)).Return(0, dberrors.ErrNotInteger)
+			},
+			wantErr: dberrors.ErrNotInteger,
+		},
+		{
+			name:  "incr overflow",
+			input: "INCR counter",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrBy(ctx, "counter", int64(1)).Return(0, dberrors.ErrOverflow)
+			},
+			wantErr: dberrors.ErrOverflow,
+		},
+		{
+			name:    "incr invalid quantity",
+			input:   "INCR counter 1",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "incrbyfloat",
+			input: "INCRBYFLOAT price 0.25",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrByFloat(ctx, "price", 0.25).Return("10.75", nil)
+			},
+			want: "VALUE 10.75",
+		},
+		{
+			name:    "incrbyfloat infinite delta",
+			input:   "INCRBYFLOAT price inf",
+			wantErr: compute.ErrInvalidArg,
+		},
+		{
+			name:  "incrbyfloat not a float value",
+			input: "INCRBYFLOAT name 1",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().IncrByFloat(ctx, "name", 1.0).Return("", dberrors.ErrNotFloat)
+			},
+			wantErr: dberrors.ErrNotFloat,
+		},
+		{
+			name:  "mset ok",
+			input: "MSET a 1 b 2",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().MSet(ctx, []kv.Pair{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}}).Return(nil)
+			},
+			want: "OK",
+		},
+		{
+			name:    "mset odd arguments",
+			input:   "MSET a 1 b",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "mset out of memory",
+			input: "MSET a 1",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().MSet(ctx, []kv.Pair{{Key: "a", Value: "1"}}).Return(dberrors.ErrOutOfMemory)
+			},
+			wantErr: dberrors.ErrOutOfMemory,
+		},
+		{
+			name:  "mget ok",
+			input: "MGET a missing b",
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().MGet(ctx, []string{"a", "missing", "b"}).Return([]kv.Lookup{
+					{Value: "1", Found: true},
+					{},
+					{Value: "NOT_FOUND", Found: true},
+				}, nil)
+			},
+			want: `VALUES "1" NOT_FOUND "NOT_FOUND"`,
+		},
+		{
+			name:    "mget no keys",
+			input:   "MGET",
+			wantErr: compute.ErrInvalidQuantity,
+		},
+		{
+			name:  "mdel ok",
+			input: "MDEL a b c",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().MDel(ctx, []string{"a", "b", "c"}).Return(2, nil)
+			},
+			want: "DELETED 2",
+		},
+		{
+			name:  "mdel read only",
+			input: "MDEL a",
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().MDel(ctx, []string{"a"}).Return(0, dberrors.ErrReadOnly)
+			},
+			wantErr: dberrors.ErrReadOnly,
+		},
+		{
+			name:    "invalid quantity stats",
+			input:   "STATS key",
+			wantErr: compute.ErrInvalidQuantity,
+		},
 		{
 			name:    "invalid command",
 			input:   "BAD key",
@@ -128,6 +1026,48 @@ func TestComputeHandler(t *testing.T) {
 			input:   "SET key !",
 			wantErr: compute.ErrInvalidSyntaxArg,
 		},
+		{
+			name:  "set quoted value",
+			input: `SET "user:1" "{\"name\": \"Ann Lee\"}"`,
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Set(ctx, "user:1", `{"name": "Ann Lee"}`).Return(nil)
+			},
+			want: "OK",
+		},
+		{
+			name:  "set empty value",
+			input: `SET key ''`,
+			setup: func(ctx context.Context, cmd *computemocks.MockCommandCompute, _ *computemocks.MockQueryCompute) {
+				cmd.EXPECT().Set(ctx, "key", "").Return(nil)
+			},
+			want: "OK",
+		},
+		{
+			name:  "get value quoted in response",
+			input: `GET key`,
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Get(ctx, "key").Return("Ann Lee", nil)
+			},
+			want: `VALUE "Ann Lee"`,
+		},
+		{
+			name:  "get value with newline",
+			input: `GET key`,
+			setup: func(ctx context.Context, _ *computemocks.MockCommandCompute, q *computemocks.MockQueryCompute) {
+				q.EXPECT().Get(ctx, "key").Return("a\nb", nil)
+			},
+			want: `VALUE "a\nb"`,
+		},
+		{
+			name:    "unterminated quote",
+			input:   `SET key "value`,
+			wantErr: command.ErrUnterminatedQuote,
+		},
+		{
+			name:    "invalid escape",
+			input:   `SET key "\q"`,
+			wantErr: compute.ErrInvalidSyntaxArg,
+		},
 		{
 			name:    "empty command",
 			input:   "   ",
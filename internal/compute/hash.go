@@ -0,0 +1,140 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+)
+
+// handleHSet parses HSET key field value [field value ...] and answers
+// ADDED n with the number of fields that did not exist before.
+func (c *Compute) handleHSet(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.hset"
+
+	args := tokens[1:]
+	if len(args) < command.CommandHSetMinQ || len(args)%2 == 0 {
+		c.log.Info("must be a key and field value pairs")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	fields := make([]kv.Pair, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		fields = append(fields, kv.Pair{Key: args[i], Value: args[i+1]})
+	}
+
+	added, err := c.commandCompute.HSet(ctx, args[0], fields)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", args[0]), slog.Int("added", added))
+	return "ADDED " + strconv.Itoa(added), nil
+}
+
+func (c *Compute) handleHGet(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.hget"
+
+	if len(tokens)-1 != command.CommandHGetQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	value, err := c.queryCompute.HGet(ctx, tokens[1], tokens[2])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "VALUE " + command.QuoteIfNeeded(value), nil
+}
+
+// handleHDel answers DELETED n with the number of fields that existed. The
+// key is deleted together with its last field.
+func (c *Compute) handleHDel(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.hdel"
+
+	if len(tokens)-1 < command.CommandHDelMinQ {
+		c.log.Info("must be a key and at least one field")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	deleted, err := c.commandCompute.HDel(ctx, tokens[1], tokens[2:])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("deleted", deleted))
+	return "DELETED " + strconv.Itoa(deleted), nil
+}
+
+// handleHGetAll answers FIELDS "f1" "v1" "f2" "v2" ... with the fields sorted
+// by name; a missing key answers a bare FIELDS.
+func (c *Compute) handleHGetAll(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.hgetall"
+
+	if len(tokens)-1 != command.CommandHGetAllQ {
+		c.log.Info("must be one arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	fields, err := c.queryCompute.HGetAll(ctx, tokens[1])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("FIELDS")
+	for _, field := range fields {
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(field.Key))
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(field.Value))
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("fields", len(fields)))
+	return b.String(), nil
+}
+
+// handleHLen answers COUNT n, 0 for a missing key.
+func (c *Compute) handleHLen(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.hlen"
+
+	if len(tokens)-1 != command.CommandHLenQ {
+		c.log.Info("must be one arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	n, err := c.queryCompute.HLen(ctx, tokens[1])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "COUNT " + strconv.Itoa(n), nil
+}
+
+// handleHExists answers EXISTS or NOT_FOUND.
+func (c *Compute) handleHExists(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.hexists"
+
+	if len(tokens)-1 != command.CommandHExistsQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	ok, err := c.queryCompute.HExists(ctx, tokens[1], tokens[2])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	if !ok {
+		return "NOT_FOUND", nil
+	}
+	return "EXISTS", nil
+}
@@ -0,0 +1,204 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/lib/glob"
+)
+
+// handleScan serves the two forms of SCAN: the cursor walk SCAN cursor
+// [MATCH pattern] [COUNT n] and the range query SCAN start end [LIMIT n]. The
+// options are matched in any case, like those of SET.
+func (c *Compute) handleScan(ctx context.Context, tokens []string) (string, error) {
+	if isScanCursor(tokens[1:]) {
+		return c.handleScanCursor(ctx, tokens)
+	}
+	return c.handleRange(ctx, tokens)
+}
+
+// isScanCursor tells the cursor walk from the range query by its cursor: a
+// lone argument, which can only be a cursor, or a number followed by an
+// option, where a range query has its end key.
+func isScanCursor(args []string) bool {
+	if len(args) < 2 {
+		return len(args) == 1
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		return false
+	}
+	switch strings.ToUpper(args[1]) {
+	case command.OptionMatch, command.OptionCount, command.OptionLimit:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleScanCursor parses SCAN cursor [MATCH pattern] [COUNT n] and answers
+// CURSOR next "k1" "k2" ..., where next is the cursor of the following call
+// and 0 once the walk is complete. A walk starts at cursor 0 and returns every
+// key that exists for its whole duration at least once. MATCH filters the
+// keys of a step, so a step may answer fewer keys than COUNT, or none.
+func (c *Compute) handleScanCursor(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.scan"
+
+	if len(tokens)-1 > command.CommandScanCursorMaxQ {
+		c.log.Info("must be one, three or five arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	cursor, err := strconv.ParseUint(tokens[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+	}
+
+	var (
+		pattern  string
+		matching bool
+		count    = command.DefaultScanCount
+		counted  bool
+	)
+	for i := 2; i < len(tokens); i += 2 {
+		if i+1 >= len(tokens) {
+			c.log.Info("option must be followed by its value", slog.String("option", tokens[i]))
+			return "", fmt.Errorf("%w", ErrInvalidQuantity)
+		}
+
+		option := strings.ToUpper(tokens[i])
+		switch {
+		case option == command.OptionMatch && !matching:
+			pattern, matching = tokens[i+1], true
+		case option == command.OptionCount && !counted:
+			n, err := strconv.Atoi(tokens[i+1])
+			if err != nil || n <= 0 {
+				return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+			}
+			count, counted = n, true
+		default:
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+		}
+	}
+
+	keys, next, err := c.queryCompute.Scan(ctx, cursor, count)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("CURSOR ")
+	b.WriteString(strconv.FormatUint(next, 10))
+	for _, key := range keys {
+		if matching && !glob.Match(pattern, key) {
+			continue
+		}
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(key))
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Uint64("cursor", next))
+	return b.String(), nil
+}
+
+// handleRange parses SCAN start end [LIMIT n] and answers the keys in
+// [start, end) in lexicographic order as KEYS "k1" "k2" ..., followed by
+// NEXT "key" when more keys remain; the next page is requested with that key
+// as start. An empty end, "", is no upper bound.
+func (c *Compute) handleRange(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.range"
+
+	limit := command.DefaultScanLimit
+	switch len(tokens) - 1 {
+	case command.CommandScanQ:
+	case command.CommandScanLimitQ:
+		if !strings.EqualFold(tokens[3], command.OptionLimit) {
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+		}
+		n, err := strconv.Atoi(tokens[4])
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+		}
+		limit = n
+	default:
+		c.log.Info("must be two or four arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	keys, next, err := c.queryCompute.Range(ctx, tokens[1], tokens[2], limit)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("keys", len(keys)))
+	return formatKeys(keys, next), nil
+}
+
+// handleKeys parses KEYS prefix* and answers every key with that prefix in
+// lexicographic order; a pattern without the trailing star matches the key
+// itself. Large key spaces are better walked with SCAN.
+func (c *Compute) handleKeys(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.keys"
+
+	if len(tokens)-1 != command.CommandKeysQ {
+		c.log.Info("must be one argument")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	prefix, isPrefix := strings.CutSuffix(tokens[1], "*")
+	if strings.Contains(prefix, "*") {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+	}
+
+	end := prefix + "\x00"
+	if isPrefix {
+		end = kv.PrefixEnd(prefix)
+	}
+
+	keys, _, err := c.queryCompute.Range(ctx, prefix, end, 0)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("keys", len(keys)))
+	return formatKeys(keys, ""), nil
+}
+
+// handleCount parses COUNT prefix and answers COUNT n.
+func (c *Compute) handleCount(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.count"
+
+	if len(tokens)-1 != command.CommandCountQ {
+		c.log.Info("must be one argument")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	count, err := c.queryCompute.CountPrefix(ctx, tokens[1])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("keys", count))
+	return "COUNT " + strconv.Itoa(count), nil
+}
+
+// formatKeys renders KEYS followed by the quoted keys and, unless next is
+// empty, NEXT and the quoted key the following page starts from. Keys are
+// always quoted, so the bare NEXT cannot be mistaken for one.
+func formatKeys(keys []string, next string) string {
+	var b strings.Builder
+	b.WriteString("KEYS")
+	for _, key := range keys {
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(key))
+	}
+	if next != "" {
+		b.WriteString(" NEXT ")
+		b.WriteString(command.Quote(next))
+	}
+	return b.String()
+}
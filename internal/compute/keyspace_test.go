@@ -0,0 +1,52 @@
+package compute_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/command"
+)
+
+func TestComputeScanMatch(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{"user/1", "user/22", "user/", "users", "order/1", "a*b", "a?b", "[x]", `a\b`, "ключ", "abc"}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "*", want: `CURSOR 0 "user/1" "user/22" "user/" "users" "order/1" "a*b" "a?b" "[x]" "a\\b" "ключ" "abc"`},
+		{pattern: "user/*", want: `CURSOR 0 "user/1" "user/22" "user/"`},
+		{pattern: "user/?", want: `CURSOR 0 "user/1"`},
+		{pattern: "*/1", want: `CURSOR 0 "user/1" "order/1"`},
+		{pattern: "user[s/]*", want: `CURSOR 0 "user/1" "user/22" "user/" "users"`},
+		{pattern: "user/[0-1]", want: `CURSOR 0 "user/1"`},
+		{pattern: "user/[^1]*", want: `CURSOR 0 "user/22"`},
+		{pattern: `a\*b`, want: `CURSOR 0 "a*b"`},
+		{pattern: `a\?b`, want: `CURSOR 0 "a?b"`},
+		{pattern: `a\\b`, want: `CURSOR 0 "a\\b"`},
+		{pattern: "[[]x*", want: `CURSOR 0 "[x]"`},
+		{pattern: "[x", want: `CURSOR 0`},
+		{pattern: "кл??", want: `CURSOR 0 "ключ"`},
+		{pattern: "a*c", want: `CURSOR 0 "abc"`},
+		{pattern: "nothing*", want: `CURSOR 0`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			c, _, query := newComputeWithMocks(t)
+			query.EXPECT().Scan(ctx, uint64(0), command.DefaultScanCount).Return(keys, 0, nil)
+
+			got, err := c.ComputeHandler(ctx, "SCAN 0 MATCH "+command.Quote(tc.pattern))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+)
+
+// handlePush serves LPUSH and RPUSH key value [value ...] and answers
+// COUNT n with the length of the list after the push.
+func (c *Compute) handlePush(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.push"
+
+	if len(tokens)-1 < command.CommandPushMinQ {
+		c.log.Info("must be a key and at least one value")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	n, err := c.commandCompute.Push(ctx, tokens[1], tokens[2:], listEnd(tokens[0]))
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("length", n))
+	return "COUNT " + strconv.Itoa(n), nil
+}
+
+// handlePop serves LPOP and RPOP key and answers VALUE x or NOT_FOUND.
+func (c *Compute) handlePop(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.pop"
+
+	if len(tokens)-1 != command.CommandPopQ {
+		c.log.Info("must be one arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	value, err := c.commandCompute.Pop(ctx, tokens[1], listEnd(tokens[0]))
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "VALUE " + command.QuoteIfNeeded(value), nil
+}
+
+// handleBPop serves BLPOP and BRPOP key [key ...] timeout. It answers
+// POPPED "key" "value" with the first key that holds a list, waiting up to
+// timeout seconds for a push, 0 waiting indefinitely, and NOT_FOUND when the
+// timeout passes first.
+func (c *Compute) handleBPop(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.bpop"
+
+	if len(tokens)-1 < command.CommandBPopMinQ {
+		c.log.Info("must be at least one key and a timeout")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	timeout, err := parseTimeout(tokens[len(tokens)-1])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	popped, err := c.commandCompute.BPop(ctx, tokens[1:len(tokens)-1], listEnd(tokens[0]), timeout)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", popped.Key))
+	return "POPPED " + command.Quote(popped.Key) + " " + command.Quote(popped.Value), nil
+}
+
+// handleLRange serves LRANGE key start stop and answers ITEMS "a" "b" ...
+// with the items between the inclusive indexes, negative ones counting from
+// the tail.
+func (c *Compute) handleLRange(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.lrange"
+
+	if len(tokens)-1 != command.CommandLRangeQ {
+		c.log.Info("must be three arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	start, err := strconv.Atoi(tokens[2])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+	}
+	stop, err := strconv.Atoi(tokens[3])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+	}
+
+	items, err := c.queryCompute.LRange(ctx, tokens[1], start, stop)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("ITEMS")
+	for _, item := range items {
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(item))
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("items", len(items)))
+	return b.String(), nil
+}
+
+// handleLLen answers COUNT n, 0 for a missing key.
+func (c *Compute) handleLLen(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.llen"
+
+	if len(tokens)-1 != command.CommandLLenQ {
+		c.log.Info("must be one arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	n, err := c.queryCompute.LLen(ctx, tokens[1])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "COUNT " + strconv.Itoa(n), nil
+}
+
+// listEnd picks the end of the list a command works on from its name.
+func listEnd(name string) kv.End {
+	switch name {
+	case command.CommandRPush, command.CommandRPop, command.CommandBRPop:
+		return kv.Right
+	default:
+		return kv.Left
+	}
+}
+
+// parseTimeout parses a non-negative, possibly fractional, number of seconds.
+func parseTimeout(raw string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(raw, 64)
+	if err != nil || seconds < 0 || seconds > float64(math.MaxInt64/time.Second) {
+		return 0, ErrInvalidArg
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
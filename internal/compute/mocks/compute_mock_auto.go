@@ -6,6 +6,9 @@ package compute_test
 
 import (
 	"context"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
+	"time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -37,6 +40,84 @@ func (_m *MockCommandCompute) EXPECT() *MockCommandCompute_Expecter {
 	return &MockCommandCompute_Expecter{mock: &_m.Mock}
 }
 
+// BPop provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) BPop(ctx context.Context, keys []string, end kv.End, timeout time.Duration) (kv.Pair, error) {
+	ret := _mock.Called(ctx, keys, end, timeout)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BPop")
+	}
+
+	var r0 kv.Pair
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string, kv.End, time.Duration) (kv.Pair, error)); ok {
+		return returnFunc(ctx, keys, end, timeout)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string, kv.End, time.Duration) kv.Pair); ok {
+		r0 = returnFunc(ctx, keys, end, timeout)
+	} else {
+		r0 = ret.Get(0).(kv.Pair)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string, kv.End, time.Duration) error); ok {
+		r1 = returnFunc(ctx, keys, end, timeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_BPop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BPop'
+type MockCommandCompute_BPop_Call struct {
+	*mock.Call
+}
+
+// BPop is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+//   - end kv.End
+//   - timeout time.Duration
+func (_e *MockCommandCompute_Expecter) BPop(ctx interface{}, keys interface{}, end interface{}, timeout interface{}) *MockCommandCompute_BPop_Call {
+	return &MockCommandCompute_BPop_Call{Call: _e.mock.On("BPop", ctx, keys, end, timeout)}
+}
+
+func (_c *MockCommandCompute_BPop_Call) Run(run func(ctx context.Context, keys []string, end kv.End, timeout time.Duration)) *MockCommandCompute_BPop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		var arg2 kv.End
+		if args[2] != nil {
+			arg2 = args[2].(kv.End)
+		}
+		var arg3 time.Duration
+		if args[3] != nil {
+			arg3 = args[3].(time.Duration)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_BPop_Call) Return(pair kv.Pair, err error) *MockCommandCompute_BPop_Call {
+	_c.Call.Return(pair, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_BPop_Call) RunAndReturn(run func(ctx context.Context, keys []string, end kv.End, timeout time.Duration) (kv.Pair, error)) *MockCommandCompute_BPop_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Del provides a mock function for the type MockCommandCompute
 func (_mock *MockCommandCompute) Del(ctx context.Context, key string) error {
 	ret := _mock.Called(ctx, key)
@@ -94,37 +175,94 @@ func (_c *MockCommandCompute_Del_Call) RunAndReturn(run func(ctx context.Context
 	return _c
 }
 
-// Set provides a mock function for the type MockCommandCompute
-func (_mock *MockCommandCompute) Set(ctx context.Context, key string, value string) error {
-	ret := _mock.Called(ctx, key, value)
+// Exec provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) Exec(ctx context.Context, fn func(ctx context.Context) error) error {
+	ret := _mock.Called(ctx, fn)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Set")
+		panic("no return value specified for Exec")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = returnFunc(ctx, key, value)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, func(ctx context.Context) error) error); ok {
+		r0 = returnFunc(ctx, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockCommandCompute_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
-type MockCommandCompute_Set_Call struct {
+// MockCommandCompute_Exec_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exec'
+type MockCommandCompute_Exec_Call struct {
 	*mock.Call
 }
 
-// Set is a helper method to define mock.On call
+// Exec is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fn func(ctx context.Context) error
+func (_e *MockCommandCompute_Expecter) Exec(ctx interface{}, fn interface{}) *MockCommandCompute_Exec_Call {
+	return &MockCommandCompute_Exec_Call{Call: _e.mock.On("Exec", ctx, fn)}
+}
+
+func (_c *MockCommandCompute_Exec_Call) Run(run func(ctx context.Context, fn func(ctx context.Context) error)) *MockCommandCompute_Exec_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 func(ctx context.Context) error
+		if args[1] != nil {
+			arg1 = args[1].(func(ctx context.Context) error)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_Exec_Call) Return(err error) *MockCommandCompute_Exec_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandCompute_Exec_Call) RunAndReturn(run func(ctx context.Context, fn func(ctx context.Context) error) error) *MockCommandCompute_Exec_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Expire provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	ret := _mock.Called(ctx, key, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Expire")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = returnFunc(ctx, key, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandCompute_Expire_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Expire'
+type MockCommandCompute_Expire_Call struct {
+	*mock.Call
+}
+
+// Expire is a helper method to define mock.On call
 //   - ctx context.Context
 //   - key string
-//   - value string
-func (_e *MockCommandCompute_Expecter) Set(ctx interface{}, key interface{}, value interface{}) *MockCommandCompute_Set_Call {
-	return &MockCommandCompute_Set_Call{Call: _e.mock.On("Set", ctx, key, value)}
+//   - ttl time.Duration
+func (_e *MockCommandCompute_Expecter) Expire(ctx interface{}, key interface{}, ttl interface{}) *MockCommandCompute_Expire_Call {
+	return &MockCommandCompute_Expire_Call{Call: _e.mock.On("Expire", ctx, key, ttl)}
 }
 
-func (_c *MockCommandCompute_Set_Call) Run(run func(ctx context.Context, key string, value string)) *MockCommandCompute_Set_Call {
+func (_c *MockCommandCompute_Expire_Call) Run(run func(ctx context.Context, key string, ttl time.Duration)) *MockCommandCompute_Expire_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -134,9 +272,9 @@ func (_c *MockCommandCompute_Set_Call) Run(run func(ctx context.Context, key str
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 string
+		var arg2 time.Duration
 		if args[2] != nil {
-			arg2 = args[2].(string)
+			arg2 = args[2].(time.Duration)
 		}
 		run(
 			arg0,
@@ -147,82 +285,128 @@ func (_c *MockCommandCompute_Set_Call) Run(run func(ctx context.Context, key str
 	return _c
 }
 
-func (_c *MockCommandCompute_Set_Call) Return(err error) *MockCommandCompute_Set_Call {
+func (_c *MockCommandCompute_Expire_Call) Return(err error) *MockCommandCompute_Expire_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockCommandCompute_Set_Call) RunAndReturn(run func(ctx context.Context, key string, value string) error) *MockCommandCompute_Set_Call {
+func (_c *MockCommandCompute_Expire_Call) RunAndReturn(run func(ctx context.Context, key string, ttl time.Duration) error) *MockCommandCompute_Expire_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NewMockQueryCompute creates a new instance of MockQueryCompute. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func NewMockQueryCompute(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *MockQueryCompute {
-	mock := &MockQueryCompute{}
-	mock.Mock.Test(t)
+// HDel provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) HDel(ctx context.Context, key string, fields []string) (int, error) {
+	ret := _mock.Called(ctx, key, fields)
 
-	t.Cleanup(func() { mock.AssertExpectations(t) })
+	if len(ret) == 0 {
+		panic("no return value specified for HDel")
+	}
 
-	return mock
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, fields)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, fields)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, fields)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockQueryCompute is an autogenerated mock type for the QueryCompute type
-type MockQueryCompute struct {
-	mock.Mock
+// MockCommandCompute_HDel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HDel'
+type MockCommandCompute_HDel_Call struct {
+	*mock.Call
 }
 
-type MockQueryCompute_Expecter struct {
-	mock *mock.Mock
+// HDel is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - fields []string
+func (_e *MockCommandCompute_Expecter) HDel(ctx interface{}, key interface{}, fields interface{}) *MockCommandCompute_HDel_Call {
+	return &MockCommandCompute_HDel_Call{Call: _e.mock.On("HDel", ctx, key, fields)}
 }
 
-func (_m *MockQueryCompute) EXPECT() *MockQueryCompute_Expecter {
-	return &MockQueryCompute_Expecter{mock: &_m.Mock}
+func (_c *MockCommandCompute_HDel_Call) Run(run func(ctx context.Context, key string, fields []string)) *MockCommandCompute_HDel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
 }
 
-// Get provides a mock function for the type MockQueryCompute
-func (_mock *MockQueryCompute) Get(ctx context.Context, key string) (string, error) {
-	ret := _mock.Called(ctx, key)
+func (_c *MockCommandCompute_HDel_Call) Return(n int, err error) *MockCommandCompute_HDel_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_HDel_Call) RunAndReturn(run func(ctx context.Context, key string, fields []string) (int, error)) *MockCommandCompute_HDel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HSet provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) HSet(ctx context.Context, key string, fields []kv.Pair) (int, error) {
+	ret := _mock.Called(ctx, key, fields)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for HSet")
 	}
 
-	var r0 string
+	var r0 int
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return returnFunc(ctx, key)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.Pair) (int, error)); ok {
+		return returnFunc(ctx, key, fields)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, key)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.Pair) int); ok {
+		r0 = returnFunc(ctx, key, fields)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(int)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, key)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []kv.Pair) error); ok {
+		r1 = returnFunc(ctx, key, fields)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockQueryCompute_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockQueryCompute_Get_Call struct {
+// MockCommandCompute_HSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HSet'
+type MockCommandCompute_HSet_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// HSet is a helper method to define mock.On call
 //   - ctx context.Context
 //   - key string
-func (_e *MockQueryCompute_Expecter) Get(ctx interface{}, key interface{}) *MockQueryCompute_Get_Call {
-	return &MockQueryCompute_Get_Call{Call: _e.mock.On("Get", ctx, key)}
+//   - fields []kv.Pair
+func (_e *MockCommandCompute_Expecter) HSet(ctx interface{}, key interface{}, fields interface{}) *MockCommandCompute_HSet_Call {
+	return &MockCommandCompute_HSet_Call{Call: _e.mock.On("HSet", ctx, key, fields)}
 }
 
-func (_c *MockQueryCompute_Get_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_Get_Call {
+func (_c *MockCommandCompute_HSet_Call) Run(run func(ctx context.Context, key string, fields []kv.Pair)) *MockCommandCompute_HSet_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -232,20 +416,2598 @@ func (_c *MockQueryCompute_Get_Call) Run(run func(ctx context.Context, key strin
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 []kv.Pair
+		if args[2] != nil {
+			arg2 = args[2].([]kv.Pair)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockQueryCompute_Get_Call) Return(s string, err error) *MockQueryCompute_Get_Call {
-	_c.Call.Return(s, err)
+func (_c *MockCommandCompute_HSet_Call) Return(n int, err error) *MockCommandCompute_HSet_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *MockQueryCompute_Get_Call) RunAndReturn(run func(ctx context.Context, key string) (string, error)) *MockQueryCompute_Get_Call {
+func (_c *MockCommandCompute_HSet_Call) RunAndReturn(run func(ctx context.Context, key string, fields []kv.Pair) (int, error)) *MockCommandCompute_HSet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IncrBy provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	ret := _mock.Called(ctx, key, delta)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrBy")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) (int64, error)); ok {
+		return returnFunc(ctx, key, delta)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) int64); ok {
+		r0 = returnFunc(ctx, key, delta)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = returnFunc(ctx, key, delta)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_IncrBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrBy'
+type MockCommandCompute_IncrBy_Call struct {
+	*mock.Call
+}
+
+// IncrBy is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - delta int64
+func (_e *MockCommandCompute_Expecter) IncrBy(ctx interface{}, key interface{}, delta interface{}) *MockCommandCompute_IncrBy_Call {
+	return &MockCommandCompute_IncrBy_Call{Call: _e.mock.On("IncrBy", ctx, key, delta)}
+}
+
+func (_c *MockCommandCompute_IncrBy_Call) Run(run func(ctx context.Context, key string, delta int64)) *MockCommandCompute_IncrBy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_IncrBy_Call) Return(n int64, err error) *MockCommandCompute_IncrBy_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_IncrBy_Call) RunAndReturn(run func(ctx context.Context, key string, delta int64) (int64, error)) *MockCommandCompute_IncrBy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IncrByFloat provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) IncrByFloat(ctx context.Context, key string, delta float64) (string, error) {
+	ret := _mock.Called(ctx, key, delta)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrByFloat")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) (string, error)); ok {
+		return returnFunc(ctx, key, delta)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) string); ok {
+		r0 = returnFunc(ctx, key, delta)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, float64) error); ok {
+		r1 = returnFunc(ctx, key, delta)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_IncrByFloat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrByFloat'
+type MockCommandCompute_IncrByFloat_Call struct {
+	*mock.Call
+}
+
+// IncrByFloat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - delta float64
+func (_e *MockCommandCompute_Expecter) IncrByFloat(ctx interface{}, key interface{}, delta interface{}) *MockCommandCompute_IncrByFloat_Call {
+	return &MockCommandCompute_IncrByFloat_Call{Call: _e.mock.On("IncrByFloat", ctx, key, delta)}
+}
+
+func (_c *MockCommandCompute_IncrByFloat_Call) Run(run func(ctx context.Context, key string, delta float64)) *MockCommandCompute_IncrByFloat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 float64
+		if args[2] != nil {
+			arg2 = args[2].(float64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_IncrByFloat_Call) Return(s string, err error) *MockCommandCompute_IncrByFloat_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_IncrByFloat_Call) RunAndReturn(run func(ctx context.Context, key string, delta float64) (string, error)) *MockCommandCompute_IncrByFloat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MDel provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) MDel(ctx context.Context, keys []string) (int, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MDel")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) (int, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) int); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_MDel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MDel'
+type MockCommandCompute_MDel_Call struct {
+	*mock.Call
+}
+
+// MDel is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockCommandCompute_Expecter) MDel(ctx interface{}, keys interface{}) *MockCommandCompute_MDel_Call {
+	return &MockCommandCompute_MDel_Call{Call: _e.mock.On("MDel", ctx, keys)}
+}
+
+func (_c *MockCommandCompute_MDel_Call) Run(run func(ctx context.Context, keys []string)) *MockCommandCompute_MDel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_MDel_Call) Return(n int, err error) *MockCommandCompute_MDel_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_MDel_Call) RunAndReturn(run func(ctx context.Context, keys []string) (int, error)) *MockCommandCompute_MDel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MSet provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) MSet(ctx context.Context, pairs []kv.Pair) error {
+	ret := _mock.Called(ctx, pairs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MSet")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []kv.Pair) error); ok {
+		r0 = returnFunc(ctx, pairs)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandCompute_MSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MSet'
+type MockCommandCompute_MSet_Call struct {
+	*mock.Call
+}
+
+// MSet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pairs []kv.Pair
+func (_e *MockCommandCompute_Expecter) MSet(ctx interface{}, pairs interface{}) *MockCommandCompute_MSet_Call {
+	return &MockCommandCompute_MSet_Call{Call: _e.mock.On("MSet", ctx, pairs)}
+}
+
+func (_c *MockCommandCompute_MSet_Call) Run(run func(ctx context.Context, pairs []kv.Pair)) *MockCommandCompute_MSet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []kv.Pair
+		if args[1] != nil {
+			arg1 = args[1].([]kv.Pair)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_MSet_Call) Return(err error) *MockCommandCompute_MSet_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandCompute_MSet_Call) RunAndReturn(run func(ctx context.Context, pairs []kv.Pair) error) *MockCommandCompute_MSet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Persist provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) Persist(ctx context.Context, key string) error {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Persist")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandCompute_Persist_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Persist'
+type MockCommandCompute_Persist_Call struct {
+	*mock.Call
+}
+
+// Persist is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockCommandCompute_Expecter) Persist(ctx interface{}, key interface{}) *MockCommandCompute_Persist_Call {
+	return &MockCommandCompute_Persist_Call{Call: _e.mock.On("Persist", ctx, key)}
+}
+
+func (_c *MockCommandCompute_Persist_Call) Run(run func(ctx context.Context, key string)) *MockCommandCompute_Persist_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_Persist_Call) Return(err error) *MockCommandCompute_Persist_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandCompute_Persist_Call) RunAndReturn(run func(ctx context.Context, key string) error) *MockCommandCompute_Persist_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Pop provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) Pop(ctx context.Context, key string, end kv.End) (string, error) {
+	ret := _mock.Called(ctx, key, end)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pop")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.End) (string, error)); ok {
+		return returnFunc(ctx, key, end)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.End) string); ok {
+		r0 = returnFunc(ctx, key, end)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, kv.End) error); ok {
+		r1 = returnFunc(ctx, key, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_Pop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pop'
+type MockCommandCompute_Pop_Call struct {
+	*mock.Call
+}
+
+// Pop is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - end kv.End
+func (_e *MockCommandCompute_Expecter) Pop(ctx interface{}, key interface{}, end interface{}) *MockCommandCompute_Pop_Call {
+	return &MockCommandCompute_Pop_Call{Call: _e.mock.On("Pop", ctx, key, end)}
+}
+
+func (_c *MockCommandCompute_Pop_Call) Run(run func(ctx context.Context, key string, end kv.End)) *MockCommandCompute_Pop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 kv.End
+		if args[2] != nil {
+			arg2 = args[2].(kv.End)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_Pop_Call) Return(s string, err error) *MockCommandCompute_Pop_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_Pop_Call) RunAndReturn(run func(ctx context.Context, key string, end kv.End) (string, error)) *MockCommandCompute_Pop_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Push provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) Push(ctx context.Context, key string, values []string, end kv.End) (int, error) {
+	ret := _mock.Called(ctx, key, values, end)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Push")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, kv.End) (int, error)); ok {
+		return returnFunc(ctx, key, values, end)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, kv.End) int); ok {
+		r0 = returnFunc(ctx, key, values, end)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string, kv.End) error); ok {
+		r1 = returnFunc(ctx, key, values, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_Push_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Push'
+type MockCommandCompute_Push_Call struct {
+	*mock.Call
+}
+
+// Push is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - values []string
+//   - end kv.End
+func (_e *MockCommandCompute_Expecter) Push(ctx interface{}, key interface{}, values interface{}, end interface{}) *MockCommandCompute_Push_Call {
+	return &MockCommandCompute_Push_Call{Call: _e.mock.On("Push", ctx, key, values, end)}
+}
+
+func (_c *MockCommandCompute_Push_Call) Run(run func(ctx context.Context, key string, values []string, end kv.End)) *MockCommandCompute_Push_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		var arg3 kv.End
+		if args[3] != nil {
+			arg3 = args[3].(kv.End)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_Push_Call) Return(n int, err error) *MockCommandCompute_Push_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_Push_Call) RunAndReturn(run func(ctx context.Context, key string, values []string, end kv.End) (int, error)) *MockCommandCompute_Push_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SAdd provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) SAdd(ctx context.Context, key string, members []string) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SAdd")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_SAdd_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SAdd'
+type MockCommandCompute_SAdd_Call struct {
+	*mock.Call
+}
+
+// SAdd is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []string
+func (_e *MockCommandCompute_Expecter) SAdd(ctx interface{}, key interface{}, members interface{}) *MockCommandCompute_SAdd_Call {
+	return &MockCommandCompute_SAdd_Call{Call: _e.mock.On("SAdd", ctx, key, members)}
+}
+
+func (_c *MockCommandCompute_SAdd_Call) Run(run func(ctx context.Context, key string, members []string)) *MockCommandCompute_SAdd_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_SAdd_Call) Return(n int, err error) *MockCommandCompute_SAdd_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_SAdd_Call) RunAndReturn(run func(ctx context.Context, key string, members []string) (int, error)) *MockCommandCompute_SAdd_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SRem provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) SRem(ctx context.Context, key string, members []string) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SRem")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_SRem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SRem'
+type MockCommandCompute_SRem_Call struct {
+	*mock.Call
+}
+
+// SRem is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []string
+func (_e *MockCommandCompute_Expecter) SRem(ctx interface{}, key interface{}, members interface{}) *MockCommandCompute_SRem_Call {
+	return &MockCommandCompute_SRem_Call{Call: _e.mock.On("SRem", ctx, key, members)}
+}
+
+func (_c *MockCommandCompute_SRem_Call) Run(run func(ctx context.Context, key string, members []string)) *MockCommandCompute_SRem_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_SRem_Call) Return(n int, err error) *MockCommandCompute_SRem_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_SRem_Call) RunAndReturn(run func(ctx context.Context, key string, members []string) (int, error)) *MockCommandCompute_SRem_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Set provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) Set(ctx context.Context, key string, value string) error {
+	ret := _mock.Called(ctx, key, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Set")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, key, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandCompute_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
+type MockCommandCompute_Set_Call struct {
+	*mock.Call
+}
+
+// Set is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value string
+func (_e *MockCommandCompute_Expecter) Set(ctx interface{}, key interface{}, value interface{}) *MockCommandCompute_Set_Call {
+	return &MockCommandCompute_Set_Call{Call: _e.mock.On("Set", ctx, key, value)}
+}
+
+func (_c *MockCommandCompute_Set_Call) Run(run func(ctx context.Context, key string, value string)) *MockCommandCompute_Set_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_Set_Call) Return(err error) *MockCommandCompute_Set_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandCompute_Set_Call) RunAndReturn(run func(ctx context.Context, key string, value string) error) *MockCommandCompute_Set_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetIf provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) SetIf(ctx context.Context, key string, value string, ttl time.Duration, cond kv.Condition) (kv.Lookup, error) {
+	ret := _mock.Called(ctx, key, value, ttl, cond)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetIf")
+	}
+
+	var r0 kv.Lookup
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Duration, kv.Condition) (kv.Lookup, error)); ok {
+		return returnFunc(ctx, key, value, ttl, cond)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Duration, kv.Condition) kv.Lookup); ok {
+		r0 = returnFunc(ctx, key, value, ttl, cond)
+	} else {
+		r0 = ret.Get(0).(kv.Lookup)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, time.Duration, kv.Condition) error); ok {
+		r1 = returnFunc(ctx, key, value, ttl, cond)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_SetIf_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetIf'
+type MockCommandCompute_SetIf_Call struct {
+	*mock.Call
+}
+
+// SetIf is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value string
+//   - ttl time.Duration
+//   - cond kv.Condition
+func (_e *MockCommandCompute_Expecter) SetIf(ctx interface{}, key interface{}, value interface{}, ttl interface{}, cond interface{}) *MockCommandCompute_SetIf_Call {
+	return &MockCommandCompute_SetIf_Call{Call: _e.mock.On("SetIf", ctx, key, value, ttl, cond)}
+}
+
+func (_c *MockCommandCompute_SetIf_Call) Run(run func(ctx context.Context, key string, value string, ttl time.Duration, cond kv.Condition)) *MockCommandCompute_SetIf_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 time.Duration
+		if args[3] != nil {
+			arg3 = args[3].(time.Duration)
+		}
+		var arg4 kv.Condition
+		if args[4] != nil {
+			arg4 = args[4].(kv.Condition)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_SetIf_Call) Return(lookup kv.Lookup, err error) *MockCommandCompute_SetIf_Call {
+	_c.Call.Return(lookup, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_SetIf_Call) RunAndReturn(run func(ctx context.Context, key string, value string, ttl time.Duration, cond kv.Condition) (kv.Lookup, error)) *MockCommandCompute_SetIf_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWithTTL provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	ret := _mock.Called(ctx, key, value, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetWithTTL")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) error); ok {
+		r0 = returnFunc(ctx, key, value, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandCompute_SetWithTTL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWithTTL'
+type MockCommandCompute_SetWithTTL_Call struct {
+	*mock.Call
+}
+
+// SetWithTTL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value string
+//   - ttl time.Duration
+func (_e *MockCommandCompute_Expecter) SetWithTTL(ctx interface{}, key interface{}, value interface{}, ttl interface{}) *MockCommandCompute_SetWithTTL_Call {
+	return &MockCommandCompute_SetWithTTL_Call{Call: _e.mock.On("SetWithTTL", ctx, key, value, ttl)}
+}
+
+func (_c *MockCommandCompute_SetWithTTL_Call) Run(run func(ctx context.Context, key string, value string, ttl time.Duration)) *MockCommandCompute_SetWithTTL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 time.Duration
+		if args[3] != nil {
+			arg3 = args[3].(time.Duration)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_SetWithTTL_Call) Return(err error) *MockCommandCompute_SetWithTTL_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandCompute_SetWithTTL_Call) RunAndReturn(run func(ctx context.Context, key string, value string, ttl time.Duration) error) *MockCommandCompute_SetWithTTL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZAdd provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) ZAdd(ctx context.Context, key string, members []kv.ScoredMember) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZAdd")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.ScoredMember) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.ScoredMember) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []kv.ScoredMember) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_ZAdd_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZAdd'
+type MockCommandCompute_ZAdd_Call struct {
+	*mock.Call
+}
+
+// ZAdd is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []kv.ScoredMember
+func (_e *MockCommandCompute_Expecter) ZAdd(ctx interface{}, key interface{}, members interface{}) *MockCommandCompute_ZAdd_Call {
+	return &MockCommandCompute_ZAdd_Call{Call: _e.mock.On("ZAdd", ctx, key, members)}
+}
+
+func (_c *MockCommandCompute_ZAdd_Call) Run(run func(ctx context.Context, key string, members []kv.ScoredMember)) *MockCommandCompute_ZAdd_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []kv.ScoredMember
+		if args[2] != nil {
+			arg2 = args[2].([]kv.ScoredMember)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_ZAdd_Call) Return(n int, err error) *MockCommandCompute_ZAdd_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_ZAdd_Call) RunAndReturn(run func(ctx context.Context, key string, members []kv.ScoredMember) (int, error)) *MockCommandCompute_ZAdd_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRem provides a mock function for the type MockCommandCompute
+func (_mock *MockCommandCompute) ZRem(ctx context.Context, key string, members []string) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRem")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandCompute_ZRem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRem'
+type MockCommandCompute_ZRem_Call struct {
+	*mock.Call
+}
+
+// ZRem is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []string
+func (_e *MockCommandCompute_Expecter) ZRem(ctx interface{}, key interface{}, members interface{}) *MockCommandCompute_ZRem_Call {
+	return &MockCommandCompute_ZRem_Call{Call: _e.mock.On("ZRem", ctx, key, members)}
+}
+
+func (_c *MockCommandCompute_ZRem_Call) Run(run func(ctx context.Context, key string, members []string)) *MockCommandCompute_ZRem_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandCompute_ZRem_Call) Return(n int, err error) *MockCommandCompute_ZRem_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandCompute_ZRem_Call) RunAndReturn(run func(ctx context.Context, key string, members []string) (int, error)) *MockCommandCompute_ZRem_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockQueryCompute creates a new instance of MockQueryCompute. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockQueryCompute(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockQueryCompute {
+	mock := &MockQueryCompute{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockQueryCompute is an autogenerated mock type for the QueryCompute type
+type MockQueryCompute struct {
+	mock.Mock
+}
+
+type MockQueryCompute_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockQueryCompute) EXPECT() *MockQueryCompute_Expecter {
+	return &MockQueryCompute_Expecter{mock: &_m.Mock}
+}
+
+// CountPrefix provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) CountPrefix(ctx context.Context, prefix string) (int, error) {
+	ret := _mock.Called(ctx, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPrefix")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, prefix)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, prefix)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_CountPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPrefix'
+type MockQueryCompute_CountPrefix_Call struct {
+	*mock.Call
+}
+
+// CountPrefix is a helper method to define mock.On call
+//   - ctx context.Context
+//   - prefix string
+func (_e *MockQueryCompute_Expecter) CountPrefix(ctx interface{}, prefix interface{}) *MockQueryCompute_CountPrefix_Call {
+	return &MockQueryCompute_CountPrefix_Call{Call: _e.mock.On("CountPrefix", ctx, prefix)}
+}
+
+func (_c *MockQueryCompute_CountPrefix_Call) Run(run func(ctx context.Context, prefix string)) *MockQueryCompute_CountPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_CountPrefix_Call) Return(n int, err error) *MockQueryCompute_CountPrefix_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_CountPrefix_Call) RunAndReturn(run func(ctx context.Context, prefix string) (int, error)) *MockQueryCompute_CountPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) Get(ctx context.Context, key string) (string, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockQueryCompute_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryCompute_Expecter) Get(ctx interface{}, key interface{}) *MockQueryCompute_Get_Call {
+	return &MockQueryCompute_Get_Call{Call: _e.mock.On("Get", ctx, key)}
+}
+
+func (_c *MockQueryCompute_Get_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_Get_Call) Return(s string, err error) *MockQueryCompute_Get_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_Get_Call) RunAndReturn(run func(ctx context.Context, key string) (string, error)) *MockQueryCompute_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HExists provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) HExists(ctx context.Context, key string, field string) (bool, error) {
+	ret := _mock.Called(ctx, key, field)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return returnFunc(ctx, key, field)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = returnFunc(ctx, key, field)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, field)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_HExists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HExists'
+type MockQueryCompute_HExists_Call struct {
+	*mock.Call
+}
+
+// HExists is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - field string
+func (_e *MockQueryCompute_Expecter) HExists(ctx interface{}, key interface{}, field interface{}) *MockQueryCompute_HExists_Call {
+	return &MockQueryCompute_HExists_Call{Call: _e.mock.On("HExists", ctx, key, field)}
+}
+
+func (_c *MockQueryCompute_HExists_Call) Run(run func(ctx context.Context, key string, field string)) *MockQueryCompute_HExists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_HExists_Call) Return(b bool, err error) *MockQueryCompute_HExists_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_HExists_Call) RunAndReturn(run func(ctx context.Context, key string, field string) (bool, error)) *MockQueryCompute_HExists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HGet provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) HGet(ctx context.Context, key string, field string) (string, error) {
+	ret := _mock.Called(ctx, key, field)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HGet")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return returnFunc(ctx, key, field)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = returnFunc(ctx, key, field)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, field)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_HGet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HGet'
+type MockQueryCompute_HGet_Call struct {
+	*mock.Call
+}
+
+// HGet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - field string
+func (_e *MockQueryCompute_Expecter) HGet(ctx interface{}, key interface{}, field interface{}) *MockQueryCompute_HGet_Call {
+	return &MockQueryCompute_HGet_Call{Call: _e.mock.On("HGet", ctx, key, field)}
+}
+
+func (_c *MockQueryCompute_HGet_Call) Run(run func(ctx context.Context, key string, field string)) *MockQueryCompute_HGet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_HGet_Call) Return(s string, err error) *MockQueryCompute_HGet_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_HGet_Call) RunAndReturn(run func(ctx context.Context, key string, field string) (string, error)) *MockQueryCompute_HGet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HGetAll provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) HGetAll(ctx context.Context, key string) ([]kv.Pair, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HGetAll")
+	}
+
+	var r0 []kv.Pair
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]kv.Pair, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []kv.Pair); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.Pair)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_HGetAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HGetAll'
+type MockQueryCompute_HGetAll_Call struct {
+	*mock.Call
+}
+
+// HGetAll is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryCompute_Expecter) HGetAll(ctx interface{}, key interface{}) *MockQueryCompute_HGetAll_Call {
+	return &MockQueryCompute_HGetAll_Call{Call: _e.mock.On("HGetAll", ctx, key)}
+}
+
+func (_c *MockQueryCompute_HGetAll_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_HGetAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_HGetAll_Call) Return(pair []kv.Pair, err error) *MockQueryCompute_HGetAll_Call {
+	_c.Call.Return(pair, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_HGetAll_Call) RunAndReturn(run func(ctx context.Context, key string) ([]kv.Pair, error)) *MockQueryCompute_HGetAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HLen provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) HLen(ctx context.Context, key string) (int, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HLen")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_HLen_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HLen'
+type MockQueryCompute_HLen_Call struct {
+	*mock.Call
+}
+
+// HLen is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryCompute_Expecter) HLen(ctx interface{}, key interface{}) *MockQueryCompute_HLen_Call {
+	return &MockQueryCompute_HLen_Call{Call: _e.mock.On("HLen", ctx, key)}
+}
+
+func (_c *MockQueryCompute_HLen_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_HLen_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_HLen_Call) Return(n int, err error) *MockQueryCompute_HLen_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_HLen_Call) RunAndReturn(run func(ctx context.Context, key string) (int, error)) *MockQueryCompute_HLen_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LLen provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) LLen(ctx context.Context, key string) (int, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LLen")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_LLen_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LLen'
+type MockQueryCompute_LLen_Call struct {
+	*mock.Call
+}
+
+// LLen is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryCompute_Expecter) LLen(ctx interface{}, key interface{}) *MockQueryCompute_LLen_Call {
+	return &MockQueryCompute_LLen_Call{Call: _e.mock.On("LLen", ctx, key)}
+}
+
+func (_c *MockQueryCompute_LLen_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_LLen_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_LLen_Call) Return(n int, err error) *MockQueryCompute_LLen_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_LLen_Call) RunAndReturn(run func(ctx context.Context, key string) (int, error)) *MockQueryCompute_LLen_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LRange provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) LRange(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	ret := _mock.Called(ctx, key, start, stop)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LRange")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]string, error)); ok {
+		return returnFunc(ctx, key, start, stop)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []string); ok {
+		r0 = returnFunc(ctx, key, start, stop)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = returnFunc(ctx, key, start, stop)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_LRange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LRange'
+type MockQueryCompute_LRange_Call struct {
+	*mock.Call
+}
+
+// LRange is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - start int
+//   - stop int
+func (_e *MockQueryCompute_Expecter) LRange(ctx interface{}, key interface{}, start interface{}, stop interface{}) *MockQueryCompute_LRange_Call {
+	return &MockQueryCompute_LRange_Call{Call: _e.mock.On("LRange", ctx, key, start, stop)}
+}
+
+func (_c *MockQueryCompute_LRange_Call) Run(run func(ctx context.Context, key string, start int, stop int)) *MockQueryCompute_LRange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_LRange_Call) Return(strings []string, err error) *MockQueryCompute_LRange_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_LRange_Call) RunAndReturn(run func(ctx context.Context, key string, start int, stop int) ([]string, error)) *MockQueryCompute_LRange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MGet provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) MGet(ctx context.Context, keys []string) ([]kv.Lookup, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MGet")
+	}
+
+	var r0 []kv.Lookup
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]kv.Lookup, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []kv.Lookup); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.Lookup)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_MGet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MGet'
+type MockQueryCompute_MGet_Call struct {
+	*mock.Call
+}
+
+// MGet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockQueryCompute_Expecter) MGet(ctx interface{}, keys interface{}) *MockQueryCompute_MGet_Call {
+	return &MockQueryCompute_MGet_Call{Call: _e.mock.On("MGet", ctx, keys)}
+}
+
+func (_c *MockQueryCompute_MGet_Call) Run(run func(ctx context.Context, keys []string)) *MockQueryCompute_MGet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_MGet_Call) Return(lookup []kv.Lookup, err error) *MockQueryCompute_MGet_Call {
+	_c.Call.Return(lookup, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_MGet_Call) RunAndReturn(run func(ctx context.Context, keys []string) ([]kv.Lookup, error)) *MockQueryCompute_MGet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Range provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) Range(ctx context.Context, start string, end string, limit int) ([]string, string, error) {
+	ret := _mock.Called(ctx, start, end, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Range")
+	}
+
+	var r0 []string
+	var r1 string
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, int) ([]string, string, error)); ok {
+		return returnFunc(ctx, start, end, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, int) []string); ok {
+		r0 = returnFunc(ctx, start, end, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, int) string); ok {
+		r1 = returnFunc(ctx, start, end, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, int) error); ok {
+		r2 = returnFunc(ctx, start, end, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockQueryCompute_Range_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Range'
+type MockQueryCompute_Range_Call struct {
+	*mock.Call
+}
+
+// Range is a helper method to define mock.On call
+//   - ctx context.Context
+//   - start string
+//   - end string
+//   - limit int
+func (_e *MockQueryCompute_Expecter) Range(ctx interface{}, start interface{}, end interface{}, limit interface{}) *MockQueryCompute_Range_Call {
+	return &MockQueryCompute_Range_Call{Call: _e.mock.On("Range", ctx, start, end, limit)}
+}
+
+func (_c *MockQueryCompute_Range_Call) Run(run func(ctx context.Context, start string, end string, limit int)) *MockQueryCompute_Range_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_Range_Call) Return(strings []string, s string, err error) *MockQueryCompute_Range_Call {
+	_c.Call.Return(strings, s, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_Range_Call) RunAndReturn(run func(ctx context.Context, start string, end string, limit int) ([]string, string, error)) *MockQueryCompute_Range_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SInter provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) SInter(ctx context.Context, keys []string) ([]string, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SInter")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_SInter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SInter'
+type MockQueryCompute_SInter_Call struct {
+	*mock.Call
+}
+
+// SInter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockQueryCompute_Expecter) SInter(ctx interface{}, keys interface{}) *MockQueryCompute_SInter_Call {
+	return &MockQueryCompute_SInter_Call{Call: _e.mock.On("SInter", ctx, keys)}
+}
+
+func (_c *MockQueryCompute_SInter_Call) Run(run func(ctx context.Context, keys []string)) *MockQueryCompute_SInter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_SInter_Call) Return(strings []string, err error) *MockQueryCompute_SInter_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_SInter_Call) RunAndReturn(run func(ctx context.Context, keys []string) ([]string, error)) *MockQueryCompute_SInter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SIsMember provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) SIsMember(ctx context.Context, key string, member string) (bool, error) {
+	ret := _mock.Called(ctx, key, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SIsMember")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return returnFunc(ctx, key, member)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = returnFunc(ctx, key, member)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_SIsMember_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SIsMember'
+type MockQueryCompute_SIsMember_Call struct {
+	*mock.Call
+}
+
+// SIsMember is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - member string
+func (_e *MockQueryCompute_Expecter) SIsMember(ctx interface{}, key interface{}, member interface{}) *MockQueryCompute_SIsMember_Call {
+	return &MockQueryCompute_SIsMember_Call{Call: _e.mock.On("SIsMember", ctx, key, member)}
+}
+
+func (_c *MockQueryCompute_SIsMember_Call) Run(run func(ctx context.Context, key string, member string)) *MockQueryCompute_SIsMember_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_SIsMember_Call) Return(b bool, err error) *MockQueryCompute_SIsMember_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_SIsMember_Call) RunAndReturn(run func(ctx context.Context, key string, member string) (bool, error)) *MockQueryCompute_SIsMember_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SMembers provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) SMembers(ctx context.Context, key string) ([]string, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SMembers")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_SMembers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SMembers'
+type MockQueryCompute_SMembers_Call struct {
+	*mock.Call
+}
+
+// SMembers is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryCompute_Expecter) SMembers(ctx interface{}, key interface{}) *MockQueryCompute_SMembers_Call {
+	return &MockQueryCompute_SMembers_Call{Call: _e.mock.On("SMembers", ctx, key)}
+}
+
+func (_c *MockQueryCompute_SMembers_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_SMembers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_SMembers_Call) Return(strings []string, err error) *MockQueryCompute_SMembers_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_SMembers_Call) RunAndReturn(run func(ctx context.Context, key string) ([]string, error)) *MockQueryCompute_SMembers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SUnion provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SUnion")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_SUnion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SUnion'
+type MockQueryCompute_SUnion_Call struct {
+	*mock.Call
+}
+
+// SUnion is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockQueryCompute_Expecter) SUnion(ctx interface{}, keys interface{}) *MockQueryCompute_SUnion_Call {
+	return &MockQueryCompute_SUnion_Call{Call: _e.mock.On("SUnion", ctx, keys)}
+}
+
+func (_c *MockQueryCompute_SUnion_Call) Run(run func(ctx context.Context, keys []string)) *MockQueryCompute_SUnion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_SUnion_Call) Return(strings []string, err error) *MockQueryCompute_SUnion_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_SUnion_Call) RunAndReturn(run func(ctx context.Context, keys []string) ([]string, error)) *MockQueryCompute_SUnion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Scan provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error) {
+	ret := _mock.Called(ctx, cursor, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scan")
+	}
+
+	var r0 []string
+	var r1 uint64
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, int) ([]string, uint64, error)); ok {
+		return returnFunc(ctx, cursor, count)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, int) []string); ok {
+		r0 = returnFunc(ctx, cursor, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uint64, int) uint64); ok {
+		r1 = returnFunc(ctx, cursor, count)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, uint64, int) error); ok {
+		r2 = returnFunc(ctx, cursor, count)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockQueryCompute_Scan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Scan'
+type MockQueryCompute_Scan_Call struct {
+	*mock.Call
+}
+
+// Scan is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cursor uint64
+//   - count int
+func (_e *MockQueryCompute_Expecter) Scan(ctx interface{}, cursor interface{}, count interface{}) *MockQueryCompute_Scan_Call {
+	return &MockQueryCompute_Scan_Call{Call: _e.mock.On("Scan", ctx, cursor, count)}
+}
+
+func (_c *MockQueryCompute_Scan_Call) Run(run func(ctx context.Context, cursor uint64, count int)) *MockQueryCompute_Scan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uint64
+		if args[1] != nil {
+			arg1 = args[1].(uint64)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_Scan_Call) Return(strings []string, v uint64, err error) *MockQueryCompute_Scan_Call {
+	_c.Call.Return(strings, v, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_Scan_Call) RunAndReturn(run func(ctx context.Context, cursor uint64, count int) ([]string, uint64, error)) *MockQueryCompute_Scan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stats provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) Stats(ctx context.Context) (stats.Stats, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 stats.Stats
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (stats.Stats, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) stats.Stats); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Get(0).(stats.Stats)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_Stats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stats'
+type MockQueryCompute_Stats_Call struct {
+	*mock.Call
+}
+
+// Stats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockQueryCompute_Expecter) Stats(ctx interface{}) *MockQueryCompute_Stats_Call {
+	return &MockQueryCompute_Stats_Call{Call: _e.mock.On("Stats", ctx)}
+}
+
+func (_c *MockQueryCompute_Stats_Call) Run(run func(ctx context.Context)) *MockQueryCompute_Stats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_Stats_Call) Return(stats stats.Stats, err error) *MockQueryCompute_Stats_Call {
+	_c.Call.Return(stats, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_Stats_Call) RunAndReturn(run func(ctx context.Context) (stats.Stats, error)) *MockQueryCompute_Stats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TTL provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TTL")
+	}
+
+	var r0 time.Duration
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (time.Duration, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) time.Duration); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_TTL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TTL'
+type MockQueryCompute_TTL_Call struct {
+	*mock.Call
+}
+
+// TTL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryCompute_Expecter) TTL(ctx interface{}, key interface{}) *MockQueryCompute_TTL_Call {
+	return &MockQueryCompute_TTL_Call{Call: _e.mock.On("TTL", ctx, key)}
+}
+
+func (_c *MockQueryCompute_TTL_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_TTL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_TTL_Call) Return(duration time.Duration, err error) *MockQueryCompute_TTL_Call {
+	_c.Call.Return(duration, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_TTL_Call) RunAndReturn(run func(ctx context.Context, key string) (time.Duration, error)) *MockQueryCompute_TTL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Version provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) Version(ctx context.Context, key string) (uint64, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Version")
+	}
+
+	var r0 uint64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (uint64, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) uint64); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_Version_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Version'
+type MockQueryCompute_Version_Call struct {
+	*mock.Call
+}
+
+// Version is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryCompute_Expecter) Version(ctx interface{}, key interface{}) *MockQueryCompute_Version_Call {
+	return &MockQueryCompute_Version_Call{Call: _e.mock.On("Version", ctx, key)}
+}
+
+func (_c *MockQueryCompute_Version_Call) Run(run func(ctx context.Context, key string)) *MockQueryCompute_Version_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_Version_Call) Return(v uint64, err error) *MockQueryCompute_Version_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_Version_Call) RunAndReturn(run func(ctx context.Context, key string) (uint64, error)) *MockQueryCompute_Version_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRange provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) ZRange(ctx context.Context, key string, start int, stop int) ([]kv.ScoredMember, error) {
+	ret := _mock.Called(ctx, key, start, stop)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRange")
+	}
+
+	var r0 []kv.ScoredMember
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]kv.ScoredMember, error)); ok {
+		return returnFunc(ctx, key, start, stop)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []kv.ScoredMember); ok {
+		r0 = returnFunc(ctx, key, start, stop)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.ScoredMember)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = returnFunc(ctx, key, start, stop)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_ZRange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRange'
+type MockQueryCompute_ZRange_Call struct {
+	*mock.Call
+}
+
+// ZRange is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - start int
+//   - stop int
+func (_e *MockQueryCompute_Expecter) ZRange(ctx interface{}, key interface{}, start interface{}, stop interface{}) *MockQueryCompute_ZRange_Call {
+	return &MockQueryCompute_ZRange_Call{Call: _e.mock.On("ZRange", ctx, key, start, stop)}
+}
+
+func (_c *MockQueryCompute_ZRange_Call) Run(run func(ctx context.Context, key string, start int, stop int)) *MockQueryCompute_ZRange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_ZRange_Call) Return(scoredMember []kv.ScoredMember, err error) *MockQueryCompute_ZRange_Call {
+	_c.Call.Return(scoredMember, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_ZRange_Call) RunAndReturn(run func(ctx context.Context, key string, start int, stop int) ([]kv.ScoredMember, error)) *MockQueryCompute_ZRange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRangeByScore provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) ZRangeByScore(ctx context.Context, key string, lower kv.ScoreBound, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error) {
+	ret := _mock.Called(ctx, key, lower, upper, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRangeByScore")
+	}
+
+	var r0 []kv.ScoredMember
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.ScoreBound, kv.ScoreBound, int) ([]kv.ScoredMember, error)); ok {
+		return returnFunc(ctx, key, lower, upper, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.ScoreBound, kv.ScoreBound, int) []kv.ScoredMember); ok {
+		r0 = returnFunc(ctx, key, lower, upper, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.ScoredMember)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, kv.ScoreBound, kv.ScoreBound, int) error); ok {
+		r1 = returnFunc(ctx, key, lower, upper, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_ZRangeByScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRangeByScore'
+type MockQueryCompute_ZRangeByScore_Call struct {
+	*mock.Call
+}
+
+// ZRangeByScore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - lower kv.ScoreBound
+//   - upper kv.ScoreBound
+//   - limit int
+func (_e *MockQueryCompute_Expecter) ZRangeByScore(ctx interface{}, key interface{}, lower interface{}, upper interface{}, limit interface{}) *MockQueryCompute_ZRangeByScore_Call {
+	return &MockQueryCompute_ZRangeByScore_Call{Call: _e.mock.On("ZRangeByScore", ctx, key, lower, upper, limit)}
+}
+
+func (_c *MockQueryCompute_ZRangeByScore_Call) Run(run func(ctx context.Context, key string, lower kv.ScoreBound, upper kv.ScoreBound, limit int)) *MockQueryCompute_ZRangeByScore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 kv.ScoreBound
+		if args[2] != nil {
+			arg2 = args[2].(kv.ScoreBound)
+		}
+		var arg3 kv.ScoreBound
+		if args[3] != nil {
+			arg3 = args[3].(kv.ScoreBound)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_ZRangeByScore_Call) Return(scoredMember []kv.ScoredMember, err error) *MockQueryCompute_ZRangeByScore_Call {
+	_c.Call.Return(scoredMember, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_ZRangeByScore_Call) RunAndReturn(run func(ctx context.Context, key string, lower kv.ScoreBound, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error)) *MockQueryCompute_ZRangeByScore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRank provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) ZRank(ctx context.Context, key string, member string) (int, error) {
+	ret := _mock.Called(ctx, key, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRank")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (int, error)); ok {
+		return returnFunc(ctx, key, member)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) int); ok {
+		r0 = returnFunc(ctx, key, member)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_ZRank_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRank'
+type MockQueryCompute_ZRank_Call struct {
+	*mock.Call
+}
+
+// ZRank is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - member string
+func (_e *MockQueryCompute_Expecter) ZRank(ctx interface{}, key interface{}, member interface{}) *MockQueryCompute_ZRank_Call {
+	return &MockQueryCompute_ZRank_Call{Call: _e.mock.On("ZRank", ctx, key, member)}
+}
+
+func (_c *MockQueryCompute_ZRank_Call) Run(run func(ctx context.Context, key string, member string)) *MockQueryCompute_ZRank_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_ZRank_Call) Return(n int, err error) *MockQueryCompute_ZRank_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_ZRank_Call) RunAndReturn(run func(ctx context.Context, key string, member string) (int, error)) *MockQueryCompute_ZRank_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZScore provides a mock function for the type MockQueryCompute
+func (_mock *MockQueryCompute) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	ret := _mock.Called(ctx, key, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZScore")
+	}
+
+	var r0 float64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (float64, error)); ok {
+		return returnFunc(ctx, key, member)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) float64); ok {
+		r0 = returnFunc(ctx, key, member)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryCompute_ZScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZScore'
+type MockQueryCompute_ZScore_Call struct {
+	*mock.Call
+}
+
+// ZScore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - member string
+func (_e *MockQueryCompute_Expecter) ZScore(ctx interface{}, key interface{}, member interface{}) *MockQueryCompute_ZScore_Call {
+	return &MockQueryCompute_ZScore_Call{Call: _e.mock.On("ZScore", ctx, key, member)}
+}
+
+func (_c *MockQueryCompute_ZScore_Call) Run(run func(ctx context.Context, key string, member string)) *MockQueryCompute_ZScore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryCompute_ZScore_Call) Return(f float64, err error) *MockQueryCompute_ZScore_Call {
+	_c.Call.Return(f, err)
+	return _c
+}
+
+func (_c *MockQueryCompute_ZScore_Call) RunAndReturn(run func(ctx context.Context, key string, member string) (float64, error)) *MockQueryCompute_ZScore_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,191 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"lesson1/internal/command"
+)
+
+// CharacterClass names a group of characters permitted in arguments.
+type CharacterClass string
+
+const (
+	ClassLetters        CharacterClass = "letters"
+	ClassDigits         CharacterClass = "digits"
+	ClassPunctuation    CharacterClass = "punctuation"
+	ClassUnicodeLetters CharacterClass = "unicode_letters"
+)
+
+var (
+	ErrUnknownCharacterClass = errors.New("unknown character class")
+	ErrKeyTooLong            = errors.New("key too long")
+	ErrValueTooLong          = errors.New("value too long")
+	ErrTooManyTokens         = errors.New("too many tokens in command")
+)
+
+// Policy restricts command arguments. Bare arguments may only contain
+// characters of the allowed classes; quoted arguments may contain anything
+// unless RestrictQuoted is set. Lengths are in bytes and zero limits are
+// unlimited.
+type Policy struct {
+	Classes []CharacterClass
+	// Punctuation lists the characters allowed by ClassPunctuation.
+	Punctuation    string
+	RestrictQuoted bool
+
+	MaxKeyLength   int
+	MaxValueLength int
+	// MaxTokens counts the command name as well as its arguments.
+	MaxTokens int
+}
+
+// DefaultPolicy allows ASCII letters, digits and command.Punctuation in bare
+// arguments and sets no limits.
+func DefaultPolicy() Policy {
+	return Policy{
+		Classes:     []CharacterClass{ClassLetters, ClassDigits, ClassPunctuation},
+		Punctuation: string(command.Punctuation),
+	}
+}
+
+// ParseCharacterClasses converts configured class names.
+func ParseCharacterClasses(names []string) ([]CharacterClass, error) {
+	classes := make([]CharacterClass, 0, len(names))
+	for _, name := range names {
+		class := CharacterClass(strings.ToLower(strings.TrimSpace(name)))
+		switch class {
+		case ClassLetters, ClassDigits, ClassPunctuation, ClassUnicodeLetters:
+			classes = append(classes, class)
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCharacterClass, name)
+		}
+	}
+	return classes, nil
+}
+
+// allows reports whether symbol belongs to one of the policy classes.
+func (p Policy) allows(symbol rune) bool {
+	for _, class := range p.Classes {
+		switch class {
+		case ClassLetters:
+			if IsAnyLetter(symbol) {
+				return true
+			}
+		case ClassDigits:
+			if IsDigit(symbol) {
+				return true
+			}
+		case ClassPunctuation:
+			if strings.ContainsRune(p.Punctuation, symbol) {
+				return true
+			}
+		case ClassUnicodeLetters:
+			if unicode.IsLetter(symbol) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkToken verifies the alphabet of one argument.
+func (p Policy) checkToken(token command.Token) error {
+	if token.Quoted && !p.RestrictQuoted {
+		return nil
+	}
+
+	for i, symbol := range token.Text {
+		if !p.allows(symbol) {
+			return fmt.Errorf("%w: %q at offset %d", ErrInvalidSyntaxArg, symbol, token.Offset+i)
+		}
+	}
+	return nil
+}
+
+// checkLengths verifies the size limits of a tokenized command.
+func (p Policy) checkLengths(tokens []string) error {
+	if p.MaxTokens > 0 && len(tokens) > p.MaxTokens {
+		return fmt.Errorf("%w: %d > %d", ErrTooManyTokens, len(tokens), p.MaxTokens)
+	}
+
+	keys, values := keysAndValues(tokens[0], tokens[1:])
+	for _, key := range keys {
+		if p.MaxKeyLength > 0 && len(key) > p.MaxKeyLength {
+			return fmt.Errorf("%w: %d > %d bytes", ErrKeyTooLong, len(key), p.MaxKeyLength)
+		}
+	}
+	for _, value := range values {
+		if p.MaxValueLength > 0 && len(value) > p.MaxValueLength {
+			return fmt.Errorf("%w: %d > %d bytes", ErrValueTooLong, len(value), p.MaxValueLength)
+		}
+	}
+	return nil
+}
+
+// keysAndValues picks the keys and the values out of a command's arguments
+// for the length limits. Options such as EX are neither.
+func keysAndValues(name string, args []string) (keys, values []string) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	switch name {
+	case command.CommandSet, command.CommandSetGet:
+		return args[:1], args[1:min(2, len(args))]
+	case command.CommandCAS, command.CommandLPush, command.CommandRPush, command.CommandPublish:
+		return args[:1], args[1:]
+	case command.CommandGet, command.CommandDel, command.CommandExpire, command.CommandTTL, command.CommandPersist,
+		command.CommandIncr, command.CommandDecr, command.CommandIncrBy, command.CommandDecrBy, command.CommandIncrByFloat,
+		command.CommandKeys, command.CommandCount, command.CommandHGetAll, command.CommandHLen,
+		command.CommandLPop, command.CommandRPop, command.CommandLRange, command.CommandLLen,
+		command.CommandSMembers, command.CommandZRange, command.CommandZRangeByScore:
+		return args[:1], nil
+	case command.CommandBLPop, command.CommandBRPop:
+		// the last argument is the timeout
+		return args[:len(args)-1], nil
+	case command.CommandScan:
+		// only the range form takes keys, the cursor form has an odd count
+		if len(args)%2 == 1 {
+			return nil, nil
+		}
+		return args[:2], nil
+	case command.CommandMSet:
+		for i, arg := range args {
+			if i%2 == 0 {
+				keys = append(keys, arg)
+			} else {
+				values = append(values, arg)
+			}
+		}
+		return keys, values
+	case command.CommandHSet:
+		// the fields of a hash are limited like keys
+		keys = append(keys, args[0])
+		for i := 1; i < len(args); i++ {
+			if i%2 == 1 {
+				keys = append(keys, args[i])
+			} else {
+				values = append(values, args[i])
+			}
+		}
+		return keys, values
+	case command.CommandZAdd:
+		// members are limited like keys, scores are not
+		keys = append(keys, args[0])
+		for i := 2; i < len(args); i += 2 {
+			keys = append(keys, args[i])
+		}
+		return keys, nil
+	case command.CommandMGet, command.CommandMDel, command.CommandWatch,
+		command.CommandHGet, command.CommandHDel, command.CommandHExists,
+		command.CommandSAdd, command.CommandSRem, command.CommandSIsMember, command.CommandSInter, command.CommandSUnion,
+		command.CommandZRem, command.CommandZScore, command.CommandZRank,
+		command.CommandSubscribe, command.CommandPSubscribe, command.CommandUnsubscribe, command.CommandPUnsubscribe:
+		return args, nil
+	default:
+		return nil, nil
+	}
+}
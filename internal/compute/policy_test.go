@@ -0,0 +1,106 @@
+package compute_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	compute "lesson1/internal/compute"
+	computemocks "lesson1/internal/compute/mocks"
+)
+
+func TestComputePolicy(t *testing.T) {
+	t.Parallel()
+
+	limits := compute.DefaultPolicy()
+	limits.MaxKeyLength = 3
+	limits.MaxValueLength = 5
+	limits.MaxTokens = 3
+
+	// HSET needs more tokens than SET
+	hashLimits := limits
+	hashLimits.MaxTokens = 0
+
+	digitsOnly := compute.DefaultPolicy()
+	digitsOnly.Classes = []compute.CharacterClass{compute.ClassDigits}
+
+	unicodeLetters := compute.DefaultPolicy()
+	unicodeLetters.Classes = append(unicodeLetters.Classes, compute.ClassUnicodeLetters)
+
+	restrictQuoted := compute.DefaultPolicy()
+	restrictQuoted.RestrictQuoted = true
+
+	customPunctuation := compute.DefaultPolicy()
+	customPunctuation.Punctuation = ":@"
+
+	tests := []struct {
+		name    string
+		policy  compute.Policy
+		input   string
+		wantSet []string
+		wantErr error
+	}{
+		{name: "within limits", policy: limits, input: "SET key value", wantSet: []string{"key", "value"}},
+		{name: "key too long", policy: limits, input: "SET keys value", wantErr: compute.ErrKeyTooLong},
+		{name: "quoted key too long", policy: limits, input: `SET "k y s" v`, wantErr: compute.ErrKeyTooLong},
+		{name: "value too long", policy: limits, input: "SET key values", wantErr: compute.ErrValueTooLong},
+		{name: "too many tokens", policy: limits, input: "SET k v EX 10", wantErr: compute.ErrTooManyTokens},
+		{name: "get key too long", policy: limits, input: "GET keys", wantErr: compute.ErrKeyTooLong},
+		{name: "mset value too long", policy: limits, input: "MSET k values", wantErr: compute.ErrValueTooLong},
+		{name: "hset field too long", policy: hashLimits, input: "HSET k fields v", wantErr: compute.ErrKeyTooLong},
+		{name: "hset value too long", policy: hashLimits, input: "HSET k f values", wantErr: compute.ErrValueTooLong},
+		{name: "sadd member too long", policy: hashLimits, input: "SADD k a members", wantErr: compute.ErrKeyTooLong},
+		{name: "zadd member too long", policy: hashLimits, input: "ZADD k 1 a 2 members", wantErr: compute.ErrKeyTooLong},
+		{name: "sinter key too long", policy: hashLimits, input: "SINTER a keys", wantErr: compute.ErrKeyTooLong},
+		{name: "digits only", policy: digitsOnly, input: "SET 1 2", wantSet: []string{"1", "2"}},
+		{name: "digits only rejects letters", policy: digitsOnly, input: "SET a 2", wantErr: compute.ErrInvalidSyntaxArg},
+		{name: "ascii rejects unicode", policy: compute.DefaultPolicy(), input: "SET ключ v", wantErr: compute.ErrInvalidSyntaxArg},
+		{name: "unicode letters", policy: unicodeLetters, input: "SET ключ знач", wantSet: []string{"ключ", "знач"}},
+		{name: "quoted bypasses alphabet", policy: compute.DefaultPolicy(), input: `SET "a b" "!"`, wantSet: []string{"a b", "!"}},
+		{name: "restrict quoted", policy: restrictQuoted, input: `SET "a b" v`, wantErr: compute.ErrInvalidSyntaxArg},
+		{name: "custom punctuation", policy: customPunctuation, input: "SET user:1 a@b", wantSet: []string{"user:1", "a@b"}},
+		{name: "custom punctuation replaces default", policy: customPunctuation, input: "SET a_b v", wantErr: compute.ErrInvalidSyntaxArg},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmdMock := computemocks.NewMockCommandCompute(t)
+			combined := struct {
+				compute.CommandCompute
+				compute.QueryCompute
+			}{
+				CommandCompute: cmdMock,
+				QueryCompute:   computemocks.NewMockQueryCompute(t),
+			}
+			c := compute.NewCompute(newTestLogger(), combined, compute.WithPolicy(tc.policy))
+
+			ctx := context.Background()
+			if tc.wantSet != nil {
+				cmdMock.EXPECT().Set(ctx, tc.wantSet[0], tc.wantSet[1]).Return(nil)
+			}
+
+			got, err := c.ComputeHandler(ctx, tc.input)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "OK", got)
+		})
+	}
+}
+
+func TestParseCharacterClasses(t *testing.T) {
+	t.Parallel()
+
+	classes, err := compute.ParseCharacterClasses([]string{"letters", " Digits ", "unicode_letters"})
+	require.NoError(t, err)
+	assert.Equal(t, []compute.CharacterClass{compute.ClassLetters, compute.ClassDigits, compute.ClassUnicodeLetters}, classes)
+
+	_, err = compute.ParseCharacterClasses([]string{"emoji"})
+	require.ErrorIs(t, err, compute.ErrUnknownCharacterClass)
+}
@@ -0,0 +1,96 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"lesson1/internal/command"
+)
+
+// isSubscriptionCommand reports whether a command changes the subscriptions
+// of the session.
+func isSubscriptionCommand(name string) bool {
+	switch name {
+	case command.CommandSubscribe, command.CommandPSubscribe, command.CommandUnsubscribe, command.CommandPUnsubscribe:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pushes returns the message lines pending for the subscriptions of the
+// session of ctx, nil when it has none. Front-ends deliver them to the
+// client between command replies, and drop the client when the channel is
+// closed because it fell behind.
+func (c *Compute) Pushes(ctx context.Context) <-chan string {
+	session := sessionFrom(ctx)
+	if session == nil || session.subscriber == nil {
+		return nil
+	}
+	return session.subscriber.Messages()
+}
+
+// handlePublish answers RECEIVERS n with the number of subscribers the
+// message was delivered to.
+func (c *Compute) handlePublish(_ context.Context, tokens []string) (string, error) {
+	if len(tokens)-1 != command.CommandPublishQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	receivers := c.broker.Publish(tokens[1], tokens[2])
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("channel", tokens[1]), slog.Int("receivers", receivers))
+	return "RECEIVERS " + strconv.Itoa(receivers), nil
+}
+
+// handleSubscription serves SUBSCRIBE, PSUBSCRIBE, UNSUBSCRIBE and
+// PUNSUBSCRIBE. The reply is SUBSCRIBED n or UNSUBSCRIBED n with the number
+// of channels and patterns the session is subscribed to afterwards. The
+// subscriber lives until its last subscription goes or ctx is done.
+func (c *Compute) handleSubscription(ctx context.Context, session *Session, tokens []string) (string, error) {
+	subscribing := tokens[0] == command.CommandSubscribe || tokens[0] == command.CommandPSubscribe
+	if subscribing && len(tokens)-1 < command.CommandSubscribeMinQ {
+		c.log.Info("must be at least one argument")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	reply := "UNSUBSCRIBED "
+	if subscribing {
+		reply = "SUBSCRIBED "
+	}
+
+	// a subscriber dropped for falling behind is replaced on the next
+	// SUBSCRIBE
+	if session.subscriber != nil && session.subscriber.Closed() {
+		session.closeSubscriber()
+	}
+	if session.subscriber == nil {
+		if !subscribing {
+			return reply + "0", nil
+		}
+		subscriber := c.broker.NewSubscriber()
+		session.subscriber = subscriber
+		session.stopSubscriber = context.AfterFunc(ctx, func() { subscriber.Close() })
+	}
+
+	var count int
+	switch tokens[0] {
+	case command.CommandSubscribe:
+		count = session.subscriber.Subscribe(tokens[1:]...)
+	case command.CommandPSubscribe:
+		count = session.subscriber.PSubscribe(tokens[1:]...)
+	case command.CommandUnsubscribe:
+		count = session.subscriber.Unsubscribe(tokens[1:]...)
+	default:
+		count = session.subscriber.PUnsubscribe(tokens[1:]...)
+	}
+	if count == 0 {
+		session.closeSubscriber()
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("subscriptions", count))
+	return reply + strconv.Itoa(count), nil
+}
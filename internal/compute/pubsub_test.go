@@ -0,0 +1,78 @@
+package compute_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	compute "lesson1/internal/compute"
+	"lesson1/internal/database/storage"
+	"lesson1/internal/database/storage/engine"
+	"lesson1/internal/pubsub"
+)
+
+func TestComputePubSub(t *testing.T) {
+	t.Parallel()
+
+	c := newRealCompute(t)
+	ctx, cancel := context.WithCancel(compute.NewSessionContext(context.Background()))
+	t.Cleanup(cancel)
+	publisher := compute.NewSessionContext(context.Background())
+
+	assert.Nil(t, c.Pushes(ctx))
+	assert.Equal(t, []string{"UNSUBSCRIBED 0"}, run(t, c, ctx, "UNSUBSCRIBE"))
+
+	assert.Equal(t,
+		[]string{"SUBSCRIBED 2", "SUBSCRIBED 3", "SUBSCRIBED 3", "ERROR"},
+		run(t, c, ctx, "SUBSCRIBE news sport", "PSUBSCRIBE user.*", "SUBSCRIBE news", "SUBSCRIBE"))
+
+	pushes := c.Pushes(ctx)
+	assert.NotNil(t, pushes)
+
+	assert.Equal(t,
+		[]string{"RECEIVERS 1", "RECEIVERS 1", "RECEIVERS 0"},
+		run(t, c, publisher, `PUBLISH news "hello world"`, "PUBLISH user.1 login", "PUBLISH weather rain"))
+	assert.Equal(t, `MESSAGE "news" "hello world"`, <-pushes)
+	assert.Equal(t, `PMESSAGE "user.*" "user.1" "login"`, <-pushes)
+
+	assert.Equal(t,
+		[]string{"UNSUBSCRIBED 2", "UNSUBSCRIBED 1", "UNSUBSCRIBED 0"},
+		run(t, c, ctx, "UNSUBSCRIBE news", "UNSUBSCRIBE", "PUNSUBSCRIBE user.*"))
+	assert.Nil(t, c.Pushes(ctx))
+
+	_, ok := <-pushes
+	assert.False(t, ok, "the last unsubscribe closes the subscriber")
+	assert.Equal(t, []string{"RECEIVERS 0"}, run(t, c, publisher, "PUBLISH news again"))
+}
+
+func TestComputePubSubSessionEnd(t *testing.T) {
+	t.Parallel()
+
+	c := newRealCompute(t)
+	ctx, cancel := context.WithCancel(compute.NewSessionContext(context.Background()))
+
+	run(t, c, ctx, "SUBSCRIBE news")
+	pushes := c.Pushes(ctx)
+	cancel()
+
+	// the subscriber is closed with the session context
+	for range pushes {
+	}
+	assert.Equal(t, []string{"RECEIVERS 0"}, run(t, c, context.Background(), "PUBLISH news m"))
+}
+
+func TestComputePubSubSharedBroker(t *testing.T) {
+	t.Parallel()
+
+	logger := newTestLogger()
+	broker := pubsub.NewBroker()
+	c := compute.NewCompute(logger, storage.NewStorage(logger, engine.NewEngine(logger)), compute.WithBroker(broker))
+
+	sub := broker.NewSubscriber()
+	t.Cleanup(func() { sub.Close() })
+	sub.Subscribe("events")
+
+	assert.Equal(t, []string{"RECEIVERS 1"}, run(t, c, context.Background(), "PUBLISH events e"))
+	assert.Equal(t, `MESSAGE "events" "e"`, <-sub.Messages())
+}
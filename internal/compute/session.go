@@ -0,0 +1,230 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"lesson1/internal/command"
+	"lesson1/internal/pubsub"
+)
+
+var (
+	ErrNoSession           = errors.New("transactions and subscriptions need a session")
+	ErrNestedMulti         = errors.New("MULTI calls can not be nested")
+	ErrExecWithoutMulti    = errors.New("EXEC without MULTI")
+	ErrDiscardWithoutMulti = errors.New("DISCARD without MULTI")
+	ErrWatchInsideMulti    = errors.New("WATCH inside MULTI is not allowed")
+	ErrExecAborted         = errors.New("transaction discarded because of previous errors")
+)
+
+// Session is the per-client transaction state: the commands queued since
+// MULTI and the versions of the keys watched since WATCH. It also holds the
+// pub/sub subscriber of the client. A session belongs to one client, which
+// issues its commands one at a time, so it is not safe for concurrent use.
+type Session struct {
+	multi   bool
+	aborted bool
+	queue   [][]string
+	watched map[string]uint64
+
+	subscriber     *pubsub.Subscriber
+	stopSubscriber func() bool
+}
+
+type sessionKey struct{}
+
+// NewSessionContext returns a context carrying a fresh session. Front-ends
+// call it once per client connection.
+func NewSessionContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &Session{})
+}
+
+func sessionFrom(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+func (s *Session) reset() {
+	s.multi, s.aborted, s.queue, s.watched = false, false, nil, nil
+}
+
+func (s *Session) closeSubscriber() {
+	s.stopSubscriber()
+	s.subscriber.Close()
+	s.subscriber, s.stopSubscriber = nil, nil
+}
+
+// isTransactionCommand reports whether a command controls the transaction
+// rather than being queued by it.
+func isTransactionCommand(name string) bool {
+	switch name {
+	case command.CommandMulti, command.CommandExec, command.CommandDiscard, command.CommandWatch, command.CommandUnwatch:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDataCommand reports whether a command can be queued inside MULTI.
+func isDataCommand(name string) bool {
+	switch name {
+	case command.CommandSet, command.CommandGet, command.CommandDel, command.CommandExpire, command.CommandTTL,
+		command.CommandPersist, command.CommandStats, command.CommandMSet, command.CommandMGet, command.CommandMDel,
+		command.CommandSetGet, command.CommandCAS, command.CommandIncr, command.CommandDecr, command.CommandIncrBy,
+		command.CommandDecrBy, command.CommandIncrByFloat, command.CommandScan, command.CommandKeys, command.CommandCount,
+		command.CommandHSet, command.CommandHGet, command.CommandHDel, command.CommandHGetAll, command.CommandHLen,
+		command.CommandHExists, command.CommandLPush, command.CommandRPush, command.CommandLPop, command.CommandRPop,
+		command.CommandLRange, command.CommandLLen, command.CommandBLPop, command.CommandBRPop,
+		command.CommandSAdd, command.CommandSRem, command.CommandSMembers, command.CommandSIsMember, command.CommandSInter,
+		command.CommandSUnion, command.CommandZAdd, command.CommandZRem, command.CommandZScore, command.CommandZRank,
+		command.CommandZRange, command.CommandZRangeByScore, command.CommandPublish:
+		return true
+	default:
+		return false
+	}
+}
+
+// queue stores a command of an open transaction. An unknown command aborts
+// the transaction at EXEC, like a syntax error does.
+func (c *Compute) queue(session *Session, tokens []string) (string, error) {
+	const op = "compute.queue"
+
+	if !isDataCommand(tokens[0]) {
+		session.aborted = true
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidCommand)
+	}
+
+	session.queue = append(session.queue, tokens)
+	return "QUEUED", nil
+}
+
+func (c *Compute) handleMulti(session *Session, tokens []string) (string, error) {
+	if len(tokens)-1 != command.CommandMultiQ {
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+	if session.multi {
+		return "", ErrNestedMulti
+	}
+
+	session.multi = true
+	return "OK", nil
+}
+
+func (c *Compute) handleDiscard(session *Session, tokens []string) (string, error) {
+	if len(tokens)-1 != command.CommandDiscardQ {
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+	if !session.multi {
+		return "", ErrDiscardWithoutMulti
+	}
+
+	session.reset()
+	return "OK", nil
+}
+
+// handleWatch records the current version of every key. A key watched twice
+// keeps the version of its first WATCH.
+func (c *Compute) handleWatch(ctx context.Context, session *Session, tokens []string) (string, error) {
+	const op = "compute.watch"
+
+	if len(tokens)-1 < command.CommandWatchMinQ {
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+	if session.multi {
+		return "", ErrWatchInsideMulti
+	}
+
+	if session.watched == nil {
+		session.watched = make(map[string]uint64, len(tokens)-1)
+	}
+	for _, key := range tokens[1:] {
+		if _, ok := session.watched[key]; ok {
+			continue
+		}
+		version, err := c.queryCompute.Version(ctx, key)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		session.watched[key] = version
+	}
+
+	return "OK", nil
+}
+
+func (c *Compute) handleUnwatch(session *Session, tokens []string) (string, error) {
+	if len(tokens)-1 != command.CommandUnwatchQ {
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	session.watched = nil
+	return "OK", nil
+}
+
+// handleExec runs the queued commands atomically. The reply is "RESULTS"
+// followed by the quoted reply of every command, failed commands being
+// replied with the error prefix; a command failing does not stop the others.
+// When a watched key changed the reply is "ABORTED" and nothing runs.
+func (c *Compute) handleExec(ctx context.Context, session *Session, tokens []string) (string, error) {
+	const op = "compute.exec"
+
+	if len(tokens)-1 != command.CommandExecQ {
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+	if !session.multi {
+		return "", ErrExecWithoutMulti
+	}
+
+	queue, watched, aborted := session.queue, session.watched, session.aborted
+	session.reset()
+
+	if aborted {
+		return "", ErrExecAborted
+	}
+
+	var (
+		replies  []string
+		conflict bool
+	)
+	err := c.commandCompute.Exec(ctx, func(ctx context.Context) error {
+		for key, version := range watched {
+			current, err := c.queryCompute.Version(ctx, key)
+			if err != nil {
+				return err
+			}
+			if current != version {
+				conflict = true
+				return nil
+			}
+		}
+
+		for _, tokens := range queue {
+			reply, err := c.execute(ctx, tokens)
+			if err != nil {
+				reply = command.ErrorPrefix + err.Error()
+			}
+			replies = append(replies, reply)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	if conflict {
+		c.log.Info("transaction aborted by a watched key")
+		return "ABORTED", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("RESULTS")
+	for _, reply := range replies {
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(reply))
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("commands", len(queue)))
+	return b.String(), nil
+}
@@ -0,0 +1,209 @@
+package compute_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	compute "lesson1/internal/compute"
+	"lesson1/internal/database/storage"
+	"lesson1/internal/database/storage/engine"
+)
+
+func newRealCompute(t *testing.T) *compute.Compute {
+	t.Helper()
+
+	logger := newTestLogger()
+	return compute.NewCompute(logger, storage.NewStorage(logger, engine.NewEngine(logger)))
+}
+
+// run sends commands in one session and returns the replies, errors being
+// rendered with the network error prefix.
+func run(t *testing.T, c *compute.Compute, ctx context.Context, commands ...string) []string {
+	t.Helper()
+
+	replies := make([]string, 0, len(commands))
+	for _, raw := range commands {
+		reply, err := c.ComputeHandler(ctx, raw)
+		if err != nil {
+			reply = "ERROR"
+		}
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+func TestSessionTransaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		commands []string
+		want     []string
+	}{
+		{
+			name:     "exec",
+			commands: []string{"SET a 1", "MULTI", "SET a 2", "GET a", "DEL missing", "EXPIRE", "EXEC", "GET a"},
+			want: []string{
+				"OK", "OK", "QUEUED", "QUEUED", "QUEUED", "QUEUED",
+				`RESULTS "OK" "VALUE 2" "DELETED" "ERROR invalid quantity of arguments"`,
+				"VALUE 2",
+			},
+		},
+		{
+			name:     "empty exec",
+			commands: []string{"MULTI", "EXEC"},
+			want:     []string{"OK", "RESULTS"},
+		},
+		{
+			name:     "discard",
+			commands: []string{"MULTI", "SET a 1", "DISCARD", "GET a", "EXEC"},
+			want:     []string{"OK", "QUEUED", "OK", "NOT_FOUND", "ERROR"},
+		},
+		{
+			name:     "unknown command aborts",
+			commands: []string{"MULTI", "SET a 1", "NOPE", "EXEC", "GET a"},
+			want:     []string{"OK", "QUEUED", "ERROR", "ERROR", "NOT_FOUND"},
+		},
+		{
+			name:     "syntax error aborts",
+			commands: []string{"MULTI", "SET a 1", `SET a "b`, "EXEC", "GET a"},
+			want:     []string{"OK", "QUEUED", "ERROR", "ERROR", "NOT_FOUND"},
+		},
+		{
+			name:     "misuse",
+			commands: []string{"EXEC", "DISCARD", "MULTI", "MULTI", "WATCH a", "EXEC"},
+			want:     []string{"ERROR", "ERROR", "OK", "ERROR", "ERROR", "RESULTS"},
+		},
+		{
+			name:     "watch unchanged",
+			commands: []string{"SET a 1", "WATCH a missing", "MULTI", "SET a 2", "EXEC"},
+			want:     []string{"OK", "OK", "OK", "QUEUED", `RESULTS "OK"`},
+		},
+		{
+			name:     "watch changed by own session",
+			commands: []string{"WATCH a", "SET a 1", "MULTI", "SET a 2", "EXEC", "GET a"},
+			want:     []string{"OK", "OK", "OK", "QUEUED", "ABORTED", "VALUE 1"},
+		},
+		{
+			name:     "unwatch",
+			commands: []string{"WATCH a", "SET a 1", "UNWATCH", "MULTI", "SET a 2", "EXEC"},
+			want:     []string{"OK", "OK", "OK", "OK", "QUEUED", `RESULTS "OK"`},
+		},
+		{
+			name:     "exec clears watches",
+			commands: []string{"WATCH a", "SET a 1", "MULTI", "EXEC", "MULTI", "SET a 2", "EXEC"},
+			want:     []string{"OK", "OK", "OK", "ABORTED", "OK", "QUEUED", `RESULTS "OK"`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newRealCompute(t)
+			ctx := compute.NewSessionContext(context.Background())
+
+			assert.Equal(t, tc.want, run(t, c, ctx, tc.commands...))
+		})
+	}
+}
+
+func TestSessionRequired(t *testing.T) {
+	t.Parallel()
+
+	c := newRealCompute(t)
+	for _, raw := range []string{"MULTI", "EXEC", "DISCARD", "WATCH a", "UNWATCH", "SUBSCRIBE a"} {
+		_, err := c.ComputeHandler(context.Background(), raw)
+		require.ErrorIs(t, err, compute.ErrNoSession, raw)
+	}
+}
+
+func TestSessionWatchConflict(t *testing.T) {
+	t.Parallel()
+
+	c := newRealCompute(t)
+	first := compute.NewSessionContext(context.Background())
+	second := compute.NewSessionContext(context.Background())
+
+	run(t, c, first, "SET balance 10", "WATCH balance", "MULTI", "SET balance 5")
+	assert.Equal(t, []string{"OK"}, run(t, c, second, "SET balance 20"))
+	assert.Equal(t, []string{"ABORTED", "VALUE 20"}, run(t, c, first, "EXEC", "GET balance"))
+}
+
+// TestSessionConcurrentIncrements runs read-modify-write transactions that
+// conflict on one counter; retrying aborted ones must lose no increment.
+func TestSessionConcurrentIncrements(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers    = 8
+		increments = 50
+	)
+
+	c := newRealCompute(t)
+	run(t, c, context.Background(), "SET counter 0")
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			ctx := compute.NewSessionContext(context.Background())
+
+			for done := 0; done < increments; {
+				replies := run(t, c, ctx, "WATCH counter", "GET counter")
+				value, err := strconv.Atoi(strings.TrimPrefix(replies[1], "VALUE "))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				replies = run(t, c, ctx, "MULTI", "SET counter "+strconv.Itoa(value+1), "EXEC")
+				switch replies[2] {
+				case `RESULTS "OK"`:
+					done++
+				case "ABORTED":
+				default:
+					assert.Fail(t, "unexpected reply", replies[2])
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"VALUE " + strconv.Itoa(workers*increments)}, run(t, c, context.Background(), "GET counter"))
+}
+
+// TestSessionExecAtomic checks that readers never observe a transaction
+// applied in part.
+func TestSessionExecAtomic(t *testing.T) {
+	t.Parallel()
+
+	c := newRealCompute(t)
+	run(t, c, context.Background(), "MSET a 0 b 0")
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ctx := compute.NewSessionContext(context.Background())
+		for i := range 300 {
+			v := strconv.Itoa(i)
+			run(t, c, ctx, "MULTI", "SET a "+v, "SET b "+v, "EXEC")
+		}
+	})
+	for range 3 {
+		wg.Go(func() {
+			for range 300 {
+				reply := run(t, c, context.Background(), "MGET a b")[0]
+				fields := strings.Fields(reply)
+				if !assert.Len(t, fields, 3) || !assert.Equal(t, fields[1], fields[2], reply) {
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
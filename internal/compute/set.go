@@ -0,0 +1,316 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+)
+
+// handleSAdd parses SADD key member [member ...] and answers ADDED n with
+// the number of members that were new.
+func (c *Compute) handleSAdd(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.sadd"
+
+	if len(tokens)-1 < command.CommandSAddMinQ {
+		c.log.Info("must be a key and at least one member")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	added, err := c.commandCompute.SAdd(ctx, tokens[1], tokens[2:])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("added", added))
+	return "ADDED " + strconv.Itoa(added), nil
+}
+
+// handleSRem answers DELETED n with the number of members that existed.
+func (c *Compute) handleSRem(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.srem"
+
+	if len(tokens)-1 < command.CommandSRemMinQ {
+		c.log.Info("must be a key and at least one member")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	removed, err := c.commandCompute.SRem(ctx, tokens[1], tokens[2:])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("deleted", removed))
+	return "DELETED " + strconv.Itoa(removed), nil
+}
+
+// handleSMembers answers MEMBERS "a" "b" ... in lexicographic order.
+func (c *Compute) handleSMembers(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.smembers"
+
+	if len(tokens)-1 != command.CommandSMembersQ {
+		c.log.Info("must be one arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	members, err := c.queryCompute.SMembers(ctx, tokens[1])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("members", len(members)))
+	return formatMembers(members), nil
+}
+
+// handleSIsMember answers EXISTS or NOT_FOUND.
+func (c *Compute) handleSIsMember(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.sismember"
+
+	if len(tokens)-1 != command.CommandSIsMemberQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	ok, err := c.queryCompute.SIsMember(ctx, tokens[1], tokens[2])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	if !ok {
+		return "NOT_FOUND", nil
+	}
+	return "EXISTS", nil
+}
+
+// handleSCombine serves SINTER and SUNION key [key ...] and answers
+// MEMBERS "a" "b" ... in lexicographic order.
+func (c *Compute) handleSCombine(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.scombine"
+
+	if len(tokens)-1 < command.CommandSInterMinQ {
+		c.log.Info("must be at least one argument")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	combine := c.queryCompute.SUnion
+	if tokens[0] == command.CommandSInter {
+		combine = c.queryCompute.SInter
+	}
+
+	members, err := combine(ctx, tokens[1:])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.Int("keys", len(tokens)-1), slog.Int("members", len(members)))
+	return formatMembers(members), nil
+}
+
+// handleZAdd parses ZADD key score member [score member ...] and answers
+// ADDED n with the number of members that were new.
+func (c *Compute) handleZAdd(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.zadd"
+
+	args := tokens[1:]
+	if len(args) < command.CommandZAddMinQ || len(args)%2 == 0 {
+		c.log.Info("must be a key and score member pairs")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	members := make([]kv.ScoredMember, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		score, err := kv.ParseScore(args[i])
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+		}
+		members = append(members, kv.ScoredMember{Member: args[i+1], Score: score})
+	}
+
+	added, err := c.commandCompute.ZAdd(ctx, args[0], members)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", args[0]), slog.Int("added", added))
+	return "ADDED " + strconv.Itoa(added), nil
+}
+
+// handleZRem answers DELETED n with the number of members that existed.
+func (c *Compute) handleZRem(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.zrem"
+
+	if len(tokens)-1 < command.CommandZRemMinQ {
+		c.log.Info("must be a key and at least one member")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	removed, err := c.commandCompute.ZRem(ctx, tokens[1], tokens[2:])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]), slog.Int("deleted", removed))
+	return "DELETED " + strconv.Itoa(removed), nil
+}
+
+// handleZScore answers SCORE x or NOT_FOUND.
+func (c *Compute) handleZScore(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.zscore"
+
+	if len(tokens)-1 != command.CommandZScoreQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	score, err := c.queryCompute.ZScore(ctx, tokens[1], tokens[2])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "SCORE " + kv.FormatScore(score), nil
+}
+
+// handleZRank answers RANK n, the position of the member in score order
+// starting at 0, or NOT_FOUND.
+func (c *Compute) handleZRank(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.zrank"
+
+	if len(tokens)-1 != command.CommandZRankQ {
+		c.log.Info("must be two arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	rank, err := c.queryCompute.ZRank(ctx, tokens[1], tokens[2])
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", tokens[1]))
+	return "RANK " + strconv.Itoa(rank), nil
+}
+
+// handleZRange parses ZRANGE key start stop [WITHSCORES] and answers
+// MEMBERS "a" "b" ..., each member followed by its score with WITHSCORES.
+func (c *Compute) handleZRange(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.zrange"
+
+	args := tokens[1:]
+	withScores := len(args) == command.CommandZRangeQ+1 && args[len(args)-1] == command.OptionWithScores
+	if len(args) != command.CommandZRangeQ && !withScores {
+		c.log.Info("must be three arguments and WITHSCORES optionally")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	start, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+	}
+	stop, err := strconv.Atoi(args[2])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+	}
+
+	members, err := c.queryCompute.ZRange(ctx, args[0], start, stop)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", args[0]), slog.Int("members", len(members)))
+	return formatScoredMembers(members, withScores), nil
+}
+
+// handleZRangeByScore parses ZRANGEBYSCORE key min max [WITHSCORES]
+// [LIMIT n]. A bound prefixed with ( is exclusive and -inf and +inf are
+// unbounded.
+func (c *Compute) handleZRangeByScore(ctx context.Context, tokens []string) (string, error) {
+	const op = "compute.zrangebyscore"
+
+	args := tokens[1:]
+	if len(args) < command.CommandZRangeByScoreMinQ || len(args) > command.CommandZRangeByScoreMaxQ {
+		c.log.Info("must be three to six arguments")
+		return "", fmt.Errorf("%w", ErrInvalidQuantity)
+	}
+
+	lower, err := parseScoreBound(args[1])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	upper, err := parseScoreBound(args[2])
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		withScores bool
+		limit      int
+	)
+	for i := 3; i < len(args); i++ {
+		switch {
+		case args[i] == command.OptionWithScores && !withScores:
+			withScores = true
+		case args[i] == command.OptionLimit && limit == 0 && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+			}
+			limit = n
+			i++
+		default:
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidArg)
+		}
+	}
+
+	members, err := c.queryCompute.ZRangeByScore(ctx, args[0], lower, upper, limit)
+	if err != nil {
+		return c.writeErrorResponse(op, err)
+	}
+
+	c.log.Info("command success", slog.String("cmd", tokens[0]), slog.String("key", args[0]), slog.Int("members", len(members)))
+	return formatScoredMembers(members, withScores), nil
+}
+
+// parseScoreBound parses a score range bound, exclusive when prefixed with (.
+func parseScoreBound(raw string) (kv.ScoreBound, error) {
+	var bound kv.ScoreBound
+	if rest, ok := strings.CutPrefix(raw, "("); ok {
+		bound.Exclusive = true
+		raw = rest
+	}
+
+	score, err := kv.ParseScore(raw)
+	if err != nil {
+		return kv.ScoreBound{}, ErrInvalidArg
+	}
+	bound.Score = score
+	return bound, nil
+}
+
+func formatMembers(members []string) string {
+	var b strings.Builder
+	b.WriteString("MEMBERS")
+	for _, member := range members {
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(member))
+	}
+	return b.String()
+}
+
+func formatScoredMembers(members []kv.ScoredMember, withScores bool) string {
+	var b strings.Builder
+	b.WriteString("MEMBERS")
+	for _, m := range members {
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(m.Member))
+		if withScores {
+			b.WriteByte(' ')
+			b.WriteString(command.Quote(kv.FormatScore(m.Score)))
+		}
+	}
+	return b.String()
+}
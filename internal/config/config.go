@@ -3,12 +3,127 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env string `yaml:"env" env-default:"envLocal"`
+	Env         string      `yaml:"env" env-default:"envLocal"`
+	Engine      Engine      `yaml:"engine"`
+	Network     Network     `yaml:"network"`
+	Cli         Cli         `yaml:"cli"`
+	WAL         WAL         `yaml:"wal"`
+	Replication Replication `yaml:"replication"`
+	Validation  Validation  `yaml:"validation"`
+	PubSub      PubSub      `yaml:"pubsub"`
+	Compression Compression `yaml:"compression"`
+}
+
+// Engine configures the storage engine. Type is in_memory for the sharded
+// hash table, ordered to also keep the keys in order, which enables the
+// SCAN, KEYS and COUNT range queries, or lsm for a log-structured merge tree
+// that keeps most of the data on disk. The sweeper and memory settings apply
+// to the in-memory engines only.
+type Engine struct {
+	Type       string `yaml:"type" env-default:"in_memory"`
+	ShardCount int    `yaml:"shard_count" env-default:"16"`
+
+	// ExpirationSweepInterval is how often the background sweeper samples
+	// keys with a TTL; ExpirationSampleSize is the sample size per shard.
+	ExpirationSweepInterval time.Duration `yaml:"expiration_sweep_interval" env-default:"100ms"`
+	ExpirationSampleSize    int           `yaml:"expiration_sample_size" env-default:"20"`
+
+	// MaxMemory limits key plus value bytes and a per-key overhead; 0 means
+	// unlimited. EvictionPolicy is one of noeviction, allkeys-lru,
+	// allkeys-lfu, volatile-lru or allkeys-random.
+	MaxMemory      int64  `yaml:"max_memory" env-default:"0"`
+	EvictionPolicy string `yaml:"eviction_policy" env-default:"noeviction"`
+
+	LSM LSM `yaml:"lsm"`
+}
+
+// LSM configures the lsm engine. Its tables in Directory are kept across
+// restarts; only the WAL records written after them are replayed. A
+// memtable is flushed to a table on level 0 once it reaches MemtableSize
+// bytes, and Level0Tables of them are compacted into the next level, in
+// tables of TableSize bytes.
+type LSM struct {
+	Directory    string `yaml:"directory" env-default:"./data/lsm"`
+	MemtableSize int64  `yaml:"memtable_size" env-default:"4194304"`
+	TableSize    int64  `yaml:"table_size" env-default:"2097152"`
+	Level0Tables int    `yaml:"level0_tables" env-default:"4"`
+}
+
+// Network configures the TCP server. The server is disabled when Address is empty.
+type Network struct {
+	Address        string        `yaml:"address"`
+	MaxConnections int           `yaml:"max_connections" env-default:"100"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout" env-default:"5m"`
+	MaxMessageSize int           `yaml:"max_message_size" env-default:"4096"`
+}
+
+// WAL configures the write-ahead log. Persistence is disabled when DataDirectory is empty.
+// Every SnapshotInterval the state is snapshotted into DataDirectory and the
+// segments the snapshot covers are deleted; 0 disables snapshots. The lsm
+// engine is not snapshotted: the segments its tables hold are deleted
+// instead, except on a master. A torn
+// record at the end of the log is always cut off at startup; a corrupted one
+// before it stops the startup when CorruptionPolicy is fail, or cuts the log
+// there, losing the records after it, when it is truncate.
+type WAL struct {
+	DataDirectory        string        `yaml:"data_directory"`
+	MaxSegmentSize       int64         `yaml:"max_segment_size" env-default:"10485760"`
+	FlushingBatchSize    int           `yaml:"flushing_batch_size" env-default:"100"`
+	FlushingBatchTimeout time.Duration `yaml:"flushing_batch_timeout" env-default:"10ms"`
+	SnapshotInterval     time.Duration `yaml:"snapshot_interval" env-default:"10m"`
+	CorruptionPolicy     string        `yaml:"corruption_policy" env-default:"fail"`
+}
+
+// Replication configures the instance role. A master serves its WAL on
+// MasterAddress when it is set; a replica pulls from MasterAddress every
+// SyncInterval and rejects client writes.
+type Replication struct {
+	ReplicaType   string        `yaml:"replica_type" env-default:"master"`
+	MasterAddress string        `yaml:"master_address"`
+	SyncInterval  time.Duration `yaml:"sync_interval" env-default:"1s"`
+}
+
+// Validation restricts command arguments. CharacterClasses apply to bare
+// arguments, and to quoted ones too when RestrictQuoted is set; the classes
+// are letters, digits, punctuation and unicode_letters. Zero limits are
+// unlimited.
+type Validation struct {
+	CharacterClasses []string `yaml:"character_classes" env-default:"letters,digits,punctuation"`
+	Punctuation      string   `yaml:"punctuation" env-default:"*/_."`
+	RestrictQuoted   bool     `yaml:"restrict_quoted" env-default:"false"`
+	MaxKeyLength     int      `yaml:"max_key_length" env-default:"0"`
+	MaxValueLength   int      `yaml:"max_value_length" env-default:"0"`
+	MaxTokens        int      `yaml:"max_tokens" env-default:"0"`
+}
+
+// PubSub configures the message channels. A subscriber buffers up to
+// BufferSize messages; OverflowPolicy is drop to discard the messages that do
+// not fit or disconnect to drop the subscriber. KeyspaceEvents publishes the
+// keys set, deleted, expired and evicted to the __keyspace__:<key> and
+// __keyevent__:<event> channels.
+type PubSub struct {
+	BufferSize     int    `yaml:"buffer_size" env-default:"128"`
+	OverflowPolicy string `yaml:"overflow_policy" env-default:"disconnect"`
+	KeyspaceEvents bool   `yaml:"keyspace_events" env-default:"false"`
+}
+
+type Cli struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+}
+
+// Compression configures value compression. Values of at least Threshold
+// bytes are compressed with Codec, none or flate, in the engine, the WAL and
+// the snapshots. The codec is stored with every value, so the setting may
+// change between restarts.
+type Compression struct {
+	Codec     string `yaml:"codec" env-default:"none"`
+	Threshold int    `yaml:"threshold" env-default:"1024"`
 }
 
 func MustLoad() *Config {
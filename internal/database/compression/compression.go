@@ -0,0 +1,152 @@
+// Package compression compresses large values. The codec a value was
+// encoded with is kept next to it, so values written under one setting are
+// still read after the setting changed.
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+)
+
+// DefaultThreshold is the size from which values are compressed.
+const DefaultThreshold = 1024
+
+// Codec is how a value is encoded. Its byte is what gets stored with the
+// value, so the existing codecs must keep their numbers.
+type Codec byte
+
+const (
+	// None stores the value as is.
+	None Codec = 0
+	// Flate stores the length of the value followed by its DEFLATE stream.
+	Flate Codec = 1
+)
+
+var (
+	ErrUnknownCodec = errors.New("unknown compression codec")
+	ErrCorrupted    = errors.New("corrupted compressed value")
+)
+
+func ParseCodec(raw string) (Codec, error) {
+	switch raw {
+	case "none", "":
+		return None, nil
+	case "flate":
+		return Flate, nil
+	default:
+		return None, fmt.Errorf("%w: %q", ErrUnknownCodec, raw)
+	}
+}
+
+func (c Codec) String() string {
+	switch c {
+	case None:
+		return "none"
+	case Flate:
+		return "flate"
+	default:
+		return fmt.Sprintf("codec(%d)", byte(c))
+	}
+}
+
+// Compressor encodes the values of at least its threshold with its codec.
+// The zero Compressor stores every value as is.
+type Compressor struct {
+	codec     Codec
+	threshold int
+}
+
+// NewCompressor returns a compressor for codec; a non-positive threshold is
+// DefaultThreshold.
+func NewCompressor(codec Codec, threshold int) Compressor {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return Compressor{codec: codec, threshold: threshold}
+}
+
+// Enabled reports whether the compressor compresses any value.
+func (c Compressor) Enabled() bool {
+	return c.codec != None
+}
+
+// Compress returns value as it is to be stored together with its codec. A
+// value below the threshold, or one that would not shrink, is kept as is.
+func (c Compressor) Compress(value string) (Codec, string) {
+	if c.codec == None || len(value) < c.threshold {
+		return None, value
+	}
+
+	var data []byte
+	switch c.codec {
+	case Flate:
+		data = deflate(value)
+	default:
+		return None, value
+	}
+	if len(data) >= len(value) {
+		return None, value
+	}
+	return c.codec, string(data)
+}
+
+// Decompress returns the value stored as data with codec.
+func Decompress(codec Codec, data string) (string, error) {
+	switch codec {
+	case None:
+		return data, nil
+	case Flate:
+		return inflate(data)
+	default:
+		return "", fmt.Errorf("%w: %d", ErrUnknownCodec, byte(codec))
+	}
+}
+
+var writers = sync.Pool{
+	New: func() any {
+		// the level is valid, NewWriter cannot fail
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+func deflate(value string) []byte {
+	buf := bytes.NewBuffer(binary.AppendUvarint(make([]byte, 0, len(value)/2), uint64(len(value))))
+
+	w := writers.Get().(*flate.Writer)
+	defer writers.Put(w)
+
+	// writes to a bytes.Buffer do not fail
+	w.Reset(buf)
+	_, _ = io.WriteString(w, value)
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func inflate(data string) (string, error) {
+	size, n := binary.Uvarint([]byte(data[:min(len(data), binary.MaxVarintLen64)]))
+	if n <= 0 || size >= math.MaxInt64 {
+		return "", fmt.Errorf("length: %w", ErrCorrupted)
+	}
+
+	r := flate.NewReader(strings.NewReader(data[n:]))
+	defer r.Close()
+
+	// reading one byte past the length is enough to tell a longer stream
+	var out strings.Builder
+	if _, err := io.Copy(&out, io.LimitReader(r, int64(size)+1)); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	if uint64(out.Len()) != size {
+		return "", fmt.Errorf("length: %w", ErrCorrupted)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,81 @@
+package compression_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/compression"
+)
+
+func TestCompressor(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat(`{"name":"value","items":[1,2,3]}`, 64)
+
+	tests := []struct {
+		name       string
+		compressor compression.Compressor
+		value      string
+		want       compression.Codec
+	}{
+		{name: "zero value", compressor: compression.Compressor{}, value: large, want: compression.None},
+		{name: "disabled", compressor: compression.NewCompressor(compression.None, 16), value: large, want: compression.None},
+		{name: "below threshold", compressor: compression.NewCompressor(compression.Flate, 4096), value: large, want: compression.None},
+		{name: "compressed", compressor: compression.NewCompressor(compression.Flate, 0), value: large, want: compression.Flate},
+		{name: "incompressible", compressor: compression.NewCompressor(compression.Flate, 1), value: "x", want: compression.None},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			codec, data := tc.compressor.Compress(tc.value)
+			assert.Equal(t, tc.want, codec)
+			if codec == compression.None {
+				assert.Equal(t, tc.value, data)
+			} else {
+				assert.Less(t, len(data), len(tc.value))
+			}
+
+			got, err := compression.Decompress(codec, data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, got)
+		})
+	}
+}
+
+func TestDecompressCorrupted(t *testing.T) {
+	t.Parallel()
+
+	codec, data := compression.NewCompressor(compression.Flate, 0).Compress(strings.Repeat("abc", 1000))
+	require.Equal(t, compression.Flate, codec)
+
+	_, err := compression.Decompress(compression.Flate, data[:len(data)/2])
+	require.ErrorIs(t, err, compression.ErrCorrupted)
+
+	// a length that does not match the stream
+	_, err = compression.Decompress(compression.Flate, "\x05"+data[2:])
+	require.ErrorIs(t, err, compression.ErrCorrupted)
+
+	_, err = compression.Decompress(compression.Codec(9), data)
+	require.ErrorIs(t, err, compression.ErrUnknownCodec)
+}
+
+func TestParseCodec(t *testing.T) {
+	t.Parallel()
+
+	for raw, want := range map[string]compression.Codec{"": compression.None, "none": compression.None, "flate": compression.Flate} {
+		got, err := compression.ParseCodec(raw)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		if raw != "" {
+			assert.Equal(t, raw, got.String())
+		}
+	}
+
+	_, err := compression.ParseCodec("zstd")
+	require.ErrorIs(t, err, compression.ErrUnknownCodec)
+}
@@ -2,4 +2,25 @@ package dberrors
 
 import "errors"
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound = errors.New("not found")
+	ErrNoExpiry = errors.New("key has no expiry")
+	ErrReadOnly = errors.New("READONLY replica does not accept writes")
+
+	// ErrConditionFailed reports a conditional write that was not applied.
+	ErrConditionFailed = errors.New("write condition not met")
+
+	// Counter errors.
+	ErrNotInteger = errors.New("value is not an integer or out of range")
+	ErrNotFloat   = errors.New("value is not a valid float")
+	ErrOverflow   = errors.New("increment or decrement would overflow")
+
+	// ErrNotOrdered rejects range queries on an engine without an ordered index.
+	ErrNotOrdered = errors.New("range queries require the ordered engine")
+
+	// ErrInvalidCursor rejects a SCAN cursor the table did not hand out.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	ErrOutOfMemory = errors.New("OOM command not allowed when used memory exceeds max_memory")
+	ErrWrongType   = errors.New("WRONGTYPE operation against a key holding the wrong kind of value")
+)
@@ -0,0 +1,135 @@
+package hashtable
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+// IncrBy adds delta to the integer stored at key, a missing key counting as
+// 0, and returns the new value together with the expiry of the key, zero when
+// it has none. The expiry is kept. It fails with dberrors.ErrNotInteger when
+// the stored value is not a 64 bit integer and with dberrors.ErrOverflow when
+// the result does not fit in one.
+func (h *HashTable) IncrBy(key string, delta int64) (int64, time.Time, error) {
+	const op = "HashTable.IncrBy"
+
+	var result int64
+	expireAt, err := h.update(key, func(current kv.Lookup) (string, error) {
+		var n int64
+		if current.Found {
+			var err error
+			if n, err = strconv.ParseInt(current.Value, 10, 64); err != nil {
+				return "", dberrors.ErrNotInteger
+			}
+		}
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return "", dberrors.ErrOverflow
+		}
+		result = n + delta
+		return strconv.FormatInt(result, 10), nil
+	})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, expireAt, nil
+}
+
+// IncrByFloat adds delta to the number stored at key, a missing key counting
+// as 0, and returns the new value in its stored form together with the expiry
+// of the key. It fails with dberrors.ErrNotFloat when the stored value is not
+// a finite number and with dberrors.ErrOverflow when the result is not finite.
+func (h *HashTable) IncrByFloat(key string, delta float64) (string, time.Time, error) {
+	const op = "HashTable.IncrByFloat"
+
+	var result string
+	expireAt, err := h.update(key, func(current kv.Lookup) (string, error) {
+		var f float64
+		if current.Found {
+			var err error
+			f, err = strconv.ParseFloat(current.Value, 64)
+			if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+				return "", dberrors.ErrNotFloat
+			}
+		}
+		sum := f + delta
+		if math.IsInf(sum, 0) || math.IsNaN(sum) {
+			return "", dberrors.ErrOverflow
+		}
+		result = strconv.FormatFloat(sum, 'f', -1, 64)
+		return result, nil
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, expireAt, nil
+}
+
+// update replaces the value of key with the one computed from its current
+// state, keeping the expiry, and returns that expiry. Memory is reserved for
+// the new value before the shard is write-locked; when the key changes in the
+// meantime the computation is retried.
+func (h *HashTable) update(key string, next func(current kv.Lookup) (string, error)) (time.Time, error) {
+	sh := h.shardFor(key)
+
+	for {
+		sh.mu.RLock()
+		current, version, err := h.lookupLocked(sh, key)
+		var oldSize int64
+		if current.Found {
+			oldSize = sh.data[key].size()
+		}
+		sh.mu.RUnlock()
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		value, err := next(current)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		e := h.newEntry(value)
+		if err := h.reserve(entrySize(key, e.value)-oldSize, func(k string) bool { return k == key }); err != nil {
+			return time.Time{}, err
+		}
+
+		sh.mu.Lock()
+		if _, v, _ := h.lookupLocked(sh, key); v != version {
+			sh.mu.Unlock()
+			continue
+		}
+
+		// an expired key still has its deadline, which must not carry over
+		var expireAt time.Time
+		if current.Found {
+			expireAt = sh.expires[key]
+		}
+		h.putLocked(sh, key, e)
+		if !expireAt.IsZero() {
+			sh.expires[key] = expireAt
+		}
+		h.notify(kv.EventSet, key)
+		sh.mu.Unlock()
+
+		return expireAt, nil
+	}
+}
+
+// lookupLocked returns the live string value and the version of a key,
+// version 0 when it does not exist, and dberrors.ErrWrongType for a key of
+// another type. The caller holds the shard lock.
+func (h *HashTable) lookupLocked(sh *shard, key string) (kv.Lookup, uint64, error) {
+	if !sh.liveLocked(key, h.now()) {
+		return kv.Lookup{}, 0, nil
+	}
+	e := sh.data[key]
+	if e.object != nil {
+		return kv.Lookup{}, e.version, dberrors.ErrWrongType
+	}
+	return kv.Lookup{Value: e.text(), Found: true}, e.version, nil
+}
@@ -0,0 +1,162 @@
+package hashtable_test
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/lib/clock/fakeclock"
+)
+
+func TestHashTableIncrBy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		initial *string
+		delta   int64
+		want    int64
+		wantErr error
+	}{
+		{name: "missing key counts from zero", delta: 5, want: 5},
+		{name: "increment", initial: ptr("10"), delta: 1, want: 11},
+		{name: "decrement below zero", initial: ptr("1"), delta: -3, want: -2},
+		{name: "max int64", initial: ptr("9223372036854775806"), delta: 1, want: math.MaxInt64},
+		{name: "overflow", initial: ptr("9223372036854775807"), delta: 1, wantErr: dberrors.ErrOverflow},
+		{name: "underflow", initial: ptr("-9223372036854775808"), delta: -1, wantErr: dberrors.ErrOverflow},
+		{name: "not an integer", initial: ptr("abc"), delta: 1, wantErr: dberrors.ErrNotInteger},
+		{name: "float is not an integer", initial: ptr("1.5"), delta: 1, wantErr: dberrors.ErrNotInteger},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := hashtable.NewHashTable(4)
+			if tc.initial != nil {
+				require.NoError(t, h.Set("k", *tc.initial))
+			}
+
+			got, _, err := h.IncrBy("k", tc.delta)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+
+				// a failed increment leaves the value alone
+				if tc.initial != nil {
+					value, err := h.Get("k")
+					require.NoError(t, err)
+					assert.Equal(t, *tc.initial, value)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestHashTableIncrByFloat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		initial *string
+		delta   float64
+		want    string
+		wantErr error
+	}{
+		{name: "missing key counts from zero", delta: 0.5, want: "0.5"},
+		{name: "integer value", initial: ptr("10"), delta: 0.1, want: "10.1"},
+		{name: "exponent value", initial: ptr("5.0e3"), delta: 200, want: "5200"},
+		{name: "whole result", initial: ptr("1.5"), delta: 1.5, want: "3"},
+		{name: "overflow", initial: ptr("1.7e308"), delta: 1.7e308, wantErr: dberrors.ErrOverflow},
+		{name: "not a number", initial: ptr("abc"), delta: 1, wantErr: dberrors.ErrNotFloat},
+		{name: "infinity is not a value", initial: ptr("inf"), delta: 1, wantErr: dberrors.ErrNotFloat},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := hashtable.NewHashTable(4)
+			if tc.initial != nil {
+				require.NoError(t, h.Set("k", *tc.initial))
+			}
+
+			got, _, err := h.IncrByFloat("k", tc.delta)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			value, err := h.Get("k")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, value)
+		})
+	}
+}
+
+func TestHashTableIncrKeepsExpiry(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now))
+	deadline := clock.Now().Add(time.Minute)
+
+	require.NoError(t, h.SetWithExpiry("k", "1", deadline))
+
+	got, expireAt, err := h.IncrBy("k", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got)
+	assert.Equal(t, deadline, expireAt)
+
+	ttl, err := h.TTL("k")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	// an expired counter starts over without expiry
+	clock.Advance(time.Minute)
+
+	got, expireAt, err = h.IncrBy("k", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+	assert.True(t, expireAt.IsZero())
+}
+
+func TestHashTableIncrByConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers = 16
+		perWork = 500
+	)
+
+	h := hashtable.NewHashTable(4)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range perWork {
+				_, _, err := h.IncrBy("counter", 1)
+				assert.NoError(t, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	got, err := h.Get("counter")
+	require.NoError(t, err)
+	assert.Equal(t, "8000", got)
+}
+
+func ptr(s string) *string {
+	return &s
+}
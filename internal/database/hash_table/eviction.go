@@ -0,0 +1,200 @@
+package hashtable
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
+)
+
+type EvictionPolicy string
+
+const (
+	PolicyNoEviction    EvictionPolicy = "noeviction"
+	PolicyAllKeysLRU    EvictionPolicy = "allkeys-lru"
+	PolicyAllKeysLFU    EvictionPolicy = "allkeys-lfu"
+	PolicyVolatileLRU   EvictionPolicy = "volatile-lru"
+	PolicyAllKeysRandom EvictionPolicy = "allkeys-random"
+
+	// DefaultEvictionSampleSize is how many keys are compared to pick one
+	// eviction victim; like Redis the policies are approximated by sampling.
+	DefaultEvictionSampleSize = 16
+
+	// samplingShards is how many shards one victim search spreads over.
+	samplingShards = 4
+
+	// entryOverhead approximates the per-key cost of the maps and the entry
+	// struct on top of the key and value bytes.
+	entryOverhead = 64
+)
+
+var ErrUnknownEvictionPolicy = errors.New("unknown eviction policy")
+
+func ParseEvictionPolicy(raw string) (EvictionPolicy, error) {
+	switch policy := EvictionPolicy(raw); policy {
+	case PolicyNoEviction, PolicyAllKeysLRU, PolicyAllKeysLFU, PolicyVolatileLRU, PolicyAllKeysRandom:
+		return policy, nil
+	case "":
+		return PolicyNoEviction, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownEvictionPolicy, raw)
+	}
+}
+
+// Stats returns the current size and eviction counters.
+func (h *HashTable) Stats() stats.Stats {
+	return stats.Stats{
+		Keys:           h.Len(),
+		UsedMemory:     h.usedMemory.Load(),
+		MaxMemory:      h.maxMemory,
+		EvictionPolicy: string(h.policy),
+		EvictedKeys:    h.evictedKeys.Load(),
+		ExpiredKeys:    h.expiredKeys.Load(),
+		MemorySaved:    h.savedMemory.Load(),
+	}
+}
+
+func entrySize(key, value string) int64 {
+	return int64(len(key) + len(value) + entryOverhead)
+}
+
+// size is the accounted memory of a stored entry.
+func (e *entry) size() int64 {
+	if e.object != nil {
+		return int64(len(e.key)+entryOverhead) + e.object.size()
+	}
+	return entrySize(e.key, e.value)
+}
+
+// OnEvict calls fn with every key evicted to make room for a write, in the
+// goroutine of the write and before it is applied. fn runs under the lock of
+// the evicted key's shard and must not use the table. OnEvict must be called
+// before the table is used.
+func (h *HashTable) OnEvict(fn func(key string)) {
+	h.onEvict = fn
+}
+
+// LiftLimit lets writes exceed the memory limit, without evicting, until
+// restore is called. It is meant for replaying writes that were already
+// admitted, together with their evictions, once.
+func (h *HashTable) LiftLimit() (restore func()) {
+	h.lifted.Add(1)
+	return sync.OnceFunc(func() { h.lifted.Add(-1) })
+}
+
+// reserve makes room for growth bytes before the protected keys are written,
+// evicting other keys according to the policy. It fails with dberrors.ErrOutOfMemory when the
+// policy forbids eviction or no candidate is left. Concurrent writers may
+// overshoot the limit by the size of their in-flight entries.
+func (h *HashTable) reserve(growth int64, protected func(key string) bool) error {
+	if h.maxMemory <= 0 || growth <= 0 || h.lifted.Load() > 0 {
+		return nil
+	}
+
+	for h.usedMemory.Load()+growth > h.maxMemory {
+		if h.policy == PolicyNoEviction {
+			return dberrors.ErrOutOfMemory
+		}
+
+		sh, victim, ok := h.pickVictim(protected)
+		if !ok {
+			return dberrors.ErrOutOfMemory
+		}
+
+		sh.mu.Lock()
+		if _, exists := sh.data[victim]; exists {
+			h.removeLocked(sh, victim)
+			h.evictedKeys.Add(1)
+			if h.onEvict != nil {
+				h.onEvict(victim)
+			}
+			h.notify(kv.EventEvicted, victim)
+		}
+		sh.mu.Unlock()
+	}
+
+	return nil
+}
+
+type candidate struct {
+	shard      *shard
+	key        string
+	lastAccess uint64
+	hits       uint64
+}
+
+// pickVictim samples keys across shards, starting at a random shard, and
+// returns the best one to evict under the policy. Protected keys, the ones
+// being written, are never chosen.
+func (h *HashTable) pickVictim(protected func(key string) bool) (*shard, string, bool) {
+	perShard := max(1, h.sampleSize/min(len(h.shards), samplingShards))
+	start := rand.IntN(len(h.shards)) //nolint:gosec // sampling does not need a secure source
+
+	var (
+		best    candidate
+		found   bool
+		sampled int
+	)
+
+	for i := 0; i < len(h.shards) && sampled < h.sampleSize; i++ {
+		sh := h.shards[(start+i)%len(h.shards)]
+
+		sh.mu.RLock()
+		taken := 0
+		consider := func(key string) bool {
+			if protected(key) {
+				return true
+			}
+			e := sh.data[key]
+			c := candidate{shard: sh, key: key, lastAccess: e.lastAccess.Load(), hits: e.hits.Load()}
+			if !found || h.better(c, best) {
+				best, found = c, true
+			}
+			sampled++
+			taken++
+			return taken < perShard && sampled < h.sampleSize
+		}
+
+		if h.policy == PolicyVolatileLRU {
+			for key := range sh.expires {
+				if !consider(key) {
+					break
+				}
+			}
+		} else {
+			for key := range sh.data {
+				if !consider(key) {
+					break
+				}
+			}
+		}
+		sh.mu.RUnlock()
+
+		if found && h.policy == PolicyAllKeysRandom {
+			break
+		}
+	}
+
+	return best.shard, best.key, found
+}
+
+// better reports whether c is a better eviction victim than current.
+func (h *HashTable) better(c, current candidate) bool {
+	switch h.policy {
+	case PolicyAllKeysLFU:
+		if c.hits != current.hits {
+			return c.hits < current.hits
+		}
+		return c.lastAccess < current.lastAccess
+	case PolicyAllKeysLRU, PolicyVolatileLRU:
+		return c.lastAccess < current.lastAccess
+	case PolicyNoEviction, PolicyAllKeysRandom:
+		return false
+	default:
+		return false
+	}
+}
@@ -0,0 +1,274 @@
+package hashtable_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/compression"
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+)
+
+// entryCost mirrors the accounting of a key of keyLen and a value of valueLen.
+func entryCost(keyLen, valueLen int) int64 {
+	return int64(keyLen + valueLen + 64)
+}
+
+func TestParseEvictionPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, raw := range []string{"noeviction", "allkeys-lru", "allkeys-lfu", "volatile-lru", "allkeys-random"} {
+		policy, err := hashtable.ParseEvictionPolicy(raw)
+		require.NoError(t, err)
+		assert.Equal(t, hashtable.EvictionPolicy(raw), policy)
+	}
+
+	policy, err := hashtable.ParseEvictionPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, hashtable.PolicyNoEviction, policy)
+
+	_, err = hashtable.ParseEvictionPolicy("volatile-ttl")
+	require.ErrorIs(t, err, hashtable.ErrUnknownEvictionPolicy)
+}
+
+func TestHashTableMemoryAccounting(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	require.NoError(t, h.Set("k1", "abc"))
+	require.NoError(t, h.Set("k2", "abcdef"))
+	assert.Equal(t, entryCost(2, 3)+entryCost(2, 6), h.Stats().UsedMemory)
+
+	require.NoError(t, h.Set("k1", "a"))
+	assert.Equal(t, entryCost(2, 1)+entryCost(2, 6), h.Stats().UsedMemory)
+
+	require.NoError(t, h.Del("k2"))
+	assert.Equal(t, entryCost(2, 1), h.Stats().UsedMemory)
+}
+
+func TestHashTableCompression(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4, hashtable.WithCompression(compression.NewCompressor(compression.Flate, 64)))
+	large := strings.Repeat("0123456789", 100)
+
+	require.NoError(t, h.Set("k1", large))
+	require.NoError(t, h.Set("k2", "small"))
+	saved := h.Stats().MemorySaved
+	assert.Positive(t, saved)
+	assert.Equal(t, entryCost(2, len(large))+entryCost(2, 5)-saved, h.Stats().UsedMemory)
+
+	got, err := h.Get("k1")
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+	assert.Equal(t, []kv.Lookup{{Value: large, Found: true}, {Value: "small", Found: true}}, h.MGet([]string{"k1", "k2"}))
+
+	previous, err := h.SetIf("k1", large+large, time.Time{}, kv.Condition{Kind: kv.IfEquals, Expected: large})
+	require.NoError(t, err)
+	assert.Equal(t, large, previous.Value)
+
+	view := h.Snapshot()
+	var items []kv.Item
+	require.NoError(t, view.Range(func(item kv.Item) error {
+		items = append(items, item)
+		return nil
+	}))
+	view.Close()
+	assert.ElementsMatch(t, []kv.Item{{Key: "k1", Value: large + large}, {Key: "k2", Value: "small"}}, items)
+
+	require.NoError(t, h.MSet([]kv.Pair{{Key: "k1", Value: "tiny"}, {Key: "k2", Value: large}}))
+	assert.Equal(t, saved, h.Stats().MemorySaved)
+
+	require.NoError(t, h.Del("k2"))
+	assert.Zero(t, h.Stats().MemorySaved)
+	assert.Equal(t, entryCost(2, 4), h.Stats().UsedMemory)
+}
+
+func TestHashTableNoEviction(t *testing.T) {
+	t.Parallel()
+
+	limit := 3 * entryCost(2, 4)
+	h := hashtable.NewHashTable(4, hashtable.WithMaxMemory(limit, hashtable.PolicyNoEviction))
+
+	for i := range 3 {
+		require.NoError(t, h.Set("k"+strconv.Itoa(i), "vvvv"))
+	}
+	require.ErrorIs(t, h.Set("k3", "vvvv"), dberrors.ErrOutOfMemory)
+	require.NoError(t, h.Set("k0", "vv"), "shrinking an existing key is always allowed")
+	require.NoError(t, h.Del("k1"))
+	require.NoError(t, h.Set("k3", "vvvv"))
+
+	s := h.Stats()
+	assert.Equal(t, 3, s.Keys)
+	assert.LessOrEqual(t, s.UsedMemory, limit)
+	assert.Zero(t, s.EvictedKeys)
+}
+
+// TestHashTableFailedConditionKeepsMemory checks that a conditional write
+// whose condition fails neither evicts keys nor runs out of memory.
+func TestHashTableFailedConditionKeepsMemory(t *testing.T) {
+	t.Parallel()
+
+	limit := 3 * entryCost(2, 4)
+	for _, policy := range []hashtable.EvictionPolicy{hashtable.PolicyNoEviction, hashtable.PolicyAllKeysLRU} {
+		h := hashtable.NewHashTable(4, hashtable.WithMaxMemory(limit, policy))
+		for i := range 3 {
+			require.NoError(t, h.Set("k"+strconv.Itoa(i), "vvvv"))
+		}
+
+		_, err := h.SetIf("k0", strings.Repeat("v", 16), time.Time{}, kv.Condition{Kind: kv.IfAbsent})
+		require.ErrorIs(t, err, dberrors.ErrConditionFailed, policy)
+		_, err = h.SetIf("k3", "vvvv", time.Time{}, kv.Condition{Kind: kv.IfPresent})
+		require.ErrorIs(t, err, dberrors.ErrConditionFailed, policy)
+
+		s := h.Stats()
+		assert.Equal(t, 3, s.Keys, policy)
+		assert.Zero(t, s.EvictedKeys, policy)
+	}
+}
+
+func TestHashTableOnEvictAndLiftLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := 2 * entryCost(2, 4)
+	h := hashtable.NewHashTable(4, hashtable.WithMaxMemory(limit, hashtable.PolicyAllKeysLRU))
+
+	var evicted []string
+	h.OnEvict(func(key string) { evicted = append(evicted, key) })
+
+	restore := h.LiftLimit()
+	for i := range 4 {
+		require.NoError(t, h.Set("k"+strconv.Itoa(i), "vvvv"))
+	}
+	assert.Equal(t, 4, h.Stats().Keys, "a lifted limit neither fails nor evicts")
+	assert.Empty(t, evicted)
+
+	restore()
+	restore()
+	require.NoError(t, h.Set("k4", "vvvv"))
+
+	s := h.Stats()
+	assert.Equal(t, 2, s.Keys)
+	assert.Len(t, evicted, 3)
+	assert.Equal(t, uint64(len(evicted)), s.EvictedKeys)
+	assert.NotContains(t, evicted, "k4")
+}
+
+func TestHashTableEvictionPolicies(t *testing.T) {
+	t.Parallel()
+
+	const keys = 10
+
+	tests := []struct {
+		name   string
+		policy hashtable.EvictionPolicy
+		// prepare runs after all keys are stored and before the overflow write
+		prepare func(t *testing.T, h *hashtable.HashTable)
+		// kept lists keys that must survive the eviction
+		kept []string
+	}{
+		{
+			name:   "allkeys-lru keeps recently used keys",
+			policy: hashtable.PolicyAllKeysLRU,
+			prepare: func(t *testing.T, h *hashtable.HashTable) {
+				t.Helper()
+				for i := 1; i < keys; i++ {
+					_, err := h.Get("k" + strconv.Itoa(i))
+					require.NoError(t, err)
+				}
+			},
+			kept: []string{"k1", "k5", "k9"},
+		},
+		{
+			name:   "allkeys-lfu keeps frequently used keys",
+			policy: hashtable.PolicyAllKeysLFU,
+			prepare: func(t *testing.T, h *hashtable.HashTable) {
+				t.Helper()
+				for i := 1; i < keys; i++ {
+					for range 3 {
+						_, err := h.Get("k" + strconv.Itoa(i))
+						require.NoError(t, err)
+					}
+				}
+				// make k0 the most recent but least frequent key
+				_, err := h.Get("k0")
+				require.NoError(t, err)
+			},
+			kept: []string{"k1", "k5", "k9"},
+		},
+		{
+			name:   "volatile-lru only evicts keys with a ttl",
+			policy: hashtable.PolicyVolatileLRU,
+			prepare: func(t *testing.T, h *hashtable.HashTable) {
+				t.Helper()
+				require.NoError(t, h.SetWithExpiry("k3", "vvvv", time.Now().Add(time.Hour)))
+			},
+			kept: []string{"k0", "k1", "k2", "k4", "k9"},
+		},
+		{
+			name:   "allkeys-random evicts some key",
+			policy: hashtable.PolicyAllKeysRandom,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			limit := keys * entryCost(2, 4)
+			h := hashtable.NewHashTable(1, hashtable.WithMaxMemory(limit, tc.policy))
+			for i := range keys {
+				require.NoError(t, h.Set("k"+strconv.Itoa(i), "vvvv"))
+			}
+			if tc.prepare != nil {
+				tc.prepare(t, h)
+			}
+
+			require.NoError(t, h.Set("new", "vvv"))
+
+			s := h.Stats()
+			assert.Equal(t, uint64(1), s.EvictedKeys)
+			assert.Equal(t, keys, s.Keys)
+			assert.LessOrEqual(t, s.UsedMemory, limit)
+			assert.Equal(t, string(tc.policy), s.EvictionPolicy)
+
+			_, err := h.Get("new")
+			require.NoError(t, err)
+			for _, key := range tc.kept {
+				_, err := h.Get(key)
+				require.NoError(t, err, key)
+			}
+		})
+	}
+}
+
+func TestHashTableVolatileLRUWithoutCandidates(t *testing.T) {
+	t.Parallel()
+
+	limit := 2 * entryCost(2, 4)
+	h := hashtable.NewHashTable(2, hashtable.WithMaxMemory(limit, hashtable.PolicyVolatileLRU))
+
+	require.NoError(t, h.Set("k0", "vvvv"))
+	require.NoError(t, h.Set("k1", "vvvv"))
+	require.ErrorIs(t, h.Set("k2", "vvvv"), dberrors.ErrOutOfMemory)
+
+	_, err := h.Get("k2")
+	require.ErrorIs(t, err, dberrors.ErrNotFound, "a rejected write must not be stored")
+}
+
+func TestHashTableValueLargerThanLimit(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(2, hashtable.WithMaxMemory(128, hashtable.PolicyAllKeysLRU))
+	require.NoError(t, h.Set("a", "b"))
+
+	require.ErrorIs(t, h.Set("big", strings.Repeat("x", 256)), dberrors.ErrOutOfMemory)
+	assert.Equal(t, uint64(1), h.Stats().EvictedKeys)
+}
@@ -0,0 +1,275 @@
+package hashtable
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+// fieldOverhead approximates the per-field cost of a hash map on top of the
+// field and value bytes.
+const fieldOverhead = 32
+
+// object is the value of a key holding another type than a string.
+type object interface {
+	// size approximates the memory of the value for the memory limit.
+	size() int64
+	// dump copies the value into the item of a snapshot.
+	dump(item *kv.Item)
+}
+
+// hashObject is the value of a hash key: a map of fields to values.
+type hashObject struct {
+	fields map[string]string
+	bytes  int64
+}
+
+func (o *hashObject) size() int64 {
+	return o.bytes
+}
+
+func (o *hashObject) dump(item *kv.Item) {
+	item.Fields = make([]kv.Pair, 0, len(o.fields))
+	for field, value := range o.fields {
+		item.Fields = append(item.Fields, kv.Pair{Key: field, Value: value})
+	}
+}
+
+func fieldSize(field, value string) int64 {
+	return int64(len(field) + len(value) + fieldOverhead)
+}
+
+// HSet sets fields of the hash stored at key, creating it when the key does
+// not exist, and returns how many fields were added rather than updated.
+// When a field repeats, its last value wins. The expiry of the key is kept.
+func (h *HashTable) HSet(key string, fields []kv.Pair) (int, error) {
+	const op = "HashTable.HSet"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	growth, err := h.hashGrowthLocked(sh, key, fields)
+	sh.mu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := h.reserve(growth, func(k string) bool { return k == key }); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	hash, err := h.hashLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if hash == nil {
+		hash = &hashObject{fields: make(map[string]string, len(fields))}
+		e := h.newEntry("")
+		e.object = hash
+		h.putLocked(sh, key, e)
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+
+	added := 0
+	for _, pair := range fields {
+		if old, ok := hash.fields[pair.Key]; ok {
+			hash.bytes -= fieldSize(pair.Key, old)
+		} else {
+			added++
+		}
+		hash.fields[pair.Key] = pair.Value
+		hash.bytes += fieldSize(pair.Key, pair.Value)
+	}
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	h.touch(e)
+	h.notify(kv.EventSet, key)
+
+	return added, nil
+}
+
+// HGet returns the value of a field. It fails with dberrors.ErrNotFound when
+// the key or the field does not exist.
+func (h *HashTable) HGet(key, field string) (string, error) {
+	const op = "HashTable.HGet"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	hash, err := h.hashLocked(sh, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if hash == nil {
+		return "", fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+
+	value, ok := hash.fields[field]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	h.touch(sh.data[key])
+
+	return value, nil
+}
+
+// HDel removes fields from the hash stored at key and returns how many
+// existed. A hash left without fields is deleted.
+func (h *HashTable) HDel(key string, fields []string) (int, error) {
+	const op = "HashTable.HDel"
+
+	sh := h.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	hash, err := h.hashLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if hash == nil {
+		return 0, nil
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+
+	deleted := 0
+	for _, field := range fields {
+		if value, ok := hash.fields[field]; ok {
+			delete(hash.fields, field)
+			hash.bytes -= fieldSize(field, value)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	if len(hash.fields) == 0 {
+		h.removeLocked(sh, key)
+		h.notify(kv.EventDel, key)
+	} else {
+		h.notify(kv.EventSet, key)
+	}
+
+	return deleted, nil
+}
+
+// HGetAll returns the fields of the hash stored at key sorted by field name,
+// none when the key does not exist.
+func (h *HashTable) HGetAll(key string) ([]kv.Pair, error) {
+	const op = "HashTable.HGetAll"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	hash, err := h.hashLocked(sh, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if hash == nil {
+		return nil, nil
+	}
+	h.touch(sh.data[key])
+
+	pairs := make([]kv.Pair, 0, len(hash.fields))
+	for _, field := range slices.Sorted(maps.Keys(hash.fields)) {
+		pairs = append(pairs, kv.Pair{Key: field, Value: hash.fields[field]})
+	}
+	return pairs, nil
+}
+
+// HLen returns the number of fields of the hash stored at key, 0 when the key
+// does not exist.
+func (h *HashTable) HLen(key string) (int, error) {
+	const op = "HashTable.HLen"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	hash, err := h.hashLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if hash == nil {
+		return 0, nil
+	}
+	return len(hash.fields), nil
+}
+
+// HExists reports whether the hash stored at key has the field.
+func (h *HashTable) HExists(key, field string) (bool, error) {
+	const op = "HashTable.HExists"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	hash, err := h.hashLocked(sh, key)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if hash == nil {
+		return false, nil
+	}
+	_, ok := hash.fields[field]
+	return ok, nil
+}
+
+// hashLocked returns the hash stored at key, nil when the key does not exist
+// or has expired, and dberrors.ErrWrongType when it holds another type. The
+// caller holds the shard lock.
+func (h *HashTable) hashLocked(sh *shard, key string) (*hashObject, error) {
+	if !sh.liveLocked(key, h.now()) {
+		return nil, nil
+	}
+	hash, ok := sh.data[key].object.(*hashObject)
+	if !ok {
+		return nil, dberrors.ErrWrongType
+	}
+	return hash, nil
+}
+
+// hashGrowthLocked estimates how much the accounted memory grows when fields
+// are set in the hash stored at key.
+func (h *HashTable) hashGrowthLocked(sh *shard, key string, fields []kv.Pair) (int64, error) {
+	hash, err := h.hashLocked(sh, key)
+	if err != nil {
+		return 0, err
+	}
+
+	var growth int64
+	if hash == nil {
+		growth = entrySize(key, "")
+	}
+	for _, pair := range fields {
+		growth += fieldSize(pair.Key, pair.Value)
+		if hash != nil {
+			if old, ok := hash.fields[pair.Key]; ok {
+				growth -= fieldSize(pair.Key, old)
+			}
+		}
+	}
+	return growth, nil
+}
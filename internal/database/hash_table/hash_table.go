@@ -2,37 +2,542 @@ package hashtable
 
 import (
 	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"lesson1/internal/database/compression"
 	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
 )
 
+const (
+	DefaultShardCount = 16
+
+	// a shard is swept again while more than a quarter of its sample expired
+	sweepRepeatRatio = 4
+)
+
+// HashTable is a concurrent-safe map split into independently locked shards.
+// The shard for a key is chosen by its FNV-1a hash, so operations on keys
+// living in different shards never contend for the same lock.
+//
+// Keys may carry an expiry deadline. Expired keys are invisible to readers
+// and are removed lazily on access or by DeleteExpired.
+//
+// When a memory limit is set, every entry is accounted as its key and value
+// size plus a fixed overhead, and writes that exceed the limit either fail or
+// evict other keys according to the eviction policy. A compressed value is
+// accounted at its compressed size.
 type HashTable struct {
-	data map[string]string
+	shards []*shard
+	now    func() time.Time
+
+	maxMemory  int64
+	policy     EvictionPolicy
+	sampleSize int
+
+	// lifted counts the callers that disabled the limit, see LiftLimit.
+	lifted atomic.Int32
+	// onEvict receives the evicted keys when set, see OnEvict.
+	onEvict func(key string)
+
+	// index keeps the keys in order when enabled, see WithOrderedIndex.
+	index *orderedIndex
+
+	// listener receives the keyspace events when set, see WithListener.
+	listener func(kv.Event)
+
+	compressor compression.Compressor
+
+	// snapshotMu is held while a View is open.
+	snapshotMu sync.Mutex
+
+	usedMemory  atomic.Int64
+	savedMemory atomic.Int64
+	accessClock atomic.Uint64
+	writeClock  atomic.Uint64
+	evictedKeys atomic.Uint64
+	expiredKeys atomic.Uint64
+}
+
+type shard struct {
+	mu      sync.RWMutex
+	data    map[string]*entry
+	expires map[string]time.Time
+
+	// buckets groups the entries by a part of their key hash that never
+	// changes, which gives Scan positions that stay valid across writes.
+	buckets [scanBuckets][]*entry
+
+	// frozen holds the keys of an open View as they were before their first
+	// write since, see Snapshot; it is nil when no view is open.
+	frozen map[string]kv.Item
+}
+
+type entry struct {
+	key   string
+	value string
+	// codec is how value is encoded, see text; saved is how many bytes the
+	// encoding spared.
+	codec compression.Codec
+	saved int64
+	// object is the value of a key holding another type than a string; value
+	// is empty then. It is changed in place under the shard write lock.
+	object object
+	// pos is the index of the entry in its scan bucket.
+	pos int
+	// version changes on every write to the key, see Version.
+	version uint64
+
+	// lastAccess and hits feed the LRU and LFU policies; they are updated
+	// under the shard read lock, hence atomic.
+	lastAccess atomic.Uint64
+	hits       atomic.Uint64
+}
+
+type Option func(*HashTable)
+
+// WithClock replaces time.Now as the source of the current time for expiry.
+func WithClock(now func() time.Time) Option {
+	return func(h *HashTable) {
+		if now != nil {
+			h.now = now
+		}
+	}
+}
+
+// WithMaxMemory limits the accounted size of the table. A non-positive limit
+// disables the limit.
+func WithMaxMemory(limit int64, policy EvictionPolicy) Option {
+	return func(h *HashTable) {
+		h.maxMemory = limit
+		h.policy = policy
+	}
 }
 
-func NewHashTable() *HashTable {
-	return &HashTable{
-		data: make(map[string]string),
+// WithListener calls fn with every change of the keyspace: keys written,
+// deleted, expired and evicted. fn runs under the lock of the key's shard,
+// so the events of one key arrive in order; it must not block or use the
+// table.
+func WithListener(fn func(kv.Event)) Option {
+	return func(h *HashTable) {
+		h.listener = fn
 	}
 }
 
+// WithCompression compresses the string values the compressor selects. The
+// fields, items and members of the other types are kept as is.
+func WithCompression(c compression.Compressor) Option {
+	return func(h *HashTable) {
+		h.compressor = c
+	}
+}
+
+func NewHashTable(shardCount int, opts ...Option) *HashTable {
+	if shardCount <= 0 {
+		shardCount = DefaultShardCount
+	}
+
+	shards := make([]*shard, shardCount)
+	for i := range shards {
+		shards[i] = &shard{
+			data:    make(map[string]*entry),
+			expires: make(map[string]time.Time),
+		}
+	}
+
+	h := &HashTable{
+		shards:     shards,
+		now:        time.Now,
+		policy:     PolicyNoEviction,
+		sampleSize: DefaultEvictionSampleSize,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *HashTable) ShardCount() int {
+	return len(h.shards)
+}
+
+// Len returns the number of stored keys, including expired keys that have
+// not been removed yet.
+func (h *HashTable) Len() int {
+	total := 0
+	for _, sh := range h.shards {
+		sh.mu.RLock()
+		total += len(sh.data)
+		sh.mu.RUnlock()
+	}
+	return total
+}
+
+// Set stores the value and clears any expiry the key had.
 func (h *HashTable) Set(key, value string) error {
-	h.data[key] = value
-	return nil
+	_, err := h.store(key, value, time.Time{}, kv.Condition{})
+	return err
+}
+
+// SetWithExpiry stores the value and makes it expire at expireAt.
+func (h *HashTable) SetWithExpiry(key, value string, expireAt time.Time) error {
+	_, err := h.store(key, value, expireAt, kv.Condition{})
+	return err
+}
+
+// SetIf stores the value, with an expiry unless expireAt is zero, when the
+// key satisfies cond; the check and the write are atomic. It returns the
+// previous state of the key either way and fails with
+// dberrors.ErrConditionFailed when nothing was written.
+func (h *HashTable) SetIf(key, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error) {
+	return h.store(key, value, expireAt, cond)
 }
 
 func (h *HashTable) Get(key string) (string, error) {
 	const op = "HashTable.Get"
 
-	result, ok := h.data[key]
-	if !ok {
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	e, ok := sh.data[key]
+	expired := ok && sh.expiredLocked(key, h.now())
+	if ok && !expired {
+		h.touch(e)
+	}
+	sh.mu.RUnlock()
+
+	if expired {
+		h.deleteIfExpired(sh, key)
+	}
+	if !ok || expired {
 		return "", fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
 	}
-	return result, nil
+	if e.object != nil {
+		return "", fmt.Errorf("%s: %w", op, dberrors.ErrWrongType)
+	}
+	return e.text(), nil
 }
 
 func (h *HashTable) Del(key string) error {
+	sh := h.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
 
-	delete(h.data, key)
+	ok := sh.liveLocked(key, h.now())
+	h.removeLocked(sh, key)
+	if ok {
+		h.notify(kv.EventDel, key)
+	}
 	return nil
 }
+
+// Expire sets the expiry deadline of an existing key.
+func (h *HashTable) Expire(key string, expireAt time.Time) error {
+	const op = "HashTable.Expire"
+
+	sh := h.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if !sh.liveLocked(key, h.now()) {
+		return fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	h.preserveLocked(sh, key)
+	sh.expires[key] = expireAt
+	sh.data[key].version = h.writeClock.Add(1)
+
+	return nil
+}
+
+// Persist removes the expiry of a key. It fails with dberrors.ErrNoExpiry
+// when the key exists but has no expiry.
+func (h *HashTable) Persist(key string) error {
+	const op = "HashTable.Persist"
+
+	sh := h.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if !sh.liveLocked(key, h.now()) {
+		return fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	if _, ok := sh.expires[key]; !ok {
+		return fmt.Errorf("%s: %w", op, dberrors.ErrNoExpiry)
+	}
+	h.preserveLocked(sh, key)
+	delete(sh.expires, key)
+	sh.data[key].version = h.writeClock.Add(1)
+
+	return nil
+}
+
+// Version returns a number that changes whenever the key is written, deleted,
+// expired or evicted; a missing key has version 0. Transactions compare
+// versions to detect that a watched key changed.
+func (h *HashTable) Version(key string) uint64 {
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if !sh.liveLocked(key, h.now()) {
+		return 0
+	}
+	return sh.data[key].version
+}
+
+// TTL returns the time left before the key expires. It fails with
+// dberrors.ErrNoExpiry when the key exists but has no expiry.
+func (h *HashTable) TTL(key string) (time.Duration, error) {
+	const op = "HashTable.TTL"
+
+	sh := h.shardFor(key)
+	now := h.now()
+
+	sh.mu.RLock()
+	live := sh.liveLocked(key, now)
+	expireAt, volatile := sh.expires[key]
+	sh.mu.RUnlock()
+
+	if !live {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	if !volatile {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNoExpiry)
+	}
+	return expireAt.Sub(now), nil
+}
+
+// DeleteExpired samples up to sampleSize keys with an expiry in every shard
+// and removes the expired ones, repeating a shard while a large share of its
+// sample turned out to be expired. It returns the number of removed keys.
+func (h *HashTable) DeleteExpired(sampleSize int) int {
+	removed := 0
+
+	for _, sh := range h.shards {
+		for {
+			sampled, expired := h.sweep(sh, sampleSize, h.now())
+			removed += expired
+			if sampled == 0 || expired*sweepRepeatRatio <= sampled {
+				break
+			}
+		}
+	}
+
+	return removed
+}
+
+func (h *HashTable) sweep(sh *shard, sampleSize int, now time.Time) (int, int) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sampled, expired := 0, 0
+	for key, expireAt := range sh.expires {
+		if sampled >= sampleSize {
+			break
+		}
+		sampled++
+
+		if !now.Before(expireAt) {
+			h.removeLocked(sh, key)
+			h.expiredKeys.Add(1)
+			h.notify(kv.EventExpired, key)
+			expired++
+		}
+	}
+
+	return sampled, expired
+}
+
+// store writes a value if cond holds, enforcing the memory limit, and returns
+// the previous state of the key. A zero expireAt stores the key without
+// expiry. The condition is checked before memory is reserved, so a write
+// that does not happen never evicts a key or fails for lack of memory, and
+// checked again once the shard is write-locked.
+func (h *HashTable) store(key, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error) {
+	const op = "HashTable.Set"
+
+	sh := h.shardFor(key)
+	e := h.newEntry(value)
+	newSize := entrySize(key, e.value)
+
+	sh.mu.RLock()
+	previous, err := h.checkLocked(sh, key, cond)
+	var oldSize int64
+	if old, ok := sh.data[key]; ok {
+		oldSize = old.size()
+	}
+	sh.mu.RUnlock()
+	if err != nil {
+		return previous, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := h.reserve(newSize-oldSize, func(k string) bool { return k == key }); err != nil {
+		return kv.Lookup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if previous, err = h.checkLocked(sh, key, cond); err != nil {
+		return previous, fmt.Errorf("%s: %w", op, err)
+	}
+
+	h.putLocked(sh, key, e)
+	if !expireAt.IsZero() {
+		sh.expires[key] = expireAt
+	}
+	h.notify(kv.EventSet, key)
+
+	return previous, nil
+}
+
+// checkLocked returns the state of key and dberrors.ErrConditionFailed when
+// cond does not hold for it. The caller holds the shard lock.
+func (h *HashTable) checkLocked(sh *shard, key string, cond kv.Condition) (kv.Lookup, error) {
+	var previous kv.Lookup
+	if sh.liveLocked(key, h.now()) {
+		old := sh.data[key]
+		if old.object != nil && cond.ReadsValue() {
+			return kv.Lookup{}, dberrors.ErrWrongType
+		}
+		previous = kv.Lookup{Value: old.text(), Found: true}
+	}
+	if !cond.Holds(previous) {
+		return previous, dberrors.ErrConditionFailed
+	}
+	return previous, nil
+}
+
+// removeLocked deletes a key and its expiry and releases its memory. The
+// caller holds the shard write lock.
+func (h *HashTable) removeLocked(sh *shard, key string) {
+	e, ok := sh.data[key]
+	if !ok {
+		return
+	}
+	h.preserveLocked(sh, key)
+	delete(sh.data, key)
+	delete(sh.expires, key)
+	h.usedMemory.Add(-e.size())
+	h.savedMemory.Add(-e.saved)
+
+	b := bucketIndex(key)
+	bucket := sh.buckets[b]
+	last := bucket[len(bucket)-1]
+	bucket[e.pos], last.pos = last, e.pos
+	bucket[len(bucket)-1] = nil
+	sh.buckets[b] = bucket[:len(bucket)-1]
+
+	if h.index != nil {
+		h.index.remove(key)
+	}
+}
+
+// putLocked stores a new entry for key, replacing the old one and its
+// expiry, and accounts its memory. The caller holds the shard write lock.
+func (h *HashTable) putLocked(sh *shard, key string, e *entry) {
+	h.preserveLocked(sh, key)
+	e.key = key
+	b := bucketIndex(key)
+
+	if old, ok := sh.data[key]; ok {
+		delete(sh.expires, key)
+		h.usedMemory.Add(-old.size())
+		h.savedMemory.Add(-old.saved)
+		e.pos = old.pos
+		sh.buckets[b][e.pos] = e
+	} else {
+		e.pos = len(sh.buckets[b])
+		sh.buckets[b] = append(sh.buckets[b], e)
+		if h.index != nil {
+			h.index.add(key)
+		}
+	}
+	sh.data[key] = e
+	h.usedMemory.Add(e.size())
+	h.savedMemory.Add(e.saved)
+}
+
+// newEntry returns an entry holding value, compressed when the compressor
+// selects it.
+func (h *HashTable) newEntry(value string) *entry {
+	codec, data := h.compressor.Compress(value)
+	e := &entry{
+		value:   data,
+		codec:   codec,
+		saved:   int64(len(value) - len(data)),
+		version: h.writeClock.Add(1),
+	}
+	h.touch(e)
+	return e
+}
+
+// text returns the string value of the entry. A value compressed by this
+// table always decompresses, anything else is a bug.
+func (e *entry) text() string {
+	value, err := compression.Decompress(e.codec, e.value)
+	if err != nil {
+		panic(fmt.Sprintf("hashtable: value of %q: %v", e.key, err))
+	}
+	return value
+}
+
+func (h *HashTable) notify(kind kv.EventType, key string) {
+	if h.listener != nil {
+		h.listener(kv.Event{Type: kind, Key: key})
+	}
+}
+
+func (h *HashTable) touch(e *entry) {
+	e.lastAccess.Store(h.accessClock.Add(1))
+	e.hits.Add(1)
+}
+
+func (h *HashTable) deleteIfExpired(sh *shard, key string) {
+	sh.mu.Lock()
+	if sh.expiredLocked(key, h.now()) {
+		h.removeLocked(sh, key)
+		h.expiredKeys.Add(1)
+		h.notify(kv.EventExpired, key)
+	}
+	sh.mu.Unlock()
+}
+
+func (sh *shard) liveLocked(key string, now time.Time) bool {
+	_, ok := sh.data[key]
+	return ok && !sh.expiredLocked(key, now)
+}
+
+func (sh *shard) expiredLocked(key string, now time.Time) bool {
+	expireAt, ok := sh.expires[key]
+	return ok && !now.Before(expireAt)
+}
+
+func (h *HashTable) shardFor(key string) *shard {
+	return h.shards[h.shardIndex(key)]
+}
+
+func (h *HashTable) shardIndex(key string) int {
+	return int(hashKey(key) % uint32(len(h.shards)))
+}
+
+// bucketIndex picks the scan bucket of a key within its shard from the high
+// bits of the hash, the low ones selecting the shard.
+func bucketIndex(key string) int {
+	return int(hashKey(key) >> (32 - scanBucketBits))
+}
+
+func hashKey(key string) uint32 {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(key))
+
+	return hasher.Sum32()
+}
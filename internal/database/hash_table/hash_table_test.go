@@ -0,0 +1,389 @@
+package hashtable_test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/lib/clock/fakeclock"
+)
+
+func TestHashTableShardCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		count int
+		want  int
+	}{
+		{name: "explicit", count: 4, want: 4},
+		{name: "single shard", count: 1, want: 1},
+		{name: "zero falls back to default", count: 0, want: hashtable.DefaultShardCount},
+		{name: "negative falls back to default", count: -3, want: hashtable.DefaultShardCount},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, hashtable.NewHashTable(tc.count).ShardCount())
+		})
+	}
+}
+
+func TestHashTableSetGetDel(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	_, err := h.Get("missing")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	require.NoError(t, h.Set("k", "v1"))
+	got, err := h.Get("k")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", got)
+
+	require.NoError(t, h.Set("k", "v2"))
+	got, err = h.Get("k")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", got)
+
+	require.NoError(t, h.Del("k"))
+	_, err = h.Get("k")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	// deleting a missing key is not an error
+	require.NoError(t, h.Del("k"))
+}
+
+func TestHashTableConcurrentDisjointKeys(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers = 32
+		perKeys = 500
+	)
+
+	h := hashtable.NewHashTable(8)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range perKeys {
+				key := strconv.Itoa(w) + "." + strconv.Itoa(i)
+				assert.NoError(t, h.Set(key, key))
+			}
+			for i := range perKeys {
+				key := strconv.Itoa(w) + "." + strconv.Itoa(i)
+				got, err := h.Get(key)
+				assert.NoError(t, err)
+				assert.Equal(t, key, got)
+			}
+			for i := 0; i < perKeys; i += 2 {
+				key := strconv.Itoa(w) + "." + strconv.Itoa(i)
+				assert.NoError(t, h.Del(key))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for w := range workers {
+		for i := range perKeys {
+			key := strconv.Itoa(w) + "." + strconv.Itoa(i)
+			_, err := h.Get(key)
+			if i%2 == 0 {
+				require.ErrorIs(t, err, dberrors.ErrNotFound)
+			} else {
+				require.NoError(t, err)
+			}
+		}
+	}
+}
+
+func TestHashTableConcurrentSharedKeys(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers    = 16
+		iterations = 2000
+		keyCount   = 8
+	)
+
+	h := hashtable.NewHashTable(2)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range iterations {
+				key := "key" + strconv.Itoa(i%keyCount)
+				switch (w + i) % 3 {
+				case 0:
+					_ = h.Set(key, strconv.Itoa(w))
+				case 1:
+					got, err := h.Get(key)
+					if err == nil {
+						_, convErr := strconv.Atoi(got)
+						assert.NoError(t, convErr)
+					}
+				default:
+					_ = h.Del(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHashTableExpiry(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now))
+
+	require.NoError(t, h.SetWithExpiry("session", "token", clock.Now().Add(10*time.Second)))
+	require.NoError(t, h.Set("plain", "value"))
+
+	ttl, err := h.TTL("session")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, ttl)
+
+	_, err = h.TTL("plain")
+	require.ErrorIs(t, err, dberrors.ErrNoExpiry)
+	_, err = h.TTL("missing")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	clock.Advance(9 * time.Second)
+	got, err := h.Get("session")
+	require.NoError(t, err)
+	assert.Equal(t, "token", got)
+
+	clock.Advance(time.Second)
+	_, err = h.Get("session")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	assert.Equal(t, 1, h.Len(), "expired key must be removed lazily on access")
+}
+
+func TestHashTableExpireAndPersist(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now))
+
+	require.ErrorIs(t, h.Expire("missing", clock.Now().Add(time.Second)), dberrors.ErrNotFound)
+	require.ErrorIs(t, h.Persist("missing"), dberrors.ErrNotFound)
+
+	require.NoError(t, h.Set("k", "v"))
+	require.ErrorIs(t, h.Persist("k"), dberrors.ErrNoExpiry)
+
+	require.NoError(t, h.Expire("k", clock.Now().Add(5*time.Second)))
+	require.NoError(t, h.Persist("k"))
+	clock.Advance(time.Minute)
+
+	_, err := h.Get("k")
+	require.NoError(t, err, "persisted key must not expire")
+
+	require.NoError(t, h.Expire("k", clock.Now().Add(time.Second)))
+	require.NoError(t, h.Set("k", "v2"))
+	clock.Advance(time.Minute)
+	_, err = h.Get("k")
+	require.NoError(t, err, "plain SET must clear the expiry")
+
+	require.NoError(t, h.SetWithExpiry("gone", "v", clock.Now()))
+	require.NoError(t, h.Del("gone"))
+	require.ErrorIs(t, h.Expire("gone", clock.Now().Add(time.Hour)), dberrors.ErrNotFound)
+}
+
+func TestHashTableDeleteExpired(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now))
+
+	for i := range 100 {
+		key := "volatile" + strconv.Itoa(i)
+		require.NoError(t, h.SetWithExpiry(key, "v", clock.Now().Add(time.Duration(i%2+1)*time.Second)))
+		require.NoError(t, h.Set("plain"+strconv.Itoa(i), "v"))
+	}
+
+	assert.Zero(t, h.DeleteExpired(20))
+
+	clock.Advance(time.Second)
+	removed := 0
+	for range 20 {
+		removed += h.DeleteExpired(20)
+	}
+	assert.Equal(t, 50, removed)
+	assert.Equal(t, 150, h.Len())
+
+	clock.Advance(time.Second)
+	for h.DeleteExpired(20) > 0 {
+	}
+	assert.Equal(t, 100, h.Len())
+}
+
+func TestHashTableVersion(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	h := hashtable.NewHashTable(4, hashtable.WithClock(func() time.Time { return now }))
+
+	assert.Zero(t, h.Version("k"))
+
+	require.NoError(t, h.Set("k", "v"))
+	v1 := h.Version("k")
+	assert.NotZero(t, v1)
+	assert.Equal(t, v1, h.Version("k"), "reads do not change the version")
+
+	_, err := h.Get("k")
+	require.NoError(t, err)
+	assert.Equal(t, v1, h.Version("k"))
+
+	require.NoError(t, h.Set("k", "v"))
+	v2 := h.Version("k")
+	assert.NotEqual(t, v1, v2, "rewriting the same value is a change")
+
+	require.NoError(t, h.Expire("k", now.Add(time.Minute)))
+	v3 := h.Version("k")
+	assert.NotEqual(t, v2, v3)
+
+	require.NoError(t, h.Persist("k"))
+	assert.NotEqual(t, v3, h.Version("k"))
+
+	require.NoError(t, h.SetWithExpiry("k", "v", now.Add(-time.Second)))
+	assert.Zero(t, h.Version("k"), "expired keys are missing")
+}
+
+func TestHashTableSetIf(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	previous, err := h.SetIf("k", "v1", time.Time{}, kv.Condition{Kind: kv.IfPresent})
+	require.ErrorIs(t, err, dberrors.ErrConditionFailed)
+	assert.Equal(t, kv.Lookup{}, previous)
+
+	previous, err = h.SetIf("k", "v1", time.Time{}, kv.Condition{Kind: kv.IfAbsent})
+	require.NoError(t, err)
+	assert.Equal(t, kv.Lookup{}, previous)
+
+	previous, err = h.SetIf("k", "v2", time.Time{}, kv.Condition{Kind: kv.IfAbsent})
+	require.ErrorIs(t, err, dberrors.ErrConditionFailed)
+	assert.Equal(t, kv.Lookup{Value: "v1", Found: true}, previous)
+
+	_, err = h.SetIf("k", "v2", time.Time{}, kv.Condition{Kind: kv.IfEquals, Expected: "other"})
+	require.ErrorIs(t, err, dberrors.ErrConditionFailed)
+
+	previous, err = h.SetIf("k", "v2", time.Now().Add(time.Hour), kv.Condition{Kind: kv.IfEquals, Expected: "v1"})
+	require.NoError(t, err)
+	assert.Equal(t, kv.Lookup{Value: "v1", Found: true}, previous)
+
+	ttl, err := h.TTL("k")
+	require.NoError(t, err)
+	assert.Positive(t, ttl)
+
+	previous, err = h.SetIf("k", "v3", time.Time{}, kv.Condition{Kind: kv.Always})
+	require.NoError(t, err)
+	assert.Equal(t, kv.Lookup{Value: "v2", Found: true}, previous)
+
+	_, err = h.TTL("k")
+	require.ErrorIs(t, err, dberrors.ErrNoExpiry)
+	assert.Equal(t, entryCost(1, 2), h.Stats().UsedMemory)
+}
+
+// TestHashTableSetIfAbsentRace checks that exactly one of many concurrent
+// SET NX calls wins, which is what a lock relies on.
+func TestHashTableSetIfAbsentRace(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	var (
+		wg   sync.WaitGroup
+		wins atomic.Int64
+	)
+	for i := range 32 {
+		wg.Go(func() {
+			_, err := h.SetIf("lock", strconv.Itoa(i), time.Time{}, kv.Condition{Kind: kv.IfAbsent})
+			if err == nil {
+				wins.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), wins.Load())
+}
+
+func TestHashTableListener(t *testing.T) {
+	t.Parallel()
+
+	var events []kv.Event
+	record := func(event kv.Event) { events = append(events, event) }
+	take := func() []kv.Event {
+		taken := events
+		events = nil
+		return taken
+	}
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now), hashtable.WithListener(record))
+
+	require.NoError(t, h.Set("a", "1"))
+	// a key repeated in MSet is stored once
+	require.NoError(t, h.MSet([]kv.Pair{{Key: "b", Value: "1"}, {Key: "b", Value: "2"}}))
+	_, _, err := h.IncrBy("a", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Event{{Type: kv.EventSet, Key: "a"}, {Type: kv.EventSet, Key: "b"}, {Type: kv.EventSet, Key: "a"}}, take())
+
+	require.NoError(t, h.Del("a"))
+	require.NoError(t, h.Del("a"))
+	assert.Equal(t, 1, h.MDel([]string{"b", "missing"}))
+	assert.Equal(t, []kv.Event{{Type: kv.EventDel, Key: "a"}, {Type: kv.EventDel, Key: "b"}}, take())
+
+	// a container is set by every change and deleted with its last member
+	_, err = h.SAdd("s", []string{"x", "y"})
+	require.NoError(t, err)
+	_, err = h.SRem("s", []string{"x"})
+	require.NoError(t, err)
+	_, err = h.SRem("s", []string{"missing"})
+	require.NoError(t, err)
+	_, err = h.SRem("s", []string{"y"})
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Event{{Type: kv.EventSet, Key: "s"}, {Type: kv.EventSet, Key: "s"}, {Type: kv.EventDel, Key: "s"}}, take())
+
+	// expired keys are reported when they are removed, on access or by a sweep
+	require.NoError(t, h.SetWithExpiry("read", "v", clock.Now().Add(time.Second)))
+	require.NoError(t, h.SetWithExpiry("swept", "v", clock.Now().Add(time.Second)))
+	take()
+	clock.Advance(time.Second)
+	_, err = h.Get("read")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	assert.Equal(t, []kv.Event{{Type: kv.EventExpired, Key: "read"}}, take())
+	assert.Equal(t, 1, h.DeleteExpired(10))
+	assert.Equal(t, []kv.Event{{Type: kv.EventExpired, Key: "swept"}}, take())
+
+	limited := hashtable.NewHashTable(1,
+		hashtable.WithMaxMemory(2*entryCost(1, 1), hashtable.PolicyAllKeysLRU), hashtable.WithListener(record))
+	require.NoError(t, limited.Set("a", "1"))
+	require.NoError(t, limited.Set("b", "2"))
+	require.NoError(t, limited.Set("c", "3"))
+	assert.Equal(t, []kv.Event{
+		{Type: kv.EventSet, Key: "a"}, {Type: kv.EventSet, Key: "b"},
+		{Type: kv.EventEvicted, Key: "a"}, {Type: kv.EventSet, Key: "c"},
+	}, take())
+}
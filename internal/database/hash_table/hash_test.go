@@ -0,0 +1,199 @@
+package hashtable_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/lib/clock/fakeclock"
+)
+
+func TestHashTableHash(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	added, err := h.HSet("user", []kv.Pair{{Key: "name", Value: "ann"}, {Key: "age", Value: "30"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	// updating a field does not count as adding it, the last value wins
+	added, err = h.HSet("user", []kv.Pair{{Key: "age", Value: "31"}, {Key: "city", Value: "x"}, {Key: "city", Value: "y"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	value, err := h.HGet("user", "age")
+	require.NoError(t, err)
+	assert.Equal(t, "31", value)
+
+	_, err = h.HGet("user", "missing")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	_, err = h.HGet("missing", "age")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	all, err := h.HGetAll("user")
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Pair{{Key: "age", Value: "31"}, {Key: "city", Value: "y"}, {Key: "name", Value: "ann"}}, all)
+
+	n, err := h.HLen("user")
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	ok, err := h.HExists("user", "city")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	deleted, err := h.HDel("user", []string{"city", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	ok, err = h.HExists("user", "city")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// the key goes away with its last field
+	deleted, err = h.HDel("user", []string{"age", "name"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, 0, h.Len())
+
+	all, err = h.HGetAll("user")
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	n, err = h.HLen("user")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestHashTableWrongType(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	require.NoError(t, h.Set("str", "1"))
+	_, err := h.HSet("hash", []kv.Pair{{Key: "f", Value: "v"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "get hash", call: func() error { _, err := h.Get("hash"); return err }},
+		{name: "incr hash", call: func() error { _, _, err := h.IncrBy("hash", 1); return err }},
+		{name: "incrbyfloat hash", call: func() error { _, _, err := h.IncrByFloat("hash", 1); return err }},
+		{name: "cas hash", call: func() error {
+			_, err := h.SetIf("hash", "v", time.Time{}, kv.Condition{Kind: kv.IfEquals, Expected: "v"})
+			return err
+		}},
+		{name: "swap hash", call: func() error {
+			_, err := h.SetIf("hash", "v", time.Time{}, kv.Condition{Kind: kv.Swap})
+			return err
+		}},
+		{name: "hset string", call: func() error { _, err := h.HSet("str", []kv.Pair{{Key: "f", Value: "v"}}); return err }},
+		{name: "hget string", call: func() error { _, err := h.HGet("str", "f"); return err }},
+		{name: "hdel string", call: func() error { _, err := h.HDel("str", []string{"f"}); return err }},
+		{name: "hgetall string", call: func() error { _, err := h.HGetAll("str"); return err }},
+		{name: "hlen string", call: func() error { _, err := h.HLen("str"); return err }},
+		{name: "hexists string", call: func() error { _, err := h.HExists("str", "f"); return err }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, tc.call(), dberrors.ErrWrongType)
+		})
+	}
+
+	// MGET reports a hash as missing, SET and DEL replace and remove any type
+	assert.Equal(t, []kv.Lookup{{}}, h.MGet([]string{"hash"}))
+	require.NoError(t, h.Set("hash", "now a string"))
+	value, err := h.Get("hash")
+	require.NoError(t, err)
+	assert.Equal(t, "now a string", value)
+
+	_, err = h.HSet("other", []kv.Pair{{Key: "f", Value: "v"}})
+	require.NoError(t, err)
+	require.NoError(t, h.Del("other"))
+	assert.Equal(t, 2, h.Len())
+}
+
+func TestHashTableHashExpiry(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now))
+
+	_, err := h.HSet("h", []kv.Pair{{Key: "f", Value: "v"}})
+	require.NoError(t, err)
+	require.NoError(t, h.Expire("h", clock.Now().Add(time.Minute)))
+
+	// writing fields keeps the deadline
+	_, err = h.HSet("h", []kv.Pair{{Key: "g", Value: "w"}})
+	require.NoError(t, err)
+	ttl, err := h.TTL("h")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	clock.Advance(time.Minute)
+	_, err = h.HGet("h", "f")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	// an expired hash is replaced by a fresh one without a deadline
+	added, err := h.HSet("h", []kv.Pair{{Key: "x", Value: "y"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	n, err := h.HLen("h")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = h.TTL("h")
+	require.ErrorIs(t, err, dberrors.ErrNoExpiry)
+}
+
+func TestHashTableHashMemory(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	_, err := h.HSet("h", []kv.Pair{{Key: "ab", Value: "cde"}, {Key: "f", Value: "g"}})
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+fieldCost(2, 3)+fieldCost(1, 1), h.Stats().UsedMemory)
+
+	_, err = h.HSet("h", []kv.Pair{{Key: "ab", Value: "c"}})
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+fieldCost(2, 1)+fieldCost(1, 1), h.Stats().UsedMemory)
+
+	_, err = h.HDel("h", []string{"ab"})
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+fieldCost(1, 1), h.Stats().UsedMemory)
+
+	_, err = h.HDel("h", []string{"f"})
+	require.NoError(t, err)
+	assert.Zero(t, h.Stats().UsedMemory)
+}
+
+func TestHashTableHashMemoryLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := entryCost(1, 0) + fieldCost(1, 4)
+	h := hashtable.NewHashTable(4, hashtable.WithMaxMemory(limit, hashtable.PolicyNoEviction))
+
+	_, err := h.HSet("h", []kv.Pair{{Key: "f", Value: "1234"}})
+	require.NoError(t, err)
+
+	_, err = h.HSet("h", []kv.Pair{{Key: "g", Value: "1"}})
+	require.ErrorIs(t, err, dberrors.ErrOutOfMemory)
+
+	n, err := h.HLen("h")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+// fieldCost mirrors the accounting of a hash field of fieldLen and a value
+// of valueLen.
+func fieldCost(fieldLen, valueLen int) int64 {
+	return int64(fieldLen + valueLen + 32)
+}
@@ -0,0 +1,251 @@
+package hashtable
+
+import (
+	"fmt"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+// itemOverhead approximates the per-item cost of a list on top of the item
+// bytes.
+const itemOverhead = 16
+
+// listObject is the value of a list key. It is a deque made of two stacks:
+// front holds the head of the list in reverse order and back holds the rest,
+// so that both ends are pushed and popped in amortized constant time.
+type listObject struct {
+	front []string
+	back  []string
+	bytes int64
+}
+
+func (o *listObject) size() int64 {
+	return o.bytes
+}
+
+func (o *listObject) dump(item *kv.Item) {
+	item.Items = make([]string, 0, o.len())
+	for i := range o.len() {
+		item.Items = append(item.Items, o.at(i))
+	}
+}
+
+func (o *listObject) len() int {
+	return len(o.front) + len(o.back)
+}
+
+func (o *listObject) at(i int) string {
+	if i < len(o.front) {
+		return o.front[len(o.front)-1-i]
+	}
+	return o.back[i-len(o.front)]
+}
+
+func (o *listObject) push(end kv.End, value string) {
+	if end == kv.Left {
+		o.front = append(o.front, value)
+	} else {
+		o.back = append(o.back, value)
+	}
+	o.bytes += itemSize(value)
+}
+
+// pop removes an item from a non-empty list.
+func (o *listObject) pop(end kv.End) string {
+	var value string
+	switch {
+	case end == kv.Left && len(o.front) > 0:
+		value, o.front = popLast(o.front)
+	case end == kv.Left:
+		value, o.back = popFirst(o.back)
+	case len(o.back) > 0:
+		value, o.back = popLast(o.back)
+	default:
+		value, o.front = popFirst(o.front)
+	}
+	o.bytes -= itemSize(value)
+	return value
+}
+
+func popLast(items []string) (string, []string) {
+	last := len(items) - 1
+	value := items[last]
+	items[last] = ""
+	return value, items[:last]
+}
+
+func popFirst(items []string) (string, []string) {
+	value := items[0]
+	items[0] = ""
+	return value, items[1:]
+}
+
+func itemSize(value string) int64 {
+	return int64(len(value) + itemOverhead)
+}
+
+// Push adds values one by one to an end of the list stored at key, creating
+// it when the key does not exist, and returns the length of the list. Values
+// pushed to the left end therefore end up in reverse order, like LPUSH. The
+// expiry of the key is kept.
+func (h *HashTable) Push(key string, values []string, end kv.End) (int, error) {
+	const op = "HashTable.Push"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	list, err := h.listLocked(sh, key)
+	sh.mu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var growth int64
+	if list == nil {
+		growth = entrySize(key, "")
+	}
+	for _, value := range values {
+		growth += itemSize(value)
+	}
+	if err := h.reserve(growth, func(k string) bool { return k == key }); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	list, err = h.listLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if list == nil {
+		list = &listObject{}
+		e := h.newEntry("")
+		e.object = list
+		h.putLocked(sh, key, e)
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+	for _, value := range values {
+		list.push(end, value)
+	}
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	h.touch(e)
+	h.notify(kv.EventSet, key)
+
+	return list.len(), nil
+}
+
+// Pop removes and returns the item at an end of the list stored at key. It
+// fails with dberrors.ErrNotFound when the key does not exist. A list left
+// without items is deleted.
+func (h *HashTable) Pop(key string, end kv.End) (string, error) {
+	const op = "HashTable.Pop"
+
+	sh := h.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	list, err := h.listLocked(sh, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if list == nil {
+		return "", fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+	value := list.pop(end)
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	if list.len() == 0 {
+		h.removeLocked(sh, key)
+		h.notify(kv.EventDel, key)
+	} else {
+		h.notify(kv.EventSet, key)
+	}
+
+	return value, nil
+}
+
+// LRange returns the items of the list stored at key from start to stop
+// inclusive. Negative indexes count from the tail, -1 being the last item,
+// and out of range indexes are clamped. A missing key has no items.
+func (h *HashTable) LRange(key string, start, stop int) ([]string, error) {
+	const op = "HashTable.LRange"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	list, err := h.listLocked(sh, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if list == nil {
+		return nil, nil
+	}
+	h.touch(sh.data[key])
+
+	n := list.len()
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return nil, nil
+	}
+
+	items := make([]string, 0, stop-start+1)
+	for i := start; i <= stop; i++ {
+		items = append(items, list.at(i))
+	}
+	return items, nil
+}
+
+// LLen returns the length of the list stored at key, 0 when the key does not
+// exist.
+func (h *HashTable) LLen(key string) (int, error) {
+	const op = "HashTable.LLen"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	list, err := h.listLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if list == nil {
+		return 0, nil
+	}
+	return list.len(), nil
+}
+
+// listLocked returns the list stored at key, nil when the key does not exist
+// or has expired, and dberrors.ErrWrongType when it holds another type. The
+// caller holds the shard lock.
+func (h *HashTable) listLocked(sh *shard, key string) (*listObject, error) {
+	if !sh.liveLocked(key, h.now()) {
+		return nil, nil
+	}
+	list, ok := sh.data[key].object.(*listObject)
+	if !ok {
+		return nil, dberrors.ErrWrongType
+	}
+	return list, nil
+}
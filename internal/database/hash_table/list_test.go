@@ -0,0 +1,162 @@
+package hashtable_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+)
+
+func TestHashTableList(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	n, err := h.Push("q", []string{"b", "a"}, kv.Left)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = h.Push("q", []string{"c", "d"}, kv.Right)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	items, err := h.LRange("q", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, items)
+
+	n, err = h.LLen("q")
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	// popping an end drains the other end's items once its own run out
+	for _, want := range []string{"d", "c", "b"} {
+		value, err := h.Pop("q", kv.Right)
+		require.NoError(t, err)
+		assert.Equal(t, want, value)
+	}
+	value, err := h.Pop("q", kv.Left)
+	require.NoError(t, err)
+	assert.Equal(t, "a", value)
+
+	// the key goes away with its last item
+	assert.Equal(t, 0, h.Len())
+	_, err = h.Pop("q", kv.Left)
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	n, err = h.LLen("q")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestHashTableListQueue(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	// a queue fed at the tail and drained at the head keeps its order
+	next := 0
+	for round := range 10 {
+		for i := range round {
+			_, err := h.Push("q", []string{string(rune('a' + (next+i)%26))}, kv.Right)
+			require.NoError(t, err)
+		}
+		for i := range round {
+			value, err := h.Pop("q", kv.Left)
+			require.NoError(t, err)
+			assert.Equal(t, string(rune('a'+(next+i)%26)), value)
+		}
+		next += round
+	}
+	assert.Equal(t, 0, h.Len())
+	assert.Zero(t, h.Stats().UsedMemory)
+}
+
+func TestHashTableLRange(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	_, err := h.Push("l", []string{"a", "b", "c", "d", "e"}, kv.Right)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		start, stop int
+		want        []string
+	}{
+		{name: "all", start: 0, stop: -1, want: []string{"a", "b", "c", "d", "e"}},
+		{name: "middle", start: 1, stop: 3, want: []string{"b", "c", "d"}},
+		{name: "negative", start: -2, stop: -1, want: []string{"d", "e"}},
+		{name: "clamped", start: -100, stop: 100, want: []string{"a", "b", "c", "d", "e"}},
+		{name: "single", start: 2, stop: 2, want: []string{"c"}},
+		{name: "start after stop", start: 3, stop: 1},
+		{name: "start past the end", start: 5, stop: 10},
+		{name: "stop before the head", start: 0, stop: -6},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			items, err := h.LRange("l", tc.start, tc.stop)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, items)
+		})
+	}
+
+	items, err := h.LRange("missing", 0, -1)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestHashTableListWrongType(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	require.NoError(t, h.Set("str", "v"))
+	_, err := h.Push("list", []string{"v"}, kv.Left)
+	require.NoError(t, err)
+
+	_, err = h.Push("str", []string{"v"}, kv.Left)
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.Pop("str", kv.Right)
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.LRange("str", 0, -1)
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.LLen("str")
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+
+	_, err = h.Get("list")
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.HSet("list", []kv.Pair{{Key: "f", Value: "v"}})
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+}
+
+func TestHashTableListMemory(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	_, err := h.Push("l", []string{"abc", "d"}, kv.Right)
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+itemCost(3)+itemCost(1), h.Stats().UsedMemory)
+
+	_, err = h.Pop("l", kv.Left)
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+itemCost(1), h.Stats().UsedMemory)
+
+	limit := entryCost(1, 0) + itemCost(4)
+	limited := hashtable.NewHashTable(4, hashtable.WithMaxMemory(limit, hashtable.PolicyNoEviction))
+	_, err = limited.Push("l", []string{"1234"}, kv.Right)
+	require.NoError(t, err)
+	_, err = limited.Push("l", []string{"5"}, kv.Right)
+	require.ErrorIs(t, err, dberrors.ErrOutOfMemory)
+}
+
+// itemCost mirrors the accounting of a list item of valueLen.
+func itemCost(valueLen int) int64 {
+	return int64(valueLen + 16)
+}
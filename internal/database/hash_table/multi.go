@@ -0,0 +1,142 @@
+package hashtable
+
+import (
+	"fmt"
+	"slices"
+
+	"lesson1/internal/database/kv"
+)
+
+// MSet stores all pairs atomically and clears their expiry: the shards of
+// every key are write-locked together, so MGet never observes a partially
+// applied batch. When a key repeats, its last value wins.
+func (h *HashTable) MSet(pairs []kv.Pair) error {
+	const op = "HashTable.MSet"
+
+	// keys holds every key once, in the order of its first pair
+	final := make(map[string]*entry, len(pairs))
+	keys := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		if _, ok := final[pair.Key]; !ok {
+			keys = append(keys, pair.Key)
+		}
+		final[pair.Key] = h.newEntry(pair.Value)
+	}
+
+	if err := h.reserve(h.batchGrowth(final), func(key string) bool {
+		_, ok := final[key]
+		return ok
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	shards := h.lockShards(keys, false)
+	defer unlockShards(shards, false)
+
+	for _, key := range keys {
+		h.putLocked(h.shardFor(key), key, final[key])
+		h.notify(kv.EventSet, key)
+	}
+
+	return nil
+}
+
+// MGet reads all keys from one consistent snapshot. Results are in the order
+// of keys; missing and expired keys are reported as not found.
+func (h *HashTable) MGet(keys []string) []kv.Lookup {
+	results := make([]kv.Lookup, len(keys))
+	var expired []string
+
+	shards := h.lockShards(keys, true)
+	now := h.now()
+	for i, key := range keys {
+		sh := h.shardFor(key)
+
+		e, ok := sh.data[key]
+		if ok && sh.expiredLocked(key, now) {
+			expired = append(expired, key)
+			continue
+		}
+		// like a missing key, a key of another type has no string to return
+		if ok && e.object == nil {
+			h.touch(e)
+			results[i] = kv.Lookup{Value: e.text(), Found: true}
+		}
+	}
+	unlockShards(shards, true)
+
+	for _, key := range expired {
+		h.deleteIfExpired(h.shardFor(key), key)
+	}
+	return results
+}
+
+// MDel deletes all keys atomically and returns how many of them existed.
+func (h *HashTable) MDel(keys []string) int {
+	shards := h.lockShards(keys, false)
+	defer unlockShards(shards, false)
+
+	now := h.now()
+	deleted := 0
+	for _, key := range keys {
+		sh := h.shardFor(key)
+		live := sh.liveLocked(key, now)
+		h.removeLocked(sh, key)
+		if live {
+			h.notify(kv.EventDel, key)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// batchGrowth estimates how much the accounted memory grows when the keys of
+// a batch take their new entries.
+func (h *HashTable) batchGrowth(entries map[string]*entry) int64 {
+	var growth int64
+	for key, e := range entries {
+		growth += entrySize(key, e.value)
+
+		sh := h.shardFor(key)
+		sh.mu.RLock()
+		if old, ok := sh.data[key]; ok {
+			growth -= old.size()
+		}
+		sh.mu.RUnlock()
+	}
+	return growth
+}
+
+// lockShards locks the distinct shards of the given keys in shard order, so
+// that concurrent batches cannot deadlock, and returns them for unlocking.
+func (h *HashTable) lockShards(keys []string, read bool) []*shard {
+	indexes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		indexes = append(indexes, h.shardIndex(key))
+	}
+	slices.Sort(indexes)
+	indexes = slices.Compact(indexes)
+
+	shards := make([]*shard, 0, len(indexes))
+	for _, i := range indexes {
+		sh := h.shards[i]
+		if read {
+			sh.mu.RLock()
+		} else {
+			sh.mu.Lock()
+		}
+		shards = append(shards, sh)
+	}
+	return shards
+}
+
+func unlockShards(shards []*shard, read bool) {
+	for i := len(shards) - 1; i >= 0; i-- {
+		if read {
+			shards[i].mu.RUnlock()
+		} else {
+			shards[i].mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package hashtable_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+)
+
+func TestHashTableMSetMGetMDel(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	require.NoError(t, h.SetWithExpiry("a", "old", time.Now().Add(time.Hour)))
+
+	require.NoError(t, h.MSet([]kv.Pair{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "a", Value: "3"}}))
+
+	assert.Equal(t, []kv.Lookup{
+		{Value: "3", Found: true},
+		{},
+		{Value: "2", Found: true},
+	}, h.MGet([]string{"a", "missing", "b"}))
+
+	_, err := h.TTL("a")
+	require.ErrorIs(t, err, dberrors.ErrNoExpiry, "MSET clears the expiry")
+
+	assert.Equal(t, 2, h.MDel([]string{"a", "b", "missing", "a"}))
+	assert.Zero(t, h.Len())
+	assert.Zero(t, h.Stats().UsedMemory)
+}
+
+func TestHashTableMGetExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	h := hashtable.NewHashTable(4, hashtable.WithClock(func() time.Time { return now }))
+
+	require.NoError(t, h.SetWithExpiry("gone", "v", now.Add(-time.Second)))
+	require.NoError(t, h.Set("kept", "v"))
+
+	assert.Equal(t, []kv.Lookup{{}, {Value: "v", Found: true}}, h.MGet([]string{"gone", "kept"}))
+	assert.Equal(t, 1, h.Len(), "expired key removed on access")
+	assert.Equal(t, 0, h.MDel([]string{"gone"}))
+}
+
+func TestHashTableMSetOutOfMemory(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4, hashtable.WithMaxMemory(entryCost(2, 2)*3, hashtable.PolicyNoEviction))
+	require.NoError(t, h.Set("k0", "v0"))
+
+	err := h.MSet([]kv.Pair{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}, {Key: "k3", Value: "v3"}})
+	require.ErrorIs(t, err, dberrors.ErrOutOfMemory)
+	assert.Equal(t, 1, h.Len(), "a rejected batch writes nothing")
+
+	require.NoError(t, h.MSet([]kv.Pair{{Key: "k0", Value: "v9"}, {Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}}))
+	assert.Equal(t, entryCost(2, 2)*3, h.Stats().UsedMemory)
+}
+
+func TestHashTableMSetEvictsOutsideBatch(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4, hashtable.WithMaxMemory(entryCost(2, 2)*2, hashtable.PolicyAllKeysLRU))
+	require.NoError(t, h.Set("k0", "v0"))
+	require.NoError(t, h.Set("k1", "v1"))
+
+	require.NoError(t, h.MSet([]kv.Pair{{Key: "k1", Value: "v9"}, {Key: "k2", Value: "v2"}}))
+	assert.Equal(t, []kv.Lookup{{}, {Value: "v9", Found: true}, {Value: "v2", Found: true}}, h.MGet([]string{"k0", "k1", "k2"}))
+}
+
+// TestHashTableMSetAtomic checks that MGet never observes a batch written
+// only in part, although the keys live in different shards.
+func TestHashTableMSetAtomic(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(16)
+
+	keys := make([]string, 8)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	pairs := func(value string) []kv.Pair {
+		result := make([]kv.Pair, len(keys))
+		for i, key := range keys {
+			result[i] = kv.Pair{Key: key, Value: value}
+		}
+		return result
+	}
+	require.NoError(t, h.MSet(pairs("0")))
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Go(func() {
+			for i := range 500 {
+				assert.NoError(t, h.MSet(pairs(strconv.Itoa(w*1000+i))))
+			}
+		})
+	}
+	for range 4 {
+		wg.Go(func() {
+			for range 500 {
+				results := h.MGet(keys)
+				for _, result := range results {
+					if !assert.Equal(t, results[0], result) {
+						return
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
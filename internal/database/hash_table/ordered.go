@@ -0,0 +1,134 @@
+package hashtable
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/skiplist"
+)
+
+// rangeBatchSize is how many keys a range query copies out of the index per
+// index lock acquisition.
+const rangeBatchSize = 256
+
+// orderedIndex keeps every stored key in lexicographic order. It is updated
+// under the shard lock of the key, so its own lock is always taken last.
+type orderedIndex struct {
+	mu   sync.RWMutex
+	keys *skiplist.List[string]
+}
+
+// WithOrderedIndex keeps the keys in a skip list next to the shards, which
+// enables Range and CountPrefix at the cost of one more lock on every insert
+// and delete.
+func WithOrderedIndex() Option {
+	return func(h *HashTable) {
+		h.index = &orderedIndex{keys: skiplist.New(strings.Compare)}
+	}
+}
+
+func (ix *orderedIndex) add(key string) {
+	ix.mu.Lock()
+	ix.keys.Insert(key)
+	ix.mu.Unlock()
+}
+
+func (ix *orderedIndex) remove(key string) {
+	ix.mu.Lock()
+	ix.keys.Delete(key)
+	ix.mu.Unlock()
+}
+
+// collect copies up to n keys in [from, end) out of the index; an empty end
+// is no upper bound.
+func (ix *orderedIndex) collect(from, end string, n int) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	keys := make([]string, 0, n)
+	for key := range ix.keys.From(from) {
+		if (end != "" && key >= end) || len(keys) == n {
+			break
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Range returns, in lexicographic order, up to limit live keys in
+// [start, end); an empty end is no upper bound and a non-positive limit is no
+// limit. next is the key to start the following page from, empty when the
+// range is exhausted. Keys written during the call may or may not be
+// returned. It fails with dberrors.ErrNotOrdered without WithOrderedIndex.
+func (h *HashTable) Range(start, end string, limit int) ([]string, string, error) {
+	const op = "HashTable.Range"
+
+	if h.index == nil {
+		return nil, "", fmt.Errorf("%s: %w", op, dberrors.ErrNotOrdered)
+	}
+
+	var (
+		keys []string
+		next string
+	)
+	h.ascend(start, end, func(key string) bool {
+		if limit > 0 && len(keys) == limit {
+			next = key
+			return false
+		}
+		keys = append(keys, key)
+		return true
+	})
+
+	return keys, next, nil
+}
+
+// CountPrefix returns the number of live keys that start with prefix. It
+// fails with dberrors.ErrNotOrdered without WithOrderedIndex.
+func (h *HashTable) CountPrefix(prefix string) (int, error) {
+	const op = "HashTable.CountPrefix"
+
+	if h.index == nil {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotOrdered)
+	}
+
+	count := 0
+	h.ascend(prefix, kv.PrefixEnd(prefix), func(string) bool {
+		count++
+		return true
+	})
+
+	return count, nil
+}
+
+// ascend calls fn for the live keys in [start, end) in order until it
+// returns false. The index is read in batches and never locked together
+// with a shard, so writers are only held up for one batch copy.
+func (h *HashTable) ascend(start, end string, fn func(key string) bool) {
+	from := start
+	for {
+		batch := h.index.collect(from, end, rangeBatchSize)
+
+		now := h.now()
+		for _, key := range batch {
+			sh := h.shardFor(key)
+
+			sh.mu.RLock()
+			live := sh.liveLocked(key, now)
+			sh.mu.RUnlock()
+
+			if live && !fn(key) {
+				return
+			}
+		}
+
+		if len(batch) < rangeBatchSize {
+			return
+		}
+		// the smallest string sorting after the last key of the batch
+		from = batch[len(batch)-1] + "\x00"
+	}
+}
@@ -0,0 +1,190 @@
+package hashtable_test
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/lib/clock/fakeclock"
+)
+
+func TestHashTableRange(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4, hashtable.WithOrderedIndex())
+	for _, key := range []string{"user:3", "user:1", "order:1", "user:2", "zebra", "user:10"} {
+		require.NoError(t, h.Set(key, "v"))
+	}
+
+	tests := []struct {
+		name     string
+		start    string
+		end      string
+		limit    int
+		wantKeys []string
+		wantNext string
+	}{
+		{
+			name:     "everything",
+			wantKeys: []string{"order:1", "user:1", "user:10", "user:2", "user:3", "zebra"},
+		},
+		{
+			name:     "half open range",
+			start:    "user:1",
+			end:      "user:3",
+			wantKeys: []string{"user:1", "user:10", "user:2"},
+		},
+		{
+			name:     "first page",
+			start:    "user:",
+			end:      kv.PrefixEnd("user:"),
+			limit:    2,
+			wantKeys: []string{"user:1", "user:10"},
+			wantNext: "user:2",
+		},
+		{
+			name:     "last page",
+			start:    "user:2",
+			end:      kv.PrefixEnd("user:"),
+			limit:    2,
+			wantKeys: []string{"user:2", "user:3"},
+		},
+		{
+			name:  "empty range",
+			start: "a",
+			end:   "b",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			keys, next, err := h.Range(tc.start, tc.end, tc.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantKeys, keys)
+			assert.Equal(t, tc.wantNext, next)
+		})
+	}
+}
+
+func TestHashTableRangeSkipsRemovedKeys(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithOrderedIndex(), hashtable.WithClock(clock.Now))
+
+	require.NoError(t, h.Set("a", "v"))
+	require.NoError(t, h.SetWithExpiry("b", "v", clock.Now().Add(time.Second)))
+	require.NoError(t, h.MSet([]kv.Pair{{Key: "c", Value: "v"}, {Key: "d", Value: "v"}}))
+	_, _, err := h.IncrBy("e", 1)
+	require.NoError(t, err)
+	require.NoError(t, h.Del("c"))
+	assert.Equal(t, 1, h.MDel([]string{"d", "missing"}))
+
+	clock.Advance(time.Second)
+
+	keys, _, err := h.Range("", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "e"}, keys)
+
+	count, err := h.CountPrefix("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestHashTableCountPrefix(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4, hashtable.WithOrderedIndex())
+	for i := range 1000 {
+		require.NoError(t, h.Set("item:"+strconv.Itoa(i), "v"))
+	}
+	require.NoError(t, h.Set("items", "v"))
+
+	tests := []struct {
+		prefix string
+		want   int
+	}{
+		{prefix: "", want: 1001},
+		{prefix: "item", want: 1001},
+		{prefix: "item:", want: 1000},
+		{prefix: "item:9", want: 111},
+		{prefix: "other", want: 0},
+	}
+
+	for _, tc := range tests {
+		count, err := h.CountPrefix(tc.prefix)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, count, tc.prefix)
+	}
+}
+
+func TestHashTableRangeRequiresIndex(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	_, _, err := h.Range("", "", 0)
+	require.ErrorIs(t, err, dberrors.ErrNotOrdered)
+
+	_, err = h.CountPrefix("")
+	require.ErrorIs(t, err, dberrors.ErrNotOrdered)
+}
+
+func TestHashTableRangeEvictedKeys(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(1,
+		hashtable.WithOrderedIndex(),
+		hashtable.WithMaxMemory(5*(64+2), hashtable.PolicyAllKeysRandom),
+	)
+	for i := range 20 {
+		require.NoError(t, h.Set("k"+strconv.Itoa(i%10), "v"))
+	}
+
+	keys, _, err := h.Range("", "", 0)
+	require.NoError(t, err)
+	assert.Len(t, keys, h.Len())
+	assert.True(t, slices.IsSorted(keys))
+}
+
+func TestHashTableRangeConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(8, hashtable.WithOrderedIndex())
+	for i := range 500 {
+		require.NoError(t, h.Set("stable:"+strconv.Itoa(i), "v"))
+	}
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Go(func() {
+			for i := range 500 {
+				key := "churn:" + strconv.Itoa(w) + ":" + strconv.Itoa(i)
+				assert.NoError(t, h.Set(key, "v"))
+				assert.NoError(t, h.Del(key))
+			}
+		})
+	}
+
+	// keys present for the whole scan are always returned
+	for range 20 {
+		count, err := h.CountPrefix("stable:")
+		require.NoError(t, err)
+		assert.Equal(t, 500, count)
+	}
+	wg.Wait()
+
+	count, err := h.CountPrefix("churn:")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
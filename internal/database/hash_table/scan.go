@@ -0,0 +1,59 @@
+package hashtable
+
+import (
+	"fmt"
+
+	"lesson1/internal/database/dberrors"
+)
+
+const (
+	// scanBucketBits sets the number of scan buckets per shard.
+	scanBucketBits = 8
+	scanBuckets    = 1 << scanBucketBits
+
+	// scanEmptyFactor bounds a Scan call to count times this many buckets,
+	// so that a sparse table does not turn one call into a full walk.
+	scanEmptyFactor = 10
+)
+
+// Scan walks the table one bucket at a time, starting at the bucket cursor
+// points to, and returns the live keys of the visited buckets together with
+// the cursor of the next call, 0 once the walk is complete. A walk starts at
+// cursor 0. It stops after collecting at least count keys or visiting ten
+// times count buckets, so a call may return no keys with a non-zero cursor.
+//
+// A key never moves to another bucket and every bucket is visited once, so
+// a key present for the whole walk is returned exactly once; keys written or
+// deleted meanwhile may or may not be. Each bucket is read under the shard
+// read lock only for as long as it takes to copy its keys. Scan fails with
+// dberrors.ErrInvalidCursor for a cursor it cannot have returned.
+func (h *HashTable) Scan(cursor uint64, count int) ([]string, uint64, error) {
+	const op = "HashTable.Scan"
+
+	total := uint64(len(h.shards)) * scanBuckets
+	if cursor >= total {
+		return nil, 0, fmt.Errorf("%s: %w", op, dberrors.ErrInvalidCursor)
+	}
+	count = max(1, count)
+
+	var keys []string
+	for visited := 0; cursor < total && len(keys) < count && visited < count*scanEmptyFactor; visited++ {
+		sh := h.shards[cursor/scanBuckets]
+
+		sh.mu.RLock()
+		now := h.now()
+		for _, e := range sh.buckets[cursor%scanBuckets] {
+			if !sh.expiredLocked(e.key, now) {
+				keys = append(keys, e.key)
+			}
+		}
+		sh.mu.RUnlock()
+
+		cursor++
+	}
+
+	if cursor == total {
+		cursor = 0
+	}
+	return keys, cursor, nil
+}
@@ -0,0 +1,127 @@
+package hashtable_test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/lib/clock/fakeclock"
+)
+
+// walk runs a full cursor scan and counts how often every key was returned.
+func walk(t *testing.T, h *hashtable.HashTable, count int) map[string]int {
+	t.Helper()
+
+	seen := make(map[string]int)
+	cursor := uint64(0)
+	for {
+		keys, next, err := h.Scan(cursor, count)
+		require.NoError(t, err)
+		for _, key := range keys {
+			seen[key]++
+		}
+		if next == 0 {
+			return seen
+		}
+		cursor = next
+	}
+}
+
+func TestHashTableScan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		shards int
+		keys   int
+		count  int
+	}{
+		{name: "empty table", shards: 4, keys: 0, count: 10},
+		{name: "small steps", shards: 4, keys: 1000, count: 1},
+		{name: "large steps", shards: 16, keys: 5000, count: 1000},
+		{name: "single shard", shards: 1, keys: 300, count: 7},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := hashtable.NewHashTable(tc.shards)
+			for i := range tc.keys {
+				require.NoError(t, h.Set("k"+strconv.Itoa(i), "v"))
+			}
+
+			seen := walk(t, h, tc.count)
+			assert.Len(t, seen, tc.keys)
+			for key, n := range seen {
+				assert.Equal(t, 1, n, key)
+			}
+		})
+	}
+}
+
+func TestHashTableScanSkipsExpiredKeys(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now))
+
+	require.NoError(t, h.Set("live", "v"))
+	require.NoError(t, h.SetWithExpiry("expired", "v", clock.Now().Add(time.Second)))
+	clock.Advance(time.Second)
+
+	assert.Equal(t, map[string]int{"live": 1}, walk(t, h, 10))
+}
+
+func TestHashTableScanInvalidCursor(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	_, _, err := h.Scan(1<<40, 10)
+	require.ErrorIs(t, err, dberrors.ErrInvalidCursor)
+}
+
+func TestHashTableScanConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	const stable = 2000
+
+	h := hashtable.NewHashTable(8)
+	for i := range stable {
+		require.NoError(t, h.Set("stable:"+strconv.Itoa(i), "v"))
+	}
+
+	var (
+		stop atomic.Bool
+		wg   sync.WaitGroup
+	)
+	for w := range 4 {
+		wg.Go(func() {
+			for i := 0; !stop.Load(); i++ {
+				key := "churn:" + strconv.Itoa(w) + ":" + strconv.Itoa(i)
+				assert.NoError(t, h.Set(key, "v"))
+				if i%2 == 0 {
+					assert.NoError(t, h.Del(key))
+				}
+				// overwrites and deletes move other entries inside their buckets
+				assert.NoError(t, h.Set("stable:"+strconv.Itoa(i%stable), "v2"))
+			}
+		})
+	}
+
+	seen := walk(t, h, 5)
+	stop.Store(true)
+	wg.Wait()
+
+	for i := range stable {
+		assert.Positive(t, seen["stable:"+strconv.Itoa(i)], i)
+	}
+}
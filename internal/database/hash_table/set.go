@@ -0,0 +1,274 @@
+package hashtable
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+// memberOverhead approximates the per-member cost of a set on top of the
+// member bytes.
+const memberOverhead = 16
+
+// setObject is the value of a set key.
+type setObject struct {
+	members map[string]struct{}
+	bytes   int64
+}
+
+func (o *setObject) size() int64 {
+	return o.bytes
+}
+
+func (o *setObject) dump(item *kv.Item) {
+	item.Members = slices.Collect(maps.Keys(o.members))
+}
+
+func memberSize(member string) int64 {
+	return int64(len(member) + memberOverhead)
+}
+
+// SAdd adds members to the set stored at key, creating it when the key does
+// not exist, and returns how many were not members yet. The expiry of the
+// key is kept.
+func (h *HashTable) SAdd(key string, members []string) (int, error) {
+	const op = "HashTable.SAdd"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	set, err := h.setLocked(sh, key)
+	var growth int64
+	if set == nil {
+		growth = entrySize(key, "")
+	}
+	for _, member := range members {
+		if set == nil || !set.has(member) {
+			growth += memberSize(member)
+		}
+	}
+	sh.mu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := h.reserve(growth, func(k string) bool { return k == key }); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	set, err = h.setLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if set == nil {
+		set = &setObject{members: make(map[string]struct{}, len(members))}
+		e := h.newEntry("")
+		e.object = set
+		h.putLocked(sh, key, e)
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+
+	added := 0
+	for _, member := range members {
+		if !set.has(member) {
+			set.members[member] = struct{}{}
+			set.bytes += memberSize(member)
+			added++
+		}
+	}
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	h.touch(e)
+	h.notify(kv.EventSet, key)
+
+	return added, nil
+}
+
+// SRem removes members from the set stored at key and returns how many were
+// members. A set left without members is deleted.
+func (h *HashTable) SRem(key string, members []string) (int, error) {
+	const op = "HashTable.SRem"
+
+	sh := h.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	set, err := h.setLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if set == nil {
+		return 0, nil
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+
+	removed := 0
+	for _, member := range members {
+		if set.has(member) {
+			delete(set.members, member)
+			set.bytes -= memberSize(member)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	if len(set.members) == 0 {
+		h.removeLocked(sh, key)
+		h.notify(kv.EventDel, key)
+	} else {
+		h.notify(kv.EventSet, key)
+	}
+
+	return removed, nil
+}
+
+// SMembers returns the members of the set stored at key in lexicographic
+// order, none when the key does not exist.
+func (h *HashTable) SMembers(key string) ([]string, error) {
+	const op = "HashTable.SMembers"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	set, err := h.setLocked(sh, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if set == nil {
+		return nil, nil
+	}
+	h.touch(sh.data[key])
+
+	return slices.Sorted(maps.Keys(set.members)), nil
+}
+
+// SIsMember reports whether member belongs to the set stored at key.
+func (h *HashTable) SIsMember(key, member string) (bool, error) {
+	const op = "HashTable.SIsMember"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	set, err := h.setLocked(sh, key)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return set != nil && set.has(member), nil
+}
+
+// SInter returns the members common to the sets stored at keys in
+// lexicographic order, read from one consistent snapshot. A missing key is
+// an empty set.
+func (h *HashTable) SInter(keys []string) ([]string, error) {
+	const op = "HashTable.SInter"
+
+	var result []string
+	err := h.readSets(keys, func(sets []*setObject) {
+		slices.SortFunc(sets, func(a, b *setObject) int { return a.len() - b.len() })
+		if len(sets) == 0 || sets[0].len() == 0 {
+			return
+		}
+
+	members:
+		for member := range sets[0].members {
+			for _, set := range sets[1:] {
+				if !set.has(member) {
+					continue members
+				}
+			}
+			result = append(result, member)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	slices.Sort(result)
+	return result, nil
+}
+
+// SUnion returns the members of any of the sets stored at keys in
+// lexicographic order, read from one consistent snapshot.
+func (h *HashTable) SUnion(keys []string) ([]string, error) {
+	const op = "HashTable.SUnion"
+
+	union := make(map[string]struct{})
+	err := h.readSets(keys, func(sets []*setObject) {
+		for _, set := range sets {
+			maps.Copy(union, set.members)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slices.Sorted(maps.Keys(union)), nil
+}
+
+// readSets calls fn with the sets stored at keys while their shards are
+// read-locked together. Missing keys are empty sets; a key of another type
+// fails with dberrors.ErrWrongType.
+func (h *HashTable) readSets(keys []string, fn func(sets []*setObject)) error {
+	shards := h.lockShards(keys, true)
+	defer unlockShards(shards, true)
+
+	sets := make([]*setObject, 0, len(keys))
+	for _, key := range keys {
+		set, err := h.setLocked(h.shardFor(key), key)
+		if err != nil {
+			return err
+		}
+		if set == nil {
+			set = &setObject{}
+		}
+		sets = append(sets, set)
+	}
+
+	fn(sets)
+	return nil
+}
+
+func (o *setObject) has(member string) bool {
+	_, ok := o.members[member]
+	return ok
+}
+
+func (o *setObject) len() int {
+	return len(o.members)
+}
+
+// setLocked returns the set stored at key, nil when the key does not exist
+// or has expired, and dberrors.ErrWrongType when it holds another type. The
+// caller holds the shard lock.
+func (h *HashTable) setLocked(sh *shard, key string) (*setObject, error) {
+	if !sh.liveLocked(key, h.now()) {
+		return nil, nil
+	}
+	set, ok := sh.data[key].object.(*setObject)
+	if !ok {
+		return nil, dberrors.ErrWrongType
+	}
+	return set, nil
+}
@@ -0,0 +1,146 @@
+package hashtable_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+)
+
+func TestHashTableSet(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	added, err := h.SAdd("s", []string{"b", "a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = h.SAdd("s", []string{"a", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	members, err := h.SMembers("s")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, members)
+
+	ok, err := h.SIsMember("s", "b")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = h.SIsMember("s", "z")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	removed, err := h.SRem("s", []string{"a", "z"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	// the key goes away with its last member
+	removed, err = h.SRem("s", []string{"b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, 0, h.Len())
+	assert.Zero(t, h.Stats().UsedMemory)
+
+	members, err = h.SMembers("s")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestHashTableSetAlgebra(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	_, err := h.SAdd("a", []string{"1", "2", "3"})
+	require.NoError(t, err)
+	_, err = h.SAdd("b", []string{"2", "3", "4"})
+	require.NoError(t, err)
+	_, err = h.SAdd("c", []string{"3", "5"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		keys  []string
+		inter []string
+		union []string
+	}{
+		{name: "single", keys: []string{"a"}, inter: []string{"1", "2", "3"}, union: []string{"1", "2", "3"}},
+		{name: "two", keys: []string{"a", "b"}, inter: []string{"2", "3"}, union: []string{"1", "2", "3", "4"}},
+		{name: "three", keys: []string{"a", "b", "c"}, inter: []string{"3"}, union: []string{"1", "2", "3", "4", "5"}},
+		{name: "missing key", keys: []string{"a", "missing"}, union: []string{"1", "2", "3"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			inter, err := h.SInter(tc.keys)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.inter, inter)
+
+			union, err := h.SUnion(tc.keys)
+			require.NoError(t, err)
+			assert.Equal(t, tc.union, union)
+		})
+	}
+}
+
+func TestHashTableSetWrongType(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	require.NoError(t, h.Set("str", "v"))
+	_, err := h.SAdd("set", []string{"m"})
+	require.NoError(t, err)
+
+	_, err = h.SAdd("str", []string{"m"})
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.SRem("str", []string{"m"})
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.SMembers("str")
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.SIsMember("str", "m")
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.SInter([]string{"set", "str"})
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.SUnion([]string{"set", "str"})
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+
+	_, err = h.Get("set")
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = h.Push("set", []string{"v"}, kv.Left)
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+}
+
+func TestHashTableSetMemory(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	_, err := h.SAdd("s", []string{"abc", "d"})
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+memberCost(3)+memberCost(1), h.Stats().UsedMemory)
+
+	_, err = h.SRem("s", []string{"abc"})
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+memberCost(1), h.Stats().UsedMemory)
+
+	limit := entryCost(1, 0) + memberCost(4)
+	limited := hashtable.NewHashTable(4, hashtable.WithMaxMemory(limit, hashtable.PolicyNoEviction))
+	_, err = limited.SAdd("s", []string{"1234"})
+	require.NoError(t, err)
+	// adding a present member needs no memory
+	_, err = limited.SAdd("s", []string{"1234"})
+	require.NoError(t, err)
+	_, err = limited.SAdd("s", []string{"5"})
+	require.ErrorIs(t, err, dberrors.ErrOutOfMemory)
+}
+
+// memberCost mirrors the accounting of a set member of memberLen.
+func memberCost(memberLen int) int64 {
+	return int64(memberLen + 16)
+}
@@ -0,0 +1,142 @@
+package hashtable
+
+import (
+	"sync"
+
+	"lesson1/internal/database/kv"
+)
+
+// viewBatch is how many keys View.Range copies per shard lock, which bounds
+// how long it holds up the writers of a shard.
+const viewBatch = 256
+
+// View is a point-in-time view of the table, see Snapshot.
+type View struct {
+	h *HashTable
+	// keys lists the live keys of every shard when the view was taken.
+	keys      [][]string
+	closeOnce sync.Once
+}
+
+// Snapshot takes a point-in-time view of the table. Writers are only held up
+// while the keys of their shard are listed: afterwards, the first write to a
+// key saves a copy of it for the view, which is copy-on-write at the key
+// level. Only one view is open at a time, Snapshot waits until the previous
+// one is closed.
+//
+// The shards are listed one after the other, so the view is consistent across
+// shards only when nothing writes while Snapshot runs; storage.Storage takes
+// it with its writes paused.
+func (h *HashTable) Snapshot() *View {
+	h.snapshotMu.Lock()
+
+	v := &View{h: h, keys: make([][]string, len(h.shards))}
+	now := h.now()
+	for i, sh := range h.shards {
+		sh.mu.Lock()
+		keys := make([]string, 0, len(sh.data))
+		for key := range sh.data {
+			if !sh.expiredLocked(key, now) {
+				keys = append(keys, key)
+			}
+		}
+		v.keys[i] = keys
+		sh.frozen = make(map[string]kv.Item)
+		sh.mu.Unlock()
+	}
+
+	return v
+}
+
+// Range calls fn with every key of the view as it was when the view was
+// taken, skipping the keys that expired since, and stops at the first error.
+// fn runs without any lock held.
+func (v *View) Range(fn func(kv.Item) error) error {
+	now := v.h.now()
+	for i, sh := range v.h.shards {
+		keys := v.keys[i]
+		for start := 0; start < len(keys); start += viewBatch {
+			batch := keys[start:min(start+viewBatch, len(keys))]
+
+			items := make([]kv.Item, 0, len(batch))
+			sh.mu.RLock()
+			for _, key := range batch {
+				// a key without a saved copy has not been written since the
+				// view was taken, so it is still there unchanged
+				item, ok := sh.frozen[key]
+				if !ok {
+					item = itemLocked(sh, key)
+				}
+				items = append(items, item)
+			}
+			sh.mu.RUnlock()
+
+			for _, item := range items {
+				if !item.ExpireAt.IsZero() && !now.Before(item.ExpireAt) {
+					continue
+				}
+				if err := fn(item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Close drops the copies saved for the view and lets the next Snapshot
+// proceed.
+func (v *View) Close() {
+	v.closeOnce.Do(func() {
+		for _, sh := range v.h.shards {
+			sh.mu.Lock()
+			sh.frozen = nil
+			sh.mu.Unlock()
+		}
+		v.h.snapshotMu.Unlock()
+	})
+}
+
+// Flush removes every key.
+func (h *HashTable) Flush() {
+	now := h.now()
+	for _, sh := range h.shards {
+		sh.mu.Lock()
+		for key := range sh.data {
+			live := !sh.expiredLocked(key, now)
+			h.removeLocked(sh, key)
+			if live {
+				h.notify(kv.EventDel, key)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// preserveLocked saves a copy of key for the open view, if any, before its
+// first write since the view was taken. Keys created since are not in the
+// view and need no copy. The caller holds the shard write lock.
+func (h *HashTable) preserveLocked(sh *shard, key string) {
+	if sh.frozen == nil {
+		return
+	}
+	if _, ok := sh.frozen[key]; ok {
+		return
+	}
+	if _, ok := sh.data[key]; !ok {
+		return
+	}
+	sh.frozen[key] = itemLocked(sh, key)
+}
+
+// itemLocked copies a stored key. The caller holds the shard lock.
+func itemLocked(sh *shard, key string) kv.Item {
+	e := sh.data[key]
+	item := kv.Item{Key: key, ExpireAt: sh.expires[key]}
+	if e.object != nil {
+		e.object.dump(&item)
+	} else {
+		item.Value = e.text()
+	}
+	return item
+}
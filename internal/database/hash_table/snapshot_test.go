@@ -0,0 +1,142 @@
+package hashtable_test
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/lib/clock/fakeclock"
+)
+
+func collectView(t *testing.T, view *hashtable.View) []kv.Item {
+	t.Helper()
+
+	var items []kv.Item
+	require.NoError(t, view.Range(func(item kv.Item) error {
+		items = append(items, item)
+		return nil
+	}))
+	slices.SortFunc(items, func(a, b kv.Item) int { return strings.Compare(a.Key, b.Key) })
+	for i := range items {
+		slices.SortFunc(items[i].Fields, func(a, b kv.Pair) int { return strings.Compare(a.Key, b.Key) })
+		slices.Sort(items[i].Members)
+	}
+	return items
+}
+
+func TestHashTableSnapshot(t *testing.T) {
+	t.Parallel()
+
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	expireAt := clock.Now().Add(time.Hour)
+	h := hashtable.NewHashTable(4, hashtable.WithClock(clock.Now))
+
+	require.NoError(t, h.Set("str", "v"))
+	require.NoError(t, h.SetWithExpiry("ttl", "v", expireAt))
+	require.NoError(t, h.SetWithExpiry("gone", "v", clock.Now().Add(time.Second)))
+	_, err := h.HSet("hash", []kv.Pair{{Key: "f", Value: "1"}, {Key: "g", Value: "2"}})
+	require.NoError(t, err)
+	_, err = h.Push("list", []string{"a", "b"}, kv.Right)
+	require.NoError(t, err)
+	_, err = h.SAdd("set", []string{"x", "y"})
+	require.NoError(t, err)
+	_, err = h.ZAdd("zset", []kv.ScoredMember{{Member: "m", Score: 2}, {Member: "n", Score: 1}})
+	require.NoError(t, err)
+
+	view := h.Snapshot()
+	t.Cleanup(view.Close)
+
+	// writes after the snapshot do not show in the view
+	require.NoError(t, h.Set("str", "changed"))
+	require.NoError(t, h.Set("new", "v"))
+	require.NoError(t, h.Persist("ttl"))
+	_, err = h.HSet("hash", []kv.Pair{{Key: "f", Value: "changed"}})
+	require.NoError(t, err)
+	_, err = h.Pop("list", kv.Left)
+	require.NoError(t, err)
+	_, err = h.Push("list", []string{"c"}, kv.Right)
+	require.NoError(t, err)
+	_, err = h.SRem("set", []string{"x", "y"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, h.MDel([]string{"zset"}))
+	clock.Advance(time.Second)
+
+	want := []kv.Item{
+		{Key: "hash", Fields: []kv.Pair{{Key: "f", Value: "1"}, {Key: "g", Value: "2"}}},
+		{Key: "list", Items: []string{"a", "b"}},
+		{Key: "set", Members: []string{"x", "y"}},
+		{Key: "str", Value: "v"},
+		{Key: "ttl", Value: "v", ExpireAt: expireAt},
+		{Key: "zset", Scored: []kv.ScoredMember{{Member: "n", Score: 1}, {Member: "m", Score: 2}}},
+	}
+	assert.Equal(t, want, collectView(t, view))
+
+	view.Close()
+	next := h.Snapshot()
+	defer next.Close()
+
+	items := collectView(t, next)
+	require.Len(t, items, 5)
+	assert.Equal(t, kv.Item{Key: "list", Items: []string{"b", "c"}}, items[1])
+	assert.Equal(t, kv.Item{Key: "str", Value: "changed"}, items[3])
+}
+
+func TestHashTableFlush(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	require.NoError(t, h.Set("a", "1"))
+	_, err := h.SAdd("s", []string{"x"})
+	require.NoError(t, err)
+
+	h.Flush()
+
+	assert.Zero(t, h.Len())
+	assert.Zero(t, h.Stats().UsedMemory)
+	require.NoError(t, h.Set("a", "2"))
+	value, err := h.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, "2", value)
+}
+
+func TestHashTableSnapshotConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	const keys = 1000
+	for i := range keys {
+		require.NoError(t, h.Set("k"+strconv.Itoa(i), "old"))
+	}
+
+	view := h.Snapshot()
+	defer view.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range keys {
+			key := "k" + strconv.Itoa(i)
+			if i%2 == 0 {
+				_ = h.Del(key)
+			} else {
+				_ = h.Set(key, "new")
+			}
+		}
+	}()
+
+	var seen int
+	require.NoError(t, view.Range(func(item kv.Item) error {
+		seen++
+		assert.Equal(t, "old", item.Value)
+		return nil
+	}))
+	<-done
+	assert.Equal(t, keys, seen)
+}
@@ -0,0 +1,313 @@
+package hashtable
+
+import (
+	"cmp"
+	"fmt"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/skiplist"
+)
+
+// zmemberOverhead approximates the per-member cost of a sorted set, its map
+// entry and its skip list node, on top of the member bytes.
+const zmemberOverhead = 64
+
+// zsetObject is the value of a sorted set key. scores answers point queries
+// and order keeps the members sorted by score, then by member, for range and
+// rank queries.
+type zsetObject struct {
+	scores map[string]float64
+	order  *skiplist.List[kv.ScoredMember]
+	bytes  int64
+}
+
+func newZSet() *zsetObject {
+	return &zsetObject{
+		scores: make(map[string]float64),
+		order:  skiplist.New(compareScored),
+	}
+}
+
+func compareScored(a, b kv.ScoredMember) int {
+	if c := cmp.Compare(a.Score, b.Score); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Member, b.Member)
+}
+
+func (o *zsetObject) size() int64 {
+	return o.bytes
+}
+
+func (o *zsetObject) dump(item *kv.Item) {
+	item.Scored = make([]kv.ScoredMember, 0, len(o.scores))
+	for m := range o.order.All() {
+		item.Scored = append(item.Scored, m)
+	}
+}
+
+func zmemberSize(member string) int64 {
+	return int64(len(member) + zmemberOverhead)
+}
+
+// ZAdd sets the scores of members of the sorted set stored at key, creating
+// it when the key does not exist, and returns how many members were added
+// rather than updated. The expiry of the key is kept.
+func (h *HashTable) ZAdd(key string, members []kv.ScoredMember) (int, error) {
+	const op = "HashTable.ZAdd"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	zset, err := h.zsetLocked(sh, key)
+	var growth int64
+	if zset == nil {
+		growth = entrySize(key, "")
+	}
+	for _, m := range members {
+		if zset == nil {
+			growth += zmemberSize(m.Member)
+		} else if _, ok := zset.scores[m.Member]; !ok {
+			growth += zmemberSize(m.Member)
+		}
+	}
+	sh.mu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := h.reserve(growth, func(k string) bool { return k == key }); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	zset, err = h.zsetLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if zset == nil {
+		zset = newZSet()
+		e := h.newEntry("")
+		e.object = zset
+		h.putLocked(sh, key, e)
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+
+	added := 0
+	for _, m := range members {
+		if old, ok := zset.scores[m.Member]; ok {
+			zset.order.Delete(kv.ScoredMember{Member: m.Member, Score: old})
+		} else {
+			zset.bytes += zmemberSize(m.Member)
+			added++
+		}
+		zset.scores[m.Member] = m.Score
+		zset.order.Insert(m)
+	}
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	h.touch(e)
+	h.notify(kv.EventSet, key)
+
+	return added, nil
+}
+
+// ZRem removes members from the sorted set stored at key and returns how
+// many were members. A sorted set left without members is deleted.
+func (h *HashTable) ZRem(key string, members []string) (int, error) {
+	const op = "HashTable.ZRem"
+
+	sh := h.shardFor(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	zset, err := h.zsetLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if zset == nil {
+		return 0, nil
+	}
+
+	h.preserveLocked(sh, key)
+	e := sh.data[key]
+	before := e.size()
+
+	removed := 0
+	for _, member := range members {
+		score, ok := zset.scores[member]
+		if !ok {
+			continue
+		}
+		delete(zset.scores, member)
+		zset.order.Delete(kv.ScoredMember{Member: member, Score: score})
+		zset.bytes -= zmemberSize(member)
+		removed++
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	h.usedMemory.Add(e.size() - before)
+	e.version = h.writeClock.Add(1)
+	if len(zset.scores) == 0 {
+		h.removeLocked(sh, key)
+		h.notify(kv.EventDel, key)
+	} else {
+		h.notify(kv.EventSet, key)
+	}
+
+	return removed, nil
+}
+
+// ZScore returns the score of a member. It fails with dberrors.ErrNotFound
+// when the key or the member does not exist.
+func (h *HashTable) ZScore(key, member string) (float64, error) {
+	const op = "HashTable.ZScore"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	zset, err := h.zsetLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if zset == nil {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+
+	score, ok := zset.scores[member]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	h.touch(sh.data[key])
+
+	return score, nil
+}
+
+// ZRank returns the position of a member in score order, starting at 0. It
+// fails with dberrors.ErrNotFound when the key or the member does not exist.
+func (h *HashTable) ZRank(key, member string) (int, error) {
+	const op = "HashTable.ZRank"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	zset, err := h.zsetLocked(sh, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if zset == nil {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+
+	score, ok := zset.scores[member]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	h.touch(sh.data[key])
+
+	return zset.order.Rank(kv.ScoredMember{Member: member, Score: score}), nil
+}
+
+// ZRange returns the members of the sorted set stored at key from position
+// start to stop inclusive in score order. Negative positions count from the
+// highest score, -1 being the last member, and out of range positions are
+// clamped.
+func (h *HashTable) ZRange(key string, start, stop int) ([]kv.ScoredMember, error) {
+	const op = "HashTable.ZRange"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	zset, err := h.zsetLocked(sh, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if zset == nil {
+		return nil, nil
+	}
+	h.touch(sh.data[key])
+
+	n := zset.order.Len()
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return nil, nil
+	}
+
+	members := make([]kv.ScoredMember, 0, stop-start+1)
+	for m := range zset.order.FromRank(start) {
+		if len(members) == stop-start+1 {
+			break
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// ZRangeByScore returns up to limit members of the sorted set stored at key
+// whose scores lie between lower and upper, in score order. A non-positive
+// limit returns all of them.
+func (h *HashTable) ZRangeByScore(key string, lower, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error) {
+	const op = "HashTable.ZRangeByScore"
+
+	sh := h.shardFor(key)
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	zset, err := h.zsetLocked(sh, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if zset == nil {
+		return nil, nil
+	}
+	h.touch(sh.data[key])
+
+	var members []kv.ScoredMember
+	// the empty member sorts first among the members of a score
+	for m := range zset.order.From(kv.ScoredMember{Score: lower.Score}) {
+		if !upper.Below(m.Score) || (limit > 0 && len(members) == limit) {
+			break
+		}
+		if lower.Above(m.Score) {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+// zsetLocked returns the sorted set stored at key, nil when the key does not
+// exist or has expired, and dberrors.ErrWrongType when it holds another
+// type. The caller holds the shard lock.
+func (h *HashTable) zsetLocked(sh *shard, key string) (*zsetObject, error) {
+	if !sh.liveLocked(key, h.now()) {
+		return nil, nil
+	}
+	zset, ok := sh.data[key].object.(*zsetObject)
+	if !ok {
+		return nil, dberrors.ErrWrongType
+	}
+	return zset, nil
+}
@@ -0,0 +1,201 @@
+package hashtable_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+)
+
+func TestHashTableZSet(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	added, err := h.ZAdd("z", []kv.ScoredMember{{Member: "b", Score: 2}, {Member: "a", Score: 1}, {Member: "c", Score: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	score, err := h.ZScore("z", "b")
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, score, 0)
+
+	rank, err := h.ZRank("z", "c")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rank)
+
+	// updating a score moves the member instead of adding it
+	added, err = h.ZAdd("z", []kv.ScoredMember{{Member: "c", Score: 0}})
+	require.NoError(t, err)
+	assert.Zero(t, added)
+
+	rank, err = h.ZRank("z", "c")
+	require.NoError(t, err)
+	assert.Zero(t, rank)
+
+	members, err := h.ZRange("z", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []kv.ScoredMember{{Member: "c", Score: 0}, {Member: "a", Score: 1}, {Member: "b", Score: 2}}, members)
+
+	_, err = h.ZScore("z", "missing")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	_, err = h.ZRank("missing", "a")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	removed, err := h.ZRem("z", []string{"a", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	rank, err = h.ZRank("z", "b")
+	require.NoError(t, err)
+	assert.Equal(t, 1, rank)
+
+	// the key goes away with its last member
+	removed, err = h.ZRem("z", []string{"b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, 0, h.Len())
+	assert.Zero(t, h.Stats().UsedMemory)
+}
+
+func TestHashTableZRange(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	// equal scores are ordered by member
+	_, err := h.ZAdd("z", []kv.ScoredMember{
+		{Member: "e", Score: 3}, {Member: "d", Score: 3}, {Member: "c", Score: 2},
+		{Member: "b", Score: 1}, {Member: "a", Score: -1},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		start, stop int
+		want        []string
+	}{
+		{name: "all", start: 0, stop: -1, want: []string{"a", "b", "c", "d", "e"}},
+		{name: "middle", start: 1, stop: 3, want: []string{"b", "c", "d"}},
+		{name: "negative", start: -2, stop: -1, want: []string{"d", "e"}},
+		{name: "clamped", start: -100, stop: 100, want: []string{"a", "b", "c", "d", "e"}},
+		{name: "start after stop", start: 3, stop: 1},
+		{name: "start past the end", start: 5, stop: 10},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			members, err := h.ZRange("z", tc.start, tc.stop)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, memberNames(members))
+		})
+	}
+
+	members, err := h.ZRange("missing", 0, -1)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestHashTableZRangeByScore(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	_, err := h.ZAdd("z", []kv.ScoredMember{
+		{Member: "a", Score: 1}, {Member: "b", Score: 2}, {Member: "c", Score: 2},
+		{Member: "d", Score: 3}, {Member: "e", Score: 4.5},
+	})
+	require.NoError(t, err)
+
+	inclusive := func(score float64) kv.ScoreBound { return kv.ScoreBound{Score: score} }
+	exclusive := func(score float64) kv.ScoreBound { return kv.ScoreBound{Score: score, Exclusive: true} }
+
+	tests := []struct {
+		name         string
+		lower, upper kv.ScoreBound
+		limit        int
+		want         []string
+	}{
+		{name: "inclusive", lower: inclusive(2), upper: inclusive(3), want: []string{"b", "c", "d"}},
+		{name: "exclusive lower", lower: exclusive(2), upper: inclusive(3), want: []string{"d"}},
+		{name: "exclusive upper", lower: inclusive(1), upper: exclusive(2), want: []string{"a"}},
+		{name: "both exclusive", lower: exclusive(1), upper: exclusive(4.5), want: []string{"b", "c", "d"}},
+		{name: "unbounded", lower: inclusive(math.Inf(-1)), upper: inclusive(math.Inf(1)), want: []string{"a", "b", "c", "d", "e"}},
+		{name: "limit", lower: inclusive(math.Inf(-1)), upper: inclusive(math.Inf(1)), limit: 2, want: []string{"a", "b"}},
+		{name: "limit after exclusive lower", lower: exclusive(1), upper: inclusive(5), limit: 2, want: []string{"b", "c"}},
+		{name: "between scores", lower: inclusive(3.5), upper: inclusive(4)},
+		{name: "empty range", lower: exclusive(2), upper: exclusive(2)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			members, err := h.ZRangeByScore("z", tc.lower, tc.upper, tc.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, memberNames(members))
+		})
+	}
+}
+
+func TestHashTableZSetWrongType(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+	require.NoError(t, h.Set("str", "v"))
+	_, err := h.SAdd("set", []string{"m"})
+	require.NoError(t, err)
+
+	for _, key := range []string{"str", "set"} {
+		_, err = h.ZAdd(key, []kv.ScoredMember{{Member: "m", Score: 1}})
+		require.ErrorIs(t, err, dberrors.ErrWrongType)
+		_, err = h.ZRem(key, []string{"m"})
+		require.ErrorIs(t, err, dberrors.ErrWrongType)
+		_, err = h.ZScore(key, "m")
+		require.ErrorIs(t, err, dberrors.ErrWrongType)
+		_, err = h.ZRank(key, "m")
+		require.ErrorIs(t, err, dberrors.ErrWrongType)
+		_, err = h.ZRange(key, 0, -1)
+		require.ErrorIs(t, err, dberrors.ErrWrongType)
+		_, err = h.ZRangeByScore(key, kv.ScoreBound{Score: 0}, kv.ScoreBound{Score: 1}, 0)
+		require.ErrorIs(t, err, dberrors.ErrWrongType)
+	}
+}
+
+func TestHashTableZSetMemory(t *testing.T) {
+	t.Parallel()
+
+	h := hashtable.NewHashTable(4)
+
+	_, err := h.ZAdd("z", []kv.ScoredMember{{Member: "abc", Score: 1}, {Member: "d", Score: 2}})
+	require.NoError(t, err)
+	want := entryCost(1, 0) + zmemberCost(3) + zmemberCost(1)
+	assert.Equal(t, want, h.Stats().UsedMemory)
+
+	// a score update costs nothing
+	_, err = h.ZAdd("z", []kv.ScoredMember{{Member: "abc", Score: 5}})
+	require.NoError(t, err)
+	assert.Equal(t, want, h.Stats().UsedMemory)
+
+	_, err = h.ZRem("z", []string{"abc"})
+	require.NoError(t, err)
+	assert.Equal(t, entryCost(1, 0)+zmemberCost(1), h.Stats().UsedMemory)
+}
+
+// zmemberCost mirrors the accounting of a sorted set member of memberLen.
+func zmemberCost(memberLen int) int64 {
+	return int64(memberLen + 64)
+}
+
+func memberNames(members []kv.ScoredMember) []string {
+	var names []string
+	for _, m := range members {
+		names = append(names, m.Member)
+	}
+	return names
+}
@@ -0,0 +1,181 @@
+// Package kv holds the value types and key helpers shared by compute,
+// storage and the engine.
+package kv
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Pair is one key and its value in a multi-key write.
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// Lookup is the result of reading one key in a multi-key read. Found
+// distinguishes a missing key from an empty value.
+type Lookup struct {
+	Value string
+	Found bool
+}
+
+// EventType names a change of the keyspace.
+type EventType string
+
+const (
+	// EventSet reports a key written by any command, including a change to
+	// a member of a hash, list or set.
+	EventSet EventType = "set"
+	// EventDel reports a key deleted by a command, or by the removal of its
+	// last member.
+	EventDel     EventType = "del"
+	EventExpired EventType = "expired"
+	EventEvicted EventType = "evicted"
+)
+
+// Event is one change of the keyspace.
+type Event struct {
+	Type EventType
+	Key  string
+}
+
+// ConditionKind selects when a conditional write takes place.
+type ConditionKind int
+
+const (
+	// Always writes unconditionally; the write still reports the previous value.
+	Always ConditionKind = iota
+	// IfAbsent writes only when the key does not exist (SET NX).
+	IfAbsent
+	// IfPresent writes only when the key exists (SET XX).
+	IfPresent
+	// IfEquals writes only when the key holds Condition.Expected (CAS).
+	IfEquals
+	// Swap writes unconditionally like Always, but the previous value is
+	// returned to the client, so it must be a string (SETGET).
+	Swap
+)
+
+// Condition guards a conditional write.
+type Condition struct {
+	Kind     ConditionKind
+	Expected string
+}
+
+// ReadsValue reports whether the condition needs the previous value of the
+// key, which only a string key has.
+func (c Condition) ReadsValue() bool {
+	return c.Kind == IfEquals || c.Kind == Swap
+}
+
+// Holds reports whether a key whose current state is current satisfies the
+// condition.
+func (c Condition) Holds(current Lookup) bool {
+	switch c.Kind {
+	case IfAbsent:
+		return !current.Found
+	case IfPresent:
+		return current.Found
+	case IfEquals:
+		return current.Found && current.Value == c.Expected
+	default:
+		return true
+	}
+}
+
+// End selects the end of a list that a push or a pop works on.
+type End int
+
+const (
+	// Left is the head of a list (LPUSH, LPOP).
+	Left End = iota
+	// Right is the tail of a list (RPUSH, RPOP).
+	Right
+)
+
+// ScoredMember is one member of a sorted set and its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// FormatScore renders a score so that ParseScore reads it back exactly.
+func FormatScore(score float64) string {
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
+
+// ParseScore reads a score, accepting infinities such as "-inf" and
+// rejecting NaN.
+func ParseScore(raw string) (float64, error) {
+	score, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(score) {
+		return 0, strconv.ErrSyntax
+	}
+	return score, nil
+}
+
+// ScoreBound is one end of a score range; an exclusive bound leaves its own
+// score out.
+type ScoreBound struct {
+	Score     float64
+	Exclusive bool
+}
+
+// Above reports whether score is past the lower bound b.
+func (b ScoreBound) Above(score float64) bool {
+	if b.Exclusive {
+		return score > b.Score
+	}
+	return score >= b.Score
+}
+
+// Below reports whether score is before the upper bound b.
+func (b ScoreBound) Below(score float64) bool {
+	if b.Exclusive {
+		return score < b.Score
+	}
+	return score <= b.Score
+}
+
+// PrefixEnd returns the smallest key that sorts after every key with the
+// given prefix, turning a prefix query into the range [prefix, PrefixEnd).
+// It returns an empty string, no upper bound, when there is no such key.
+func PrefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ""
+}
+
+// Item is one key of a snapshot with its value and expiry, zero when it has
+// none. Exactly one of the value fields is used, according to the type of
+// the key: Value for a string, Fields for a hash, Items for a list, Members
+// for a set and Scored for a sorted set.
+type Item struct {
+	Key      string
+	ExpireAt time.Time
+
+	Value   string
+	Fields  []Pair
+	Items   []string
+	Members []string
+	Scored  []ScoredMember
+}
+
+// View is a point-in-time view of the keyspace that stays unchanged while
+// the keys are written. It must be closed once ranged over.
+type View interface {
+	// Range calls fn with every key that was live when the view was taken,
+	// in no particular order, and stops at the first error.
+	Range(fn func(Item) error) error
+	Close()
+}
@@ -0,0 +1,190 @@
+// Package notify streams the changes of the keyspace to in-process
+// subscribers and to pub/sub channels.
+package notify
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"lesson1/internal/database/kv"
+	"lesson1/internal/pubsub"
+)
+
+const (
+	// KeyspacePrefix starts the channel of a key, which receives the name of
+	// every event of the key: __keyspace__:user/1 receives "set".
+	KeyspacePrefix = "__keyspace__:"
+	// KeyeventPrefix starts the channel of an event type, which receives the
+	// key of every such event: __keyevent__:expired receives "user/1".
+	KeyeventPrefix = "__keyevent__:"
+
+	DefaultBufferSize = 128
+	DefaultQueueSize  = 1024
+)
+
+// Notifier fans keyspace events out to its subscribers. Notify never blocks:
+// an event that does not fit in the buffer of a subscriber is dropped for
+// that subscriber and counted.
+//
+// Notify runs under the locks of the engine, so the events for the pub/sub
+// channels, whose publishing matches every pattern subscription, are only
+// queued there and published by the goroutine of Start. An event that does
+// not fit in the queue is dropped and counted too.
+type Notifier struct {
+	broker *pubsub.Broker
+	queue  chan kv.Event
+
+	mu   sync.RWMutex
+	subs map[*subscription]struct{}
+
+	dropped atomic.Uint64
+}
+
+type subscription struct {
+	events chan kv.Event
+	types  []kv.EventType
+
+	// mu orders deliveries with the close of events.
+	mu     sync.Mutex
+	closed bool
+}
+
+type Option func(*options)
+
+type options struct {
+	broker    *pubsub.Broker
+	queueSize int
+}
+
+// WithBroker also publishes every event to the pub/sub channels of its key
+// and of its type, see KeyspacePrefix and KeyeventPrefix. The events are
+// published once Start runs.
+func WithBroker(broker *pubsub.Broker) Option {
+	return func(o *options) {
+		o.broker = broker
+	}
+}
+
+// WithQueueSize sets how many events wait to be published to the broker.
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.queueSize = size
+		}
+	}
+}
+
+func NewNotifier(opts ...Option) *Notifier {
+	o := options{queueSize: DefaultQueueSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	n := &Notifier{
+		broker: o.broker,
+		subs:   make(map[*subscription]struct{}),
+	}
+	if n.broker != nil {
+		n.queue = make(chan kv.Event, o.queueSize)
+	}
+
+	return n
+}
+
+// Start publishes the queued events to the broker until parent is cancelled.
+// The error channel is closed once the publishing goroutine has exited.
+func (n *Notifier) Start(parent context.Context) (context.Context, <-chan error) {
+	ctx, cancel := context.WithCancel(parent)
+	errCh := make(chan error)
+
+	go func() {
+		defer close(errCh)
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-n.queue:
+				n.broker.Publish(KeyspacePrefix+event.Key, string(event.Type))
+				n.broker.Publish(KeyeventPrefix+string(event.Type), event.Key)
+			}
+		}
+	}()
+
+	return ctx, errCh
+}
+
+// Subscribe returns the events of the given types, all of them when none is
+// given, from now until ctx is done, when the channel is closed. A
+// non-positive bufferSize falls back to DefaultBufferSize.
+func (n *Notifier) Subscribe(ctx context.Context, bufferSize int, types ...kv.EventType) <-chan kv.Event {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	sub := &subscription{events: make(chan kv.Event, bufferSize), types: types}
+
+	n.mu.Lock()
+	n.subs[sub] = struct{}{}
+	n.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		n.mu.Lock()
+		delete(n.subs, sub)
+		n.mu.Unlock()
+
+		sub.mu.Lock()
+		sub.closed = true
+		close(sub.events)
+		sub.mu.Unlock()
+	})
+
+	return sub.events
+}
+
+// Notify delivers an event. It is meant to be the listener of the engine.
+func (n *Notifier) Notify(event kv.Event) {
+	n.mu.RLock()
+	for sub := range n.subs {
+		if !sub.deliver(event) {
+			n.dropped.Add(1)
+		}
+	}
+	n.mu.RUnlock()
+
+	if n.queue != nil {
+		select {
+		case n.queue <- event:
+		default:
+			n.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns how many events were dropped for subscribers, or for the
+// broker, that did not keep up.
+func (n *Notifier) Dropped() uint64 {
+	return n.dropped.Load()
+}
+
+// deliver reports false when the event was dropped for a full buffer.
+func (s *subscription) deliver(event kv.Event) bool {
+	if len(s.types) > 0 && !slices.Contains(s.types, event.Type) {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return true
+	}
+	select {
+	case s.events <- event:
+		return true
+	default:
+		return false
+	}
+}
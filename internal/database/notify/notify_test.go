@@ -0,0 +1,95 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/notify"
+	"lesson1/internal/pubsub"
+)
+
+func TestNotifierSubscribe(t *testing.T) {
+	t.Parallel()
+
+	n := notify.NewNotifier()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	all := n.Subscribe(ctx, 0)
+	expired := n.Subscribe(ctx, 0, kv.EventExpired, kv.EventEvicted)
+
+	n.Notify(kv.Event{Type: kv.EventSet, Key: "a"})
+	n.Notify(kv.Event{Type: kv.EventExpired, Key: "b"})
+
+	assert.Equal(t, kv.Event{Type: kv.EventSet, Key: "a"}, <-all)
+	assert.Equal(t, kv.Event{Type: kv.EventExpired, Key: "b"}, <-all)
+	assert.Equal(t, kv.Event{Type: kv.EventExpired, Key: "b"}, <-expired)
+	assert.Empty(t, expired)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-all:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+
+	// events after the end of a subscription go nowhere
+	n.Notify(kv.Event{Type: kv.EventDel, Key: "a"})
+	assert.Zero(t, n.Dropped())
+}
+
+func TestNotifierDropsForFullBuffer(t *testing.T) {
+	t.Parallel()
+
+	n := notify.NewNotifier()
+	events := n.Subscribe(t.Context(), 1)
+
+	n.Notify(kv.Event{Type: kv.EventSet, Key: "a"})
+	n.Notify(kv.Event{Type: kv.EventSet, Key: "b"})
+	n.Notify(kv.Event{Type: kv.EventSet, Key: "c"})
+
+	assert.Equal(t, kv.Event{Type: kv.EventSet, Key: "a"}, <-events)
+	assert.Equal(t, uint64(2), n.Dropped())
+}
+
+func TestNotifierPublishes(t *testing.T) {
+	t.Parallel()
+
+	broker := pubsub.NewBroker()
+	sub := broker.NewSubscriber()
+	t.Cleanup(func() { sub.Close() })
+	sub.Subscribe(notify.KeyspacePrefix+"user/1", notify.KeyeventPrefix+"expired")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := notify.NewNotifier(notify.WithBroker(broker))
+	_, errCh := n.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		<-errCh
+	})
+
+	n.Notify(kv.Event{Type: kv.EventSet, Key: "user/1"})
+	n.Notify(kv.Event{Type: kv.EventExpired, Key: "user/2"})
+
+	assert.Equal(t, `MESSAGE "__keyspace__:user/1" "set"`, <-sub.Messages())
+	assert.Equal(t, `MESSAGE "__keyevent__:expired" "user/2"`, <-sub.Messages())
+	assert.Empty(t, sub.Messages())
+}
+
+func TestNotifierDropsForFullQueue(t *testing.T) {
+	t.Parallel()
+
+	// nothing publishes the queue before Start
+	n := notify.NewNotifier(notify.WithBroker(pubsub.NewBroker()), notify.WithQueueSize(2))
+	for range 5 {
+		n.Notify(kv.Event{Type: kv.EventSet, Key: "k"})
+	}
+	assert.Equal(t, uint64(3), n.Dropped())
+}
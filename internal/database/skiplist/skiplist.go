@@ -0,0 +1,203 @@
+// Package skiplist implements an ordered set on top of a probabilistic skip
+// list. It is not safe for concurrent use; callers guard it with their own
+// lock.
+package skiplist
+
+import (
+	"iter"
+	"math/rand/v2"
+)
+
+const (
+	// MaxLevel bounds the height of a node; 32 levels keep searches
+	// logarithmic for far more elements than fit in memory.
+	MaxLevel = 32
+
+	// promotion is the inverse probability that a node reaches the next level.
+	promotion = 4
+)
+
+// List keeps distinct elements sorted by compare. Every link records how
+// many elements it skips, which turns the position of an element into a
+// logarithmic query as well.
+type List[T any] struct {
+	compare func(a, b T) int
+	head    *node[T]
+	level   int
+	length  int
+}
+
+type node[T any] struct {
+	value T
+	next  []link[T]
+}
+
+// link points to the next node on one level. span counts the elements
+// between the two nodes, the target included; a link to the end of the list
+// spans the rest of it.
+type link[T any] struct {
+	to   *node[T]
+	span int
+}
+
+// New returns an empty list ordered by compare, which returns a negative
+// number, zero or a positive number when a sorts before, equal to or after b.
+func New[T any](compare func(a, b T) int) *List[T] {
+	return &List[T]{
+		compare: compare,
+		head:    &node[T]{next: make([]link[T], MaxLevel)},
+		level:   1,
+	}
+}
+
+// Len returns the number of elements.
+func (l *List[T]) Len() int {
+	return l.length
+}
+
+// Insert adds v and reports whether it was not present yet.
+func (l *List[T]) Insert(v T) bool {
+	var (
+		update [MaxLevel]*node[T]
+		rank   [MaxLevel]int
+	)
+	x := l.findPredecessors(v, &update, &rank)
+
+	if next := x.next[0].to; next != nil && l.compare(next.value, v) == 0 {
+		return false
+	}
+
+	level := randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			update[i] = l.head
+			rank[i] = 0
+			l.head.next[i].span = l.length
+		}
+		l.level = level
+	}
+
+	n := &node[T]{value: v, next: make([]link[T], level)}
+	for i := range level {
+		before := &update[i].next[i]
+		n.next[i] = link[T]{to: before.to, span: before.span - (rank[0] - rank[i])}
+		*before = link[T]{to: n, span: rank[0] - rank[i] + 1}
+	}
+	for i := level; i < l.level; i++ {
+		update[i].next[i].span++
+	}
+	l.length++
+
+	return true
+}
+
+// Delete removes v and reports whether it was present.
+func (l *List[T]) Delete(v T) bool {
+	var update [MaxLevel]*node[T]
+	x := l.findPredecessors(v, &update, nil)
+
+	n := x.next[0].to
+	if n == nil || l.compare(n.value, v) != 0 {
+		return false
+	}
+
+	for i := range l.level {
+		before := &update[i].next[i]
+		if before.to == n {
+			*before = link[T]{to: n.next[i].to, span: before.span + n.next[i].span - 1}
+		} else {
+			before.span--
+		}
+	}
+	for l.level > 1 && l.head.next[l.level-1].to == nil {
+		l.level--
+	}
+	l.length--
+
+	return true
+}
+
+// Contains reports whether v is in the list.
+func (l *List[T]) Contains(v T) bool {
+	n := l.seek(v)
+	return n != nil && l.compare(n.value, v) == 0
+}
+
+// Rank returns the number of elements that sort before v, which is the
+// position of v when it is in the list.
+func (l *List[T]) Rank(v T) int {
+	var (
+		update [MaxLevel]*node[T]
+		rank   [MaxLevel]int
+	)
+	l.findPredecessors(v, &update, &rank)
+	return rank[0]
+}
+
+// All yields the elements in order.
+func (l *List[T]) All() iter.Seq[T] {
+	return l.iterate(l.head.next[0].to)
+}
+
+// From yields the elements that do not sort before v, in order.
+func (l *List[T]) From(v T) iter.Seq[T] {
+	return l.iterate(l.seek(v))
+}
+
+// FromRank yields the elements from position rank on, in order.
+func (l *List[T]) FromRank(rank int) iter.Seq[T] {
+	if rank < 0 || rank >= l.length {
+		return l.iterate(nil)
+	}
+
+	x, traversed := l.head, 0
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i].to != nil && traversed+x.next[i].span <= rank+1 {
+			traversed += x.next[i].span
+			x = x.next[i].to
+		}
+	}
+	return l.iterate(x)
+}
+
+func (l *List[T]) iterate(start *node[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for n := start; n != nil; n = n.next[0].to {
+			if !yield(n.value) {
+				return
+			}
+		}
+	}
+}
+
+// seek returns the first node that does not sort before v.
+func (l *List[T]) seek(v T) *node[T] {
+	var update [MaxLevel]*node[T]
+	return l.findPredecessors(v, &update, nil).next[0].to
+}
+
+// findPredecessors records, for every level, the last node that sorts
+// before v and, when rank is not nil, the position that node follows, and
+// returns the predecessor on the bottom level.
+func (l *List[T]) findPredecessors(v T, update *[MaxLevel]*node[T], rank *[MaxLevel]int) *node[T] {
+	x, traversed := l.head, 0
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i].to != nil && l.compare(x.next[i].to.value, v) < 0 {
+			traversed += x.next[i].span
+			x = x.next[i].to
+		}
+		update[i] = x
+		if rank != nil {
+			rank[i] = traversed
+		}
+	}
+	return x
+}
+
+func randomLevel() int {
+	level := 1
+	for level < MaxLevel && rand.IntN(promotion) == 0 { //nolint:gosec // level choice does not need a secure source
+		level++
+	}
+	return level
+}
@@ -0,0 +1,123 @@
+package skiplist_test
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/skiplist"
+)
+
+func TestListInsertDelete(t *testing.T) {
+	t.Parallel()
+
+	l := skiplist.New(cmp.Compare[string])
+
+	assert.True(t, l.Insert("b"))
+	assert.True(t, l.Insert("a"))
+	assert.True(t, l.Insert("c"))
+	assert.False(t, l.Insert("b"))
+	assert.Equal(t, 3, l.Len())
+
+	assert.True(t, l.Contains("a"))
+	assert.False(t, l.Contains("d"))
+
+	assert.True(t, l.Delete("b"))
+	assert.False(t, l.Delete("b"))
+	assert.Equal(t, 2, l.Len())
+
+	assert.Equal(t, []string{"a", "c"}, slices.Collect(l.All()))
+}
+
+func TestListFrom(t *testing.T) {
+	t.Parallel()
+
+	l := skiplist.New(cmp.Compare[string])
+	for _, v := range []string{"apple", "apricot", "banana", "cherry"} {
+		l.Insert(v)
+	}
+
+	tests := []struct {
+		name string
+		from string
+		want []string
+	}{
+		{name: "from the start", from: "", want: []string{"apple", "apricot", "banana", "cherry"}},
+		{name: "existing element", from: "apricot", want: []string{"apricot", "banana", "cherry"}},
+		{name: "between elements", from: "b", want: []string{"banana", "cherry"}},
+		{name: "past the end", from: "d", want: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, slices.Collect(l.From(tc.from)))
+		})
+	}
+}
+
+func TestListMatchesSortedSlice(t *testing.T) {
+	t.Parallel()
+
+	l := skiplist.New(cmp.Compare[int])
+	present := make(map[int]bool)
+
+	rng := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // deterministic test data
+	for range 5000 {
+		v := rng.IntN(1000)
+		if rng.IntN(3) == 0 {
+			assert.Equal(t, present[v], l.Delete(v), strconv.Itoa(v))
+			delete(present, v)
+		} else {
+			assert.Equal(t, !present[v], l.Insert(v), strconv.Itoa(v))
+			present[v] = true
+		}
+	}
+
+	want := make([]int, 0, len(present))
+	for v := range present {
+		want = append(want, v)
+	}
+	slices.Sort(want)
+
+	require.Equal(t, len(want), l.Len())
+	assert.Equal(t, want, slices.Collect(l.All()))
+
+	// ranks and positions agree with the sorted slice
+	for v := range 1000 {
+		rank, _ := slices.BinarySearch(want, v)
+		require.Equal(t, rank, l.Rank(v), strconv.Itoa(v))
+	}
+	for rank := range want {
+		got := slices.Collect(l.FromRank(rank))
+		require.Equal(t, want[rank:], got, strconv.Itoa(rank))
+	}
+}
+
+func TestListRank(t *testing.T) {
+	t.Parallel()
+
+	l := skiplist.New(cmp.Compare[string])
+	for _, v := range []string{"d", "b", "a", "c"} {
+		l.Insert(v)
+	}
+
+	assert.Equal(t, 0, l.Rank("a"))
+	assert.Equal(t, 2, l.Rank("c"))
+	assert.Equal(t, 2, l.Rank("bb"), "a missing element ranks where it would be inserted")
+	assert.Equal(t, 4, l.Rank("z"))
+
+	assert.Equal(t, []string{"c", "d"}, slices.Collect(l.FromRank(2)))
+	assert.Empty(t, slices.Collect(l.FromRank(4)))
+	assert.Empty(t, slices.Collect(l.FromRank(-1)))
+
+	l.Delete("b")
+	assert.Equal(t, 1, l.Rank("c"))
+	assert.Equal(t, []string{"c", "d"}, slices.Collect(l.FromRank(1)))
+}
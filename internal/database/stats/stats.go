@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Stats is a point-in-time snapshot of engine counters exposed through the
+// STATS command.
+type Stats struct {
+	Keys           int
+	UsedMemory     int64
+	MaxMemory      int64
+	EvictionPolicy string
+	EvictedKeys    uint64
+	ExpiredKeys    uint64
+
+	// MemorySaved is how many bytes value compression spares in the values
+	// held in memory; DiskSaved is how many it spared in the WAL records and
+	// snapshots written since the start.
+	MemorySaved int64
+	DiskSaved   int64
+}
+
+// String renders the counters as space separated name=value pairs so that
+// they fit on a single response line.
+func (s Stats) String() string {
+	fields := []string{
+		"keys=" + strconv.Itoa(s.Keys),
+		"used_memory=" + strconv.FormatInt(s.UsedMemory, 10),
+		"max_memory=" + strconv.FormatInt(s.MaxMemory, 10),
+		"eviction_policy=" + s.EvictionPolicy,
+		"evicted_keys=" + strconv.FormatUint(s.EvictedKeys, 10),
+		"expired_keys=" + strconv.FormatUint(s.ExpiredKeys, 10),
+		"memory_saved=" + strconv.FormatInt(s.MemorySaved, 10),
+		"disk_saved=" + strconv.FormatInt(s.DiskSaved, 10),
+	}
+	return strings.Join(fields, " ")
+}
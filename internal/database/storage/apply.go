@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/storage/wal"
+)
+
+// Apply executes a logged record against the engine without logging it
+// again. It is used by WAL recovery and by replicas applying the master's log.
+// Misses are ignored: the record only describes the state to reach, and the
+// memory limit is lifted: the keys evicted by the logged writes are logged too.
+// A transaction record is applied atomically.
+func (s *Storage) Apply(ctx context.Context, record wal.Record) error {
+	defer s.liftLimit()()
+
+	if record.Command != command.CommandExec {
+		defer s.shared(ctx)()
+		return ignoreMiss(s.applyRecord(ctx, record))
+	}
+
+	records, err := decodeTransaction(record)
+	if err != nil {
+		return err
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	for _, record := range records {
+		if err := ignoreMiss(s.applyRecord(ctx, record)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ignoreMiss(err error) error {
+	if errors.Is(err, dberrors.ErrNotFound) || errors.Is(err, dberrors.ErrNoExpiry) {
+		return nil
+	}
+	return err
+}
+
+func (s *Storage) applyRecord(ctx context.Context, record wal.Record) error {
+	args := record.Args
+
+	switch {
+	case record.Command == command.CommandSet && len(args) == command.CommandSetQ:
+		return s.commandStorage.Set(ctx, args[0], args[1])
+	case record.Command == command.CommandSet && len(args) == command.CommandSetQ+2 && args[2] == command.OptionPXAT:
+		expireAt, err := parseDeadline(args[3])
+		if err != nil {
+			return unknownRecord(record)
+		}
+		return s.commandStorage.SetWithExpiry(ctx, args[0], args[1], expireAt)
+	case record.Command == command.CommandDel && len(args) == command.CommandDelQ:
+		return s.commandStorage.Del(ctx, args[0])
+	case record.Command == command.CommandPExpireAt && len(args) == 2:
+		expireAt, err := parseDeadline(args[1])
+		if err != nil {
+			return unknownRecord(record)
+		}
+		return s.commandStorage.Expire(ctx, args[0], expireAt)
+	case record.Command == command.CommandPersist && len(args) == command.CommandPersistQ:
+		return s.commandStorage.Persist(ctx, args[0])
+	case record.Command == command.CommandMSet && len(args) >= command.CommandMSetMinQ && len(args)%2 == 0:
+		pairs := make([]kv.Pair, 0, len(args)/2)
+		for i := 0; i < len(args); i += 2 {
+			pairs = append(pairs, kv.Pair{Key: args[i], Value: args[i+1]})
+		}
+		return s.commandStorage.MSet(ctx, pairs)
+	case record.Command == command.CommandMDel && len(args) >= command.CommandMDelMinQ:
+		_, err := s.commandStorage.MDel(ctx, args)
+		return err
+	case record.Command == command.CommandHSet && len(args) >= command.CommandHSetMinQ && len(args)%2 == 1:
+		fields := make([]kv.Pair, 0, len(args)/2)
+		for i := 1; i < len(args); i += 2 {
+			fields = append(fields, kv.Pair{Key: args[i], Value: args[i+1]})
+		}
+		_, err := s.commandStorage.HSet(ctx, args[0], fields)
+		return err
+	case record.Command == command.CommandHDel && len(args) >= command.CommandHDelMinQ:
+		_, err := s.commandStorage.HDel(ctx, args[0], args[1:])
+		return err
+	case (record.Command == command.CommandLPush || record.Command == command.CommandRPush) &&
+		len(args) >= command.CommandPushMinQ:
+		end := kv.Left
+		if record.Command == command.CommandRPush {
+			end = kv.Right
+		}
+		_, err := s.commandStorage.Push(ctx, args[0], args[1:], end)
+		return err
+	case (record.Command == command.CommandLPop || record.Command == command.CommandRPop) &&
+		len(args) == command.CommandPopQ:
+		end := kv.Left
+		if record.Command == command.CommandRPop {
+			end = kv.Right
+		}
+		_, err := s.commandStorage.Pop(ctx, args[0], end)
+		return err
+	case record.Command == command.CommandSAdd && len(args) >= command.CommandSAddMinQ:
+		_, err := s.commandStorage.SAdd(ctx, args[0], args[1:])
+		return err
+	case record.Command == command.CommandSRem && len(args) >= command.CommandSRemMinQ:
+		_, err := s.commandStorage.SRem(ctx, args[0], args[1:])
+		return err
+	case record.Command == command.CommandZAdd && len(args) >= command.CommandZAddMinQ && len(args)%2 == 1:
+		members := make([]kv.ScoredMember, 0, len(args)/2)
+		for i := 1; i < len(args); i += 2 {
+			score, err := kv.ParseScore(args[i])
+			if err != nil {
+				return unknownRecord(record)
+			}
+			members = append(members, kv.ScoredMember{Member: args[i+1], Score: score})
+		}
+		_, err := s.commandStorage.ZAdd(ctx, args[0], members)
+		return err
+	case record.Command == command.CommandZRem && len(args) >= command.CommandZRemMinQ:
+		_, err := s.commandStorage.ZRem(ctx, args[0], args[1:])
+		return err
+	default:
+		return unknownRecord(record)
+	}
+}
+
+func unknownRecord(record wal.Record) error {
+	return fmt.Errorf("%w: lsn %d: %s", ErrUnknownRecord, record.LSN, record.Command)
+}
+
+// formatDeadline encodes an absolute expiry as unix milliseconds.
+func formatDeadline(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func parseDeadline(raw string) (time.Time, error) {
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"lesson1/internal/database/compression"
 	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
 )
 
 type Engine struct {
@@ -21,8 +25,82 @@ type QueryEngine struct {
 	hashTable *hashtable.HashTable
 }
 
-func NewEngine(log *slog.Logger) *Engine {
-	hashTable := hashtable.NewHashTable()
+type Option func(*options)
+
+type options struct {
+	shardCount int
+	now        func() time.Time
+	maxMemory  int64
+	policy     hashtable.EvictionPolicy
+	ordered    bool
+	listener   func(kv.Event)
+	compressor compression.Compressor
+}
+
+// WithShardCount sets the number of independently locked shards of the
+// underlying hash table. Non-positive values fall back to the default.
+func WithShardCount(count int) Option {
+	return func(o *options) {
+		o.shardCount = count
+	}
+}
+
+// WithClock replaces time.Now as the clock used to expire keys.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithMaxMemory caps the accounted memory of the stored keys and selects what
+// happens when a write would exceed it. A non-positive limit means unlimited.
+func WithMaxMemory(limit int64, policy hashtable.EvictionPolicy) Option {
+	return func(o *options) {
+		o.maxMemory = limit
+		o.policy = policy
+	}
+}
+
+// WithOrderedIndex keeps the keys in lexicographic order so that the engine
+// answers Range and CountPrefix.
+func WithOrderedIndex() Option {
+	return func(o *options) {
+		o.ordered = true
+	}
+}
+
+// WithListener calls fn with every keyspace event: keys set, deleted,
+// expired and evicted. fn must not block, see notify.Notifier.
+func WithListener(fn func(kv.Event)) Option {
+	return func(o *options) {
+		o.listener = fn
+	}
+}
+
+// WithCompression compresses the string values the compressor selects.
+func WithCompression(c compression.Compressor) Option {
+	return func(o *options) {
+		o.compressor = c
+	}
+}
+
+func NewEngine(log *slog.Logger, opts ...Option) *Engine {
+	o := options{shardCount: hashtable.DefaultShardCount, policy: hashtable.PolicyNoEviction}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tableOpts := []hashtable.Option{
+		hashtable.WithClock(o.now),
+		hashtable.WithMaxMemory(o.maxMemory, o.policy),
+		hashtable.WithListener(o.listener),
+		hashtable.WithCompression(o.compressor),
+	}
+	if o.ordered {
+		tableOpts = append(tableOpts, hashtable.WithOrderedIndex())
+	}
+
+	hashTable := hashtable.NewHashTable(o.shardCount, tableOpts...)
 	return &Engine{
 		log:           log,
 		commandEngine: CommandEngine{hashTable: hashTable},
@@ -42,6 +120,60 @@ func (e *Engine) Set(ctx context.Context, key, value string) error {
 	return nil
 }
 
+func (e *Engine) SetWithExpiry(ctx context.Context, key, value string, expireAt time.Time) error {
+	const op = "engine.SetWithExpiry"
+	_ = ctx
+
+	err := e.commandEngine.hashTable.SetWithExpiry(key, value, expireAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetIf atomically stores the value when cond holds and returns the previous
+// state of the key.
+func (e *Engine) SetIf(ctx context.Context, key, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error) {
+	const op = "engine.SetIf"
+	_ = ctx
+
+	previous, err := e.commandEngine.hashTable.SetIf(key, value, expireAt, cond)
+	if err != nil {
+		return previous, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return previous, nil
+}
+
+// IncrBy atomically adds delta to the integer stored at key and returns the
+// result and the unchanged expiry of the key.
+func (e *Engine) IncrBy(ctx context.Context, key string, delta int64) (int64, time.Time, error) {
+	const op = "engine.IncrBy"
+	_ = ctx
+
+	result, expireAt, err := e.commandEngine.hashTable.IncrBy(key, delta)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, expireAt, nil
+}
+
+// IncrByFloat atomically adds delta to the number stored at key and returns
+// the result as stored and the unchanged expiry of the key.
+func (e *Engine) IncrByFloat(ctx context.Context, key string, delta float64) (string, time.Time, error) {
+	const op = "engine.IncrByFloat"
+	_ = ctx
+
+	result, expireAt, err := e.commandEngine.hashTable.IncrByFloat(key, delta)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, expireAt, nil
+}
+
 func (e *Engine) Get(ctx context.Context, key string) (string, error) {
 	const op = "engine.Get"
 	_ = ctx
@@ -64,3 +196,417 @@ func (e *Engine) Del(ctx context.Context, key string) error {
 
 	return nil
 }
+
+// MSet stores all pairs atomically.
+func (e *Engine) MSet(ctx context.Context, pairs []kv.Pair) error {
+	const op = "engine.MSet"
+	_ = ctx
+
+	err := e.commandEngine.hashTable.MSet(pairs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// MGet reads all keys from one consistent snapshot.
+func (e *Engine) MGet(ctx context.Context, keys []string) ([]kv.Lookup, error) {
+	_ = ctx
+
+	return e.queryEngine.hashTable.MGet(keys), nil
+}
+
+// MDel deletes all keys atomically and returns how many existed.
+func (e *Engine) MDel(ctx context.Context, keys []string) (int, error) {
+	_ = ctx
+
+	return e.commandEngine.hashTable.MDel(keys), nil
+}
+
+// HSet sets fields of the hash stored at key and returns how many were added.
+func (e *Engine) HSet(ctx context.Context, key string, fields []kv.Pair) (int, error) {
+	const op = "engine.HSet"
+	_ = ctx
+
+	added, err := e.commandEngine.hashTable.HSet(key, fields)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return added, nil
+}
+
+// HDel removes fields from the hash stored at key and returns how many existed.
+func (e *Engine) HDel(ctx context.Context, key string, fields []string) (int, error) {
+	const op = "engine.HDel"
+	_ = ctx
+
+	deleted, err := e.commandEngine.hashTable.HDel(key, fields)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
+
+func (e *Engine) HGet(ctx context.Context, key, field string) (string, error) {
+	const op = "engine.HGet"
+	_ = ctx
+
+	value, err := e.queryEngine.hashTable.HGet(key, field)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return value, nil
+}
+
+// HGetAll returns the fields of the hash stored at key sorted by name.
+func (e *Engine) HGetAll(ctx context.Context, key string) ([]kv.Pair, error) {
+	const op = "engine.HGetAll"
+	_ = ctx
+
+	fields, err := e.queryEngine.hashTable.HGetAll(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return fields, nil
+}
+
+func (e *Engine) HLen(ctx context.Context, key string) (int, error) {
+	const op = "engine.HLen"
+	_ = ctx
+
+	n, err := e.queryEngine.hashTable.HLen(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+func (e *Engine) HExists(ctx context.Context, key, field string) (bool, error) {
+	const op = "engine.HExists"
+	_ = ctx
+
+	ok, err := e.queryEngine.hashTable.HExists(key, field)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// Push adds values to an end of the list stored at key and returns its length.
+func (e *Engine) Push(ctx context.Context, key string, values []string, end kv.End) (int, error) {
+	const op = "engine.Push"
+	_ = ctx
+
+	n, err := e.commandEngine.hashTable.Push(key, values, end)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+// Pop removes and returns the item at an end of the list stored at key.
+func (e *Engine) Pop(ctx context.Context, key string, end kv.End) (string, error) {
+	const op = "engine.Pop"
+	_ = ctx
+
+	value, err := e.commandEngine.hashTable.Pop(key, end)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return value, nil
+}
+
+func (e *Engine) LRange(ctx context.Context, key string, start, stop int) ([]string, error) {
+	const op = "engine.LRange"
+	_ = ctx
+
+	items, err := e.queryEngine.hashTable.LRange(key, start, stop)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return items, nil
+}
+
+func (e *Engine) LLen(ctx context.Context, key string) (int, error) {
+	const op = "engine.LLen"
+	_ = ctx
+
+	n, err := e.queryEngine.hashTable.LLen(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+// SAdd adds members to the set stored at key and returns how many were new.
+func (e *Engine) SAdd(ctx context.Context, key string, members []string) (int, error) {
+	const op = "engine.SAdd"
+	_ = ctx
+
+	added, err := e.commandEngine.hashTable.SAdd(key, members)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return added, nil
+}
+
+// SRem removes members from the set stored at key and returns how many were
+// members.
+func (e *Engine) SRem(ctx context.Context, key string, members []string) (int, error) {
+	const op = "engine.SRem"
+	_ = ctx
+
+	removed, err := e.commandEngine.hashTable.SRem(key, members)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return removed, nil
+}
+
+// SMembers returns the members of the set stored at key in lexicographic order.
+func (e *Engine) SMembers(ctx context.Context, key string) ([]string, error) {
+	const op = "engine.SMembers"
+	_ = ctx
+
+	members, err := e.queryEngine.hashTable.SMembers(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+func (e *Engine) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	const op = "engine.SIsMember"
+	_ = ctx
+
+	ok, err := e.queryEngine.hashTable.SIsMember(key, member)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// ZAdd sets scores in the sorted set stored at key and returns how many
+// members were new.
+func (e *Engine) ZAdd(ctx context.Context, key string, members []kv.ScoredMember) (int, error) {
+	const op = "engine.ZAdd"
+	_ = ctx
+
+	added, err := e.commandEngine.hashTable.ZAdd(key, members)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return added, nil
+}
+
+// ZRem removes members from the sorted set stored at key and returns how many
+// were members.
+func (e *Engine) ZRem(ctx context.Context, key string, members []string) (int, error) {
+	const op = "engine.ZRem"
+	_ = ctx
+
+	removed, err := e.commandEngine.hashTable.ZRem(key, members)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return removed, nil
+}
+
+func (e *Engine) ZScore(ctx context.Context, key, member string) (float64, error) {
+	const op = "engine.ZScore"
+	_ = ctx
+
+	score, err := e.queryEngine.hashTable.ZScore(key, member)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return score, nil
+}
+
+// ZRank returns the position of a member in score order.
+func (e *Engine) ZRank(ctx context.Context, key, member string) (int, error) {
+	const op = "engine.ZRank"
+	_ = ctx
+
+	rank, err := e.queryEngine.hashTable.ZRank(key, member)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return rank, nil
+}
+
+// SInter returns the members common to the sets stored at keys.
+func (e *Engine) SInter(ctx context.Context, keys []string) ([]string, error) {
+	const op = "engine.SInter"
+	_ = ctx
+
+	members, err := e.queryEngine.hashTable.SInter(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// SUnion returns the members of any of the sets stored at keys.
+func (e *Engine) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	const op = "engine.SUnion"
+	_ = ctx
+
+	members, err := e.queryEngine.hashTable.SUnion(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// ZRange returns the members of the sorted set stored at key between two
+// positions in score order.
+func (e *Engine) ZRange(ctx context.Context, key string, start, stop int) ([]kv.ScoredMember, error) {
+	const op = "engine.ZRange"
+	_ = ctx
+
+	members, err := e.queryEngine.hashTable.ZRange(key, start, stop)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// ZRangeByScore returns up to limit members of the sorted set stored at key
+// whose scores lie between lower and upper.
+func (e *Engine) ZRangeByScore(ctx context.Context, key string, lower, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error) {
+	const op = "engine.ZRangeByScore"
+	_ = ctx
+
+	members, err := e.queryEngine.hashTable.ZRangeByScore(key, lower, upper, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+func (e *Engine) Expire(ctx context.Context, key string, expireAt time.Time) error {
+	const op = "engine.Expire"
+	_ = ctx
+
+	err := e.commandEngine.hashTable.Expire(key, expireAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (e *Engine) Persist(ctx context.Context, key string) error {
+	const op = "engine.Persist"
+	_ = ctx
+
+	err := e.commandEngine.hashTable.Persist(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (e *Engine) TTL(ctx context.Context, key string) (time.Duration, error) {
+	const op = "engine.TTL"
+	_ = ctx
+
+	ttl, err := e.queryEngine.hashTable.TTL(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return ttl, nil
+}
+
+// Scan returns the keys of the next buckets of a cursor walk over the table
+// and the cursor to continue from, 0 when the walk is complete.
+func (e *Engine) Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error) {
+	const op = "engine.Scan"
+	_ = ctx
+
+	keys, next, err := e.queryEngine.hashTable.Scan(cursor, count)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, next, nil
+}
+
+// Range returns up to limit keys in [start, end) in lexicographic order and
+// the key the next page starts from.
+func (e *Engine) Range(ctx context.Context, start, end string, limit int) ([]string, string, error) {
+	const op = "engine.Range"
+	_ = ctx
+
+	keys, next, err := e.queryEngine.hashTable.Range(start, end, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, next, nil
+}
+
+// CountPrefix returns the number of keys that start with prefix.
+func (e *Engine) CountPrefix(ctx context.Context, prefix string) (int, error) {
+	const op = "engine.CountPrefix"
+	_ = ctx
+
+	count, err := e.queryEngine.hashTable.CountPrefix(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// Version returns the write version of a key, 0 when it does not exist.
+func (e *Engine) Version(ctx context.Context, key string) (uint64, error) {
+	_ = ctx
+
+	return e.queryEngine.hashTable.Version(key), nil
+}
+
+// OnEvict calls fn with every key evicted by a write, see
+// hashtable.HashTable.OnEvict.
+func (e *Engine) OnEvict(fn func(key string)) {
+	e.commandEngine.hashTable.OnEvict(fn)
+}
+
+// LiftLimit suspends the memory limit until restore is called, see
+// hashtable.HashTable.LiftLimit.
+func (e *Engine) LiftLimit() (restore func()) {
+	return e.commandEngine.hashTable.LiftLimit()
+}
+
+func (e *Engine) Stats(ctx context.Context) (stats.Stats, error) {
+	_ = ctx
+
+	return e.queryEngine.hashTable.Stats(), nil
+}
+
+// Snapshot returns a point-in-time view of every key, see
+// hashtable.HashTable.Snapshot.
+func (e *Engine) Snapshot(ctx context.Context) (kv.View, error) {
+	_ = ctx
+
+	return e.queryEngine.hashTable.Snapshot(), nil
+}
+
+// Flush removes every key.
+func (e *Engine) Flush(ctx context.Context) error {
+	_ = ctx
+
+	e.commandEngine.hashTable.Flush()
+	return nil
+}
+
+// Len returns the number of stored keys, including expired keys that have
+// not been removed yet.
+func (e *Engine) Len() int {
+	return e.queryEngine.hashTable.Len()
+}
+
+// DeleteExpired removes a sample of expired keys and reports how many were removed.
+func (e *Engine) DeleteExpired(sampleSize int) int {
+	return e.commandEngine.hashTable.DeleteExpired(sampleSize)
+}
@@ -3,12 +3,14 @@ package engine_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"lesson1/internal/database/dberrors"
 	"lesson1/internal/database/storage/engine"
+	"lesson1/internal/lib/clock/fakeclock"
 	"lesson1/internal/lib/logger/slogdiscard"
 )
 
@@ -74,9 +76,8 @@ func TestEngineDel(t *testing.T) {
 			key:   "foo",
 		},
 		{
-			name:    "del not found",
-			key:     "missing",
-			wantErr: dberrors.ErrNotFound,
+			name: "del missing key",
+			key:  "missing",
 		},
 	}
 
@@ -103,3 +104,24 @@ func TestEngineDel(t *testing.T) {
 		})
 	}
 }
+
+func TestEngineTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	e := engine.NewEngine(slogdiscard.NewDiscardLogger(), engine.WithClock(clock.Now))
+
+	require.NoError(t, e.SetWithExpiry(ctx, "k", "v", clock.Now().Add(30*time.Second)))
+
+	ttl, err := e.TTL(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, ttl)
+
+	require.NoError(t, e.Expire(ctx, "k", clock.Now().Add(time.Second)))
+	clock.Advance(time.Second)
+
+	_, err = e.Get(ctx, "k")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	require.ErrorIs(t, e.Persist(ctx, "k"), dberrors.ErrNotFound)
+}
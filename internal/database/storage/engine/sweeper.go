@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	DefaultSweepInterval   = 100 * time.Millisecond
+	DefaultSweepSampleSize = 20
+)
+
+// Sweeper periodically evicts expired keys that nobody reads, so that lazy
+// expiration on access is not the only way memory gets reclaimed.
+type Sweeper struct {
+	log        *slog.Logger
+	engine     *Engine
+	interval   time.Duration
+	sampleSize int
+}
+
+func NewSweeper(log *slog.Logger, engine *Engine, interval time.Duration, sampleSize int) *Sweeper {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	if sampleSize <= 0 {
+		sampleSize = DefaultSweepSampleSize
+	}
+
+	return &Sweeper{
+		log:        log,
+		engine:     engine,
+		interval:   interval,
+		sampleSize: sampleSize,
+	}
+}
+
+// Start runs the sweeper until parent is cancelled. The error channel is
+// closed once the sweeper goroutine has exited.
+func (s *Sweeper) Start(parent context.Context) (context.Context, <-chan error) {
+	const op = "engine.Sweeper.Start"
+
+	ctx, cancel := context.WithCancel(parent)
+	errCh := make(chan error)
+
+	go func() {
+		defer close(errCh)
+		defer cancel()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.log.Info("sweeper stopped", slog.String("operation", op))
+				return
+			case <-ticker.C:
+				if removed := s.engine.DeleteExpired(s.sampleSize); removed > 0 {
+					s.log.Debug("expired keys removed", slog.String("operation", op), slog.Int("count", removed))
+				}
+			}
+		}
+	}()
+
+	return ctx, errCh
+}
@@ -0,0 +1,50 @@
+package engine_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/storage/engine"
+	"lesson1/internal/lib/clock/fakeclock"
+	"lesson1/internal/lib/logger/slogdiscard"
+)
+
+func TestSweeperRemovesExpiredKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	e := engine.NewEngine(logger, engine.WithClock(clock.Now))
+
+	for i := range 200 {
+		key := strconv.Itoa(i)
+		if i%4 == 0 {
+			require.NoError(t, e.Set(ctx, key, "v"))
+			continue
+		}
+		require.NoError(t, e.SetWithExpiry(ctx, key, "v", clock.Now().Add(time.Minute)))
+	}
+
+	sweeperCtx, cancel := context.WithCancel(ctx)
+	_, errCh := engine.NewSweeper(logger, e, time.Millisecond, 5).Start(sweeperCtx)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 200, e.Len(), "nothing is expired yet")
+
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return e.Len() == 50 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case _, ok := <-errCh:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("sweeper did not stop after cancellation")
+	}
+}
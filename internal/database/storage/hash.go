@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+)
+
+// HSet sets fields of the hash stored at key, creating it when needed, and
+// returns how many fields were added. It is logged as the HSET it was.
+func (s *Storage) HSet(ctx context.Context, key string, fields []kv.Pair) (int, error) {
+	const op = "storage.HSet"
+
+	args := make([]string, 0, 1+2*len(fields))
+	args = append(args, key)
+	for _, field := range fields {
+		args = append(args, field.Key, field.Value)
+	}
+
+	var added int
+	err := s.mutate(ctx, func() error {
+		var err error
+		added, err = s.commandStorage.HSet(ctx, key, fields)
+		return err
+	}, command.CommandHSet, args...)
+	if err != nil {
+		s.logKeyError("hset", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return added, nil
+}
+
+// HDel removes fields from the hash stored at key and returns how many
+// existed; the key is deleted with its last field.
+func (s *Storage) HDel(ctx context.Context, key string, fields []string) (int, error) {
+	const op = "storage.HDel"
+
+	var deleted int
+	err := s.mutate(ctx, func() error {
+		var err error
+		deleted, err = s.commandStorage.HDel(ctx, key, fields)
+		return err
+	}, command.CommandHDel, append([]string{key}, fields...)...)
+	if err != nil {
+		s.logKeyError("hdel", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
+
+// HGet fails with dberrors.ErrNotFound when the key or the field is missing.
+func (s *Storage) HGet(ctx context.Context, key, field string) (string, error) {
+	const op = "storage.HGet"
+
+	defer s.shared(ctx)()
+
+	value, err := s.queryStorage.HGet(ctx, key, field)
+	if err != nil {
+		s.logKeyError("hget", key, err)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return value, nil
+}
+
+// HGetAll returns the fields of the hash stored at key sorted by name.
+func (s *Storage) HGetAll(ctx context.Context, key string) ([]kv.Pair, error) {
+	const op = "storage.HGetAll"
+
+	defer s.shared(ctx)()
+
+	fields, err := s.queryStorage.HGetAll(ctx, key)
+	if err != nil {
+		s.logKeyError("hgetall", key, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return fields, nil
+}
+
+func (s *Storage) HLen(ctx context.Context, key string) (int, error) {
+	const op = "storage.HLen"
+
+	defer s.shared(ctx)()
+
+	n, err := s.queryStorage.HLen(ctx, key)
+	if err != nil {
+		s.logKeyError("hlen", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+func (s *Storage) HExists(ctx context.Context, key, field string) (bool, error) {
+	const op = "storage.HExists"
+
+	defer s.shared(ctx)()
+
+	ok, err := s.queryStorage.HExists(ctx, key, field)
+	if err != nil {
+		s.logKeyError("hexists", key, err)
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+// Push adds values to an end of the list stored at key, creating it when
+// needed, and returns the length of the list. Pops blocked on the key are
+// woken up.
+func (s *Storage) Push(ctx context.Context, key string, values []string, end kv.End) (int, error) {
+	const op = "storage.Push"
+
+	var n int
+	err := s.mutate(ctx, func() error {
+		var err error
+		n, err = s.commandStorage.Push(ctx, key, values, end)
+		return err
+	}, pushCommand(end), append([]string{key}, values...)...)
+	if err != nil {
+		s.logKeyError("push", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.waiters.notify(key)
+	return n, nil
+}
+
+// Pop removes and returns the item at an end of the list stored at key. It
+// fails with dberrors.ErrNotFound when the list does not exist.
+func (s *Storage) Pop(ctx context.Context, key string, end kv.End) (string, error) {
+	const op = "storage.Pop"
+
+	value, err := s.pop(ctx, key, end)
+	if err != nil {
+		s.logKeyError("pop", key, err)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return value, nil
+}
+
+// BPop pops from the first of keys that holds a list, waiting for a push
+// when none does. It gives up with dberrors.ErrNotFound once timeout has
+// passed, a zero timeout waiting indefinitely, and with the context error
+// when ctx is done, such as when the client goes away. Inside a transaction
+// it does not wait. When several pops wait on a key, a push wakes them all
+// and the first to pop gets the item while the others keep waiting.
+func (s *Storage) BPop(ctx context.Context, keys []string, end kv.End, timeout time.Duration) (kv.Pair, error) {
+	const op = "storage.BPop"
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		// watch before trying so that a push in between is not missed
+		wake, stop := s.waiters.watch(keys)
+
+		popped, err := s.popFirst(ctx, keys, end)
+		if err == nil || !errors.Is(err, dberrors.ErrNotFound) || transactionFrom(ctx) != nil {
+			stop()
+			if err != nil {
+				s.log.Info("blocking pop failed", slog.Any("keys", keys), slog.Any("err", err))
+				return kv.Pair{}, fmt.Errorf("%s: %w", op, err)
+			}
+			return popped, nil
+		}
+
+		select {
+		case <-wake:
+			stop()
+		case <-expired:
+			stop()
+			return kv.Pair{}, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+		case <-ctx.Done():
+			stop()
+			s.log.Info("blocking pop cancelled", slog.Any("keys", keys), slog.Any("reason", ctx.Err()))
+			return kv.Pair{}, fmt.Errorf("%s: %w", op, ctx.Err())
+		}
+	}
+}
+
+// popFirst pops from the first of keys that holds a list and fails with
+// dberrors.ErrNotFound when none does.
+func (s *Storage) popFirst(ctx context.Context, keys []string, end kv.End) (kv.Pair, error) {
+	for _, key := range keys {
+		value, err := s.pop(ctx, key, end)
+		if err == nil {
+			return kv.Pair{Key: key, Value: value}, nil
+		}
+		if !errors.Is(err, dberrors.ErrNotFound) {
+			return kv.Pair{}, err
+		}
+	}
+	return kv.Pair{}, dberrors.ErrNotFound
+}
+
+// pop is logged as the LPOP or RPOP of the key.
+func (s *Storage) pop(ctx context.Context, key string, end kv.End) (string, error) {
+	var value string
+	err := s.mutate(ctx, func() error {
+		var err error
+		value, err = s.commandStorage.Pop(ctx, key, end)
+		return err
+	}, popCommand(end), key)
+	return value, err
+}
+
+// LRange returns the items from start to stop inclusive, negative indexes
+// counting from the tail.
+func (s *Storage) LRange(ctx context.Context, key string, start, stop int) ([]string, error) {
+	const op = "storage.LRange"
+
+	defer s.shared(ctx)()
+
+	items, err := s.queryStorage.LRange(ctx, key, start, stop)
+	if err != nil {
+		s.logKeyError("lrange", key, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return items, nil
+}
+
+func (s *Storage) LLen(ctx context.Context, key string) (int, error) {
+	const op = "storage.LLen"
+
+	defer s.shared(ctx)()
+
+	n, err := s.queryStorage.LLen(ctx, key)
+	if err != nil {
+		s.logKeyError("llen", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+func pushCommand(end kv.End) string {
+	if end == kv.Left {
+		return command.CommandLPush
+	}
+	return command.CommandRPush
+}
+
+func popCommand(end kv.End) string {
+	if end == kv.Left {
+		return command.CommandLPop
+	}
+	return command.CommandRPop
+}
+
+// waiters tracks the blocking pops waiting on each key. Its zero value is
+// ready to use.
+type waiters struct {
+	mu    sync.Mutex
+	byKey map[string]map[chan struct{}]struct{}
+}
+
+// watch registers a waiter on keys. The returned channel receives a value
+// after a push to any of them; stop unregisters the waiter.
+func (w *waiters) watch(keys []string) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	if w.byKey == nil {
+		w.byKey = make(map[string]map[chan struct{}]struct{})
+	}
+	for _, key := range keys {
+		if w.byKey[key] == nil {
+			w.byKey[key] = make(map[chan struct{}]struct{})
+		}
+		w.byKey[key][wake] = struct{}{}
+	}
+	w.mu.Unlock()
+
+	stop := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		for _, key := range keys {
+			delete(w.byKey[key], wake)
+			if len(w.byKey[key]) == 0 {
+				delete(w.byKey, key)
+			}
+		}
+	}
+	return wake, stop
+}
+
+// notify wakes every waiter on key.
+func (w *waiters) notify(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for wake := range w.byKey[key] {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package lsm
+
+import (
+	"hash/fnv"
+	"math/bits"
+)
+
+const (
+	// bloomBitsPerKey and bloomHashes give a false positive rate of about
+	// one percent.
+	bloomBitsPerKey = 10
+	bloomHashes     = 7
+)
+
+// bloom is the bloom filter of the keys of a table, which lets a lookup skip
+// the tables that do not hold a key without reading them.
+type bloom struct {
+	bits []byte
+}
+
+func newBloom(keys int) *bloom {
+	size := max(keys*bloomBitsPerKey, 64)
+	return &bloom{bits: make([]byte, (size+7)/8)}
+}
+
+func (b *bloom) add(key string) {
+	h1, h2 := bloomHash(key)
+	n := uint64(len(b.bits)) * 8
+	for i := range uint64(bloomHashes) {
+		bit := (h1 + i*h2) % n
+		b.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// mayContain reports false only for a key that was never added.
+func (b *bloom) mayContain(key string) bool {
+	h1, h2 := bloomHash(key)
+	n := uint64(len(b.bits)) * 8
+	for i := range uint64(bloomHashes) {
+		bit := (h1 + i*h2) % n
+		if b.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHash derives the two hashes the probes are combined from, see
+// Kirsch and Mitzenmacher, "Less Hashing, Same Performance".
+func bloomHash(key string) (uint64, uint64) {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(key))
+	sum := hasher.Sum64()
+
+	return sum, bits.RotateLeft64(sum, 32) | 1
+}
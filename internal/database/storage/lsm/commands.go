@@ -0,0 +1,334 @@
+package lsm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+// Set stores the value and clears any expiry the key had.
+func (e *Engine) Set(ctx context.Context, key, value string) error {
+	const op = "lsm.Set"
+	_ = ctx
+
+	if _, err := e.store(key, value, time.Time{}, kv.Condition{}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetWithExpiry stores the value and makes it expire at expireAt.
+func (e *Engine) SetWithExpiry(ctx context.Context, key, value string, expireAt time.Time) error {
+	const op = "lsm.SetWithExpiry"
+	_ = ctx
+
+	if _, err := e.store(key, value, expireAt, kv.Condition{}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetIf atomically stores the value when cond holds and returns the previous
+// state of the key. It fails with dberrors.ErrConditionFailed when nothing
+// was written.
+func (e *Engine) SetIf(ctx context.Context, key, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error) {
+	const op = "lsm.SetIf"
+	_ = ctx
+
+	previous, err := e.store(key, value, expireAt, cond)
+	if err != nil {
+		return previous, fmt.Errorf("%s: %w", op, err)
+	}
+	return previous, nil
+}
+
+func (e *Engine) store(key, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error) {
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, found, err := e.liveLocked(key, kindTombstone)
+	if err != nil {
+		return kv.Lookup{}, err
+	}
+
+	var previous kv.Lookup
+	if found {
+		if current.kind != kindString && cond.ReadsValue() {
+			return kv.Lookup{}, dberrors.ErrWrongType
+		}
+		previous = kv.Lookup{Value: current.value, Found: true}
+	}
+	if !cond.Holds(previous) {
+		return previous, dberrors.ErrConditionFailed
+	}
+
+	e.putLocked(key, entry{kind: kindString, expireAt: unixNano(expireAt), value: value})
+	e.notify(kv.EventSet, key)
+	return previous, nil
+}
+
+// IncrBy atomically adds delta to the integer stored at key, a missing key
+// counting as 0, and returns the result and the unchanged expiry of the key.
+func (e *Engine) IncrBy(ctx context.Context, key string, delta int64) (int64, time.Time, error) {
+	const op = "lsm.IncrBy"
+	_ = ctx
+
+	var result int64
+	expireAt, err := e.update(key, func(current kv.Lookup) (string, error) {
+		var n int64
+		if current.Found {
+			var err error
+			if n, err = strconv.ParseInt(current.Value, 10, 64); err != nil {
+				return "", dberrors.ErrNotInteger
+			}
+		}
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return "", dberrors.ErrOverflow
+		}
+		result = n + delta
+		return strconv.FormatInt(result, 10), nil
+	})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, expireAt, nil
+}
+
+// IncrByFloat atomically adds delta to the number stored at key and returns
+// the result as stored and the unchanged expiry of the key.
+func (e *Engine) IncrByFloat(ctx context.Context, key string, delta float64) (string, time.Time, error) {
+	const op = "lsm.IncrByFloat"
+	_ = ctx
+
+	var result string
+	expireAt, err := e.update(key, func(current kv.Lookup) (string, error) {
+		var f float64
+		if current.Found {
+			var err error
+			f, err = strconv.ParseFloat(current.Value, 64)
+			if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+				return "", dberrors.ErrNotFloat
+			}
+		}
+		sum := f + delta
+		if math.IsInf(sum, 0) || math.IsNaN(sum) {
+			return "", dberrors.ErrOverflow
+		}
+		result = strconv.FormatFloat(sum, 'f', -1, 64)
+		return result, nil
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, expireAt, nil
+}
+
+// update replaces the string stored at key with the one computed from it,
+// keeping the expiry, and returns that expiry.
+func (e *Engine) update(key string, next func(current kv.Lookup) (string, error)) (time.Time, error) {
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, found, err := e.liveLocked(key, kindString)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	value, err := next(kv.Lookup{Value: current.value, Found: found})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	e.putLocked(key, entry{kind: kindString, expireAt: current.expireAt, value: value})
+	e.notify(kv.EventSet, key)
+	return current.deadline(), nil
+}
+
+func (e *Engine) Get(ctx context.Context, key string) (string, error) {
+	const op = "lsm.Get"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	current, found, err := e.liveLocked(key, kindString)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return "", fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	return current.value, nil
+}
+
+func (e *Engine) Del(ctx context.Context, key string) error {
+	const op = "lsm.Del"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	_, found, err := e.liveLocked(key, kindTombstone)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return nil
+	}
+
+	e.deleteLocked(key)
+	e.notify(kv.EventDel, key)
+	return nil
+}
+
+// MSet stores all pairs atomically and clears their expiry. When a key
+// repeats, its last value wins.
+func (e *Engine) MSet(ctx context.Context, pairs []kv.Pair) error {
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	for _, pair := range pairs {
+		e.putLocked(pair.Key, entry{kind: kindString, value: pair.Value})
+		e.notify(kv.EventSet, pair.Key)
+	}
+	return nil
+}
+
+// MGet reads all keys at once. Missing keys and keys of another type are
+// reported as not found.
+func (e *Engine) MGet(ctx context.Context, keys []string) ([]kv.Lookup, error) {
+	const op = "lsm.MGet"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	results := make([]kv.Lookup, len(keys))
+	for i, key := range keys {
+		current, found, err := e.liveLocked(key, kindTombstone)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if found && current.kind == kindString {
+			results[i] = kv.Lookup{Value: current.value, Found: true}
+		}
+	}
+	return results, nil
+}
+
+// MDel deletes all keys atomically and returns how many existed.
+func (e *Engine) MDel(ctx context.Context, keys []string) (int, error) {
+	const op = "lsm.MDel"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		_, found, err := e.liveLocked(key, kindTombstone)
+		if err != nil {
+			return deleted, fmt.Errorf("%s: %w", op, err)
+		}
+		if found {
+			e.deleteLocked(key)
+			e.notify(kv.EventDel, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Expire sets the expiry deadline of an existing key.
+func (e *Engine) Expire(ctx context.Context, key string, expireAt time.Time) error {
+	const op = "lsm.Expire"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, found, err := e.liveLocked(key, kindTombstone)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+
+	current.expireAt = unixNano(expireAt)
+	e.putLocked(key, current)
+	return nil
+}
+
+// Persist removes the expiry of a key. It fails with dberrors.ErrNoExpiry
+// when the key exists but has no expiry.
+func (e *Engine) Persist(ctx context.Context, key string) error {
+	const op = "lsm.Persist"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, found, err := e.liveLocked(key, kindTombstone)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	if current.expireAt == 0 {
+		return fmt.Errorf("%s: %w", op, dberrors.ErrNoExpiry)
+	}
+
+	current.expireAt = 0
+	e.putLocked(key, current)
+	return nil
+}
+
+// TTL returns the time left before the key expires. It fails with
+// dberrors.ErrNoExpiry when the key exists but has no expiry.
+func (e *Engine) TTL(ctx context.Context, key string) (time.Duration, error) {
+	const op = "lsm.TTL"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	current, found, err := e.liveLocked(key, kindTombstone)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	if current.expireAt == 0 {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNoExpiry)
+	}
+	return current.deadline().Sub(e.now()), nil
+}
+
+// Version returns a number that changes whenever the key is written or
+// deleted; a missing key has version 0.
+func (e *Engine) Version(ctx context.Context, key string) (uint64, error) {
+	const op = "lsm.Version"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	current, found, err := e.liveLocked(key, kindTombstone)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return 0, nil
+	}
+	return current.seq, nil
+}
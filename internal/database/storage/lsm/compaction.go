@@ -0,0 +1,326 @@
+package lsm
+
+import (
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// retryInterval is how long a failed flush or compaction waits before it
+// is tried again.
+const retryInterval = time.Second
+
+// compaction merges inputs, tables of level and the tables of level+1 they
+// overlap, into new tables of level+1.
+type compaction struct {
+	level  int
+	inputs [2][]*table
+	// bottom tells that no level below level+1 holds tables, so tombstones
+	// and expired records have nothing left to hide and are dropped.
+	bottom bool
+}
+
+func (e *Engine) flushLoop() {
+	defer e.wg.Done()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-e.flushCh:
+		case <-retry:
+		}
+
+		retry = nil
+		for {
+			flushed, err := e.flushOne()
+			if err != nil {
+				e.log.Error("lsm flush failed", slog.Any("err", err))
+				retry = time.After(retryInterval)
+				break
+			}
+			if !flushed {
+				break
+			}
+		}
+	}
+}
+
+// flushOne writes the oldest full memtable to a new table of level 0 and
+// reports whether there was one. The manifest then moves the checkpoint to
+// the last record the memtable held.
+func (e *Engine) flushOne() (bool, error) {
+	e.flushMu.Lock()
+	defer e.flushMu.Unlock()
+
+	e.mu.RLock()
+	if len(e.imm) == 0 {
+		e.mu.RUnlock()
+		return false, nil
+	}
+	// a full memtable is never written again, so it is read unlocked
+	mem := e.imm[0]
+	e.mu.RUnlock()
+
+	if err := e.checkpointed(mem.lsn); err != nil {
+		return false, err
+	}
+
+	w, err := e.newTableWriter()
+	if err != nil {
+		return false, err
+	}
+	for r := range mem.from("") {
+		if err := w.add(r); err != nil {
+			return false, w.abort(err)
+		}
+	}
+	t, err := w.finish()
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	e.levels[0] = append(e.levels[0], t)
+	e.imm = e.imm[1:]
+	e.checkpointLSN = mem.lsn
+	e.roomy.Broadcast()
+	e.mu.Unlock()
+
+	if err := e.saveManifest(); err != nil {
+		return false, err
+	}
+
+	e.log.Debug("lsm memtable flushed", slog.String("table", tableName(t.id)), slog.Int("entries", t.entries))
+	signal(e.compactCh)
+	return true, nil
+}
+
+func (e *Engine) compactLoop() {
+	defer e.wg.Done()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-e.compactCh:
+		case <-retry:
+		}
+
+		retry = nil
+		for {
+			compacted, err := e.compactOne()
+			if err != nil {
+				e.log.Error("lsm compaction failed", slog.Any("err", err))
+				retry = time.After(retryInterval)
+				break
+			}
+			if !compacted {
+				break
+			}
+
+			select {
+			case <-e.stop:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// compactOne runs one compaction, if a level needs one, and reports
+// whether it did. Only compactions and Flush remove tables, both under
+// compactMu, so the inputs stay valid while they are merged unlocked.
+func (e *Engine) compactOne() (bool, error) {
+	e.compactMu.Lock()
+	defer e.compactMu.Unlock()
+
+	e.mu.RLock()
+	c, ok := e.pickLocked()
+	e.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	outputs, dropped, err := e.merge(c)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	inputs := make(map[*table]struct{})
+	for _, tables := range c.inputs {
+		for _, t := range tables {
+			inputs[t] = struct{}{}
+		}
+	}
+	for _, level := range []int{c.level, c.level + 1} {
+		e.levels[level] = slices.DeleteFunc(e.levels[level], func(t *table) bool {
+			_, ok := inputs[t]
+			return ok
+		})
+	}
+	next := append(e.levels[c.level+1], outputs...)
+	slices.SortFunc(next, func(a, b *table) int { return strings.Compare(a.smallest, b.smallest) })
+	e.levels[c.level+1] = next
+	e.mu.Unlock()
+
+	// the inputs stay on disk as long as the manifest there lists them
+	if err := e.saveManifest(); err != nil {
+		return false, err
+	}
+	for t := range inputs {
+		t.drop()
+	}
+
+	e.log.Debug("lsm compaction",
+		slog.Int("level", c.level),
+		slog.Int("inputs", len(inputs)),
+		slog.Int("outputs", len(outputs)),
+		slog.Int("dropped", dropped),
+	)
+	return true, nil
+}
+
+// pickLocked chooses the next compaction: level 0 once it holds enough
+// tables, otherwise the first level past its size budget. Past level 0, one
+// table is compacted at a time, taking the tables of a level in turn.
+func (e *Engine) pickLocked() (compaction, bool) {
+	c := compaction{level: -1}
+
+	if len(e.levels[0]) >= e.level0Tables {
+		c.level = 0
+		c.inputs[0] = slices.Clone(e.levels[0])
+	} else {
+		budget := e.tableSize * int64(e.level0Tables)
+		for level := 1; level < maxLevels-1; level++ {
+			if levelSize(e.levels[level]) > budget {
+				c.level = level
+				break
+			}
+			budget *= levelSizeRatio
+		}
+		if c.level < 0 {
+			return compaction{}, false
+		}
+
+		tables := e.levels[c.level]
+		i := searchTables(tables, e.compactFrom[c.level])
+		if i == len(tables) {
+			i = 0
+		}
+		c.inputs[0] = []*table{tables[i]}
+		e.compactFrom[c.level] = tables[i].largest + "\x00"
+	}
+
+	smallest, largest := c.inputs[0][0].smallest, c.inputs[0][0].largest
+	for _, t := range c.inputs[0][1:] {
+		smallest, largest = min(smallest, t.smallest), max(largest, t.largest)
+	}
+	for _, t := range e.levels[c.level+1] {
+		if t.overlaps(smallest, largest) {
+			c.inputs[1] = append(c.inputs[1], t)
+		}
+	}
+
+	c.bottom = true
+	for _, tables := range e.levels[c.level+2:] {
+		if len(tables) > 0 {
+			c.bottom = false
+		}
+	}
+	return c, true
+}
+
+// merge writes the newest version of every key of the inputs to new tables
+// cut at the table size, and returns them with the number of records
+// dropped for good.
+func (e *Engine) merge(c compaction) ([]*table, int, error) {
+	var sources []iterator
+	// the tables of level 0 overlap, the newest comes first
+	if c.level == 0 {
+		for i := len(c.inputs[0]) - 1; i >= 0; i-- {
+			sources = append(sources, newLevelIter(c.inputs[0][i:i+1], ""))
+		}
+	} else {
+		sources = append(sources, newLevelIter(c.inputs[0], ""))
+	}
+	sources = append(sources, newLevelIter(c.inputs[1], ""))
+
+	merged := newMergeIter(sources)
+	defer merged.close()
+
+	var (
+		outputs []*table
+		w       *tableWriter
+		dropped int
+	)
+	abort := func(err error) ([]*table, int, error) {
+		if w != nil {
+			_ = w.abort(err)
+		}
+		for _, t := range outputs {
+			t.drop()
+		}
+		return nil, 0, err
+	}
+
+	now := e.now()
+	for r, ok := merged.next(); ok; r, ok = merged.next() {
+		if c.bottom && !r.live(now) {
+			if r.kind != kindTombstone {
+				e.expiredKeys.Add(1)
+			}
+			dropped++
+			continue
+		}
+
+		if w == nil {
+			var err error
+			if w, err = e.newTableWriter(); err != nil {
+				return abort(err)
+			}
+		}
+		if err := w.add(r); err != nil {
+			return abort(err)
+		}
+		if w.size() >= e.tableSize {
+			t, err := w.finish()
+			w = nil
+			if err != nil {
+				return abort(err)
+			}
+			outputs = append(outputs, t)
+		}
+	}
+	if err := merged.close(); err != nil {
+		return abort(err)
+	}
+
+	if w != nil {
+		t, err := w.finish()
+		w = nil
+		if err != nil {
+			return abort(err)
+		}
+		outputs = append(outputs, t)
+	}
+	return outputs, dropped, nil
+}
+
+func (e *Engine) newTableWriter() (*tableWriter, error) {
+	id := e.nextTable.Add(1)
+	return newTableWriter(filepath.Join(e.dir, tableName(id)), id)
+}
+
+func levelSize(tables []*table) int64 {
+	var size int64
+	for _, t := range tables {
+		size += t.size
+	}
+	return size
+}
@@ -0,0 +1,622 @@
+package lsm
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+// putContainerLocked stores the new value of a hash, list, set or sorted
+// set, keeping the expiry of the key when it was live, or deletes the key
+// when the container is left empty.
+func (e *Engine) putContainerLocked(key string, current entry, k kind, value string, empty bool) {
+	if empty {
+		e.deleteLocked(key)
+		e.notify(kv.EventDel, key)
+		return
+	}
+	e.putLocked(key, entry{kind: k, expireAt: current.expireAt, value: value})
+	e.notify(kv.EventSet, key)
+}
+
+// hashLocked returns the fields of the hash stored at key, nil when the key
+// does not exist.
+func (e *Engine) hashLocked(key string) (entry, map[string]string, error) {
+	current, found, err := e.liveLocked(key, kindHash)
+	if err != nil || !found {
+		return entry{}, nil, err
+	}
+	pairs, err := decodePairs(current.value)
+	if err != nil {
+		return entry{}, nil, err
+	}
+
+	fields := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		fields[pair.Key] = pair.Value
+	}
+	return current, fields, nil
+}
+
+// encodeHash stores the fields sorted by name.
+func encodeHash(fields map[string]string) string {
+	pairs := make([]kv.Pair, 0, len(fields))
+	for _, field := range slices.Sorted(maps.Keys(fields)) {
+		pairs = append(pairs, kv.Pair{Key: field, Value: fields[field]})
+	}
+	return encodePairs(pairs)
+}
+
+// HSet sets fields of the hash stored at key and returns how many were added.
+func (e *Engine) HSet(ctx context.Context, key string, fields []kv.Pair) (int, error) {
+	const op = "lsm.HSet"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, hash, err := e.hashLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if hash == nil {
+		hash = make(map[string]string, len(fields))
+	}
+
+	added := 0
+	for _, pair := range fields {
+		if _, ok := hash[pair.Key]; !ok {
+			added++
+		}
+		hash[pair.Key] = pair.Value
+	}
+
+	e.putContainerLocked(key, current, kindHash, encodeHash(hash), false)
+	return added, nil
+}
+
+// HDel removes fields from the hash stored at key and returns how many existed.
+func (e *Engine) HDel(ctx context.Context, key string, fields []string) (int, error) {
+	const op = "lsm.HDel"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, hash, err := e.hashLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted := 0
+	for _, field := range fields {
+		if _, ok := hash[field]; ok {
+			delete(hash, field)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	e.putContainerLocked(key, current, kindHash, encodeHash(hash), len(hash) == 0)
+	return deleted, nil
+}
+
+func (e *Engine) HGet(ctx context.Context, key, field string) (string, error) {
+	const op = "lsm.HGet"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, hash, err := e.hashLocked(key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	value, ok := hash[field]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	return value, nil
+}
+
+// HGetAll returns the fields of the hash stored at key sorted by name.
+func (e *Engine) HGetAll(ctx context.Context, key string) ([]kv.Pair, error) {
+	const op = "lsm.HGetAll"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	current, found, err := e.liveLocked(key, kindHash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return nil, nil
+	}
+	pairs, err := decodePairs(current.value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return pairs, nil
+}
+
+func (e *Engine) HLen(ctx context.Context, key string) (int, error) {
+	const op = "lsm.HLen"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, hash, err := e.hashLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(hash), nil
+}
+
+func (e *Engine) HExists(ctx context.Context, key, field string) (bool, error) {
+	const op = "lsm.HExists"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, hash, err := e.hashLocked(key)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	_, ok := hash[field]
+	return ok, nil
+}
+
+// listLocked returns the items of the list stored at key, none when the key
+// does not exist.
+func (e *Engine) listLocked(key string) (entry, []string, error) {
+	current, found, err := e.liveLocked(key, kindList)
+	if err != nil || !found {
+		return entry{}, nil, err
+	}
+	items, err := decodeStrings(current.value)
+	if err != nil {
+		return entry{}, nil, err
+	}
+	return current, items, nil
+}
+
+// Push adds values to an end of the list stored at key and returns its length.
+func (e *Engine) Push(ctx context.Context, key string, values []string, end kv.End) (int, error) {
+	const op = "lsm.Push"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, items, err := e.listLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if end == kv.Left {
+		// every value is pushed in turn, so the last one ends up first
+		pushed := slices.Clone(values)
+		slices.Reverse(pushed)
+		items = append(pushed, items...)
+	} else {
+		items = append(items, values...)
+	}
+
+	e.putContainerLocked(key, current, kindList, encodeStrings(items), false)
+	return len(items), nil
+}
+
+// Pop removes and returns the item at an end of the list stored at key.
+func (e *Engine) Pop(ctx context.Context, key string, end kv.End) (string, error) {
+	const op = "lsm.Pop"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, items, err := e.listLocked(key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+
+	var value string
+	if end == kv.Left {
+		value, items = items[0], items[1:]
+	} else {
+		value, items = items[len(items)-1], items[:len(items)-1]
+	}
+
+	e.putContainerLocked(key, current, kindList, encodeStrings(items), len(items) == 0)
+	return value, nil
+}
+
+func (e *Engine) LRange(ctx context.Context, key string, start, stop int) ([]string, error) {
+	const op = "lsm.LRange"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, items, err := e.listLocked(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start, stop, ok := clampRange(start, stop, len(items))
+	if !ok {
+		return nil, nil
+	}
+	return items[start : stop+1], nil
+}
+
+func (e *Engine) LLen(ctx context.Context, key string) (int, error) {
+	const op = "lsm.LLen"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, items, err := e.listLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(items), nil
+}
+
+// clampRange turns the inclusive positions of LRANGE and ZRANGE, negative
+// ones counting from the end, into indexes of a sequence of n elements, and
+// reports whether the range holds any.
+func clampRange(start, stop, n int) (int, int, bool) {
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	return start, stop, start <= stop
+}
+
+// setLocked returns the members of the set stored at key in order, none
+// when the key does not exist.
+func (e *Engine) setLocked(key string) (entry, []string, error) {
+	current, found, err := e.liveLocked(key, kindSet)
+	if err != nil || !found {
+		return entry{}, nil, err
+	}
+	members, err := decodeStrings(current.value)
+	if err != nil {
+		return entry{}, nil, err
+	}
+	return current, members, nil
+}
+
+// SAdd adds members to the set stored at key and returns how many were new.
+func (e *Engine) SAdd(ctx context.Context, key string, members []string) (int, error) {
+	const op = "lsm.SAdd"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, set, err := e.setLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	before := len(set)
+	set = append(set, members...)
+	slices.Sort(set)
+	set = slices.Compact(set)
+
+	e.putContainerLocked(key, current, kindSet, encodeStrings(set), false)
+	return len(set) - before, nil
+}
+
+// SRem removes members from the set stored at key and returns how many were
+// members.
+func (e *Engine) SRem(ctx context.Context, key string, members []string) (int, error) {
+	const op = "lsm.SRem"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, set, err := e.setLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	removed := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		if _, ok := slices.BinarySearch(set, member); ok {
+			removed[member] = struct{}{}
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	set = slices.DeleteFunc(set, func(member string) bool {
+		_, ok := removed[member]
+		return ok
+	})
+
+	e.putContainerLocked(key, current, kindSet, encodeStrings(set), len(set) == 0)
+	return len(removed), nil
+}
+
+// SMembers returns the members of the set stored at key in lexicographic order.
+func (e *Engine) SMembers(ctx context.Context, key string) ([]string, error) {
+	const op = "lsm.SMembers"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, set, err := e.setLocked(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return set, nil
+}
+
+func (e *Engine) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	const op = "lsm.SIsMember"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, set, err := e.setLocked(key)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	_, ok := slices.BinarySearch(set, member)
+	return ok, nil
+}
+
+// SInter returns the members common to the sets stored at keys.
+func (e *Engine) SInter(ctx context.Context, keys []string) ([]string, error) {
+	const op = "lsm.SInter"
+	_ = ctx
+
+	sets, err := e.readSets(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(sets) == 0 {
+		return nil, nil
+	}
+
+	var result []string
+members:
+	for _, member := range sets[0] {
+		for _, set := range sets[1:] {
+			if _, ok := slices.BinarySearch(set, member); !ok {
+				continue members
+			}
+		}
+		result = append(result, member)
+	}
+	return result, nil
+}
+
+// SUnion returns the members of any of the sets stored at keys.
+func (e *Engine) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	const op = "lsm.SUnion"
+	_ = ctx
+
+	sets, err := e.readSets(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var union []string
+	for _, set := range sets {
+		union = append(union, set...)
+	}
+	slices.Sort(union)
+	return slices.Compact(union), nil
+}
+
+// readSets reads the sets stored at keys at once, a missing key reading as
+// an empty set.
+func (e *Engine) readSets(keys []string) ([][]string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	sets := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		_, set, err := e.setLocked(key)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+func compareScored(a, b kv.ScoredMember) int {
+	if c := cmp.Compare(a.Score, b.Score); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Member, b.Member)
+}
+
+// zsetLocked returns the members of the sorted set stored at key in score
+// order, none when the key does not exist.
+func (e *Engine) zsetLocked(key string) (entry, []kv.ScoredMember, error) {
+	current, found, err := e.liveLocked(key, kindZSet)
+	if err != nil || !found {
+		return entry{}, nil, err
+	}
+	members, err := decodeScored(current.value)
+	if err != nil {
+		return entry{}, nil, err
+	}
+	return current, members, nil
+}
+
+// ZAdd sets scores in the sorted set stored at key and returns how many
+// members were new.
+func (e *Engine) ZAdd(ctx context.Context, key string, members []kv.ScoredMember) (int, error) {
+	const op = "lsm.ZAdd"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, zset, err := e.zsetLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	scores := make(map[string]float64, len(zset)+len(members))
+	for _, m := range zset {
+		scores[m.Member] = m.Score
+	}
+	added := 0
+	for _, m := range members {
+		if _, ok := scores[m.Member]; !ok {
+			added++
+		}
+		scores[m.Member] = m.Score
+	}
+
+	zset = zset[:0]
+	for member, score := range scores {
+		zset = append(zset, kv.ScoredMember{Member: member, Score: score})
+	}
+	slices.SortFunc(zset, compareScored)
+
+	e.putContainerLocked(key, current, kindZSet, encodeScored(zset), false)
+	return added, nil
+}
+
+// ZRem removes members from the sorted set stored at key and returns how many
+// were members.
+func (e *Engine) ZRem(ctx context.Context, key string, members []string) (int, error) {
+	const op = "lsm.ZRem"
+	_ = ctx
+
+	e.lockWrite()
+	defer e.mu.Unlock()
+
+	current, zset, err := e.zsetLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	removed := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		removed[member] = struct{}{}
+	}
+	before := len(zset)
+	zset = slices.DeleteFunc(zset, func(m kv.ScoredMember) bool {
+		_, ok := removed[m.Member]
+		return ok
+	})
+	if len(zset) == before {
+		return 0, nil
+	}
+
+	e.putContainerLocked(key, current, kindZSet, encodeScored(zset), len(zset) == 0)
+	return before - len(zset), nil
+}
+
+func (e *Engine) ZScore(ctx context.Context, key, member string) (float64, error) {
+	const op = "lsm.ZScore"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, zset, err := e.zsetLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	i := slices.IndexFunc(zset, func(m kv.ScoredMember) bool { return m.Member == member })
+	if i < 0 {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	return zset[i].Score, nil
+}
+
+// ZRank returns the position of a member in score order.
+func (e *Engine) ZRank(ctx context.Context, key, member string) (int, error) {
+	const op = "lsm.ZRank"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, zset, err := e.zsetLocked(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	i := slices.IndexFunc(zset, func(m kv.ScoredMember) bool { return m.Member == member })
+	if i < 0 {
+		return 0, fmt.Errorf("%s: %w", op, dberrors.ErrNotFound)
+	}
+	return i, nil
+}
+
+// ZRange returns the members of the sorted set stored at key between two
+// positions in score order.
+func (e *Engine) ZRange(ctx context.Context, key string, start, stop int) ([]kv.ScoredMember, error) {
+	const op = "lsm.ZRange"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, zset, err := e.zsetLocked(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start, stop, ok := clampRange(start, stop, len(zset))
+	if !ok {
+		return nil, nil
+	}
+	return zset[start : stop+1], nil
+}
+
+// ZRangeByScore returns up to limit members of the sorted set stored at key
+// whose scores lie between lower and upper.
+func (e *Engine) ZRangeByScore(ctx context.Context, key string, lower, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error) {
+	const op = "lsm.ZRangeByScore"
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, zset, err := e.zsetLocked(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var members []kv.ScoredMember
+	for _, m := range zset[sort.Search(len(zset), func(i int) bool { return lower.Above(zset[i].Score) }):] {
+		if !upper.Below(m.Score) || (limit > 0 && len(members) == limit) {
+			break
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
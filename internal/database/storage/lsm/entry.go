@@ -0,0 +1,271 @@
+package lsm
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"lesson1/internal/database/compression"
+	"lesson1/internal/database/kv"
+)
+
+// entryOverhead approximates the memory of a memtable entry on top of its
+// key and value bytes.
+const entryOverhead = 48
+
+var errCorruptedEntry = errors.New("corrupted entry")
+
+// kind is the type of the value of an entry.
+type kind byte
+
+const (
+	// kindTombstone marks a deleted key. It hides the older versions of the
+	// key until compaction reaches the bottom level, where it is dropped.
+	kindTombstone kind = iota
+	kindString
+	kindHash
+	kindList
+	kindSet
+	kindZSet
+)
+
+// entry is one version of a key. Containers are stored whole: value holds
+// the encoded fields, items or members, see the encode functions below.
+type entry struct {
+	kind kind
+	// seq orders the writes of the engine; it is the version of the key.
+	seq uint64
+	// expireAt is the expiry deadline in Unix nanoseconds, 0 without one.
+	expireAt int64
+	// codec is how value is encoded, see decompressed.
+	codec compression.Codec
+	value string
+	// saved is how many bytes the codec spared, for Stats. It is only known
+	// in the memtables and not stored in the tables.
+	saved int64
+}
+
+// record is an entry together with its key, as it is stored in a table.
+type record struct {
+	key string
+	entry
+}
+
+func (e entry) live(now time.Time) bool {
+	return e.kind != kindTombstone && !e.expired(now)
+}
+
+func (e entry) expired(now time.Time) bool {
+	return e.expireAt != 0 && now.UnixNano() >= e.expireAt
+}
+
+func (e entry) deadline() time.Time {
+	if e.expireAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, e.expireAt)
+}
+
+// decompressed returns the entry with its value decoded.
+func (e entry) decompressed() (entry, error) {
+	if e.codec == compression.None {
+		return e, nil
+	}
+	value, err := compression.Decompress(e.codec, e.value)
+	if err != nil {
+		return entry{}, err
+	}
+	e.codec, e.value, e.saved = compression.None, value, 0
+	return e, nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func recordSize(key string, e entry) int64 {
+	return int64(len(key) + len(e.value) + entryOverhead)
+}
+
+// appendRecord encodes a record as
+//
+//	string key | byte kind | byte codec | uvarint seq | varint expireAt | string value
+//
+// where every string is a uvarint length followed by raw bytes.
+func appendRecord(buf []byte, r record) []byte {
+	buf = appendString(buf, r.key)
+	buf = append(buf, byte(r.kind), byte(r.codec))
+	buf = binary.AppendUvarint(buf, r.seq)
+	buf = binary.AppendVarint(buf, r.expireAt)
+	return appendString(buf, r.value)
+}
+
+// readRecord decodes one record from the front of buf and returns the rest.
+func readRecord(buf []byte) (record, []byte, error) {
+	var (
+		r   record
+		err error
+	)
+	if r.key, buf, err = readString(buf); err != nil {
+		return record{}, nil, err
+	}
+	if len(buf) < 2 {
+		return record{}, nil, errCorruptedEntry
+	}
+	r.kind, r.codec, buf = kind(buf[0]), compression.Codec(buf[1]), buf[2:]
+
+	var n int
+	if r.seq, n = binary.Uvarint(buf); n <= 0 {
+		return record{}, nil, errCorruptedEntry
+	}
+	buf = buf[n:]
+	if r.expireAt, n = binary.Varint(buf); n <= 0 {
+		return record{}, nil, errCorruptedEntry
+	}
+	buf = buf[n:]
+
+	if r.value, buf, err = readString(buf); err != nil {
+		return record{}, nil, err
+	}
+	return r, buf, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(buf []byte) (string, []byte, error) {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf)-n) < length {
+		return "", nil, errCorruptedEntry
+	}
+	end := n + int(length)
+	return string(buf[n:end]), buf[end:], nil
+}
+
+// encodeStrings encodes the items of a list, the members of a set or the
+// flattened fields of a hash as a uvarint count followed by the strings.
+func encodeStrings(items []string) string {
+	buf := binary.AppendUvarint(nil, uint64(len(items)))
+	for _, item := range items {
+		buf = appendString(buf, item)
+	}
+	return string(buf)
+}
+
+func decodeStrings(value string) ([]string, error) {
+	buf := []byte(value)
+	count, n := binary.Uvarint(buf)
+	if n <= 0 || count > uint64(len(buf)) {
+		return nil, errCorruptedEntry
+	}
+	buf = buf[n:]
+
+	items := make([]string, 0, count)
+	for range count {
+		var (
+			item string
+			err  error
+		)
+		if item, buf, err = readString(buf); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(buf) != 0 {
+		return nil, errCorruptedEntry
+	}
+	return items, nil
+}
+
+func encodePairs(pairs []kv.Pair) string {
+	flat := make([]string, 0, 2*len(pairs))
+	for _, pair := range pairs {
+		flat = append(flat, pair.Key, pair.Value)
+	}
+	return encodeStrings(flat)
+}
+
+func decodePairs(value string) ([]kv.Pair, error) {
+	flat, err := decodeStrings(value)
+	if err != nil || len(flat)%2 != 0 {
+		return nil, errCorruptedEntry
+	}
+
+	pairs := make([]kv.Pair, 0, len(flat)/2)
+	for i := 0; i < len(flat); i += 2 {
+		pairs = append(pairs, kv.Pair{Key: flat[i], Value: flat[i+1]})
+	}
+	return pairs, nil
+}
+
+// encodeScored encodes the members of a sorted set in score order, every
+// member followed by the IEEE 754 bits of its score.
+func encodeScored(members []kv.ScoredMember) string {
+	buf := binary.AppendUvarint(nil, uint64(len(members)))
+	for _, m := range members {
+		buf = appendString(buf, m.Member)
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(m.Score))
+	}
+	return string(buf)
+}
+
+func decodeScored(value string) ([]kv.ScoredMember, error) {
+	buf := []byte(value)
+	count, n := binary.Uvarint(buf)
+	if n <= 0 || count > uint64(len(buf)) {
+		return nil, errCorruptedEntry
+	}
+	buf = buf[n:]
+
+	members := make([]kv.ScoredMember, 0, count)
+	for range count {
+		var (
+			m   kv.ScoredMember
+			err error
+		)
+		if m.Member, buf, err = readString(buf); err != nil {
+			return nil, err
+		}
+		if len(buf) < 8 {
+			return nil, errCorruptedEntry
+		}
+		m.Score = math.Float64frombits(binary.BigEndian.Uint64(buf))
+		buf = buf[8:]
+		members = append(members, m)
+	}
+	if len(buf) != 0 {
+		return nil, errCorruptedEntry
+	}
+	return members, nil
+}
+
+// item decodes an entry into the item of a snapshot.
+func (r record) item() (kv.Item, error) {
+	item := kv.Item{Key: r.key, ExpireAt: r.deadline()}
+
+	var err error
+	if r.entry, err = r.decompressed(); err != nil {
+		return item, err
+	}
+	switch r.kind {
+	case kindString:
+		item.Value = r.value
+	case kindHash:
+		item.Fields, err = decodePairs(r.value)
+	case kindList:
+		item.Items, err = decodeStrings(r.value)
+	case kindSet:
+		item.Members, err = decodeStrings(r.value)
+	case kindZSet:
+		item.Scored, err = decodeScored(r.value)
+	default:
+		err = errCorruptedEntry
+	}
+	return item, err
+}
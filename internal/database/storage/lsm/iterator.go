@@ -0,0 +1,135 @@
+package lsm
+
+// iterator yields records in key order. close releases it and returns the
+// first error met, after which next yields nothing.
+type iterator interface {
+	next() (record, bool)
+	close() error
+}
+
+// sliceIter yields records already sorted in memory.
+type sliceIter struct {
+	records []record
+}
+
+func (it *sliceIter) next() (record, bool) {
+	if len(it.records) == 0 {
+		return record{}, false
+	}
+	r := it.records[0]
+	it.records = it.records[1:]
+	return r, true
+}
+
+func (it *sliceIter) close() error {
+	return nil
+}
+
+// levelIter yields the records of tables that do not overlap and are sorted
+// by key, as every level but the first holds them, one block at a time.
+type levelIter struct {
+	tables  []*table
+	block   int
+	records []record
+	start   string
+	err     error
+}
+
+// newLevelIter starts at the first record with a key not before start.
+func newLevelIter(tables []*table, start string) *levelIter {
+	i := 0
+	for i < len(tables) && tables[i].largest < start {
+		i++
+	}
+	it := &levelIter{tables: tables[i:], start: start}
+	if len(it.tables) > 0 {
+		it.block = it.tables[0].blockFor(start)
+	}
+	return it
+}
+
+func (it *levelIter) next() (record, bool) {
+	for len(it.records) == 0 {
+		if it.err != nil || len(it.tables) == 0 {
+			return record{}, false
+		}
+		t := it.tables[0]
+		if it.block >= len(t.index) {
+			it.tables = it.tables[1:]
+			it.block = 0
+			continue
+		}
+
+		records, err := t.records(it.block)
+		if err != nil {
+			it.err = err
+			return record{}, false
+		}
+		it.block++
+		for len(records) > 0 && records[0].key < it.start {
+			records = records[1:]
+		}
+		it.records = records
+	}
+
+	r := it.records[0]
+	it.records = it.records[1:]
+	return r, true
+}
+
+func (it *levelIter) close() error {
+	return it.err
+}
+
+// mergeIter merges sources ordered from the newest to the oldest into one
+// sequence that holds every key once, with the record of the newest source
+// that has it. Tombstones and expired records are yielded too; the caller
+// decides what they mean.
+type mergeIter struct {
+	sources []iterator
+	heads   []record
+	ok      []bool
+}
+
+func newMergeIter(sources []iterator) *mergeIter {
+	it := &mergeIter{
+		sources: sources,
+		heads:   make([]record, len(sources)),
+		ok:      make([]bool, len(sources)),
+	}
+	for i, source := range sources {
+		it.heads[i], it.ok[i] = source.next()
+	}
+	return it
+}
+
+func (it *mergeIter) next() (record, bool) {
+	// a handful of sources is merged, a linear pick beats a heap
+	newest := -1
+	for i := range it.sources {
+		if it.ok[i] && (newest < 0 || it.heads[i].key < it.heads[newest].key) {
+			newest = i
+		}
+	}
+	if newest < 0 {
+		return record{}, false
+	}
+
+	r := it.heads[newest]
+	for i, source := range it.sources {
+		if it.ok[i] && it.heads[i].key == r.key {
+			it.heads[i], it.ok[i] = source.next()
+		}
+	}
+	return r, true
+}
+
+func (it *mergeIter) close() error {
+	var first error
+	for _, source := range it.sources {
+		if err := source.close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,462 @@
+// Package lsm implements a storage engine on top of a log-structured merge
+// tree, for datasets that do not fit in memory.
+package lsm
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lesson1/internal/database/compression"
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+)
+
+const (
+	DefaultMemtableSize = 4 << 20
+	DefaultTableSize    = 2 << 20
+	DefaultLevel0Tables = 4
+
+	// maxLevels bounds the depth of the tree; with a size ratio of ten
+	// between levels the last one is never full in practice.
+	maxLevels = 7
+	// levelSizeRatio is how much larger every level past the first is
+	// allowed to grow than the previous one.
+	levelSizeRatio = 10
+	// maxImmutable is how many full memtables may wait for their flush
+	// before writers are held up.
+	maxImmutable = 2
+)
+
+// Engine is a log-structured merge tree. Writes go to an in-memory memtable;
+// a full memtable is flushed in the background to an immutable sorted table
+// on level 0, and a background compaction merges the tables of a level into
+// the next one, keeping every level past the first free of overlapping keys
+// and dropping overwritten versions, and tombstones once no older version
+// can remain below them. A lookup checks the memtables, then the tables from
+// the newest to the oldest, skipping those whose bloom filter rules the key
+// out.
+//
+// Hashes, lists, sets and sorted sets are stored whole under their key, so a
+// write to one of them rewrites it: the engine suits many keys rather than
+// large containers. The compressor applies to these encoded containers as it
+// does to strings.
+//
+// The tables outlive the engine: a manifest lists them, and Open reopens
+// them. Close flushes the memtables, and with a storage WAL the engine is a
+// storage.Checkpointer, so that recovery only replays the records written
+// after the last flushed memtable.
+type Engine struct {
+	log          *slog.Logger
+	dir          string
+	memtableSize int64
+	tableSize    int64
+	level0Tables int
+	now          func() time.Time
+	listener     func(kv.Event)
+	compressor   compression.Compressor
+
+	mu sync.RWMutex
+	// roomy is signalled when a flush leaves room for another memtable.
+	roomy *sync.Cond
+	mem   *memtable
+	// imm holds the full memtables waiting for their flush, oldest first.
+	imm []*memtable
+	// levels[0] holds tables that may overlap, oldest first; the other
+	// levels hold tables sorted by key that do not.
+	levels [maxLevels][]*table
+	seq    uint64
+	closed bool
+	// checkpointLSN is the last record the tables hold, see Logged; once
+	// checkpointing, the memtable is only switched between records.
+	checkpointLSN uint64
+	checkpointing bool
+	// onCheckpoint vets the checkpoint before it moves, see OnCheckpoint.
+	onCheckpoint func(lsn uint64) error
+	// compactFrom is where the next compaction of a level starts, so that
+	// the compactions of a level go round its key space.
+	compactFrom [maxLevels]string
+
+	cursors cursors
+
+	// flushMu and compactMu are held by the background flush and compaction
+	// while they work; Flush takes both to drop every table.
+	flushMu   sync.Mutex
+	compactMu sync.Mutex
+	// manifestMu serializes the writes of the manifest.
+	manifestMu sync.Mutex
+
+	nextTable   atomic.Uint64
+	expiredKeys atomic.Uint64
+
+	flushCh   chan struct{}
+	compactCh chan struct{}
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+type Option func(*Engine)
+
+// WithMemtableSize sets the size at which the memtable is flushed to a
+// table, which bounds the memory of the engine to about three times it.
+func WithMemtableSize(size int64) Option {
+	return func(e *Engine) {
+		if size > 0 {
+			e.memtableSize = size
+		}
+	}
+}
+
+// WithTableSize sets the size at which compaction starts a new table.
+func WithTableSize(size int64) Option {
+	return func(e *Engine) {
+		if size > 0 {
+			e.tableSize = size
+		}
+	}
+}
+
+// WithLevel0Tables sets how many flushed tables trigger their compaction
+// into level 1. Level 1 may then hold that many table sizes, and every level
+// after it ten times more than the previous one.
+func WithLevel0Tables(count int) Option {
+	return func(e *Engine) {
+		if count > 0 {
+			e.level0Tables = count
+		}
+	}
+}
+
+// WithClock replaces time.Now as the clock used to expire keys.
+func WithClock(now func() time.Time) Option {
+	return func(e *Engine) {
+		if now != nil {
+			e.now = now
+		}
+	}
+}
+
+// WithListener calls fn with the keys set and deleted. fn runs under the
+// engine lock, so the events arrive in order; it must not block or use the
+// engine. Expired keys are dropped by compaction without an event.
+func WithListener(fn func(kv.Event)) Option {
+	return func(e *Engine) {
+		e.listener = fn
+	}
+}
+
+// WithCompression compresses the values the compressor selects, in the
+// memtables and in the tables.
+func WithCompression(c compression.Compressor) Option {
+	return func(e *Engine) {
+		e.compressor = c
+	}
+}
+
+// Open starts an engine keeping its tables in dir, creating the directory
+// if needed. The tables of the manifest are reopened, and the files it does
+// not list deleted.
+func Open(log *slog.Logger, dir string, opts ...Option) (*Engine, error) {
+	const op = "lsm.Open"
+
+	e := &Engine{
+		log:          log,
+		dir:          dir,
+		memtableSize: DefaultMemtableSize,
+		tableSize:    DefaultTableSize,
+		level0Tables: DefaultLevel0Tables,
+		now:          time.Now,
+		mem:          newMemtable(),
+		cursors:      newCursors(),
+		flushCh:      make(chan struct{}, 1),
+		compactCh:    make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+	e.roomy = sync.NewCond(&e.mu)
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := e.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	e.wg.Add(2)
+	go e.flushLoop()
+	go e.compactLoop()
+
+	return e, nil
+}
+
+// load reopens the tables of the manifest and deletes the files it does not
+// list.
+func (e *Engine) load() error {
+	m, err := readManifest(e.dir)
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[string]struct{})
+	for level, ids := range m.Levels {
+		for _, id := range ids {
+			t, err := openTable(filepath.Join(e.dir, tableName(id)), id)
+			if err != nil {
+				e.closeTables()
+				return err
+			}
+			e.levels[level] = append(e.levels[level], t)
+			listed[tableName(id)] = struct{}{}
+		}
+	}
+	e.checkpointLSN = m.LSN
+	e.seq = m.Seq
+	e.nextTable.Store(m.NextTable)
+
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		e.closeTables()
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, ok := listed[name]; ok || entry.IsDir() {
+			continue
+		}
+		if !strings.HasSuffix(name, tableSuffix) && name != manifestName+".tmp" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(e.dir, name)); err != nil {
+			e.closeTables()
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops the background flush and compaction, flushes the memtables
+// and closes the tables.
+func (e *Engine) Close() error {
+	const op = "lsm.Close"
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.roomy.Broadcast()
+	e.mu.Unlock()
+
+	close(e.stop)
+	e.wg.Wait()
+	defer e.closeTables()
+
+	e.mu.Lock()
+	if len(e.mem.entries) > 0 {
+		e.rotateLocked()
+	}
+	e.mu.Unlock()
+	for {
+		flushed, err := e.flushOne()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !flushed {
+			break
+		}
+	}
+
+	// the records logged after the last write are held as well
+	e.mu.RLock()
+	lsn := e.mem.lsn
+	e.mu.RUnlock()
+	if err := e.checkpointed(lsn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	e.mu.Lock()
+	e.checkpointLSN = lsn
+	e.mu.Unlock()
+	if err := e.saveManifest(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// closeTables closes the files of the tables, keeping them on disk.
+func (e *Engine) closeTables() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for level, tables := range e.levels {
+		for _, t := range tables {
+			t.unref()
+		}
+		e.levels[level] = nil
+	}
+}
+
+// CheckpointLSN returns the last record of the storage WAL the tables hold
+// whole, see Logged.
+func (e *Engine) CheckpointLSN() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.checkpointLSN
+}
+
+// OnCheckpoint calls fn before the checkpoint moves to lsn, from the
+// goroutine of the flush; a memtable is only flushed once fn succeeded. The
+// storage waits there for the WAL to fsync the records, so that the tables
+// never hold a record the WAL may lose.
+func (e *Engine) OnCheckpoint(fn func(lsn uint64) error) {
+	e.onCheckpoint = fn
+}
+
+// checkpointed calls the OnCheckpoint function, if any.
+func (e *Engine) checkpointed(lsn uint64) error {
+	if e.onCheckpoint == nil {
+		return nil
+	}
+	return e.onCheckpoint(lsn)
+}
+
+// Logged tells that the memtable holds every write of the records up to
+// lsn, so that the table it is flushed to moves the checkpoint there. From
+// the first call on, a full memtable is only switched here, between
+// records: a record replayed after a crash must not find part of its writes
+// in the tables already.
+func (e *Engine) Logged(lsn uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.checkpointing = true
+	e.mem.lsn = lsn
+	if e.mem.bytes >= e.memtableSize {
+		e.rotateLocked()
+	}
+}
+
+// lockWrite takes the engine lock for a write, waiting first while too
+// many memtables wait for their flush.
+func (e *Engine) lockWrite() {
+	e.mu.Lock()
+	for len(e.imm) >= maxImmutable && !e.closed {
+		e.roomy.Wait()
+	}
+}
+
+// lookupLocked returns the newest version of key, which may be a tombstone
+// or expired, with its value still compressed. The caller holds the engine
+// lock.
+func (e *Engine) lookupLocked(key string) (entry, bool, error) {
+	if en, ok := e.mem.get(key); ok {
+		return en, true, nil
+	}
+	for i := len(e.imm) - 1; i >= 0; i-- {
+		if en, ok := e.imm[i].get(key); ok {
+			return en, true, nil
+		}
+	}
+
+	level0 := e.levels[0]
+	for i := len(level0) - 1; i >= 0; i-- {
+		en, ok, err := level0[i].get(key)
+		if err != nil || ok {
+			return en, ok, err
+		}
+	}
+
+	for _, tables := range e.levels[1:] {
+		i := searchTables(tables, key)
+		if i == len(tables) {
+			continue
+		}
+		en, ok, err := tables[i].get(key)
+		if err != nil || ok {
+			return en, ok, err
+		}
+	}
+	return entry{}, false, nil
+}
+
+// liveLocked returns the live version of key, if any, with its value
+// decompressed, and dberrors.ErrWrongType when it is of another kind than
+// want, unless want is kindTombstone, which accepts any kind.
+func (e *Engine) liveLocked(key string, want kind) (entry, bool, error) {
+	en, ok, err := e.lookupLocked(key)
+	if err != nil {
+		return entry{}, false, err
+	}
+	if !ok || !en.live(e.now()) {
+		return entry{}, false, nil
+	}
+	if want != kindTombstone && en.kind != want {
+		return entry{}, false, dberrors.ErrWrongType
+	}
+	if en, err = en.decompressed(); err != nil {
+		return entry{}, false, err
+	}
+	return en, true, nil
+}
+
+// putLocked writes a new version of key, compressing its value, and
+// switches to a new memtable once the current one is full, unless Logged
+// does. The caller holds the engine write lock.
+func (e *Engine) putLocked(key string, en entry) {
+	e.seq++
+	en.seq = e.seq
+	if en.kind != kindTombstone {
+		var data string
+		en.codec, data = e.compressor.Compress(en.value)
+		en.saved = int64(len(en.value) - len(data))
+		en.value = data
+	}
+	e.mem.put(key, en)
+
+	if !e.checkpointing && e.mem.bytes >= e.memtableSize {
+		e.rotateLocked()
+	}
+}
+
+// rotateLocked queues the memtable for its flush and starts a new one,
+// holding the same records so far. The caller holds the engine write lock.
+func (e *Engine) rotateLocked() {
+	lsn := e.mem.lsn
+	e.imm = append(e.imm, e.mem)
+	e.mem = newMemtable()
+	e.mem.lsn = lsn
+	signal(e.flushCh)
+}
+
+// deleteLocked writes a tombstone for key.
+func (e *Engine) deleteLocked(key string) {
+	e.putLocked(key, entry{kind: kindTombstone})
+}
+
+func (e *Engine) notify(kind kv.EventType, key string) {
+	if e.listener != nil {
+		e.listener(kv.Event{Type: kind, Key: key})
+	}
+}
+
+// searchTables returns the first of tables sorted by key whose largest key
+// is not before key, len(tables) when there is none.
+func searchTables(tables []*table, key string) int {
+	return sort.Search(len(tables), func(i int) bool { return tables[i].largest >= key })
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,600 @@
+package lsm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/compression"
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/storage"
+	"lesson1/internal/database/storage/lsm"
+	"lesson1/internal/database/storage/wal"
+	"lesson1/internal/lib/clock/fakeclock"
+	"lesson1/internal/lib/logger/slogdiscard"
+)
+
+// openEngine opens an engine with tiny memtables and tables, so that a few
+// hundred keys go through flushes and compactions.
+func openEngine(t *testing.T, dir string, opts ...lsm.Option) *lsm.Engine {
+	t.Helper()
+
+	opts = append([]lsm.Option{
+		lsm.WithMemtableSize(1 << 10),
+		lsm.WithTableSize(2 << 10),
+		lsm.WithLevel0Tables(2),
+	}, opts...)
+	e, err := lsm.Open(slogdiscard.NewDiscardLogger(), dir, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = e.Close() })
+
+	return e
+}
+
+func tables(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sst"))
+	require.NoError(t, err)
+	return paths
+}
+
+func key(i int) string {
+	return fmt.Sprintf("key%04d", i)
+}
+
+func TestEngineSetGetDel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := openEngine(t, dir)
+
+	const total = 1000
+	for i := range total {
+		require.NoError(t, e.Set(ctx, key(i), "v1"))
+	}
+	for i := 0; i < total; i += 2 {
+		require.NoError(t, e.Set(ctx, key(i), "v2"))
+	}
+	for i := 0; i < total; i += 4 {
+		require.NoError(t, e.Del(ctx, key(i)))
+	}
+	require.NoError(t, e.Del(ctx, key(0)))
+
+	require.Eventually(t, func() bool { return len(tables(t, dir)) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	check := func() {
+		for i := range total {
+			got, err := e.Get(ctx, key(i))
+			switch {
+			case i%4 == 0:
+				require.ErrorIs(t, err, dberrors.ErrNotFound, key(i))
+			case i%2 == 0:
+				require.NoError(t, err)
+				assert.Equal(t, "v2", got, key(i))
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "v1", got, key(i))
+			}
+		}
+	}
+	check()
+
+	// more writes push the earlier ones down the levels
+	for i := range total {
+		require.NoError(t, e.Set(ctx, fmt.Sprintf("other%04d", i), "x"))
+	}
+	check()
+
+	n, err := e.CountPrefix(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, total-total/4, n)
+}
+
+func TestEngineRangeAndScan(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	e := openEngine(t, t.TempDir())
+
+	const total = 500
+	for i := range total {
+		require.NoError(t, e.Set(ctx, key(i), "v"))
+	}
+	require.NoError(t, e.Del(ctx, key(1)))
+
+	keys, next, err := e.Range(ctx, key(0), key(5), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{key(0), key(2), key(3)}, keys)
+	assert.Equal(t, key(4), next)
+
+	keys, next, err = e.Range(ctx, next, key(5), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{key(4)}, keys)
+	assert.Empty(t, next)
+
+	var (
+		scanned []string
+		cursor  uint64
+	)
+	for {
+		var page []string
+		page, cursor, err = e.Scan(ctx, cursor, 64)
+		require.NoError(t, err)
+		scanned = append(scanned, page...)
+		if cursor == 0 {
+			break
+		}
+	}
+	assert.Len(t, scanned, total-1)
+	assert.IsIncreasing(t, scanned)
+
+	_, _, err = e.Scan(ctx, 1<<40, 10)
+	require.ErrorIs(t, err, dberrors.ErrInvalidCursor)
+}
+
+// TestEngineRangeDuringWrites checks that range queries read a consistent
+// view while writes flush and compact the tables they read.
+func TestEngineRangeDuringWrites(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := openEngine(t, dir)
+
+	const total = 300
+	for i := range total {
+		require.NoError(t, e.Set(ctx, key(i), "v"))
+	}
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := range 2000 {
+			assert.NoError(t, e.Set(ctx, fmt.Sprintf("other%04d", i), "x"))
+		}
+	})
+
+	for range 50 {
+		keys, next, err := e.Range(ctx, "key", "kez", 0)
+		require.NoError(t, err)
+		assert.Len(t, keys, total)
+		assert.Empty(t, next)
+
+		n, err := e.CountPrefix(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, total, n)
+	}
+	wg.Wait()
+}
+
+func TestEngineExpiry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+	e := openEngine(t, t.TempDir(), lsm.WithClock(clock.Now))
+
+	require.NoError(t, e.SetWithExpiry(ctx, "session", "abc", clock.Now().Add(time.Minute)))
+	require.NoError(t, e.Set(ctx, "counter", "1"))
+
+	ttl, err := e.TTL(ctx, "session")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+	_, err = e.TTL(ctx, "counter")
+	require.ErrorIs(t, err, dberrors.ErrNoExpiry)
+
+	require.NoError(t, e.Expire(ctx, "counter", clock.Now().Add(time.Second)))
+	n, expireAt, err := e.IncrBy(ctx, "counter", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, clock.Now().Add(time.Second), expireAt)
+
+	require.NoError(t, e.Persist(ctx, "session"))
+	require.ErrorIs(t, e.Persist(ctx, "session"), dberrors.ErrNoExpiry)
+
+	clock.Advance(time.Hour)
+	_, err = e.Get(ctx, "counter")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	got, err := e.Get(ctx, "session")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", got)
+
+	version, err := e.Version(ctx, "counter")
+	require.NoError(t, err)
+	assert.Zero(t, version)
+}
+
+func TestEngineConditionalWrites(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	e := openEngine(t, t.TempDir())
+
+	_, err := e.SetIf(ctx, "k", "v1", time.Time{}, kv.Condition{Kind: kv.IfPresent})
+	require.ErrorIs(t, err, dberrors.ErrConditionFailed)
+
+	previous, err := e.SetIf(ctx, "k", "v1", time.Time{}, kv.Condition{Kind: kv.IfAbsent})
+	require.NoError(t, err)
+	assert.False(t, previous.Found)
+
+	previous, err = e.SetIf(ctx, "k", "v2", time.Time{}, kv.Condition{Kind: kv.IfEquals, Expected: "v1"})
+	require.NoError(t, err)
+	assert.Equal(t, kv.Lookup{Value: "v1", Found: true}, previous)
+
+	before, err := e.Version(ctx, "k")
+	require.NoError(t, err)
+	require.NoError(t, e.MSet(ctx, []kv.Pair{{Key: "k", Value: "v3"}, {Key: "j", Value: "x"}}))
+	after, err := e.Version(ctx, "k")
+	require.NoError(t, err)
+	assert.Greater(t, after, before)
+
+	lookups, err := e.MGet(ctx, []string{"k", "missing", "j"})
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Lookup{{Value: "v3", Found: true}, {}, {Value: "x", Found: true}}, lookups)
+
+	deleted, err := e.MDel(ctx, []string{"k", "missing", "j"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	_, _, err = e.IncrByFloat(ctx, "f", 1.5)
+	require.NoError(t, err)
+	result, _, err := e.IncrByFloat(ctx, "f", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "2.5", result)
+	_, _, err = e.IncrBy(ctx, "f", 1)
+	require.ErrorIs(t, err, dberrors.ErrNotInteger)
+}
+
+func TestEngineContainers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	e := openEngine(t, t.TempDir())
+
+	added, err := e.HSet(ctx, "h", []kv.Pair{{Key: "b", Value: "2"}, {Key: "a", Value: "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	fields, err := e.HGetAll(ctx, "h")
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Pair{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}}, fields)
+	deleted, err := e.HDel(ctx, "h", []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	_, err = e.HGet(ctx, "h", "a")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	n, err := e.Push(ctx, "l", []string{"a", "b"}, kv.Left)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	_, err = e.Push(ctx, "l", []string{"c"}, kv.Right)
+	require.NoError(t, err)
+	items, err := e.LRange(ctx, "l", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a", "c"}, items)
+	value, err := e.Pop(ctx, "l", kv.Right)
+	require.NoError(t, err)
+	assert.Equal(t, "c", value)
+
+	added, err = e.SAdd(ctx, "s1", []string{"x", "y", "x"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	_, err = e.SAdd(ctx, "s2", []string{"y", "z"})
+	require.NoError(t, err)
+	inter, err := e.SInter(ctx, []string{"s1", "s2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"y"}, inter)
+	union, err := e.SUnion(ctx, []string{"s1", "s2", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "y", "z"}, union)
+
+	added, err = e.ZAdd(ctx, "z", []kv.ScoredMember{{Member: "a", Score: 3}, {Member: "b", Score: 1}, {Member: "c", Score: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	rank, err := e.ZRank(ctx, "z", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rank)
+	members, err := e.ZRangeByScore(ctx, "z", kv.ScoreBound{Score: 1, Exclusive: true}, kv.ScoreBound{Score: 3}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []kv.ScoredMember{{Member: "c", Score: 2}, {Member: "a", Score: 3}}, members)
+	removed, err := e.ZRem(ctx, "z", []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	_, err = e.ZScore(ctx, "z", "a")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	_, err = e.Get(ctx, "s1")
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	_, err = e.Push(ctx, "s1", []string{"a"}, kv.Left)
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+}
+
+func TestEngineCompression(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := openEngine(t, dir, lsm.WithCompression(compression.NewCompressor(compression.Flate, 64)))
+	large := strings.Repeat("0123456789", 50)
+
+	require.NoError(t, e.Set(ctx, "first", large))
+	st, err := e.Stats(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, st.MemorySaved)
+	assert.Less(t, st.UsedMemory, int64(len(large)))
+
+	// enough compressed values to go through flushes and compactions
+	for i := range 200 {
+		require.NoError(t, e.Set(ctx, key(i), large+strconv.Itoa(i)))
+	}
+	_, err = e.Push(ctx, "list", []string{large, large}, kv.Right)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(tables(t, dir)) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	got, err := e.Get(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+	for i := range 200 {
+		got, err := e.Get(ctx, key(i))
+		require.NoError(t, err)
+		require.Equal(t, large+strconv.Itoa(i), got)
+	}
+	items, err := e.LRange(ctx, "list", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{large, large}, items)
+
+	n, _, err := e.IncrBy(ctx, "counter", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	view, err := e.Snapshot(ctx)
+	require.NoError(t, err)
+	defer view.Close()
+	require.NoError(t, view.Range(func(item kv.Item) error {
+		if item.Key == "first" {
+			assert.Equal(t, large, item.Value)
+		}
+		return nil
+	}))
+}
+
+func TestEngineSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := openEngine(t, dir)
+
+	const total = 300
+	for i := range total {
+		require.NoError(t, e.Set(ctx, key(i), "before"))
+	}
+	_, err := e.SAdd(ctx, "set", []string{"m"})
+	require.NoError(t, err)
+
+	view, err := e.Snapshot(ctx)
+	require.NoError(t, err)
+	defer view.Close()
+
+	// writes after the view, enough to flush and compact the tables it reads
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range total {
+			assert.NoError(t, e.Set(ctx, key(i), "after"))
+		}
+		assert.NoError(t, e.Del(ctx, "set"))
+	}()
+
+	seen := 0
+	require.NoError(t, view.Range(func(item kv.Item) error {
+		seen++
+		if item.Key == "set" {
+			assert.Equal(t, []string{"m"}, item.Members)
+		} else {
+			assert.Equal(t, "before", item.Value, item.Key)
+		}
+		return nil
+	}))
+	assert.Equal(t, total+1, seen)
+	wg.Wait()
+}
+
+func TestEngineFlush(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	var (
+		mu      sync.Mutex
+		deleted int
+	)
+	e := openEngine(t, dir, lsm.WithListener(func(event kv.Event) {
+		if event.Type == kv.EventDel {
+			mu.Lock()
+			deleted++
+			mu.Unlock()
+		}
+	}))
+
+	const total = 300
+	for i := range total {
+		require.NoError(t, e.Set(ctx, key(i), "v"))
+	}
+	require.NoError(t, e.Flush(ctx))
+
+	mu.Lock()
+	assert.Equal(t, total, deleted)
+	mu.Unlock()
+
+	_, err := e.Get(ctx, key(0))
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	assert.Empty(t, tables(t, dir))
+
+	require.NoError(t, e.Set(ctx, key(0), "again"))
+	got, err := e.Get(ctx, key(0))
+	require.NoError(t, err)
+	assert.Equal(t, "again", got)
+}
+
+func TestEngineReopensTables(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := openEngine(t, dir)
+	for i := range 300 {
+		require.NoError(t, first.Set(ctx, key(i), "v"+strconv.Itoa(i)))
+	}
+	require.NoError(t, first.Del(ctx, key(7)))
+	require.NoError(t, first.Close())
+
+	// a table no manifest lists is left over from a flush cut short
+	orphan := filepath.Join(dir, "999999.sst")
+	require.NoError(t, os.WriteFile(orphan, []byte("partial"), 0o600))
+
+	second := openEngine(t, dir)
+	for _, i := range []int{0, 150, 299} {
+		got, err := second.Get(ctx, key(i))
+		require.NoError(t, err)
+		assert.Equal(t, "v"+strconv.Itoa(i), got)
+	}
+	_, err := second.Get(ctx, key(7))
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	assert.NoFileExists(t, orphan)
+
+	// the writes after the restart are the newest versions
+	require.NoError(t, second.Set(ctx, key(0), "again"))
+	got, err := second.Get(ctx, key(0))
+	require.NoError(t, err)
+	assert.Equal(t, "again", got)
+}
+
+func TestEngineRecoverFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	walDir, tableDir := t.TempDir(), t.TempDir()
+
+	openWAL := func() *wal.WAL {
+		w, err := wal.New(logger, walDir, wal.WithMaxSegmentSize(512))
+		require.NoError(t, err)
+		return w
+	}
+	open := func() (*storage.Storage, *wal.WAL, *lsm.Engine) {
+		w := openWAL()
+		e, err := lsm.Open(logger, tableDir, lsm.WithMemtableSize(1<<10), lsm.WithTableSize(2<<10))
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, e, storage.WithWAL(w), storage.WithCheckpointer(e))
+		require.NoError(t, s.Recover(ctx))
+		return s, w, e
+	}
+	get := func(s *storage.Storage, key string) string {
+		got, err := s.Get(ctx, key)
+		if errors.Is(err, dberrors.ErrNotFound) {
+			return ""
+		}
+		require.NoError(t, err)
+		return got
+	}
+
+	s, w, e := open()
+	for i := range 200 {
+		require.NoError(t, s.Set(ctx, key(i), "v"+strconv.Itoa(i)))
+	}
+	require.NoError(t, s.Del(ctx, key(7)))
+
+	// the segments the flushed tables hold are no longer needed
+	segments := func() int {
+		paths, err := filepath.Glob(filepath.Join(walDir, "wal_*.log"))
+		require.NoError(t, err)
+		return len(paths)
+	}
+	before := segments()
+	require.Eventually(t, func() bool { return e.CheckpointLSN() > 100 }, 5*time.Second, 10*time.Millisecond)
+	lsn, err := s.TruncateLog()
+	require.NoError(t, err)
+	assert.Positive(t, lsn)
+	assert.Less(t, segments(), before)
+
+	require.NoError(t, w.Close())
+	require.NoError(t, e.Close())
+	assert.Equal(t, w.LastLSN(), e.CheckpointLSN())
+
+	// the records logged after the checkpoint are replayed, as after a
+	// crash that lost the memtables
+	w = openWAL()
+	require.NoError(t, w.Append(ctx, "SET", key(150), "late"))
+	require.NoError(t, w.Append(ctx, "DEL", key(151)))
+	require.NoError(t, w.Close())
+
+	s, w, e = open()
+	assert.Equal(t, "v0", get(s, key(0)))
+	assert.Empty(t, get(s, key(7)))
+	assert.Equal(t, "late", get(s, key(150)))
+	assert.Empty(t, get(s, key(151)))
+	assert.Equal(t, "v152", get(s, key(152)))
+	require.NoError(t, w.Close())
+	require.NoError(t, e.Close())
+
+	// a snapshot past the checkpoint replaces the tables
+	w = openWAL()
+	require.NoError(t, w.WriteSnapshot(w.LastLSN()+1, func(emit func(command string, args ...string) error) error {
+		return emit("SET", "only", "x")
+	}))
+	require.NoError(t, w.Close())
+
+	s, w, e = open()
+	t.Cleanup(func() {
+		_ = w.Close()
+		_ = e.Close()
+	})
+	assert.Equal(t, "x", get(s, "only"))
+	assert.Empty(t, get(s, key(0)))
+}
+
+func TestEngineCheckpointAheadOfWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	tableDir := t.TempDir()
+
+	open := func(walDir string) (*storage.Storage, *wal.WAL, *lsm.Engine) {
+		w, err := wal.New(logger, walDir)
+		require.NoError(t, err)
+		e, err := lsm.Open(logger, tableDir)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = w.Close()
+			_ = e.Close()
+		})
+		return storage.NewStorage(logger, e, storage.WithWAL(w), storage.WithCheckpointer(e)), w, e
+	}
+
+	s, w, e := open(t.TempDir())
+	require.NoError(t, s.Recover(ctx))
+	require.NoError(t, s.Set(ctx, "k", "v"))
+	require.NoError(t, w.Close())
+	require.NoError(t, e.Close())
+
+	// the tables hold a record a new log does not
+	s, _, _ = open(t.TempDir())
+	require.ErrorIs(t, s.Recover(ctx), storage.ErrCheckpointAhead)
+}
@@ -0,0 +1,111 @@
+package lsm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const manifestName = "MANIFEST"
+
+// manifest is the state of the tree on disk: the tables of every level, in
+// the order the engine keeps them, and the position they reach. Every
+// change of the tables is made durable by writing a new manifest; the files
+// it does not list are left over from a flush or a compaction cut short.
+type manifest struct {
+	// LSN is the last storage record the tables hold whole, see
+	// Engine.Logged.
+	LSN uint64 `json:"lsn"`
+	// Seq is the sequence number of the last write, so that the versions
+	// written after a restart stay the newest.
+	Seq       uint64     `json:"seq"`
+	NextTable uint64     `json:"next_table"`
+	Levels    [][]uint64 `json:"levels"`
+}
+
+// readManifest returns the manifest of dir, the zero manifest when there is
+// none yet.
+func readManifest(dir string) (manifest, error) {
+	var m manifest
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestName)) //nolint:gosec // dir is the engine directory
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", manifestName, err)
+	}
+	if len(m.Levels) > maxLevels {
+		return m, fmt.Errorf("%s: %d levels", manifestName, len(m.Levels))
+	}
+	return m, nil
+}
+
+// saveManifest writes the manifest of the current tables. It is written to a
+// temporary file renamed into place once fsynced, so a crash leaves either
+// the previous manifest or the new one. manifestMu keeps an older state from
+// being written over a newer one.
+func (e *Engine) saveManifest() error {
+	e.manifestMu.Lock()
+	defer e.manifestMu.Unlock()
+
+	e.mu.RLock()
+	m := manifest{
+		LSN:       e.checkpointLSN,
+		Seq:       e.seq,
+		NextTable: e.nextTable.Load(),
+		Levels:    make([][]uint64, len(e.levels)),
+	}
+	for level, tables := range e.levels {
+		for _, t := range tables {
+			m.Levels[level] = append(m.Levels[level], t.id)
+		}
+	}
+	e.mu.RUnlock()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(e.dir, manifestName)
+	if err := writeFileSync(path+".tmp", data); err != nil {
+		_ = os.Remove(path + ".tmp")
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	return syncDir(e.dir)
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640) //nolint:gosec // path is built from the engine directory
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir) //nolint:gosec // dir is the engine directory
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,77 @@
+package lsm
+
+import (
+	"iter"
+	"strings"
+
+	"lesson1/internal/database/skiplist"
+)
+
+// memtable holds the latest writes in memory: the entries by key and the
+// keys in order. It is not safe for concurrent use; the engine lock guards
+// it. Once full it becomes immutable and is flushed to a table.
+type memtable struct {
+	entries map[string]entry
+	keys    *skiplist.List[string]
+	bytes   int64
+	// saved is how many bytes compression spares in the values.
+	saved int64
+	// live counts the entries that are not tombstones, for Stats.
+	live int
+	// lsn is the last storage record it holds whole, see Engine.Logged.
+	lsn uint64
+}
+
+func newMemtable() *memtable {
+	return &memtable{
+		entries: make(map[string]entry),
+		keys:    skiplist.New(strings.Compare),
+	}
+}
+
+func (m *memtable) get(key string) (entry, bool) {
+	e, ok := m.entries[key]
+	return e, ok
+}
+
+func (m *memtable) put(key string, e entry) {
+	if old, ok := m.entries[key]; ok {
+		m.bytes -= recordSize(key, old)
+		m.saved -= old.saved
+		if old.kind != kindTombstone {
+			m.live--
+		}
+	} else {
+		m.keys.Insert(key)
+	}
+	m.entries[key] = e
+	m.bytes += recordSize(key, e)
+	m.saved += e.saved
+	if e.kind != kindTombstone {
+		m.live++
+	}
+}
+
+// from yields the records with a key not before start, in key order.
+func (m *memtable) from(start string) iter.Seq[record] {
+	return func(yield func(record) bool) {
+		for key := range m.keys.From(start) {
+			if !yield(record{key: key, entry: m.entries[key]}) {
+				return
+			}
+		}
+	}
+}
+
+// between returns a copy of the records in [start, end) in key order; an
+// empty end is no upper bound.
+func (m *memtable) between(start, end string) []record {
+	var records []record
+	for r := range m.from(start) {
+		if end != "" && r.key >= end {
+			break
+		}
+		records = append(records, r)
+	}
+	return records
+}
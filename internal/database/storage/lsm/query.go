@@ -0,0 +1,322 @@
+package lsm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
+)
+
+// maxCursors bounds the Scan cursors remembered at once; the oldest one is
+// forgotten first.
+const maxCursors = 1024
+
+// cursors remembers the key every Scan cursor resumes from. A cursor is a
+// number, so the key it stands for is kept here.
+type cursors struct {
+	mu    sync.Mutex
+	last  uint64
+	keys  map[uint64]string
+	order []uint64
+}
+
+func newCursors() cursors {
+	return cursors{keys: make(map[uint64]string)}
+}
+
+func (c *cursors) add(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.last++
+	c.keys[c.last] = key
+	c.order = append(c.order, c.last)
+	if len(c.order) > maxCursors {
+		delete(c.keys, c.order[0])
+		c.order = c.order[1:]
+	}
+	return c.last
+}
+
+func (c *cursors) get(cursor uint64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.keys[cursor]
+	return key, ok
+}
+
+// Scan returns up to count keys in lexicographic order and the cursor to
+// continue from, 0 when the walk is complete. A walk starts at cursor 0. A
+// key present for the whole walk is returned exactly once. It fails with
+// dberrors.ErrInvalidCursor for a cursor it did not hand out or has
+// forgotten.
+func (e *Engine) Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error) {
+	const op = "lsm.Scan"
+	_ = ctx
+
+	var start string
+	if cursor != 0 {
+		key, ok := e.cursors.get(cursor)
+		if !ok {
+			return nil, 0, fmt.Errorf("%s: %w", op, dberrors.ErrInvalidCursor)
+		}
+		start = key
+	}
+
+	keys, next, err := e.Range(ctx, start, "", max(1, count))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if next == "" {
+		return keys, 0, nil
+	}
+	return keys, e.cursors.add(next), nil
+}
+
+// Range returns up to limit keys in [start, end) in lexicographic order and
+// the key the next page starts from, empty when the range is exhausted. An
+// empty end is no upper bound and a non-positive limit is no limit. The
+// tables are read from a view, without holding the engine lock.
+func (e *Engine) Range(ctx context.Context, start, end string, limit int) ([]string, string, error) {
+	const op = "lsm.Range"
+	_ = ctx
+
+	e.mu.RLock()
+	v := e.viewLocked(start, end)
+	e.mu.RUnlock()
+	defer v.Close()
+
+	var (
+		keys []string
+		next string
+	)
+	err := v.ascend(start, end, func(key string) bool {
+		if limit > 0 && len(keys) == limit {
+			next = key
+			return false
+		}
+		keys = append(keys, key)
+		return true
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, next, nil
+}
+
+// CountPrefix returns the number of keys that start with prefix.
+func (e *Engine) CountPrefix(ctx context.Context, prefix string) (int, error) {
+	const op = "lsm.CountPrefix"
+	_ = ctx
+
+	end := kv.PrefixEnd(prefix)
+	e.mu.RLock()
+	v := e.viewLocked(prefix, end)
+	e.mu.RUnlock()
+	defer v.Close()
+
+	count := 0
+	err := v.ascend(prefix, end, func(string) bool {
+		count++
+		return true
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// Stats reports the memory of the memtables as used memory, and the bytes
+// compression spares in them as saved memory. The number of
+// keys is an estimate: a key is counted once for every memtable and table
+// holding a version of it until compaction merges them, and expired keys
+// are counted until compaction drops them.
+func (e *Engine) Stats(ctx context.Context) (stats.Stats, error) {
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	s := stats.Stats{
+		Keys:           e.mem.live,
+		UsedMemory:     e.mem.bytes,
+		EvictionPolicy: "noeviction",
+		ExpiredKeys:    e.expiredKeys.Load(),
+		MemorySaved:    e.mem.saved,
+	}
+	for _, mem := range e.imm {
+		s.Keys += mem.live
+		s.UsedMemory += mem.bytes
+		s.MemorySaved += mem.saved
+	}
+	for _, tables := range e.levels {
+		for _, t := range tables {
+			s.Keys += t.live
+		}
+	}
+	return s, nil
+}
+
+// view is a point-in-time view of the engine: copies of the memtables, or of
+// the part of them a query reads, and references to the tables, which are
+// immutable.
+type view struct {
+	e         *Engine
+	mems      [][]record
+	levels    [maxLevels][]*table
+	closeOnce sync.Once
+}
+
+// Snapshot returns a point-in-time view of every key. Taking it copies the
+// memtables; the tables it reads are kept until it is closed, even when
+// compaction replaces them.
+func (e *Engine) Snapshot(ctx context.Context) (kv.View, error) {
+	_ = ctx
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.viewLocked("", ""), nil
+}
+
+// viewLocked returns a view of the keys in [start, end), an empty end being
+// no upper bound. The caller holds the engine lock.
+func (e *Engine) viewLocked(start, end string) *view {
+	v := &view{e: e, mems: [][]record{e.mem.between(start, end)}}
+	for i := len(e.imm) - 1; i >= 0; i-- {
+		v.mems = append(v.mems, e.imm[i].between(start, end))
+	}
+	for level, tables := range e.levels {
+		for _, t := range tables {
+			t.ref()
+		}
+		v.levels[level] = append([]*table(nil), tables...)
+	}
+	return v
+}
+
+// records calls fn for the newest record of every key in [start, end) in
+// order, tombstones and expired keys included, until it returns false.
+func (v *view) records(start, end string, fn func(record) bool) error {
+	var sources []iterator
+	for _, records := range v.mems {
+		sources = append(sources, &sliceIter{records: records})
+	}
+	level0 := v.levels[0]
+	for i := len(level0) - 1; i >= 0; i-- {
+		sources = append(sources, newLevelIter(level0[i:i+1], start))
+	}
+	for _, tables := range v.levels[1:] {
+		if len(tables) > 0 {
+			sources = append(sources, newLevelIter(tables, start))
+		}
+	}
+
+	merged := newMergeIter(sources)
+	for r, ok := merged.next(); ok; r, ok = merged.next() {
+		if end != "" && r.key >= end {
+			break
+		}
+		if !fn(r) {
+			break
+		}
+	}
+	return merged.close()
+}
+
+// ascend calls fn for the live keys in [start, end) in order until it
+// returns false.
+func (v *view) ascend(start, end string, fn func(key string) bool) error {
+	now := v.e.now()
+	return v.records(start, end, func(r record) bool {
+		return !r.live(now) || fn(r.key)
+	})
+}
+
+// Range calls fn with every key of the view in lexicographic order, skipping
+// the keys that expired since the view was taken, and stops at the first
+// error.
+func (v *view) Range(fn func(kv.Item) error) error {
+	var fnErr error
+	now := v.e.now()
+	err := v.records("", "", func(r record) bool {
+		if !r.live(now) {
+			return true
+		}
+		item, err := r.item()
+		if err == nil {
+			err = fn(item)
+		}
+		fnErr = err
+		return err == nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// Close releases the tables of the view.
+func (v *view) Close() {
+	v.closeOnce.Do(func() {
+		for _, tables := range v.levels {
+			for _, t := range tables {
+				t.unref()
+			}
+		}
+	})
+}
+
+// Flush removes every key, dropping the memtables and the tables.
+func (e *Engine) Flush(ctx context.Context) error {
+	const op = "lsm.Flush"
+	_ = ctx
+
+	// the background flush and compaction must not install tables holding
+	// the dropped keys afterwards
+	e.compactMu.Lock()
+	defer e.compactMu.Unlock()
+	e.flushMu.Lock()
+	defer e.flushMu.Unlock()
+
+	e.mu.Lock()
+	if e.listener != nil {
+		v := e.viewLocked("", "")
+		err := v.ascend("", "", func(key string) bool {
+			e.notify(kv.EventDel, key)
+			return true
+		})
+		v.Close()
+		if err != nil {
+			e.mu.Unlock()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// the writes before the flush are gone from the tables, the log is to
+	// be replayed from its start until a new checkpoint
+	e.mem = newMemtable()
+	e.imm = nil
+	e.checkpointLSN = 0
+	e.roomy.Broadcast()
+	dropped := e.levels
+	for level := range e.levels {
+		e.levels[level] = nil
+		e.compactFrom[level] = ""
+	}
+	e.mu.Unlock()
+
+	if err := e.saveManifest(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, tables := range dropped {
+		for _, t := range tables {
+			t.drop()
+		}
+	}
+	return nil
+}
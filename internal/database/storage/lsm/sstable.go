@@ -0,0 +1,404 @@
+package lsm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+	"sync/atomic"
+)
+
+const (
+	// blockSize is the size a data block is cut at. The index keeps one key
+	// per block, so a lookup reads a single block.
+	blockSize = 4 << 10
+
+	blockHeaderSize = 8
+	footerSize      = 40
+	tableMagic      = "KVSST001"
+
+	tableSuffix = ".sst"
+)
+
+var (
+	ErrCorruptedTable = errors.New("corrupted table")
+
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// blockHandle locates a data block and holds its last key.
+type blockHandle struct {
+	lastKey string
+	offset  int64
+	length  int64
+}
+
+// table is an immutable sorted string table. The file is
+//
+//	data blocks | index block | bloom block | footer
+//
+// where every block is framed as uint32 payload length | uint32 CRC-32C of
+// the payload | payload. A data block holds records in key order. The index
+// block holds the smallest key, the entry counts and the handle of every
+// data block; it is kept in memory together with the bloom filter, so a
+// lookup reads at most one data block. The footer holds the offsets and
+// lengths of the index and bloom blocks and the magic.
+type table struct {
+	id   uint64
+	path string
+	file *os.File
+	size int64
+
+	smallest string
+	largest  string
+	// entries counts the records, live those that are not tombstones.
+	entries int
+	live    int
+
+	index  []blockHandle
+	filter *bloom
+
+	// refs counts the level holding the table and the open views reading
+	// it; the file is closed once nobody does, and deleted too when the
+	// table was dropped from the tree.
+	refs    atomic.Int32
+	dropped atomic.Bool
+}
+
+func tableName(id uint64) string {
+	return fmt.Sprintf("%06d%s", id, tableSuffix)
+}
+
+// tableWriter writes a table from records added in key order.
+type tableWriter struct {
+	id     uint64
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+	offset int64
+
+	block   []byte
+	lastKey string
+	index   []blockHandle
+	keys    []string
+
+	smallest string
+	entries  int
+	live     int
+}
+
+func newTableWriter(path string, id uint64) (*tableWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640) //nolint:gosec // path is built from the engine directory
+	if err != nil {
+		return nil, err
+	}
+	return &tableWriter{id: id, path: path, file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (w *tableWriter) add(r record) error {
+	if w.entries == 0 {
+		w.smallest = r.key
+	}
+	w.block = appendRecord(w.block, r)
+	w.lastKey = r.key
+	w.keys = append(w.keys, r.key)
+	w.entries++
+	if r.kind != kindTombstone {
+		w.live++
+	}
+
+	if len(w.block) >= blockSize {
+		return w.flushBlock()
+	}
+	return nil
+}
+
+// size returns the bytes written so far, the pending block included.
+func (w *tableWriter) size() int64 {
+	return w.offset + int64(len(w.block))
+}
+
+func (w *tableWriter) flushBlock() error {
+	if len(w.block) == 0 {
+		return nil
+	}
+	offset, length, err := w.writeBlock(w.block)
+	if err != nil {
+		return err
+	}
+	w.index = append(w.index, blockHandle{lastKey: w.lastKey, offset: offset, length: length})
+	w.block = w.block[:0]
+	return nil
+}
+
+func (w *tableWriter) writeBlock(payload []byte) (int64, int64, error) {
+	frame := make([]byte, 0, blockHeaderSize+len(payload))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.Checksum(payload, castagnoli))
+	frame = append(frame, payload...)
+
+	offset := w.offset
+	n, err := w.writer.Write(frame)
+	w.offset += int64(n)
+	return offset, int64(n), err
+}
+
+// finish writes the index, the bloom filter and the footer and opens the
+// table for reading.
+func (w *tableWriter) finish() (*table, error) {
+	if err := w.flushBlock(); err != nil {
+		return nil, w.abort(err)
+	}
+
+	index := appendString(nil, w.smallest)
+	index = binary.AppendUvarint(index, uint64(w.entries))
+	index = binary.AppendUvarint(index, uint64(w.live))
+	index = binary.AppendUvarint(index, uint64(len(w.index)))
+	for _, h := range w.index {
+		index = appendString(index, h.lastKey)
+		index = binary.AppendUvarint(index, uint64(h.offset))
+		index = binary.AppendUvarint(index, uint64(h.length))
+	}
+	indexOffset, indexLength, err := w.writeBlock(index)
+	if err != nil {
+		return nil, w.abort(err)
+	}
+
+	filter := newBloom(len(w.keys))
+	for _, key := range w.keys {
+		filter.add(key)
+	}
+	bloomOffset, bloomLength, err := w.writeBlock(filter.bits)
+	if err != nil {
+		return nil, w.abort(err)
+	}
+
+	footer := make([]byte, 0, footerSize)
+	for _, v := range []int64{indexOffset, indexLength, bloomOffset, bloomLength} {
+		footer = binary.BigEndian.AppendUint64(footer, uint64(v))
+	}
+	footer = append(footer, tableMagic...)
+	if _, err := w.writer.Write(footer); err != nil {
+		return nil, w.abort(err)
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		return nil, w.abort(err)
+	}
+	// the table must be on disk before a manifest lists it
+	if err := w.file.Sync(); err != nil {
+		return nil, w.abort(err)
+	}
+	if err := w.file.Close(); err != nil {
+		return nil, w.abort(err)
+	}
+
+	t, err := openTable(w.path, w.id)
+	if err != nil {
+		_ = os.Remove(w.path)
+		return nil, err
+	}
+	return t, nil
+}
+
+// abort deletes the partially written table and returns err.
+func (w *tableWriter) abort(err error) error {
+	_ = w.file.Close()
+	_ = os.Remove(w.path)
+	return err
+}
+
+func openTable(path string, id uint64) (*table, error) {
+	file, err := os.Open(path) //nolint:gosec // path is built from the engine directory
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := readTable(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: %w", tableName(id), err)
+	}
+	t.id = id
+	t.path = path
+	t.refs.Store(1)
+	return t, nil
+}
+
+func readTable(file *os.File) (*table, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	t := &table{file: file, size: info.Size()}
+
+	if t.size < footerSize {
+		return nil, fmt.Errorf("size: %w", ErrCorruptedTable)
+	}
+	footer := make([]byte, footerSize)
+	if _, err := file.ReadAt(footer, t.size-footerSize); err != nil {
+		return nil, err
+	}
+	if string(footer[32:]) != tableMagic {
+		return nil, fmt.Errorf("magic: %w", ErrCorruptedTable)
+	}
+	indexOffset := int64(binary.BigEndian.Uint64(footer[0:]))
+	indexLength := int64(binary.BigEndian.Uint64(footer[8:]))
+	bloomOffset := int64(binary.BigEndian.Uint64(footer[16:]))
+	bloomLength := int64(binary.BigEndian.Uint64(footer[24:]))
+
+	index, err := t.readBlock(indexOffset, indexLength)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.decodeIndex(index); err != nil {
+		return nil, err
+	}
+
+	bits, err := t.readBlock(bloomOffset, bloomLength)
+	if err != nil {
+		return nil, err
+	}
+	if len(bits) == 0 {
+		return nil, fmt.Errorf("bloom: %w", ErrCorruptedTable)
+	}
+	t.filter = &bloom{bits: bits}
+
+	return t, nil
+}
+
+func (t *table) decodeIndex(buf []byte) error {
+	var err error
+	if t.smallest, buf, err = readString(buf); err != nil {
+		return fmt.Errorf("index: %w", ErrCorruptedTable)
+	}
+
+	var counts [3]uint64
+	for i := range counts {
+		var n int
+		if counts[i], n = binary.Uvarint(buf); n <= 0 {
+			return fmt.Errorf("index: %w", ErrCorruptedTable)
+		}
+		buf = buf[n:]
+	}
+	t.entries, t.live = int(counts[0]), int(counts[1])
+
+	t.index = make([]blockHandle, 0, counts[2])
+	for range counts[2] {
+		var h blockHandle
+		if h.lastKey, buf, err = readString(buf); err != nil {
+			return fmt.Errorf("index: %w", ErrCorruptedTable)
+		}
+		offset, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return fmt.Errorf("index: %w", ErrCorruptedTable)
+		}
+		buf = buf[n:]
+		length, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return fmt.Errorf("index: %w", ErrCorruptedTable)
+		}
+		buf = buf[n:]
+		h.offset, h.length = int64(offset), int64(length)
+		t.index = append(t.index, h)
+	}
+	if len(buf) != 0 || len(t.index) == 0 {
+		return fmt.Errorf("index: %w", ErrCorruptedTable)
+	}
+
+	t.largest = t.index[len(t.index)-1].lastKey
+	return nil
+}
+
+// readBlock reads the frame at offset and returns its payload once its
+// checksum matched.
+func (t *table) readBlock(offset, length int64) ([]byte, error) {
+	if offset < 0 || length < blockHeaderSize || offset+length > t.size {
+		return nil, fmt.Errorf("block bounds: %w", ErrCorruptedTable)
+	}
+	frame := make([]byte, length)
+	if _, err := t.file.ReadAt(frame, offset); err != nil {
+		return nil, err
+	}
+
+	payload := frame[blockHeaderSize:]
+	if int64(binary.BigEndian.Uint32(frame)) != int64(len(payload)) {
+		return nil, fmt.Errorf("block length: %w", ErrCorruptedTable)
+	}
+	if crc32.Checksum(payload, castagnoli) != binary.BigEndian.Uint32(frame[4:]) {
+		return nil, fmt.Errorf("block checksum: %w", ErrCorruptedTable)
+	}
+	return payload, nil
+}
+
+// records reads the records of data block i.
+func (t *table) records(i int) ([]record, error) {
+	h := t.index[i]
+	buf, err := t.readBlock(h.offset, h.length)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []record
+	for len(buf) > 0 {
+		var r record
+		if r, buf, err = readRecord(buf); err != nil {
+			return nil, fmt.Errorf("block %d: %w: %w", i, ErrCorruptedTable, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// blockFor returns the first data block that may hold key, len(t.index)
+// when key sorts after every key of the table.
+func (t *table) blockFor(key string) int {
+	return sort.Search(len(t.index), func(i int) bool { return t.index[i].lastKey >= key })
+}
+
+// get returns the entry of key, consulting the bloom filter before reading
+// the one data block that may hold it.
+func (t *table) get(key string) (entry, bool, error) {
+	if key < t.smallest || key > t.largest || !t.filter.mayContain(key) {
+		return entry{}, false, nil
+	}
+
+	records, err := t.records(t.blockFor(key))
+	if err != nil {
+		return entry{}, false, err
+	}
+	i := sort.Search(len(records), func(i int) bool { return records[i].key >= key })
+	if i < len(records) && records[i].key == key {
+		return records[i].entry, true, nil
+	}
+	return entry{}, false, nil
+}
+
+// overlaps reports whether the table holds keys in [smallest, largest].
+func (t *table) overlaps(smallest, largest string) bool {
+	return t.largest >= smallest && t.smallest <= largest
+}
+
+func (t *table) ref() {
+	t.refs.Add(1)
+}
+
+// unref drops a reference and closes the file with the last one.
+func (t *table) unref() {
+	if t.refs.Add(-1) == 0 {
+		_ = t.file.Close()
+		if t.dropped.Load() {
+			_ = os.Remove(t.path)
+		}
+	}
+}
+
+// drop takes the table out of the tree: its file is deleted with the last
+// reference.
+func (t *table) drop() {
+	t.dropped.Store(true)
+	t.unref()
+}
@@ -6,6 +6,9 @@ package storage_test
 
 import (
 	"context"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
+	"time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -94,37 +97,37 @@ func (_c *MockCommandStorage_Del_Call) RunAndReturn(run func(ctx context.Context
 	return _c
 }
 
-// Set provides a mock function for the type MockCommandStorage
-func (_mock *MockCommandStorage) Set(ctx context.Context, key string, value string) error {
-	ret := _mock.Called(ctx, key, value)
+// Expire provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) Expire(ctx context.Context, key string, expireAt time.Time) error {
+	ret := _mock.Called(ctx, key, expireAt)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Set")
+		panic("no return value specified for Expire")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = returnFunc(ctx, key, value)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = returnFunc(ctx, key, expireAt)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockCommandStorage_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
-type MockCommandStorage_Set_Call struct {
+// MockCommandStorage_Expire_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Expire'
+type MockCommandStorage_Expire_Call struct {
 	*mock.Call
 }
 
-// Set is a helper method to define mock.On call
+// Expire is a helper method to define mock.On call
 //   - ctx context.Context
 //   - key string
-//   - value string
-func (_e *MockCommandStorage_Expecter) Set(ctx interface{}, key interface{}, value interface{}) *MockCommandStorage_Set_Call {
-	return &MockCommandStorage_Set_Call{Call: _e.mock.On("Set", ctx, key, value)}
+//   - expireAt time.Time
+func (_e *MockCommandStorage_Expecter) Expire(ctx interface{}, key interface{}, expireAt interface{}) *MockCommandStorage_Expire_Call {
+	return &MockCommandStorage_Expire_Call{Call: _e.mock.On("Expire", ctx, key, expireAt)}
 }
 
-func (_c *MockCommandStorage_Set_Call) Run(run func(ctx context.Context, key string, value string)) *MockCommandStorage_Set_Call {
+func (_c *MockCommandStorage_Expire_Call) Run(run func(ctx context.Context, key string, expireAt time.Time)) *MockCommandStorage_Expire_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -134,9 +137,9 @@ func (_c *MockCommandStorage_Set_Call) Run(run func(ctx context.Context, key str
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 string
+		var arg2 time.Time
 		if args[2] != nil {
-			arg2 = args[2].(string)
+			arg2 = args[2].(time.Time)
 		}
 		run(
 			arg0,
@@ -147,82 +150,107 @@ func (_c *MockCommandStorage_Set_Call) Run(run func(ctx context.Context, key str
 	return _c
 }
 
-func (_c *MockCommandStorage_Set_Call) Return(err error) *MockCommandStorage_Set_Call {
+func (_c *MockCommandStorage_Expire_Call) Return(err error) *MockCommandStorage_Expire_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockCommandStorage_Set_Call) RunAndReturn(run func(ctx context.Context, key string, value string) error) *MockCommandStorage_Set_Call {
+func (_c *MockCommandStorage_Expire_Call) RunAndReturn(run func(ctx context.Context, key string, expireAt time.Time) error) *MockCommandStorage_Expire_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NewMockQueryStorage creates a new instance of MockQueryStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func NewMockQueryStorage(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *MockQueryStorage {
-	mock := &MockQueryStorage{}
-	mock.Mock.Test(t)
+// Flush provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) Flush(ctx context.Context) error {
+	ret := _mock.Called(ctx)
 
-	t.Cleanup(func() { mock.AssertExpectations(t) })
+	if len(ret) == 0 {
+		panic("no return value specified for Flush")
+	}
 
-	return mock
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
 }
 
-// MockQueryStorage is an autogenerated mock type for the QueryStorage type
-type MockQueryStorage struct {
-	mock.Mock
+// MockCommandStorage_Flush_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Flush'
+type MockCommandStorage_Flush_Call struct {
+	*mock.Call
 }
 
-type MockQueryStorage_Expecter struct {
-	mock *mock.Mock
+// Flush is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockCommandStorage_Expecter) Flush(ctx interface{}) *MockCommandStorage_Flush_Call {
+	return &MockCommandStorage_Flush_Call{Call: _e.mock.On("Flush", ctx)}
 }
 
-func (_m *MockQueryStorage) EXPECT() *MockQueryStorage_Expecter {
-	return &MockQueryStorage_Expecter{mock: &_m.Mock}
+func (_c *MockCommandStorage_Flush_Call) Run(run func(ctx context.Context)) *MockCommandStorage_Flush_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
 }
 
-// Get provides a mock function for the type MockQueryStorage
-func (_mock *MockQueryStorage) Get(ctx context.Context, key string) (string, error) {
-	ret := _mock.Called(ctx, key)
+func (_c *MockCommandStorage_Flush_Call) Return(err error) *MockCommandStorage_Flush_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandStorage_Flush_Call) RunAndReturn(run func(ctx context.Context) error) *MockCommandStorage_Flush_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HDel provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) HDel(ctx context.Context, key string, fields []string) (int, error) {
+	ret := _mock.Called(ctx, key, fields)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for HDel")
 	}
 
-	var r0 string
+	var r0 int
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return returnFunc(ctx, key)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, fields)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, key)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, fields)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(int)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, key)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, fields)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockQueryStorage_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockQueryStorage_Get_Call struct {
+// MockCommandStorage_HDel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HDel'
+type MockCommandStorage_HDel_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// HDel is a helper method to define mock.On call
 //   - ctx context.Context
 //   - key string
-func (_e *MockQueryStorage_Expecter) Get(ctx interface{}, key interface{}) *MockQueryStorage_Get_Call {
-	return &MockQueryStorage_Get_Call{Call: _e.mock.On("Get", ctx, key)}
+//   - fields []string
+func (_e *MockCommandStorage_Expecter) HDel(ctx interface{}, key interface{}, fields interface{}) *MockCommandStorage_HDel_Call {
+	return &MockCommandStorage_HDel_Call{Call: _e.mock.On("HDel", ctx, key, fields)}
 }
 
-func (_c *MockQueryStorage_Get_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_Get_Call {
+func (_c *MockCommandStorage_HDel_Call) Run(run func(ctx context.Context, key string, fields []string)) *MockCommandStorage_HDel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -232,20 +260,2742 @@ func (_c *MockQueryStorage_Get_Call) Run(run func(ctx context.Context, key strin
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockQueryStorage_Get_Call) Return(s string, err error) *MockQueryStorage_Get_Call {
-	_c.Call.Return(s, err)
+func (_c *MockCommandStorage_HDel_Call) Return(n int, err error) *MockCommandStorage_HDel_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *MockQueryStorage_Get_Call) RunAndReturn(run func(ctx context.Context, key string) (string, error)) *MockQueryStorage_Get_Call {
+func (_c *MockCommandStorage_HDel_Call) RunAndReturn(run func(ctx context.Context, key string, fields []string) (int, error)) *MockCommandStorage_HDel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HSet provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) HSet(ctx context.Context, key string, fields []kv.Pair) (int, error) {
+	ret := _mock.Called(ctx, key, fields)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HSet")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.Pair) (int, error)); ok {
+		return returnFunc(ctx, key, fields)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.Pair) int); ok {
+		r0 = returnFunc(ctx, key, fields)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []kv.Pair) error); ok {
+		r1 = returnFunc(ctx, key, fields)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_HSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HSet'
+type MockCommandStorage_HSet_Call struct {
+	*mock.Call
+}
+
+// HSet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - fields []kv.Pair
+func (_e *MockCommandStorage_Expecter) HSet(ctx interface{}, key interface{}, fields interface{}) *MockCommandStorage_HSet_Call {
+	return &MockCommandStorage_HSet_Call{Call: _e.mock.On("HSet", ctx, key, fields)}
+}
+
+func (_c *MockCommandStorage_HSet_Call) Run(run func(ctx context.Context, key string, fields []kv.Pair)) *MockCommandStorage_HSet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []kv.Pair
+		if args[2] != nil {
+			arg2 = args[2].([]kv.Pair)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_HSet_Call) Return(n int, err error) *MockCommandStorage_HSet_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_HSet_Call) RunAndReturn(run func(ctx context.Context, key string, fields []kv.Pair) (int, error)) *MockCommandStorage_HSet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IncrBy provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) IncrBy(ctx context.Context, key string, delta int64) (int64, time.Time, error) {
+	ret := _mock.Called(ctx, key, delta)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrBy")
+	}
+
+	var r0 int64
+	var r1 time.Time
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) (int64, time.Time, error)); ok {
+		return returnFunc(ctx, key, delta)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) int64); ok {
+		r0 = returnFunc(ctx, key, delta)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int64) time.Time); ok {
+		r1 = returnFunc(ctx, key, delta)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int64) error); ok {
+		r2 = returnFunc(ctx, key, delta)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockCommandStorage_IncrBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrBy'
+type MockCommandStorage_IncrBy_Call struct {
+	*mock.Call
+}
+
+// IncrBy is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - delta int64
+func (_e *MockCommandStorage_Expecter) IncrBy(ctx interface{}, key interface{}, delta interface{}) *MockCommandStorage_IncrBy_Call {
+	return &MockCommandStorage_IncrBy_Call{Call: _e.mock.On("IncrBy", ctx, key, delta)}
+}
+
+func (_c *MockCommandStorage_IncrBy_Call) Run(run func(ctx context.Context, key string, delta int64)) *MockCommandStorage_IncrBy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_IncrBy_Call) Return(n int64, time time.Time, err error) *MockCommandStorage_IncrBy_Call {
+	_c.Call.Return(n, time, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_IncrBy_Call) RunAndReturn(run func(ctx context.Context, key string, delta int64) (int64, time.Time, error)) *MockCommandStorage_IncrBy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IncrByFloat provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) IncrByFloat(ctx context.Context, key string, delta float64) (string, time.Time, error) {
+	ret := _mock.Called(ctx, key, delta)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrByFloat")
+	}
+
+	var r0 string
+	var r1 time.Time
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) (string, time.Time, error)); ok {
+		return returnFunc(ctx, key, delta)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) string); ok {
+		r0 = returnFunc(ctx, key, delta)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, float64) time.Time); ok {
+		r1 = returnFunc(ctx, key, delta)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, float64) error); ok {
+		r2 = returnFunc(ctx, key, delta)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockCommandStorage_IncrByFloat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrByFloat'
+type MockCommandStorage_IncrByFloat_Call struct {
+	*mock.Call
+}
+
+// IncrByFloat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - delta float64
+func (_e *MockCommandStorage_Expecter) IncrByFloat(ctx interface{}, key interface{}, delta interface{}) *MockCommandStorage_IncrByFloat_Call {
+	return &MockCommandStorage_IncrByFloat_Call{Call: _e.mock.On("IncrByFloat", ctx, key, delta)}
+}
+
+func (_c *MockCommandStorage_IncrByFloat_Call) Run(run func(ctx context.Context, key string, delta float64)) *MockCommandStorage_IncrByFloat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 float64
+		if args[2] != nil {
+			arg2 = args[2].(float64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_IncrByFloat_Call) Return(s string, time time.Time, err error) *MockCommandStorage_IncrByFloat_Call {
+	_c.Call.Return(s, time, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_IncrByFloat_Call) RunAndReturn(run func(ctx context.Context, key string, delta float64) (string, time.Time, error)) *MockCommandStorage_IncrByFloat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MDel provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) MDel(ctx context.Context, keys []string) (int, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MDel")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) (int, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) int); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_MDel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MDel'
+type MockCommandStorage_MDel_Call struct {
+	*mock.Call
+}
+
+// MDel is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockCommandStorage_Expecter) MDel(ctx interface{}, keys interface{}) *MockCommandStorage_MDel_Call {
+	return &MockCommandStorage_MDel_Call{Call: _e.mock.On("MDel", ctx, keys)}
+}
+
+func (_c *MockCommandStorage_MDel_Call) Run(run func(ctx context.Context, keys []string)) *MockCommandStorage_MDel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_MDel_Call) Return(n int, err error) *MockCommandStorage_MDel_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_MDel_Call) RunAndReturn(run func(ctx context.Context, keys []string) (int, error)) *MockCommandStorage_MDel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MSet provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) MSet(ctx context.Context, pairs []kv.Pair) error {
+	ret := _mock.Called(ctx, pairs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MSet")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []kv.Pair) error); ok {
+		r0 = returnFunc(ctx, pairs)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandStorage_MSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MSet'
+type MockCommandStorage_MSet_Call struct {
+	*mock.Call
+}
+
+// MSet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pairs []kv.Pair
+func (_e *MockCommandStorage_Expecter) MSet(ctx interface{}, pairs interface{}) *MockCommandStorage_MSet_Call {
+	return &MockCommandStorage_MSet_Call{Call: _e.mock.On("MSet", ctx, pairs)}
+}
+
+func (_c *MockCommandStorage_MSet_Call) Run(run func(ctx context.Context, pairs []kv.Pair)) *MockCommandStorage_MSet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []kv.Pair
+		if args[1] != nil {
+			arg1 = args[1].([]kv.Pair)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_MSet_Call) Return(err error) *MockCommandStorage_MSet_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandStorage_MSet_Call) RunAndReturn(run func(ctx context.Context, pairs []kv.Pair) error) *MockCommandStorage_MSet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Persist provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) Persist(ctx context.Context, key string) error {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Persist")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandStorage_Persist_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Persist'
+type MockCommandStorage_Persist_Call struct {
+	*mock.Call
+}
+
+// Persist is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockCommandStorage_Expecter) Persist(ctx interface{}, key interface{}) *MockCommandStorage_Persist_Call {
+	return &MockCommandStorage_Persist_Call{Call: _e.mock.On("Persist", ctx, key)}
+}
+
+func (_c *MockCommandStorage_Persist_Call) Run(run func(ctx context.Context, key string)) *MockCommandStorage_Persist_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_Persist_Call) Return(err error) *MockCommandStorage_Persist_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandStorage_Persist_Call) RunAndReturn(run func(ctx context.Context, key string) error) *MockCommandStorage_Persist_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Pop provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) Pop(ctx context.Context, key string, end kv.End) (string, error) {
+	ret := _mock.Called(ctx, key, end)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pop")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.End) (string, error)); ok {
+		return returnFunc(ctx, key, end)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.End) string); ok {
+		r0 = returnFunc(ctx, key, end)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, kv.End) error); ok {
+		r1 = returnFunc(ctx, key, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_Pop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pop'
+type MockCommandStorage_Pop_Call struct {
+	*mock.Call
+}
+
+// Pop is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - end kv.End
+func (_e *MockCommandStorage_Expecter) Pop(ctx interface{}, key interface{}, end interface{}) *MockCommandStorage_Pop_Call {
+	return &MockCommandStorage_Pop_Call{Call: _e.mock.On("Pop", ctx, key, end)}
+}
+
+func (_c *MockCommandStorage_Pop_Call) Run(run func(ctx context.Context, key string, end kv.End)) *MockCommandStorage_Pop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 kv.End
+		if args[2] != nil {
+			arg2 = args[2].(kv.End)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_Pop_Call) Return(s string, err error) *MockCommandStorage_Pop_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_Pop_Call) RunAndReturn(run func(ctx context.Context, key string, end kv.End) (string, error)) *MockCommandStorage_Pop_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Push provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) Push(ctx context.Context, key string, values []string, end kv.End) (int, error) {
+	ret := _mock.Called(ctx, key, values, end)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Push")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, kv.End) (int, error)); ok {
+		return returnFunc(ctx, key, values, end)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, kv.End) int); ok {
+		r0 = returnFunc(ctx, key, values, end)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string, kv.End) error); ok {
+		r1 = returnFunc(ctx, key, values, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_Push_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Push'
+type MockCommandStorage_Push_Call struct {
+	*mock.Call
+}
+
+// Push is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - values []string
+//   - end kv.End
+func (_e *MockCommandStorage_Expecter) Push(ctx interface{}, key interface{}, values interface{}, end interface{}) *MockCommandStorage_Push_Call {
+	return &MockCommandStorage_Push_Call{Call: _e.mock.On("Push", ctx, key, values, end)}
+}
+
+func (_c *MockCommandStorage_Push_Call) Run(run func(ctx context.Context, key string, values []string, end kv.End)) *MockCommandStorage_Push_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		var arg3 kv.End
+		if args[3] != nil {
+			arg3 = args[3].(kv.End)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_Push_Call) Return(n int, err error) *MockCommandStorage_Push_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_Push_Call) RunAndReturn(run func(ctx context.Context, key string, values []string, end kv.End) (int, error)) *MockCommandStorage_Push_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SAdd provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) SAdd(ctx context.Context, key string, members []string) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SAdd")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_SAdd_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SAdd'
+type MockCommandStorage_SAdd_Call struct {
+	*mock.Call
+}
+
+// SAdd is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []string
+func (_e *MockCommandStorage_Expecter) SAdd(ctx interface{}, key interface{}, members interface{}) *MockCommandStorage_SAdd_Call {
+	return &MockCommandStorage_SAdd_Call{Call: _e.mock.On("SAdd", ctx, key, members)}
+}
+
+func (_c *MockCommandStorage_SAdd_Call) Run(run func(ctx context.Context, key string, members []string)) *MockCommandStorage_SAdd_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_SAdd_Call) Return(n int, err error) *MockCommandStorage_SAdd_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_SAdd_Call) RunAndReturn(run func(ctx context.Context, key string, members []string) (int, error)) *MockCommandStorage_SAdd_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SRem provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) SRem(ctx context.Context, key string, members []string) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SRem")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_SRem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SRem'
+type MockCommandStorage_SRem_Call struct {
+	*mock.Call
+}
+
+// SRem is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []string
+func (_e *MockCommandStorage_Expecter) SRem(ctx interface{}, key interface{}, members interface{}) *MockCommandStorage_SRem_Call {
+	return &MockCommandStorage_SRem_Call{Call: _e.mock.On("SRem", ctx, key, members)}
+}
+
+func (_c *MockCommandStorage_SRem_Call) Run(run func(ctx context.Context, key string, members []string)) *MockCommandStorage_SRem_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_SRem_Call) Return(n int, err error) *MockCommandStorage_SRem_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_SRem_Call) RunAndReturn(run func(ctx context.Context, key string, members []string) (int, error)) *MockCommandStorage_SRem_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Set provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) Set(ctx context.Context, key string, value string) error {
+	ret := _mock.Called(ctx, key, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Set")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, key, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandStorage_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
+type MockCommandStorage_Set_Call struct {
+	*mock.Call
+}
+
+// Set is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value string
+func (_e *MockCommandStorage_Expecter) Set(ctx interface{}, key interface{}, value interface{}) *MockCommandStorage_Set_Call {
+	return &MockCommandStorage_Set_Call{Call: _e.mock.On("Set", ctx, key, value)}
+}
+
+func (_c *MockCommandStorage_Set_Call) Run(run func(ctx context.Context, key string, value string)) *MockCommandStorage_Set_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_Set_Call) Return(err error) *MockCommandStorage_Set_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandStorage_Set_Call) RunAndReturn(run func(ctx context.Context, key string, value string) error) *MockCommandStorage_Set_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetIf provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) SetIf(ctx context.Context, key string, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error) {
+	ret := _mock.Called(ctx, key, value, expireAt, cond)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetIf")
+	}
+
+	var r0 kv.Lookup
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time, kv.Condition) (kv.Lookup, error)); ok {
+		return returnFunc(ctx, key, value, expireAt, cond)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time, kv.Condition) kv.Lookup); ok {
+		r0 = returnFunc(ctx, key, value, expireAt, cond)
+	} else {
+		r0 = ret.Get(0).(kv.Lookup)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, time.Time, kv.Condition) error); ok {
+		r1 = returnFunc(ctx, key, value, expireAt, cond)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_SetIf_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetIf'
+type MockCommandStorage_SetIf_Call struct {
+	*mock.Call
+}
+
+// SetIf is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value string
+//   - expireAt time.Time
+//   - cond kv.Condition
+func (_e *MockCommandStorage_Expecter) SetIf(ctx interface{}, key interface{}, value interface{}, expireAt interface{}, cond interface{}) *MockCommandStorage_SetIf_Call {
+	return &MockCommandStorage_SetIf_Call{Call: _e.mock.On("SetIf", ctx, key, value, expireAt, cond)}
+}
+
+func (_c *MockCommandStorage_SetIf_Call) Run(run func(ctx context.Context, key string, value string, expireAt time.Time, cond kv.Condition)) *MockCommandStorage_SetIf_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 time.Time
+		if args[3] != nil {
+			arg3 = args[3].(time.Time)
+		}
+		var arg4 kv.Condition
+		if args[4] != nil {
+			arg4 = args[4].(kv.Condition)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_SetIf_Call) Return(lookup kv.Lookup, err error) *MockCommandStorage_SetIf_Call {
+	_c.Call.Return(lookup, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_SetIf_Call) RunAndReturn(run func(ctx context.Context, key string, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error)) *MockCommandStorage_SetIf_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetWithExpiry provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) SetWithExpiry(ctx context.Context, key string, value string, expireAt time.Time) error {
+	ret := _mock.Called(ctx, key, value, expireAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetWithExpiry")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = returnFunc(ctx, key, value, expireAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandStorage_SetWithExpiry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetWithExpiry'
+type MockCommandStorage_SetWithExpiry_Call struct {
+	*mock.Call
+}
+
+// SetWithExpiry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value string
+//   - expireAt time.Time
+func (_e *MockCommandStorage_Expecter) SetWithExpiry(ctx interface{}, key interface{}, value interface{}, expireAt interface{}) *MockCommandStorage_SetWithExpiry_Call {
+	return &MockCommandStorage_SetWithExpiry_Call{Call: _e.mock.On("SetWithExpiry", ctx, key, value, expireAt)}
+}
+
+func (_c *MockCommandStorage_SetWithExpiry_Call) Run(run func(ctx context.Context, key string, value string, expireAt time.Time)) *MockCommandStorage_SetWithExpiry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 time.Time
+		if args[3] != nil {
+			arg3 = args[3].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_SetWithExpiry_Call) Return(err error) *MockCommandStorage_SetWithExpiry_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandStorage_SetWithExpiry_Call) RunAndReturn(run func(ctx context.Context, key string, value string, expireAt time.Time) error) *MockCommandStorage_SetWithExpiry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZAdd provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) ZAdd(ctx context.Context, key string, members []kv.ScoredMember) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZAdd")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.ScoredMember) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []kv.ScoredMember) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []kv.ScoredMember) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_ZAdd_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZAdd'
+type MockCommandStorage_ZAdd_Call struct {
+	*mock.Call
+}
+
+// ZAdd is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []kv.ScoredMember
+func (_e *MockCommandStorage_Expecter) ZAdd(ctx interface{}, key interface{}, members interface{}) *MockCommandStorage_ZAdd_Call {
+	return &MockCommandStorage_ZAdd_Call{Call: _e.mock.On("ZAdd", ctx, key, members)}
+}
+
+func (_c *MockCommandStorage_ZAdd_Call) Run(run func(ctx context.Context, key string, members []kv.ScoredMember)) *MockCommandStorage_ZAdd_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []kv.ScoredMember
+		if args[2] != nil {
+			arg2 = args[2].([]kv.ScoredMember)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_ZAdd_Call) Return(n int, err error) *MockCommandStorage_ZAdd_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_ZAdd_Call) RunAndReturn(run func(ctx context.Context, key string, members []kv.ScoredMember) (int, error)) *MockCommandStorage_ZAdd_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRem provides a mock function for the type MockCommandStorage
+func (_mock *MockCommandStorage) ZRem(ctx context.Context, key string, members []string) (int, error) {
+	ret := _mock.Called(ctx, key, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRem")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (int, error)); ok {
+		return returnFunc(ctx, key, members)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) int); ok {
+		r0 = returnFunc(ctx, key, members)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, key, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandStorage_ZRem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRem'
+type MockCommandStorage_ZRem_Call struct {
+	*mock.Call
+}
+
+// ZRem is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - members []string
+func (_e *MockCommandStorage_Expecter) ZRem(ctx interface{}, key interface{}, members interface{}) *MockCommandStorage_ZRem_Call {
+	return &MockCommandStorage_ZRem_Call{Call: _e.mock.On("ZRem", ctx, key, members)}
+}
+
+func (_c *MockCommandStorage_ZRem_Call) Run(run func(ctx context.Context, key string, members []string)) *MockCommandStorage_ZRem_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandStorage_ZRem_Call) Return(n int, err error) *MockCommandStorage_ZRem_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockCommandStorage_ZRem_Call) RunAndReturn(run func(ctx context.Context, key string, members []string) (int, error)) *MockCommandStorage_ZRem_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockQueryStorage creates a new instance of MockQueryStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockQueryStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockQueryStorage {
+	mock := &MockQueryStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockQueryStorage is an autogenerated mock type for the QueryStorage type
+type MockQueryStorage struct {
+	mock.Mock
+}
+
+type MockQueryStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockQueryStorage) EXPECT() *MockQueryStorage_Expecter {
+	return &MockQueryStorage_Expecter{mock: &_m.Mock}
+}
+
+// CountPrefix provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) CountPrefix(ctx context.Context, prefix string) (int, error) {
+	ret := _mock.Called(ctx, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPrefix")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, prefix)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, prefix)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_CountPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPrefix'
+type MockQueryStorage_CountPrefix_Call struct {
+	*mock.Call
+}
+
+// CountPrefix is a helper method to define mock.On call
+//   - ctx context.Context
+//   - prefix string
+func (_e *MockQueryStorage_Expecter) CountPrefix(ctx interface{}, prefix interface{}) *MockQueryStorage_CountPrefix_Call {
+	return &MockQueryStorage_CountPrefix_Call{Call: _e.mock.On("CountPrefix", ctx, prefix)}
+}
+
+func (_c *MockQueryStorage_CountPrefix_Call) Run(run func(ctx context.Context, prefix string)) *MockQueryStorage_CountPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_CountPrefix_Call) Return(n int, err error) *MockQueryStorage_CountPrefix_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_CountPrefix_Call) RunAndReturn(run func(ctx context.Context, prefix string) (int, error)) *MockQueryStorage_CountPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) Get(ctx context.Context, key string) (string, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockQueryStorage_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryStorage_Expecter) Get(ctx interface{}, key interface{}) *MockQueryStorage_Get_Call {
+	return &MockQueryStorage_Get_Call{Call: _e.mock.On("Get", ctx, key)}
+}
+
+func (_c *MockQueryStorage_Get_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_Get_Call) Return(s string, err error) *MockQueryStorage_Get_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_Get_Call) RunAndReturn(run func(ctx context.Context, key string) (string, error)) *MockQueryStorage_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HExists provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) HExists(ctx context.Context, key string, field string) (bool, error) {
+	ret := _mock.Called(ctx, key, field)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return returnFunc(ctx, key, field)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = returnFunc(ctx, key, field)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, field)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_HExists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HExists'
+type MockQueryStorage_HExists_Call struct {
+	*mock.Call
+}
+
+// HExists is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - field string
+func (_e *MockQueryStorage_Expecter) HExists(ctx interface{}, key interface{}, field interface{}) *MockQueryStorage_HExists_Call {
+	return &MockQueryStorage_HExists_Call{Call: _e.mock.On("HExists", ctx, key, field)}
+}
+
+func (_c *MockQueryStorage_HExists_Call) Run(run func(ctx context.Context, key string, field string)) *MockQueryStorage_HExists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_HExists_Call) Return(b bool, err error) *MockQueryStorage_HExists_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_HExists_Call) RunAndReturn(run func(ctx context.Context, key string, field string) (bool, error)) *MockQueryStorage_HExists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HGet provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) HGet(ctx context.Context, key string, field string) (string, error) {
+	ret := _mock.Called(ctx, key, field)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HGet")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return returnFunc(ctx, key, field)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = returnFunc(ctx, key, field)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, field)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_HGet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HGet'
+type MockQueryStorage_HGet_Call struct {
+	*mock.Call
+}
+
+// HGet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - field string
+func (_e *MockQueryStorage_Expecter) HGet(ctx interface{}, key interface{}, field interface{}) *MockQueryStorage_HGet_Call {
+	return &MockQueryStorage_HGet_Call{Call: _e.mock.On("HGet", ctx, key, field)}
+}
+
+func (_c *MockQueryStorage_HGet_Call) Run(run func(ctx context.Context, key string, field string)) *MockQueryStorage_HGet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_HGet_Call) Return(s string, err error) *MockQueryStorage_HGet_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_HGet_Call) RunAndReturn(run func(ctx context.Context, key string, field string) (string, error)) *MockQueryStorage_HGet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HGetAll provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) HGetAll(ctx context.Context, key string) ([]kv.Pair, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HGetAll")
+	}
+
+	var r0 []kv.Pair
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]kv.Pair, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []kv.Pair); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.Pair)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_HGetAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HGetAll'
+type MockQueryStorage_HGetAll_Call struct {
+	*mock.Call
+}
+
+// HGetAll is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryStorage_Expecter) HGetAll(ctx interface{}, key interface{}) *MockQueryStorage_HGetAll_Call {
+	return &MockQueryStorage_HGetAll_Call{Call: _e.mock.On("HGetAll", ctx, key)}
+}
+
+func (_c *MockQueryStorage_HGetAll_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_HGetAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_HGetAll_Call) Return(pair []kv.Pair, err error) *MockQueryStorage_HGetAll_Call {
+	_c.Call.Return(pair, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_HGetAll_Call) RunAndReturn(run func(ctx context.Context, key string) ([]kv.Pair, error)) *MockQueryStorage_HGetAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HLen provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) HLen(ctx context.Context, key string) (int, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HLen")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_HLen_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HLen'
+type MockQueryStorage_HLen_Call struct {
+	*mock.Call
+}
+
+// HLen is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryStorage_Expecter) HLen(ctx interface{}, key interface{}) *MockQueryStorage_HLen_Call {
+	return &MockQueryStorage_HLen_Call{Call: _e.mock.On("HLen", ctx, key)}
+}
+
+func (_c *MockQueryStorage_HLen_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_HLen_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_HLen_Call) Return(n int, err error) *MockQueryStorage_HLen_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_HLen_Call) RunAndReturn(run func(ctx context.Context, key string) (int, error)) *MockQueryStorage_HLen_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LLen provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) LLen(ctx context.Context, key string) (int, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LLen")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_LLen_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LLen'
+type MockQueryStorage_LLen_Call struct {
+	*mock.Call
+}
+
+// LLen is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryStorage_Expecter) LLen(ctx interface{}, key interface{}) *MockQueryStorage_LLen_Call {
+	return &MockQueryStorage_LLen_Call{Call: _e.mock.On("LLen", ctx, key)}
+}
+
+func (_c *MockQueryStorage_LLen_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_LLen_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_LLen_Call) Return(n int, err error) *MockQueryStorage_LLen_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_LLen_Call) RunAndReturn(run func(ctx context.Context, key string) (int, error)) *MockQueryStorage_LLen_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LRange provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) LRange(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	ret := _mock.Called(ctx, key, start, stop)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LRange")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]string, error)); ok {
+		return returnFunc(ctx, key, start, stop)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []string); ok {
+		r0 = returnFunc(ctx, key, start, stop)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = returnFunc(ctx, key, start, stop)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_LRange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LRange'
+type MockQueryStorage_LRange_Call struct {
+	*mock.Call
+}
+
+// LRange is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - start int
+//   - stop int
+func (_e *MockQueryStorage_Expecter) LRange(ctx interface{}, key interface{}, start interface{}, stop interface{}) *MockQueryStorage_LRange_Call {
+	return &MockQueryStorage_LRange_Call{Call: _e.mock.On("LRange", ctx, key, start, stop)}
+}
+
+func (_c *MockQueryStorage_LRange_Call) Run(run func(ctx context.Context, key string, start int, stop int)) *MockQueryStorage_LRange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_LRange_Call) Return(strings []string, err error) *MockQueryStorage_LRange_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_LRange_Call) RunAndReturn(run func(ctx context.Context, key string, start int, stop int) ([]string, error)) *MockQueryStorage_LRange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MGet provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) MGet(ctx context.Context, keys []string) ([]kv.Lookup, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MGet")
+	}
+
+	var r0 []kv.Lookup
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]kv.Lookup, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []kv.Lookup); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.Lookup)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_MGet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MGet'
+type MockQueryStorage_MGet_Call struct {
+	*mock.Call
+}
+
+// MGet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockQueryStorage_Expecter) MGet(ctx interface{}, keys interface{}) *MockQueryStorage_MGet_Call {
+	return &MockQueryStorage_MGet_Call{Call: _e.mock.On("MGet", ctx, keys)}
+}
+
+func (_c *MockQueryStorage_MGet_Call) Run(run func(ctx context.Context, keys []string)) *MockQueryStorage_MGet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_MGet_Call) Return(lookup []kv.Lookup, err error) *MockQueryStorage_MGet_Call {
+	_c.Call.Return(lookup, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_MGet_Call) RunAndReturn(run func(ctx context.Context, keys []string) ([]kv.Lookup, error)) *MockQueryStorage_MGet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Range provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) Range(ctx context.Context, start string, end string, limit int) ([]string, string, error) {
+	ret := _mock.Called(ctx, start, end, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Range")
+	}
+
+	var r0 []string
+	var r1 string
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, int) ([]string, string, error)); ok {
+		return returnFunc(ctx, start, end, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, int) []string); ok {
+		r0 = returnFunc(ctx, start, end, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, int) string); ok {
+		r1 = returnFunc(ctx, start, end, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, int) error); ok {
+		r2 = returnFunc(ctx, start, end, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockQueryStorage_Range_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Range'
+type MockQueryStorage_Range_Call struct {
+	*mock.Call
+}
+
+// Range is a helper method to define mock.On call
+//   - ctx context.Context
+//   - start string
+//   - end string
+//   - limit int
+func (_e *MockQueryStorage_Expecter) Range(ctx interface{}, start interface{}, end interface{}, limit interface{}) *MockQueryStorage_Range_Call {
+	return &MockQueryStorage_Range_Call{Call: _e.mock.On("Range", ctx, start, end, limit)}
+}
+
+func (_c *MockQueryStorage_Range_Call) Run(run func(ctx context.Context, start string, end string, limit int)) *MockQueryStorage_Range_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_Range_Call) Return(strings []string, s string, err error) *MockQueryStorage_Range_Call {
+	_c.Call.Return(strings, s, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_Range_Call) RunAndReturn(run func(ctx context.Context, start string, end string, limit int) ([]string, string, error)) *MockQueryStorage_Range_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SInter provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) SInter(ctx context.Context, keys []string) ([]string, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SInter")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_SInter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SInter'
+type MockQueryStorage_SInter_Call struct {
+	*mock.Call
+}
+
+// SInter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockQueryStorage_Expecter) SInter(ctx interface{}, keys interface{}) *MockQueryStorage_SInter_Call {
+	return &MockQueryStorage_SInter_Call{Call: _e.mock.On("SInter", ctx, keys)}
+}
+
+func (_c *MockQueryStorage_SInter_Call) Run(run func(ctx context.Context, keys []string)) *MockQueryStorage_SInter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_SInter_Call) Return(strings []string, err error) *MockQueryStorage_SInter_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_SInter_Call) RunAndReturn(run func(ctx context.Context, keys []string) ([]string, error)) *MockQueryStorage_SInter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SIsMember provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) SIsMember(ctx context.Context, key string, member string) (bool, error) {
+	ret := _mock.Called(ctx, key, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SIsMember")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return returnFunc(ctx, key, member)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = returnFunc(ctx, key, member)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_SIsMember_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SIsMember'
+type MockQueryStorage_SIsMember_Call struct {
+	*mock.Call
+}
+
+// SIsMember is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - member string
+func (_e *MockQueryStorage_Expecter) SIsMember(ctx interface{}, key interface{}, member interface{}) *MockQueryStorage_SIsMember_Call {
+	return &MockQueryStorage_SIsMember_Call{Call: _e.mock.On("SIsMember", ctx, key, member)}
+}
+
+func (_c *MockQueryStorage_SIsMember_Call) Run(run func(ctx context.Context, key string, member string)) *MockQueryStorage_SIsMember_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_SIsMember_Call) Return(b bool, err error) *MockQueryStorage_SIsMember_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_SIsMember_Call) RunAndReturn(run func(ctx context.Context, key string, member string) (bool, error)) *MockQueryStorage_SIsMember_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SMembers provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) SMembers(ctx context.Context, key string) ([]string, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SMembers")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_SMembers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SMembers'
+type MockQueryStorage_SMembers_Call struct {
+	*mock.Call
+}
+
+// SMembers is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryStorage_Expecter) SMembers(ctx interface{}, key interface{}) *MockQueryStorage_SMembers_Call {
+	return &MockQueryStorage_SMembers_Call{Call: _e.mock.On("SMembers", ctx, key)}
+}
+
+func (_c *MockQueryStorage_SMembers_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_SMembers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_SMembers_Call) Return(strings []string, err error) *MockQueryStorage_SMembers_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_SMembers_Call) RunAndReturn(run func(ctx context.Context, key string) ([]string, error)) *MockQueryStorage_SMembers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SUnion provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	ret := _mock.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SUnion")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return returnFunc(ctx, keys)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = returnFunc(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_SUnion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SUnion'
+type MockQueryStorage_SUnion_Call struct {
+	*mock.Call
+}
+
+// SUnion is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockQueryStorage_Expecter) SUnion(ctx interface{}, keys interface{}) *MockQueryStorage_SUnion_Call {
+	return &MockQueryStorage_SUnion_Call{Call: _e.mock.On("SUnion", ctx, keys)}
+}
+
+func (_c *MockQueryStorage_SUnion_Call) Run(run func(ctx context.Context, keys []string)) *MockQueryStorage_SUnion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_SUnion_Call) Return(strings []string, err error) *MockQueryStorage_SUnion_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_SUnion_Call) RunAndReturn(run func(ctx context.Context, keys []string) ([]string, error)) *MockQueryStorage_SUnion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Scan provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error) {
+	ret := _mock.Called(ctx, cursor, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scan")
+	}
+
+	var r0 []string
+	var r1 uint64
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, int) ([]string, uint64, error)); ok {
+		return returnFunc(ctx, cursor, count)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, int) []string); ok {
+		r0 = returnFunc(ctx, cursor, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uint64, int) uint64); ok {
+		r1 = returnFunc(ctx, cursor, count)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, uint64, int) error); ok {
+		r2 = returnFunc(ctx, cursor, count)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockQueryStorage_Scan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Scan'
+type MockQueryStorage_Scan_Call struct {
+	*mock.Call
+}
+
+// Scan is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cursor uint64
+//   - count int
+func (_e *MockQueryStorage_Expecter) Scan(ctx interface{}, cursor interface{}, count interface{}) *MockQueryStorage_Scan_Call {
+	return &MockQueryStorage_Scan_Call{Call: _e.mock.On("Scan", ctx, cursor, count)}
+}
+
+func (_c *MockQueryStorage_Scan_Call) Run(run func(ctx context.Context, cursor uint64, count int)) *MockQueryStorage_Scan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uint64
+		if args[1] != nil {
+			arg1 = args[1].(uint64)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_Scan_Call) Return(strings []string, v uint64, err error) *MockQueryStorage_Scan_Call {
+	_c.Call.Return(strings, v, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_Scan_Call) RunAndReturn(run func(ctx context.Context, cursor uint64, count int) ([]string, uint64, error)) *MockQueryStorage_Scan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Snapshot provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) Snapshot(ctx context.Context) (kv.View, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Snapshot")
+	}
+
+	var r0 kv.View
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (kv.View, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) kv.View); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Get(0).(kv.View)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_Snapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Snapshot'
+type MockQueryStorage_Snapshot_Call struct {
+	*mock.Call
+}
+
+// Snapshot is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockQueryStorage_Expecter) Snapshot(ctx interface{}) *MockQueryStorage_Snapshot_Call {
+	return &MockQueryStorage_Snapshot_Call{Call: _e.mock.On("Snapshot", ctx)}
+}
+
+func (_c *MockQueryStorage_Snapshot_Call) Run(run func(ctx context.Context)) *MockQueryStorage_Snapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_Snapshot_Call) Return(view kv.View, err error) *MockQueryStorage_Snapshot_Call {
+	_c.Call.Return(view, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_Snapshot_Call) RunAndReturn(run func(ctx context.Context) (kv.View, error)) *MockQueryStorage_Snapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stats provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) Stats(ctx context.Context) (stats.Stats, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 stats.Stats
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (stats.Stats, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) stats.Stats); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Get(0).(stats.Stats)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_Stats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stats'
+type MockQueryStorage_Stats_Call struct {
+	*mock.Call
+}
+
+// Stats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockQueryStorage_Expecter) Stats(ctx interface{}) *MockQueryStorage_Stats_Call {
+	return &MockQueryStorage_Stats_Call{Call: _e.mock.On("Stats", ctx)}
+}
+
+func (_c *MockQueryStorage_Stats_Call) Run(run func(ctx context.Context)) *MockQueryStorage_Stats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_Stats_Call) Return(stats stats.Stats, err error) *MockQueryStorage_Stats_Call {
+	_c.Call.Return(stats, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_Stats_Call) RunAndReturn(run func(ctx context.Context) (stats.Stats, error)) *MockQueryStorage_Stats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TTL provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TTL")
+	}
+
+	var r0 time.Duration
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (time.Duration, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) time.Duration); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_TTL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TTL'
+type MockQueryStorage_TTL_Call struct {
+	*mock.Call
+}
+
+// TTL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryStorage_Expecter) TTL(ctx interface{}, key interface{}) *MockQueryStorage_TTL_Call {
+	return &MockQueryStorage_TTL_Call{Call: _e.mock.On("TTL", ctx, key)}
+}
+
+func (_c *MockQueryStorage_TTL_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_TTL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_TTL_Call) Return(duration time.Duration, err error) *MockQueryStorage_TTL_Call {
+	_c.Call.Return(duration, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_TTL_Call) RunAndReturn(run func(ctx context.Context, key string) (time.Duration, error)) *MockQueryStorage_TTL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Version provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) Version(ctx context.Context, key string) (uint64, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Version")
+	}
+
+	var r0 uint64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (uint64, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) uint64); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_Version_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Version'
+type MockQueryStorage_Version_Call struct {
+	*mock.Call
+}
+
+// Version is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockQueryStorage_Expecter) Version(ctx interface{}, key interface{}) *MockQueryStorage_Version_Call {
+	return &MockQueryStorage_Version_Call{Call: _e.mock.On("Version", ctx, key)}
+}
+
+func (_c *MockQueryStorage_Version_Call) Run(run func(ctx context.Context, key string)) *MockQueryStorage_Version_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_Version_Call) Return(v uint64, err error) *MockQueryStorage_Version_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_Version_Call) RunAndReturn(run func(ctx context.Context, key string) (uint64, error)) *MockQueryStorage_Version_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRange provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) ZRange(ctx context.Context, key string, start int, stop int) ([]kv.ScoredMember, error) {
+	ret := _mock.Called(ctx, key, start, stop)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRange")
+	}
+
+	var r0 []kv.ScoredMember
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]kv.ScoredMember, error)); ok {
+		return returnFunc(ctx, key, start, stop)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []kv.ScoredMember); ok {
+		r0 = returnFunc(ctx, key, start, stop)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.ScoredMember)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = returnFunc(ctx, key, start, stop)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_ZRange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRange'
+type MockQueryStorage_ZRange_Call struct {
+	*mock.Call
+}
+
+// ZRange is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - start int
+//   - stop int
+func (_e *MockQueryStorage_Expecter) ZRange(ctx interface{}, key interface{}, start interface{}, stop interface{}) *MockQueryStorage_ZRange_Call {
+	return &MockQueryStorage_ZRange_Call{Call: _e.mock.On("ZRange", ctx, key, start, stop)}
+}
+
+func (_c *MockQueryStorage_ZRange_Call) Run(run func(ctx context.Context, key string, start int, stop int)) *MockQueryStorage_ZRange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_ZRange_Call) Return(scoredMember []kv.ScoredMember, err error) *MockQueryStorage_ZRange_Call {
+	_c.Call.Return(scoredMember, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_ZRange_Call) RunAndReturn(run func(ctx context.Context, key string, start int, stop int) ([]kv.ScoredMember, error)) *MockQueryStorage_ZRange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRangeByScore provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) ZRangeByScore(ctx context.Context, key string, lower kv.ScoreBound, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error) {
+	ret := _mock.Called(ctx, key, lower, upper, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRangeByScore")
+	}
+
+	var r0 []kv.ScoredMember
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.ScoreBound, kv.ScoreBound, int) ([]kv.ScoredMember, error)); ok {
+		return returnFunc(ctx, key, lower, upper, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, kv.ScoreBound, kv.ScoreBound, int) []kv.ScoredMember); ok {
+		r0 = returnFunc(ctx, key, lower, upper, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kv.ScoredMember)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, kv.ScoreBound, kv.ScoreBound, int) error); ok {
+		r1 = returnFunc(ctx, key, lower, upper, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_ZRangeByScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRangeByScore'
+type MockQueryStorage_ZRangeByScore_Call struct {
+	*mock.Call
+}
+
+// ZRangeByScore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - lower kv.ScoreBound
+//   - upper kv.ScoreBound
+//   - limit int
+func (_e *MockQueryStorage_Expecter) ZRangeByScore(ctx interface{}, key interface{}, lower interface{}, upper interface{}, limit interface{}) *MockQueryStorage_ZRangeByScore_Call {
+	return &MockQueryStorage_ZRangeByScore_Call{Call: _e.mock.On("ZRangeByScore", ctx, key, lower, upper, limit)}
+}
+
+func (_c *MockQueryStorage_ZRangeByScore_Call) Run(run func(ctx context.Context, key string, lower kv.ScoreBound, upper kv.ScoreBound, limit int)) *MockQueryStorage_ZRangeByScore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 kv.ScoreBound
+		if args[2] != nil {
+			arg2 = args[2].(kv.ScoreBound)
+		}
+		var arg3 kv.ScoreBound
+		if args[3] != nil {
+			arg3 = args[3].(kv.ScoreBound)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_ZRangeByScore_Call) Return(scoredMember []kv.ScoredMember, err error) *MockQueryStorage_ZRangeByScore_Call {
+	_c.Call.Return(scoredMember, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_ZRangeByScore_Call) RunAndReturn(run func(ctx context.Context, key string, lower kv.ScoreBound, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error)) *MockQueryStorage_ZRangeByScore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZRank provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) ZRank(ctx context.Context, key string, member string) (int, error) {
+	ret := _mock.Called(ctx, key, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRank")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (int, error)); ok {
+		return returnFunc(ctx, key, member)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) int); ok {
+		r0 = returnFunc(ctx, key, member)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_ZRank_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZRank'
+type MockQueryStorage_ZRank_Call struct {
+	*mock.Call
+}
+
+// ZRank is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - member string
+func (_e *MockQueryStorage_Expecter) ZRank(ctx interface{}, key interface{}, member interface{}) *MockQueryStorage_ZRank_Call {
+	return &MockQueryStorage_ZRank_Call{Call: _e.mock.On("ZRank", ctx, key, member)}
+}
+
+func (_c *MockQueryStorage_ZRank_Call) Run(run func(ctx context.Context, key string, member string)) *MockQueryStorage_ZRank_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_ZRank_Call) Return(n int, err error) *MockQueryStorage_ZRank_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_ZRank_Call) RunAndReturn(run func(ctx context.Context, key string, member string) (int, error)) *MockQueryStorage_ZRank_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ZScore provides a mock function for the type MockQueryStorage
+func (_mock *MockQueryStorage) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	ret := _mock.Called(ctx, key, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZScore")
+	}
+
+	var r0 float64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (float64, error)); ok {
+		return returnFunc(ctx, key, member)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) float64); ok {
+		r0 = returnFunc(ctx, key, member)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, key, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQueryStorage_ZScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ZScore'
+type MockQueryStorage_ZScore_Call struct {
+	*mock.Call
+}
+
+// ZScore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - member string
+func (_e *MockQueryStorage_Expecter) ZScore(ctx interface{}, key interface{}, member interface{}) *MockQueryStorage_ZScore_Call {
+	return &MockQueryStorage_ZScore_Call{Call: _e.mock.On("ZScore", ctx, key, member)}
+}
+
+func (_c *MockQueryStorage_ZScore_Call) Run(run func(ctx context.Context, key string, member string)) *MockQueryStorage_ZScore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQueryStorage_ZScore_Call) Return(f float64, err error) *MockQueryStorage_ZScore_Call {
+	_c.Call.Return(f, err)
+	return _c
+}
+
+func (_c *MockQueryStorage_ZScore_Call) RunAndReturn(run func(ctx context.Context, key string, member string) (float64, error)) *MockQueryStorage_ZScore_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+)
+
+// SAdd adds members to the set stored at key, creating it when needed, and
+// returns how many were not members yet.
+func (s *Storage) SAdd(ctx context.Context, key string, members []string) (int, error) {
+	const op = "storage.SAdd"
+
+	var added int
+	err := s.mutate(ctx, func() error {
+		var err error
+		added, err = s.commandStorage.SAdd(ctx, key, members)
+		return err
+	}, command.CommandSAdd, append([]string{key}, members...)...)
+	if err != nil {
+		s.logKeyError("sadd", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return added, nil
+}
+
+// SRem removes members from the set stored at key and returns how many were
+// members; the key is deleted with its last member.
+func (s *Storage) SRem(ctx context.Context, key string, members []string) (int, error) {
+	const op = "storage.SRem"
+
+	var removed int
+	err := s.mutate(ctx, func() error {
+		var err error
+		removed, err = s.commandStorage.SRem(ctx, key, members)
+		return err
+	}, command.CommandSRem, append([]string{key}, members...)...)
+	if err != nil {
+		s.logKeyError("srem", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return removed, nil
+}
+
+// SMembers returns the members of the set stored at key in lexicographic
+// order.
+func (s *Storage) SMembers(ctx context.Context, key string) ([]string, error) {
+	const op = "storage.SMembers"
+
+	defer s.shared(ctx)()
+
+	members, err := s.queryStorage.SMembers(ctx, key)
+	if err != nil {
+		s.logKeyError("smembers", key, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+func (s *Storage) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	const op = "storage.SIsMember"
+
+	defer s.shared(ctx)()
+
+	ok, err := s.queryStorage.SIsMember(ctx, key, member)
+	if err != nil {
+		s.logKeyError("sismember", key, err)
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// SInter returns the members common to the sets stored at keys, a missing
+// key being an empty set.
+func (s *Storage) SInter(ctx context.Context, keys []string) ([]string, error) {
+	const op = "storage.SInter"
+
+	defer s.shared(ctx)()
+
+	members, err := s.queryStorage.SInter(ctx, keys)
+	if err != nil {
+		s.log.Info("sinter failed", slog.Int("keys", len(keys)), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// SUnion returns the members of any of the sets stored at keys.
+func (s *Storage) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	const op = "storage.SUnion"
+
+	defer s.shared(ctx)()
+
+	members, err := s.queryStorage.SUnion(ctx, keys)
+	if err != nil {
+		s.log.Info("sunion failed", slog.Int("keys", len(keys)), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// ZAdd sets the scores of members of the sorted set stored at key, creating
+// it when needed, and returns how many members were added. It is logged as
+// a ZADD with the scores formatted by kv.FormatScore.
+func (s *Storage) ZAdd(ctx context.Context, key string, members []kv.ScoredMember) (int, error) {
+	const op = "storage.ZAdd"
+
+	args := make([]string, 0, 1+2*len(members))
+	args = append(args, key)
+	for _, m := range members {
+		args = append(args, kv.FormatScore(m.Score), m.Member)
+	}
+
+	var added int
+	err := s.mutate(ctx, func() error {
+		var err error
+		added, err = s.commandStorage.ZAdd(ctx, key, members)
+		return err
+	}, command.CommandZAdd, args...)
+	if err != nil {
+		s.logKeyError("zadd", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return added, nil
+}
+
+// ZRem removes members from the sorted set stored at key and returns how
+// many were members; the key is deleted with its last member.
+func (s *Storage) ZRem(ctx context.Context, key string, members []string) (int, error) {
+	const op = "storage.ZRem"
+
+	var removed int
+	err := s.mutate(ctx, func() error {
+		var err error
+		removed, err = s.commandStorage.ZRem(ctx, key, members)
+		return err
+	}, command.CommandZRem, append([]string{key}, members...)...)
+	if err != nil {
+		s.logKeyError("zrem", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return removed, nil
+}
+
+// ZScore fails with dberrors.ErrNotFound when the key or the member is
+// missing.
+func (s *Storage) ZScore(ctx context.Context, key, member string) (float64, error) {
+	const op = "storage.ZScore"
+
+	defer s.shared(ctx)()
+
+	score, err := s.queryStorage.ZScore(ctx, key, member)
+	if err != nil {
+		s.logKeyError("zscore", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return score, nil
+}
+
+// ZRank fails with dberrors.ErrNotFound when the key or the member is
+// missing.
+func (s *Storage) ZRank(ctx context.Context, key, member string) (int, error) {
+	const op = "storage.ZRank"
+
+	defer s.shared(ctx)()
+
+	rank, err := s.queryStorage.ZRank(ctx, key, member)
+	if err != nil {
+		s.logKeyError("zrank", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return rank, nil
+}
+
+// ZRange returns the members between two positions in score order,
+// negative positions counting from the highest score.
+func (s *Storage) ZRange(ctx context.Context, key string, start, stop int) ([]kv.ScoredMember, error) {
+	const op = "storage.ZRange"
+
+	defer s.shared(ctx)()
+
+	members, err := s.queryStorage.ZRange(ctx, key, start, stop)
+	if err != nil {
+		s.logKeyError("zrange", key, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// ZRangeByScore returns up to limit members whose scores lie between lower
+// and upper, in score order.
+func (s *Storage) ZRangeByScore(ctx context.Context, key string, lower, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error) {
+	const op = "storage.ZRangeByScore"
+
+	defer s.shared(ctx)()
+
+	members, err := s.queryStorage.ZRangeByScore(ctx, key, lower, upper, limit)
+	if err != nil {
+		s.logKeyError("zrangebyscore", key, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/storage/wal"
+)
+
+const DefaultSnapshotInterval = 10 * time.Minute
+
+var (
+	ErrNoWAL          = errors.New("snapshots need the wal")
+	ErrNoCheckpointer = errors.New("engine without a checkpoint")
+)
+
+// Snapshot writes the state of the engine to a snapshot of the WAL and
+// returns the LSN it covers, after which the WAL drops the segments it
+// covers. Operations are paused only while the engine takes a point-in-time
+// view; the view is written while writers go on. Nothing is written when no
+// record was logged since the previous snapshot.
+func (s *Storage) Snapshot(ctx context.Context) (uint64, error) {
+	const op = "storage.Snapshot"
+
+	if s.wal == nil {
+		return 0, fmt.Errorf("%s: %w", op, ErrNoWAL)
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	// with the transaction lock held exclusively no mutation is between its
+	// apply and its record, so the view matches the last LSN exactly
+	s.txMu.Lock()
+	lsn := s.wal.LastLSN()
+	if lsn == s.snapshotLSN {
+		s.txMu.Unlock()
+		return lsn, nil
+	}
+	view, err := s.queryStorage.Snapshot(ctx)
+	s.txMu.Unlock()
+	if err != nil {
+		s.log.Error("snapshot failed", slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer view.Close()
+
+	err = s.wal.WriteSnapshot(lsn, func(emit func(command string, args ...string) error) error {
+		return view.Range(func(item kv.Item) error {
+			for _, record := range itemRecords(item) {
+				if err := emit(record.Command, record.Args...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		s.log.Error("snapshot failed", slog.Uint64("lsn", lsn), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.snapshotLSN = lsn
+	return lsn, nil
+}
+
+// Restore replaces every key with the state described by the records of a
+// snapshot, atomically and without logging them. A replica uses it to load
+// the snapshot of its master when the master no longer has the records it
+// is missing.
+func (s *Storage) Restore(ctx context.Context, records []wal.Record) error {
+	const op = "storage.Restore"
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	defer s.liftLimit()()
+
+	if err := s.commandStorage.Flush(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, record := range records {
+		if err := ignoreMiss(s.applyRecord(ctx, record)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// itemRecords returns the records that recreate a key: the write of its
+// value, carrying its expiry for a string and followed by PEXPIREAT for the
+// other types.
+func itemRecords(item kv.Item) []wal.Record {
+	var args []string
+	switch {
+	case item.Fields != nil:
+		args = make([]string, 0, 1+2*len(item.Fields))
+		args = append(args, item.Key)
+		for _, field := range item.Fields {
+			args = append(args, field.Key, field.Value)
+		}
+		return withExpiry(wal.Record{Command: command.CommandHSet, Args: args}, item)
+	case item.Items != nil:
+		args = append([]string{item.Key}, item.Items...)
+		return withExpiry(wal.Record{Command: command.CommandRPush, Args: args}, item)
+	case item.Members != nil:
+		args = append([]string{item.Key}, item.Members...)
+		return withExpiry(wal.Record{Command: command.CommandSAdd, Args: args}, item)
+	case item.Scored != nil:
+		args = make([]string, 0, 1+2*len(item.Scored))
+		args = append(args, item.Key)
+		for _, m := range item.Scored {
+			args = append(args, kv.FormatScore(m.Score), m.Member)
+		}
+		return withExpiry(wal.Record{Command: command.CommandZAdd, Args: args}, item)
+	default:
+		return []wal.Record{setRecord(item.Key, item.Value, item.ExpireAt)}
+	}
+}
+
+func withExpiry(record wal.Record, item kv.Item) []wal.Record {
+	if item.ExpireAt.IsZero() {
+		return []wal.Record{record}
+	}
+	return []wal.Record{record, {Command: command.CommandPExpireAt, Args: []string{item.Key, formatDeadline(item.ExpireAt)}}}
+}
+
+// TruncateLog deletes the WAL segments whose records the engine holds
+// durably, see WithCheckpointer, and returns its checkpoint. It takes the
+// place of Snapshot for an engine that keeps its state on disk.
+func (s *Storage) TruncateLog() (uint64, error) {
+	const op = "storage.TruncateLog"
+
+	if s.wal == nil {
+		return 0, fmt.Errorf("%s: %w", op, ErrNoWAL)
+	}
+	if s.checkpointer == nil {
+		return 0, fmt.Errorf("%s: %w", op, ErrNoCheckpointer)
+	}
+
+	lsn := s.checkpointer.CheckpointLSN()
+	if err := s.wal.Truncate(lsn); err != nil {
+		s.log.Error("wal truncation failed", slog.Uint64("lsn", lsn), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return lsn, nil
+}
+
+// Snapshotter periodically snapshots the storage so that the WAL replayed at
+// startup stays short.
+type Snapshotter struct {
+	log      *slog.Logger
+	storage  *Storage
+	interval time.Duration
+}
+
+func NewSnapshotter(log *slog.Logger, storage *Storage, interval time.Duration) *Snapshotter {
+	if interval <= 0 {
+		interval = DefaultSnapshotInterval
+	}
+
+	return &Snapshotter{
+		log:      log,
+		storage:  storage,
+		interval: interval,
+	}
+}
+
+// Start runs the snapshotter until parent is cancelled. The error channel is
+// closed once the snapshotter goroutine has exited.
+func (s *Snapshotter) Start(parent context.Context) (context.Context, <-chan error) {
+	const op = "storage.Snapshotter.Start"
+
+	ctx, cancel := context.WithCancel(parent)
+	errCh := make(chan error)
+
+	go func() {
+		defer close(errCh)
+		defer cancel()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.log.Info("snapshotter stopped", slog.String("operation", op))
+				return
+			case <-ticker.C:
+				// a failure is logged by Snapshot and retried on the next tick
+				if lsn, err := s.storage.Snapshot(ctx); err == nil {
+					s.log.Debug("snapshot taken", slog.String("operation", op), slog.Uint64("lsn", lsn))
+				}
+			}
+		}
+	}()
+
+	return ctx, errCh
+}
+
+// LogTruncator periodically deletes the WAL segments an engine with a
+// checkpoint holds, see TruncateLog, so that the WAL replayed at startup
+// stays short without snapshots.
+type LogTruncator struct {
+	log      *slog.Logger
+	storage  *Storage
+	interval time.Duration
+}
+
+func NewLogTruncator(log *slog.Logger, storage *Storage, interval time.Duration) *LogTruncator {
+	if interval <= 0 {
+		interval = DefaultSnapshotInterval
+	}
+
+	return &LogTruncator{
+		log:      log,
+		storage:  storage,
+		interval: interval,
+	}
+}
+
+// Start runs the truncator until parent is cancelled. The error channel is
+// closed once the truncator goroutine has exited.
+func (t *LogTruncator) Start(parent context.Context) (context.Context, <-chan error) {
+	const op = "storage.LogTruncator.Start"
+
+	ctx, cancel := context.WithCancel(parent)
+	errCh := make(chan error)
+
+	go func() {
+		defer close(errCh)
+		defer cancel()
+
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				t.log.Info("log truncator stopped", slog.String("operation", op))
+				return
+			case <-ticker.C:
+				// a failure is logged by TruncateLog and retried on the next tick
+				if lsn, err := t.storage.TruncateLog(); err == nil {
+					t.log.Debug("wal truncated to checkpoint", slog.String("operation", op), slog.Uint64("lsn", lsn))
+				}
+			}
+		}
+	}()
+
+	return ctx, errCh
+}
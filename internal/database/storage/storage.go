@@ -5,41 +5,286 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"time"
 
+	"lesson1/internal/command"
 	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/kv"
+	"lesson1/internal/database/stats"
+	"lesson1/internal/database/storage/wal"
+)
+
+var (
+	ErrUnknownRecord = errors.New("unknown wal record")
+	// ErrCheckpointAhead reports an engine holding records the WAL does not.
+	ErrCheckpointAhead = errors.New("engine checkpoint past the end of the wal")
 )
 
 type Storage struct {
 	log            *slog.Logger
 	commandStorage CommandStorage
 	queryStorage   QueryStorage
+
+	// writeMu orders engine mutations with their WAL records so that replay
+	// reproduces exactly the state the engine reached.
+	writeMu  sync.Mutex
+	wal      WriteAheadLog
+	readOnly bool
+
+	// txMu is held shared by every operation and exclusively by Exec.
+	txMu sync.RWMutex
+
+	// evictor is the engine's memory limit when set, see WithEvictor. The
+	// keys it evicts while a write is applied are kept in evicted until the
+	// write is logged.
+	evictor Evictor
+	evictMu sync.Mutex
+	evicted []string
+
+	// checkpointer is told the LSN of every record logged, see
+	// WithCheckpointer.
+	checkpointer Checkpointer
+
+	// waiters parks blocking pops until a push to one of their keys.
+	waiters waiters
+
+	// snapshotMu serializes snapshots; snapshotLSN is the position of the
+	// last one written.
+	snapshotMu  sync.Mutex
+	snapshotLSN uint64
+
+	now func() time.Time
 }
 
 type CommandStorage interface {
 	Set(ctx context.Context, key, value string) error
+	SetWithExpiry(ctx context.Context, key, value string, expireAt time.Time) error
+	SetIf(ctx context.Context, key, value string, expireAt time.Time, cond kv.Condition) (kv.Lookup, error)
+	IncrBy(ctx context.Context, key string, delta int64) (int64, time.Time, error)
+	IncrByFloat(ctx context.Context, key string, delta float64) (string, time.Time, error)
 	Del(ctx context.Context, key string) error
+	Expire(ctx context.Context, key string, expireAt time.Time) error
+	Persist(ctx context.Context, key string) error
+	MSet(ctx context.Context, pairs []kv.Pair) error
+	MDel(ctx context.Context, keys []string) (int, error)
+	HSet(ctx context.Context, key string, fields []kv.Pair) (int, error)
+	HDel(ctx context.Context, key string, fields []string) (int, error)
+	Push(ctx context.Context, key string, values []string, end kv.End) (int, error)
+	Pop(ctx context.Context, key string, end kv.End) (string, error)
+	SAdd(ctx context.Context, key string, members []string) (int, error)
+	SRem(ctx context.Context, key string, members []string) (int, error)
+	ZAdd(ctx context.Context, key string, members []kv.ScoredMember) (int, error)
+	ZRem(ctx context.Context, key string, members []string) (int, error)
+	Flush(ctx context.Context) error
 }
 
 type QueryStorage interface {
 	Get(ctx context.Context, key string) (string, error)
+	MGet(ctx context.Context, keys []string) ([]kv.Lookup, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Stats(ctx context.Context) (stats.Stats, error)
+	Version(ctx context.Context, key string) (uint64, error)
+	Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error)
+	Range(ctx context.Context, start, end string, limit int) ([]string, string, error)
+	CountPrefix(ctx context.Context, prefix string) (int, error)
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) ([]kv.Pair, error)
+	HLen(ctx context.Context, key string) (int, error)
+	HExists(ctx context.Context, key, field string) (bool, error)
+	LRange(ctx context.Context, key string, start, stop int) ([]string, error)
+	LLen(ctx context.Context, key string) (int, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SInter(ctx context.Context, keys []string) ([]string, error)
+	SUnion(ctx context.Context, keys []string) ([]string, error)
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	ZRank(ctx context.Context, key, member string) (int, error)
+	ZRange(ctx context.Context, key string, start, stop int) ([]kv.ScoredMember, error)
+	ZRangeByScore(ctx context.Context, key string, lower, upper kv.ScoreBound, limit int) ([]kv.ScoredMember, error)
+	Snapshot(ctx context.Context) (kv.View, error)
+}
+
+type WriteAheadLog interface {
+	Submit(command string, args ...string) <-chan error
+	ReplayAfter(afterLSN uint64, apply func(wal.Record) error) error
+	LastLSN() uint64
+	WaitSynced(lsn uint64) error
+	SnapshotLSN() (uint64, error)
+	WriteSnapshot(lsn uint64, dump func(emit func(command string, args ...string) error) error) error
+	Truncate(lsn uint64) error
+	SavedBytes() int64
+}
+
+// Evictor is an engine that evicts keys to stay under a memory limit.
+type Evictor interface {
+	// OnEvict calls fn with every key evicted by a write, in the goroutine
+	// of the write.
+	OnEvict(fn func(key string))
+	// LiftLimit lets writes exceed the limit until restore is called.
+	LiftLimit() (restore func())
+}
+
+// Checkpointer is an engine that keeps the writes it holds durable by
+// itself, so that Recover only replays the records it does not hold yet.
+type Checkpointer interface {
+	// CheckpointLSN returns the LSN of the last record whose writes the
+	// engine holds durably.
+	CheckpointLSN() uint64
+	// Logged tells that the engine holds every write of the records up to
+	// lsn. The engine only makes whole records durable.
+	Logged(lsn uint64)
+	// OnCheckpoint calls fn before the checkpoint moves to lsn; it only
+	// moves once fn succeeded.
+	OnCheckpoint(fn func(lsn uint64) error)
+}
+
+type Option func(*Storage)
+
+// WithWAL makes every successful mutation durable before it is acknowledged.
+func WithWAL(log WriteAheadLog) Option {
+	return func(s *Storage) {
+		s.wal = log
+	}
+}
+
+// WithCheckpointer tells the engine the LSN of the records it applies and
+// has Recover replay only the records logged after its checkpoint, which
+// never passes the records fsynced. It needs WithWAL.
+func WithCheckpointer(c Checkpointer) Option {
+	return func(s *Storage) {
+		s.checkpointer = c
+	}
+}
+
+// WithEvictor logs the keys the engine evicts as DEL records ahead of the
+// write that evicted them, so that replay and replicas drop the same keys,
+// and lifts the memory limit while logged records are applied.
+func WithEvictor(e Evictor) Option {
+	return func(s *Storage) {
+		s.evictor = e
+	}
+}
+
+// WithReadOnly rejects client writes with dberrors.ErrReadOnly. Records
+// received from a replication master are still accepted through Apply.
+func WithReadOnly() Option {
+	return func(s *Storage) {
+		s.readOnly = true
+	}
+}
+
+// WithClock replaces time.Now as the clock that turns relative TTLs into the
+// absolute deadlines stored in the engine and the WAL.
+func WithClock(now func() time.Time) Option {
+	return func(s *Storage) {
+		if now != nil {
+			s.now = now
+		}
+	}
 }
 
 func NewStorage(log *slog.Logger, eng interface {
 	CommandStorage
 	QueryStorage
-},
+}, opts ...Option,
 ) *Storage {
-	return &Storage{
+	s := &Storage{
 		log:            log,
 		commandStorage: eng,
 		queryStorage:   eng,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.evictor != nil {
+		s.evictor.OnEvict(s.collectEviction)
+	}
+	if s.checkpointer != nil && s.wal != nil {
+		s.checkpointer.OnCheckpoint(s.wal.WaitSynced)
+	}
+
+	return s
+}
+
+// Recover replays the WAL into the engine. It must be called before the
+// storage starts serving commands. With a checkpointer, only the records
+// after its checkpoint are replayed.
+func (s *Storage) Recover(ctx context.Context) error {
+	const op = "storage.Recover"
+
+	if s.wal == nil {
+		return nil
+	}
+
+	snapshotLSN, err := s.wal.SnapshotLSN()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	afterLSN, err := s.checkpoint(ctx, snapshotLSN)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.wal.ReplayAfter(afterLSN, func(record wal.Record) error {
+		if err := s.Apply(ctx, record); err != nil {
+			return err
+		}
+		// the records of a snapshot share its LSN, which is only reached
+		// with the last of them
+		if record.LSN == snapshotLSN {
+			s.logged(record.LSN - 1)
+		} else {
+			s.logged(record.LSN)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.logged(s.wal.LastLSN())
+	return nil
+}
+
+// checkpoint returns the LSN after which the WAL is to be replayed. The
+// engine is cleared when it misses records only a newer snapshot still
+// holds, and the snapshot replayed instead.
+func (s *Storage) checkpoint(ctx context.Context, snapshotLSN uint64) (uint64, error) {
+	if s.checkpointer == nil {
+		return 0, nil
+	}
+
+	// the checkpoint only covers fsynced records, so the log, whose older
+	// segments may be gone, cannot be behind it
+	lsn := s.checkpointer.CheckpointLSN()
+	if lastLSN := s.wal.LastLSN(); lsn > lastLSN {
+		return 0, fmt.Errorf("%w: %d > %d", ErrCheckpointAhead, lsn, lastLSN)
+	}
+	if snapshotLSN > lsn {
+		if err := s.commandStorage.Flush(ctx); err != nil {
+			return 0, err
+		}
+		lsn = 0
+	}
+	s.checkpointer.Logged(lsn)
+	return lsn, nil
+}
+
+// logged passes the LSN of the last record applied to the checkpointer.
+func (s *Storage) logged(lsn uint64) {
+	if s.checkpointer != nil {
+		s.checkpointer.Logged(lsn)
 	}
 }
 
 func (s *Storage) Set(ctx context.Context, key, value string) error {
 	const op = "storage.Set"
 
-	err := s.commandStorage.Set(ctx, key, value)
+	err := s.mutate(ctx, func() error {
+		return s.commandStorage.Set(ctx, key, value)
+	}, command.CommandSet, key, value)
 	if err != nil {
 		s.log.Error("set failed", slog.String("key", key), slog.Any("err", err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -50,6 +295,8 @@ func (s *Storage) Set(ctx context.Context, key, value string) error {
 func (s *Storage) Get(ctx context.Context, key string) (string, error) {
 	const op = "storage.Get"
 
+	defer s.shared(ctx)()
+
 	result, err := s.queryStorage.Get(ctx, key)
 	if err != nil {
 		if errors.Is(err, dberrors.ErrNotFound) {
@@ -65,7 +312,9 @@ func (s *Storage) Get(ctx context.Context, key string) (string, error) {
 func (s *Storage) Del(ctx context.Context, key string) error {
 	const op = "storage.Del"
 
-	err := s.commandStorage.Del(ctx, key)
+	err := s.mutate(ctx, func() error {
+		return s.commandStorage.Del(ctx, key)
+	}, command.CommandDel, key)
 	if err != nil {
 		if errors.Is(err, dberrors.ErrNotFound) {
 			s.log.Info("del not found", slog.String("key", key))
@@ -76,3 +325,384 @@ func (s *Storage) Del(ctx context.Context, key string) error {
 	}
 	return nil
 }
+
+// SetWithTTL stores the value with an expiry ttl from now. The absolute
+// deadline is logged so that replay does not extend the key's lifetime.
+func (s *Storage) SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	const op = "storage.SetWithTTL"
+
+	expireAt := s.now().Add(ttl)
+	err := s.mutate(ctx, func() error {
+		return s.commandStorage.SetWithExpiry(ctx, key, value, expireAt)
+	}, command.CommandSet, key, value, command.OptionPXAT, formatDeadline(expireAt))
+	if err != nil {
+		s.log.Error("set failed", slog.String("key", key), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetIf stores the value, expiring after ttl unless ttl is zero, when cond
+// holds, and returns the previous state of the key. The write is logged as
+// the plain SET it amounts to; a write whose condition failed, reported as
+// dberrors.ErrConditionFailed, is not logged.
+func (s *Storage) SetIf(ctx context.Context, key, value string, ttl time.Duration, cond kv.Condition) (kv.Lookup, error) {
+	const op = "storage.SetIf"
+
+	var expireAt time.Time
+	args := []string{key, value}
+	if ttl > 0 {
+		expireAt = s.now().Add(ttl)
+		args = append(args, command.OptionPXAT, formatDeadline(expireAt))
+	}
+
+	var previous kv.Lookup
+	err := s.mutate(ctx, func() error {
+		var err error
+		previous, err = s.commandStorage.SetIf(ctx, key, value, expireAt, cond)
+		return err
+	}, command.CommandSet, args...)
+	if err != nil {
+		if errors.Is(err, dberrors.ErrConditionFailed) {
+			s.log.Info("set skipped", slog.String("key", key), slog.Any("reason", err))
+		} else {
+			s.log.Error("set failed", slog.String("key", key), slog.Any("err", err))
+		}
+		return previous, fmt.Errorf("%s: %w", op, err)
+	}
+	return previous, nil
+}
+
+// IncrBy atomically adds delta to the integer stored at key, a missing key
+// counting as 0, and returns the result. The write is logged as a SET of the
+// resulting value carrying the kept expiry, so replay does not depend on the
+// value the counter had.
+func (s *Storage) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	const op = "storage.IncrBy"
+
+	var result int64
+	err := s.mutateRecord(ctx, func() (wal.Record, error) {
+		var (
+			expireAt time.Time
+			err      error
+		)
+		result, expireAt, err = s.commandStorage.IncrBy(ctx, key, delta)
+		return setRecord(key, strconv.FormatInt(result, 10), expireAt), err
+	})
+	if err != nil {
+		s.logCounterError("incrby", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// IncrByFloat is IncrBy for floating point numbers; the result is returned
+// in the form it is stored and logged in.
+func (s *Storage) IncrByFloat(ctx context.Context, key string, delta float64) (string, error) {
+	const op = "storage.IncrByFloat"
+
+	var result string
+	err := s.mutateRecord(ctx, func() (wal.Record, error) {
+		var (
+			expireAt time.Time
+			err      error
+		)
+		result, expireAt, err = s.commandStorage.IncrByFloat(ctx, key, delta)
+		return setRecord(key, result, expireAt), err
+	})
+	if err != nil {
+		s.logCounterError("incrbyfloat", key, err)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+func (s *Storage) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	const op = "storage.Expire"
+
+	expireAt := s.now().Add(ttl)
+	err := s.mutate(ctx, func() error {
+		return s.commandStorage.Expire(ctx, key, expireAt)
+	}, command.CommandPExpireAt, key, formatDeadline(expireAt))
+	if err != nil {
+		s.logKeyError("expire", key, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) Persist(ctx context.Context, key string) error {
+	const op = "storage.Persist"
+
+	err := s.mutate(ctx, func() error {
+		return s.commandStorage.Persist(ctx, key)
+	}, command.CommandPersist, key)
+	if err != nil {
+		s.logKeyError("persist", key, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// MSet stores all pairs atomically and logs them as a single WAL record.
+func (s *Storage) MSet(ctx context.Context, pairs []kv.Pair) error {
+	const op = "storage.MSet"
+
+	args := make([]string, 0, 2*len(pairs))
+	for _, pair := range pairs {
+		args = append(args, pair.Key, pair.Value)
+	}
+
+	err := s.mutate(ctx, func() error {
+		return s.commandStorage.MSet(ctx, pairs)
+	}, command.CommandMSet, args...)
+	if err != nil {
+		s.log.Error("mset failed", slog.Int("keys", len(pairs)), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) MGet(ctx context.Context, keys []string) ([]kv.Lookup, error) {
+	const op = "storage.MGet"
+
+	defer s.shared(ctx)()
+
+	result, err := s.queryStorage.MGet(ctx, keys)
+	if err != nil {
+		s.log.Error("mget failed", slog.Int("keys", len(keys)), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// MDel deletes all keys atomically, logs them as a single WAL record and
+// returns how many of them existed.
+func (s *Storage) MDel(ctx context.Context, keys []string) (int, error) {
+	const op = "storage.MDel"
+
+	var deleted int
+	err := s.mutate(ctx, func() error {
+		var err error
+		deleted, err = s.commandStorage.MDel(ctx, keys)
+		return err
+	}, command.CommandMDel, keys...)
+	if err != nil {
+		s.log.Error("mdel failed", slog.Int("keys", len(keys)), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
+
+func (s *Storage) TTL(ctx context.Context, key string) (time.Duration, error) {
+	const op = "storage.TTL"
+
+	defer s.shared(ctx)()
+
+	ttl, err := s.queryStorage.TTL(ctx, key)
+	if err != nil {
+		s.logKeyError("ttl", key, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return ttl, nil
+}
+
+func (s *Storage) Stats(ctx context.Context) (stats.Stats, error) {
+	const op = "storage.Stats"
+
+	result, err := s.queryStorage.Stats(ctx)
+	if err != nil {
+		s.log.Error("stats failed", slog.Any("err", err))
+		return stats.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if s.wal != nil {
+		result.DiskSaved = s.wal.SavedBytes()
+	}
+	return result, nil
+}
+
+// Version returns the write version of a key; see hashtable.HashTable.Version.
+func (s *Storage) Version(ctx context.Context, key string) (uint64, error) {
+	const op = "storage.Version"
+
+	defer s.shared(ctx)()
+
+	version, err := s.queryStorage.Version(ctx, key)
+	if err != nil {
+		s.log.Error("version failed", slog.String("key", key), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return version, nil
+}
+
+// Scan continues a cursor walk over all keys, started with cursor 0, and
+// returns about count keys and the cursor of the next call, 0 when the walk
+// is complete. Keys present for the whole walk are returned at least once.
+func (s *Storage) Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error) {
+	const op = "storage.Scan"
+
+	defer s.shared(ctx)()
+
+	keys, next, err := s.queryStorage.Scan(ctx, cursor, count)
+	if err != nil {
+		s.log.Info("scan failed", slog.Uint64("cursor", cursor), slog.Any("err", err))
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, next, nil
+}
+
+// Range returns up to limit keys in [start, end) in lexicographic order, an
+// empty end being no upper bound and a non-positive limit no limit, and the
+// key the next page starts from, empty after the last page. It fails with
+// dberrors.ErrNotOrdered unless the engine keeps an ordered index.
+func (s *Storage) Range(ctx context.Context, start, end string, limit int) ([]string, string, error) {
+	const op = "storage.Range"
+
+	defer s.shared(ctx)()
+
+	keys, next, err := s.queryStorage.Range(ctx, start, end, limit)
+	if err != nil {
+		s.log.Error("range failed", slog.String("start", start), slog.String("end", end), slog.Any("err", err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, next, nil
+}
+
+// CountPrefix returns the number of keys that start with prefix. It fails
+// with dberrors.ErrNotOrdered unless the engine keeps an ordered index.
+func (s *Storage) CountPrefix(ctx context.Context, prefix string) (int, error) {
+	const op = "storage.CountPrefix"
+
+	defer s.shared(ctx)()
+
+	count, err := s.queryStorage.CountPrefix(ctx, prefix)
+	if err != nil {
+		s.log.Error("count failed", slog.String("prefix", prefix), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// logCounterError logs values that are not numbers at info level and
+// everything else as an error.
+func (s *Storage) logCounterError(action, key string, err error) {
+	if errors.Is(err, dberrors.ErrNotInteger) || errors.Is(err, dberrors.ErrNotFloat) || errors.Is(err, dberrors.ErrOverflow) ||
+		errors.Is(err, dberrors.ErrWrongType) {
+		s.log.Info(action+" rejected", slog.String("key", key), slog.Any("reason", err))
+		return
+	}
+	s.log.Error(action+" failed", slog.String("key", key), slog.Any("err", err))
+}
+
+// logKeyError logs expected misses and type mismatches at info level and
+// everything else as an error.
+func (s *Storage) logKeyError(action, key string, err error) {
+	if errors.Is(err, dberrors.ErrNotFound) || errors.Is(err, dberrors.ErrNoExpiry) || errors.Is(err, dberrors.ErrWrongType) {
+		s.log.Info(action+" skipped", slog.String("key", key), slog.Any("reason", err))
+		return
+	}
+	s.log.Error(action+" failed", slog.String("key", key), slog.Any("err", err))
+}
+
+// mutate applies a change to the engine and, when a WAL is configured, waits
+// until it is logged before returning. Only the apply and the enqueue happen
+// under writeMu, so concurrent callers share one group-committed fsync.
+// Inside Exec the record is collected and logged with the whole transaction.
+// Failed mutations are not logged.
+func (s *Storage) mutate(ctx context.Context, apply func() error, cmd string, args ...string) error {
+	return s.mutateRecord(ctx, func() (wal.Record, error) {
+		return wal.Record{Command: cmd, Args: args}, apply()
+	})
+}
+
+// mutateRecord is mutate for changes whose record is only known once they
+// are applied, such as the value a counter reached. The keys evicted to make
+// room for the change are logged ahead of it, even when it failed.
+func (s *Storage) mutateRecord(ctx context.Context, apply func() (wal.Record, error)) error {
+	if s.readOnly {
+		return dberrors.ErrReadOnly
+	}
+
+	if tx := transactionFrom(ctx); tx != nil {
+		record, err := apply()
+		tx.records = append(tx.records, s.takeEvictions()...)
+		if err != nil {
+			return err
+		}
+		tx.records = append(tx.records, record)
+		return nil
+	}
+
+	s.txMu.RLock()
+	if s.wal == nil {
+		defer s.txMu.RUnlock()
+		_, err := apply()
+		return err
+	}
+
+	s.writeMu.Lock()
+	record, err := apply()
+	var pending []<-chan error
+	for _, eviction := range s.takeEvictions() {
+		pending = append(pending, s.wal.Submit(eviction.Command, eviction.Args...))
+	}
+	if err == nil {
+		pending = append(pending, s.wal.Submit(record.Command, record.Args...))
+	}
+	// the writes are serialized, the last record logged is the write's own
+	s.logged(s.wal.LastLSN())
+	s.writeMu.Unlock()
+	s.txMu.RUnlock()
+
+	for _, done := range pending {
+		if waitErr := wal.Wait(ctx, done); waitErr != nil {
+			return waitErr
+		}
+	}
+	return err
+}
+
+// collectEviction keeps a key evicted by the write being applied. Writes are
+// only serialized when they are logged, so nothing is kept without a WAL.
+func (s *Storage) collectEviction(key string) {
+	if s.wal == nil {
+		return
+	}
+
+	s.evictMu.Lock()
+	s.evicted = append(s.evicted, key)
+	s.evictMu.Unlock()
+}
+
+// takeEvictions returns the DEL records of the keys evicted since the last
+// call.
+func (s *Storage) takeEvictions() []wal.Record {
+	s.evictMu.Lock()
+	keys := s.evicted
+	s.evicted = nil
+	s.evictMu.Unlock()
+
+	records := make([]wal.Record, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, wal.Record{Command: command.CommandDel, Args: []string{key}})
+	}
+	return records
+}
+
+// liftLimit suspends the engine's memory limit, if it has one, while records
+// that were admitted once are applied again.
+func (s *Storage) liftLimit() (restore func()) {
+	if s.evictor == nil {
+		return func() {}
+	}
+	return s.evictor.LiftLimit()
+}
+
+// setRecord builds the SET record that recreates a value with its expiry.
+func setRecord(key, value string, expireAt time.Time) wal.Record {
+	args := []string{key, value}
+	if !expireAt.IsZero() {
+		args = append(args, command.OptionPXAT, formatDeadline(expireAt))
+	}
+	return wal.Record{Command: command.CommandSet, Args: args}
+}
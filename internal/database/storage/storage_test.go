@@ -2,14 +2,24 @@ package storage_test
 
 import (
 	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"lesson1/internal/database/compression"
 	"lesson1/internal/database/dberrors"
+	hashtable "lesson1/internal/database/hash_table"
+	"lesson1/internal/database/kv"
 	"lesson1/internal/database/storage"
 	"lesson1/internal/database/storage/engine"
+	"lesson1/internal/database/storage/wal"
+	"lesson1/internal/lib/clock/fakeclock"
 	"lesson1/internal/lib/logger/slogdiscard"
 )
 
@@ -58,9 +68,8 @@ func TestStorage(t *testing.T) {
 			},
 		},
 		{
-			name:    "del not found",
-			run:     func(ctx context.Context, s *storage.Storage) (string, error) { return "", s.Del(ctx, "missing") },
-			wantErr: dberrors.ErrNotFound,
+			name: "del missing key",
+			run:  func(ctx context.Context, s *storage.Storage) (string, error) { return "", s.Del(ctx, "missing") },
 		},
 	}
 
@@ -90,3 +99,702 @@ func TestStorage(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageRecoverFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir, wal.WithMaxSegmentSize(128))
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+	want := map[string]string{}
+	for i := range 50 {
+		key := "key" + strconv.Itoa(i%10)
+		value := "value" + strconv.Itoa(i)
+		require.NoError(t, before.Set(ctx, key, value))
+		want[key] = value
+	}
+	for _, key := range []string{"key1", "key4", "key7"} {
+		require.NoError(t, before.Del(ctx, key))
+		delete(want, key)
+	}
+	require.NoError(t, before.Del(ctx, "missing"))
+
+	// simulate a crash: the engine is dropped, only the files survive
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	for i := range 10 {
+		key := "key" + strconv.Itoa(i)
+		got, err := after.Get(ctx, key)
+		if value, ok := want[key]; ok {
+			require.NoError(t, err)
+			assert.Equal(t, value, got)
+		} else {
+			require.ErrorIs(t, err, dberrors.ErrNotFound)
+		}
+	}
+	assert.Equal(t, uint64(54), afterWAL.LastLSN())
+}
+
+func BenchmarkStorageSetGroupCommit(b *testing.B) {
+	for _, batchSize := range []int{1, 10, 100} {
+		b.Run("batch_"+strconv.Itoa(batchSize), func(b *testing.B) {
+			logger := slogdiscard.NewDiscardLogger()
+			w, err := wal.New(logger, b.TempDir(),
+				wal.WithBatchSize(batchSize),
+				wal.WithBatchTimeout(time.Millisecond),
+			)
+			require.NoError(b, err)
+			defer w.Close()
+
+			s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+			ctx := context.Background()
+
+			var seq atomic.Int64
+
+			b.SetParallelism(128)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					key := "key" + strconv.FormatInt(seq.Add(1)%1024, 10)
+					if err := s.Set(ctx, key, "value"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestStorageRecoverTTLFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		eng := engine.NewEngine(logger, engine.WithClock(clock.Now))
+		s := storage.NewStorage(logger, eng, storage.WithWAL(w), storage.WithClock(clock.Now))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+	require.NoError(t, before.SetWithTTL(ctx, "short", "v", 10*time.Second))
+	require.NoError(t, before.SetWithTTL(ctx, "long", "v", time.Hour))
+	require.NoError(t, before.Set(ctx, "persisted", "v"))
+	require.NoError(t, before.Expire(ctx, "persisted", time.Minute))
+	require.NoError(t, before.Persist(ctx, "persisted"))
+	require.ErrorIs(t, before.Expire(ctx, "missing", time.Minute), dberrors.ErrNotFound)
+	require.NoError(t, beforeWAL.Close())
+
+	// downtime counts against the TTL: deadlines are absolute
+	clock.Advance(30 * time.Second)
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	_, err := after.Get(ctx, "short")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	ttl, err := after.TTL(ctx, "long")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour-30*time.Second, ttl)
+
+	_, err = after.TTL(ctx, "persisted")
+	require.ErrorIs(t, err, dberrors.ErrNoExpiry)
+}
+
+// TestStorageEvictionsFromWAL checks that the keys evicted by the memory
+// limit are logged, so that replay drops exactly them without evicting
+// anything itself, whatever the policy of the restarted engine.
+func TestStorageEvictionsFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+	limit := int64(3 * (2 + 4 + 64))
+
+	open := func(policy hashtable.EvictionPolicy) (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		eng := engine.NewEngine(logger, engine.WithMaxMemory(limit, policy))
+		s := storage.NewStorage(logger, eng, storage.WithWAL(w), storage.WithEvictor(eng))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	keys := func(s *storage.Storage) []string {
+		var found []string
+		for i := range 6 {
+			key := "k" + strconv.Itoa(i)
+			if _, err := s.Get(ctx, key); err == nil {
+				found = append(found, key)
+			}
+		}
+		return found
+	}
+
+	before, beforeWAL := open(hashtable.PolicyAllKeysLRU)
+	for i := range 6 {
+		require.NoError(t, before.Set(ctx, "k"+strconv.Itoa(i), "vvvv"))
+		// reads are not logged but steer the victims
+		_, _ = before.Get(ctx, "k0")
+	}
+	want := keys(before)
+	require.Len(t, want, 3)
+	require.Contains(t, want, "k0")
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open(hashtable.PolicyNoEviction)
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	assert.Equal(t, want, keys(after))
+	stats, err := after.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.EvictedKeys)
+}
+
+func TestStorageCountersFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		eng := engine.NewEngine(logger, engine.WithClock(clock.Now))
+		s := storage.NewStorage(logger, eng, storage.WithWAL(w), storage.WithClock(clock.Now))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+
+	n, err := before.IncrBy(ctx, "hits", 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	require.NoError(t, before.Expire(ctx, "hits", time.Minute))
+	n, err = before.IncrBy(ctx, "hits", -2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	f, err := before.IncrByFloat(ctx, "price", 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, "1.5", f)
+
+	require.NoError(t, before.Set(ctx, "name", "bob"))
+	_, err = before.IncrBy(ctx, "name", 1)
+	require.ErrorIs(t, err, dberrors.ErrNotInteger)
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	got, err := after.Get(ctx, "hits")
+	require.NoError(t, err)
+	assert.Equal(t, "3", got)
+
+	// the counter kept the expiry set between the increments
+	ttl, err := after.TTL(ctx, "hits")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	got, err = after.Get(ctx, "price")
+	require.NoError(t, err)
+	assert.Equal(t, "1.5", got)
+}
+
+func TestStorageHashesFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+	clock := fakeclock.New(time.Unix(1_700_000_000, 0))
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		eng := engine.NewEngine(logger, engine.WithClock(clock.Now))
+		s := storage.NewStorage(logger, eng, storage.WithWAL(w), storage.WithClock(clock.Now))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+
+	added, err := before.HSet(ctx, "user", []kv.Pair{{Key: "name", Value: "ann"}, {Key: "age", Value: "30"}, {Key: "city", Value: "x"}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	require.NoError(t, before.Expire(ctx, "user", time.Minute))
+
+	_, err = before.HSet(ctx, "user", []kv.Pair{{Key: "age", Value: "31"}})
+	require.NoError(t, err)
+	deleted, err := before.HDel(ctx, "user", []string{"city"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = before.HSet(ctx, "gone", []kv.Pair{{Key: "f", Value: "v"}})
+	require.NoError(t, err)
+	_, err = before.HDel(ctx, "gone", []string{"f"})
+	require.NoError(t, err)
+
+	require.NoError(t, before.Set(ctx, "name", "bob"))
+	_, err = before.HSet(ctx, "name", []kv.Pair{{Key: "f", Value: "v"}})
+	require.ErrorIs(t, err, dberrors.ErrWrongType)
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	fields, err := after.HGetAll(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Pair{{Key: "age", Value: "31"}, {Key: "name", Value: "ann"}}, fields)
+
+	// the hash kept the expiry set between the writes
+	ttl, err := after.TTL(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	n, err := after.HLen(ctx, "gone")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	got, err := after.Get(ctx, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", got)
+}
+
+func TestStorageRecoverBatchesFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+	require.NoError(t, before.MSet(ctx, []kv.Pair{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "c", Value: "3"}}))
+
+	deleted, err := before.MDel(ctx, []string{"a", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	// each batch is a single record
+	assert.Equal(t, uint64(2), beforeWAL.LastLSN())
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	results, err := after.MGet(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Lookup{{}, {Value: "2", Found: true}, {Value: "3", Found: true}}, results)
+}
+
+func TestStorageExecFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+	require.NoError(t, before.Set(ctx, "gone", "v"))
+
+	err := before.Exec(ctx, func(ctx context.Context) error {
+		require.NoError(t, before.Set(ctx, "a", "1"))
+		require.NoError(t, before.MSet(ctx, []kv.Pair{{Key: "b", Value: "2"}, {Key: "c", Value: ""}}))
+		require.NoError(t, before.Del(ctx, "gone"))
+		require.NoError(t, before.Del(ctx, "missing"))
+		require.ErrorIs(t, before.Exec(ctx, func(context.Context) error { return nil }), storage.ErrNestedTransaction)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the transaction is a single record after the plain SET
+	assert.Equal(t, uint64(2), beforeWAL.LastLSN())
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	results, err := after.MGet(ctx, []string{"a", "b", "c", "gone"})
+	require.NoError(t, err)
+	assert.Equal(t, []kv.Lookup{{Value: "1", Found: true}, {Value: "2", Found: true}, {Value: "", Found: true}, {}}, results)
+}
+
+func TestStorageConditionalWritesFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+
+	_, err := before.SetIf(ctx, "lock", "a", time.Hour, kv.Condition{Kind: kv.IfAbsent})
+	require.NoError(t, err)
+
+	previous, err := before.SetIf(ctx, "lock", "b", time.Hour, kv.Condition{Kind: kv.IfAbsent})
+	require.ErrorIs(t, err, dberrors.ErrConditionFailed)
+	assert.Equal(t, kv.Lookup{Value: "a", Found: true}, previous)
+
+	_, err = before.SetIf(ctx, "counter", "2", 0, kv.Condition{Kind: kv.IfEquals, Expected: "1"})
+	require.ErrorIs(t, err, dberrors.ErrConditionFailed)
+
+	// failed conditions are not logged
+	assert.Equal(t, uint64(1), beforeWAL.LastLSN())
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	got, err := after.Get(ctx, "lock")
+	require.NoError(t, err)
+	assert.Equal(t, "a", got)
+
+	ttl, err := after.TTL(ctx, "lock")
+	require.NoError(t, err)
+	assert.Positive(t, ttl)
+}
+
+func TestStorageListsFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+
+	n, err := before.Push(ctx, "jobs", []string{"a", "b", "c"}, kv.Right)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	_, err = before.Push(ctx, "jobs", []string{"z"}, kv.Left)
+	require.NoError(t, err)
+
+	value, err := before.Pop(ctx, "jobs", kv.Left)
+	require.NoError(t, err)
+	assert.Equal(t, "z", value)
+	popped, err := before.BPop(ctx, []string{"empty", "jobs"}, kv.Right, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, kv.Pair{Key: "jobs", Value: "c"}, popped)
+
+	_, err = before.Pop(ctx, "empty", kv.Left)
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	items, err := after.LRange(ctx, "jobs", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, items)
+}
+
+func TestStorageSetsFromWAL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir)
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+
+	added, err := before.SAdd(ctx, "tags", []string{"go", "db", "kv"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	_, err = before.SRem(ctx, "tags", []string{"db"})
+	require.NoError(t, err)
+
+	_, err = before.ZAdd(ctx, "board", []kv.ScoredMember{{Member: "ann", Score: 1.5}, {Member: "bob", Score: -2}, {Member: "cid", Score: 7}})
+	require.NoError(t, err)
+	_, err = before.ZAdd(ctx, "board", []kv.ScoredMember{{Member: "bob", Score: 10}})
+	require.NoError(t, err)
+	_, err = before.ZRem(ctx, "board", []string{"cid"})
+	require.NoError(t, err)
+	require.NoError(t, beforeWAL.Close())
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	members, err := after.SMembers(ctx, "tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "kv"}, members)
+
+	board, err := after.ZRange(ctx, "board", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []kv.ScoredMember{{Member: "ann", Score: 1.5}, {Member: "bob", Score: 10}}, board)
+}
+
+func TestStorageBPop(t *testing.T) {
+	t.Parallel()
+
+	logger := slogdiscard.NewDiscardLogger()
+	newStorage := func() *storage.Storage {
+		return storage.NewStorage(logger, engine.NewEngine(logger))
+	}
+
+	t.Run("woken by a push", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		s := newStorage()
+
+		result := make(chan kv.Pair, 1)
+		go func() {
+			popped, err := s.BPop(ctx, []string{"a", "b"}, kv.Left, 0)
+			assert.NoError(t, err)
+			result <- popped
+		}()
+
+		// give the pop time to park before pushing
+		time.Sleep(20 * time.Millisecond)
+		_, err := s.Push(ctx, "b", []string{"job"}, kv.Right)
+		require.NoError(t, err)
+
+		select {
+		case popped := <-result:
+			assert.Equal(t, kv.Pair{Key: "b", Value: "job"}, popped)
+		case <-time.After(time.Second):
+			t.Fatal("blocked pop was not woken by the push")
+		}
+
+		n, err := s.LLen(ctx, "b")
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newStorage().BPop(context.Background(), []string{"a"}, kv.Left, 20*time.Millisecond)
+		require.ErrorIs(t, err, dberrors.ErrNotFound)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStorage()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		_, err := s.BPop(ctx, []string{"a"}, kv.Left, 0)
+		require.ErrorIs(t, err, context.Canceled)
+
+		// the abandoned pop does not take the next item
+		_, err = s.Push(context.Background(), "a", []string{"job"}, kv.Right)
+		require.NoError(t, err)
+		n, err := s.LLen(context.Background(), "a")
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("does not wait inside a transaction", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStorage()
+		err := s.Exec(context.Background(), func(ctx context.Context) error {
+			_, err := s.BPop(ctx, []string{"a"}, kv.Left, 0)
+			return err
+		})
+		require.ErrorIs(t, err, dberrors.ErrNotFound)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		s := newStorage()
+		require.NoError(t, s.Set(ctx, "a", "v"))
+
+		_, err := s.BPop(ctx, []string{"a"}, kv.Left, 0)
+		require.ErrorIs(t, err, dberrors.ErrWrongType)
+	})
+}
+
+func TestStorageRecoverFromSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+
+	open := func() (*storage.Storage, *wal.WAL) {
+		w, err := wal.New(logger, dir, wal.WithMaxSegmentSize(128))
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open()
+
+	_, err := before.Snapshot(ctx)
+	require.NoError(t, err)
+	snapshots, err := filepath.Glob(filepath.Join(dir, "snapshot_*.snap"))
+	require.NoError(t, err)
+	assert.Empty(t, snapshots, "nothing to snapshot")
+
+	require.NoError(t, before.Set(ctx, "str", "v"))
+	require.NoError(t, before.SetWithTTL(ctx, "ttl", "v", time.Hour))
+	_, err = before.HSet(ctx, "hash", []kv.Pair{{Key: "f", Value: "1"}})
+	require.NoError(t, err)
+	_, err = before.Push(ctx, "list", []string{"a", "b", "c"}, kv.Right)
+	require.NoError(t, err)
+	_, err = before.SAdd(ctx, "set", []string{"x"})
+	require.NoError(t, err)
+	_, err = before.ZAdd(ctx, "zset", []kv.ScoredMember{{Member: "m", Score: 1.5}})
+	require.NoError(t, err)
+	require.NoError(t, before.Expire(ctx, "zset", time.Hour))
+
+	lsn, err := before.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), lsn)
+
+	// records logged after the snapshot are replayed on top of it
+	_, err = before.Pop(ctx, "list", kv.Left)
+	require.NoError(t, err)
+	require.NoError(t, before.Del(ctx, "str"))
+	require.NoError(t, beforeWAL.Close())
+
+	// the snapshot made the first segment obsolete
+	assert.NoFileExists(t, filepath.Join(dir, "wal_00000000000000000001.log"))
+
+	after, afterWAL := open()
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	_, err = after.Get(ctx, "str")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	ttl, err := after.TTL(ctx, "ttl")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+	value, err := after.HGet(ctx, "hash", "f")
+	require.NoError(t, err)
+	assert.Equal(t, "1", value)
+	items, err := after.LRange(ctx, "list", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, items)
+	members, err := after.SMembers(ctx, "set")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x"}, members)
+	score, err := after.ZScore(ctx, "zset", "m")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.5, score, 0)
+	_, err = after.TTL(ctx, "zset")
+	require.NoError(t, err)
+}
+
+func TestStorageCompressionStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	dir := t.TempDir()
+	large := strings.Repeat(`{"user":"alice"}`, 100)
+
+	open := func(codec compression.Codec) (*storage.Storage, *wal.WAL) {
+		compressor := compression.NewCompressor(codec, 64)
+		w, err := wal.New(logger, dir, wal.WithCompression(compressor))
+		require.NoError(t, err)
+
+		s := storage.NewStorage(logger, engine.NewEngine(logger, engine.WithCompression(compressor)), storage.WithWAL(w))
+		require.NoError(t, s.Recover(ctx))
+		return s, w
+	}
+
+	before, beforeWAL := open(compression.Flate)
+	require.NoError(t, before.Set(ctx, "k", large))
+
+	st, err := before.Stats(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, st.MemorySaved)
+	assert.Positive(t, st.DiskSaved)
+	require.NoError(t, beforeWAL.Close())
+
+	// the values logged compressed are read back with compression turned off
+	after, afterWAL := open(compression.None)
+	t.Cleanup(func() { _ = afterWAL.Close() })
+
+	value, err := after.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, large, value)
+	st, err = after.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, st.MemorySaved)
+	assert.Zero(t, st.DiskSaved)
+}
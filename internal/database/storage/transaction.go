@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/storage/wal"
+)
+
+var ErrNestedTransaction = errors.New("nested transaction")
+
+type transactionKey struct{}
+
+// transaction collects the WAL records of the mutations made by one Exec.
+type transaction struct {
+	records []wal.Record
+}
+
+func transactionFrom(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(transactionKey{}).(*transaction)
+	return tx
+}
+
+// Exec runs fn with exclusive access to the storage: no other operation runs
+// until fn returns, so nobody observes a half-applied transaction. Storage
+// calls made by fn must use the context it receives. The mutations are logged
+// as a single WAL record, durable before Exec returns; a failed mutation
+// inside fn is not logged, the others are kept.
+func (s *Storage) Exec(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "storage.Exec"
+
+	if transactionFrom(ctx) != nil {
+		return fmt.Errorf("%s: %w", op, ErrNestedTransaction)
+	}
+
+	tx := &transaction{}
+
+	s.txMu.Lock()
+	fnErr := fn(context.WithValue(ctx, transactionKey{}, tx))
+
+	var done <-chan error
+	if s.wal != nil && len(tx.records) > 0 {
+		done = s.wal.Submit(command.CommandExec, encodeTransaction(tx.records)...)
+		s.logged(s.wal.LastLSN())
+	}
+	s.txMu.Unlock()
+
+	if done != nil {
+		if err := wal.Wait(ctx, done); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return fnErr
+}
+
+// shared holds the transaction lock in shared mode for one operation. Calls
+// made inside Exec already hold it exclusively.
+func (s *Storage) shared(ctx context.Context) (unlock func()) {
+	if transactionFrom(ctx) != nil {
+		return func() {}
+	}
+
+	s.txMu.RLock()
+	return s.txMu.RUnlock
+}
+
+// encodeTransaction flattens records into the arguments of one EXEC record:
+// the argument count, the command and the arguments of every record in turn.
+func encodeTransaction(records []wal.Record) []string {
+	var args []string
+	for _, record := range records {
+		args = append(args, strconv.Itoa(len(record.Args)), record.Command)
+		args = append(args, record.Args...)
+	}
+	return args
+}
+
+func decodeTransaction(record wal.Record) ([]wal.Record, error) {
+	var (
+		records []wal.Record
+		args    = record.Args
+	)
+
+	for len(args) > 0 {
+		if len(args) < 2 {
+			return nil, unknownRecord(record)
+		}
+
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 || n > len(args)-2 {
+			return nil, unknownRecord(record)
+		}
+
+		records = append(records, wal.Record{LSN: record.LSN, Command: args[1], Args: args[2 : 2+n]})
+		args = args[2+n:]
+	}
+	return records, nil
+}
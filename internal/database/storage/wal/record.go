@@ -0,0 +1,156 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"lesson1/internal/database/compression"
+)
+
+// frameHeaderSize is the payload length and checksum in front of a payload.
+const frameHeaderSize = 8
+
+var ErrCorruptedRecord = errors.New("corrupted wal record")
+
+// Record is a single logged mutation: the command name as understood by the
+// compute layer together with its arguments.
+type Record struct {
+	LSN     uint64   `json:"lsn"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// encode serialises the record as a checksummed, length-prefixed frame:
+//
+//	uint32 payload length | uint32 CRC-32C of the payload | payload
+//	payload: uvarint lsn | string command | uvarint argc | args...
+//	arg: byte codec | string data
+//
+// where every string is a uvarint length followed by raw bytes. Every
+// argument is encoded as c selects it; encode also returns how many bytes
+// that spared.
+func (r Record) encode(c compression.Compressor) ([]byte, int64) {
+	var saved int64
+
+	payload := binary.AppendUvarint(nil, r.LSN)
+	payload = appendString(payload, r.Command)
+	payload = binary.AppendUvarint(payload, uint64(len(r.Args)))
+	for _, arg := range r.Args {
+		codec, data := c.Compress(arg)
+		saved += int64(len(arg) - len(data))
+		payload = append(payload, byte(codec))
+		payload = appendString(payload, data)
+	}
+
+	frame := make([]byte, 0, frameHeaderSize+len(payload))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.Checksum(payload, castagnoli))
+	return append(frame, payload...), saved
+}
+
+// readRecord reads one frame out of the remaining bytes of a file and
+// returns it with its size. It returns io.EOF when the reader is exhausted
+// exactly on a frame boundary and io.ErrUnexpectedEOF for a torn frame, one
+// running past the end of the file. A complete frame that fails its checksum
+// or does not decode is reported as ErrCorruptedRecord together with its
+// size.
+func readRecord(reader *bufio.Reader, remaining int64) (Record, int64, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return Record{}, 0, err
+	}
+
+	// a length past the end of the file is a torn write, or garbage that
+	// must not be allocated
+	length := int64(binary.BigEndian.Uint32(header[:4]))
+	size := frameHeaderSize + length
+	if size > remaining {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return Record{}, 0, err
+	}
+
+	if crc32.Checksum(payload, castagnoli) != binary.BigEndian.Uint32(header[4:]) {
+		return Record{}, size, fmt.Errorf("checksum: %w", ErrCorruptedRecord)
+	}
+
+	record, err := decodePayload(payload)
+	if err != nil {
+		return Record{}, size, err
+	}
+
+	return record, size, nil
+}
+
+func decodePayload(payload []byte) (Record, error) {
+	var record Record
+
+	lsn, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return Record{}, fmt.Errorf("lsn: %w", ErrCorruptedRecord)
+	}
+	record.LSN = lsn
+	payload = payload[n:]
+
+	command, payload, err := readString(payload)
+	if err != nil {
+		return Record{}, fmt.Errorf("command: %w", err)
+	}
+	record.Command = command
+
+	argc, n := binary.Uvarint(payload)
+	if n <= 0 || argc > uint64(len(payload)) {
+		return Record{}, fmt.Errorf("argc: %w", ErrCorruptedRecord)
+	}
+	payload = payload[n:]
+
+	record.Args = make([]string, 0, argc)
+	for range argc {
+		if len(payload) == 0 {
+			return Record{}, fmt.Errorf("codec: %w", ErrCorruptedRecord)
+		}
+		codec := compression.Codec(payload[0])
+
+		var data string
+		data, payload, err = readString(payload[1:])
+		if err != nil {
+			return Record{}, fmt.Errorf("arg: %w", err)
+		}
+		arg, err := compression.Decompress(codec, data)
+		if err != nil {
+			return Record{}, fmt.Errorf("arg: %w: %w", ErrCorruptedRecord, err)
+		}
+		record.Args = append(record.Args, arg)
+	}
+
+	if len(payload) != 0 {
+		return Record{}, fmt.Errorf("trailing bytes: %w", ErrCorruptedRecord)
+	}
+
+	return record, nil
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
+
+func readString(src []byte) (string, []byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 || size > uint64(len(src)-n) {
+		return "", nil, ErrCorruptedRecord
+	}
+	src = src[n:]
+
+	return string(src[:size]), src[size:], nil
+}
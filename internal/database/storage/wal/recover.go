@@ -0,0 +1,226 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+type CorruptionPolicy string
+
+const (
+	// PolicyFail refuses to open a log with a corrupted record before its
+	// tail.
+	PolicyFail CorruptionPolicy = "fail"
+	// PolicyTruncate cuts the log at the first corrupted record, losing the
+	// records after it.
+	PolicyTruncate CorruptionPolicy = "truncate"
+)
+
+var ErrUnknownCorruptionPolicy = errors.New("unknown corruption policy")
+
+func ParseCorruptionPolicy(raw string) (CorruptionPolicy, error) {
+	switch policy := CorruptionPolicy(raw); policy {
+	case PolicyFail, PolicyTruncate:
+		return policy, nil
+	case "":
+		return PolicyFail, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownCorruptionPolicy, raw)
+	}
+}
+
+// WithCorruptionPolicy sets what New does with a corrupted record found
+// before the tail of the log.
+func WithCorruptionPolicy(policy CorruptionPolicy) Option {
+	return func(w *WAL) {
+		if policy != "" {
+			w.corruptionPolicy = policy
+		}
+	}
+}
+
+// segmentScan is the outcome of checking every frame of a segment.
+type segmentScan struct {
+	size int64
+	// valid is the size of the intact frames in front of the first bad one.
+	valid   int64
+	lastLSN uint64
+	// bad is why the first bad frame failed, nil when there is none. tail
+	// tells whether that frame runs to the end of the file.
+	bad  error
+	tail bool
+}
+
+// recover checks every frame of the log before it is written to and
+// positions it after the last intact record. A bad frame running to the end
+// of the newest segment is what a crash in the middle of a write leaves
+// behind: it is cut off with a warning. A bad frame anywhere else means the
+// log is corrupted, and the corruption policy either fails or cuts the log
+// there, deleting the segments after it.
+func (w *WAL) recover() error {
+	segments, err := w.segments()
+	if err != nil {
+		return err
+	}
+
+	for i, path := range segments {
+		scan, err := scanSegment(path)
+		if err != nil {
+			return err
+		}
+		if scan.lastLSN > 0 {
+			w.lastLSN = scan.lastLSN
+		}
+		if scan.bad == nil {
+			continue
+		}
+
+		name := filepath.Base(path)
+		torn := scan.tail && i == len(segments)-1
+		if !torn && w.corruptionPolicy == PolicyFail {
+			return fmt.Errorf("%s: offset %d: %w", name, scan.valid, scan.bad)
+		}
+
+		if err := truncateFile(path, scan.valid); err != nil {
+			return err
+		}
+		for _, later := range segments[i+1:] {
+			if err := os.Remove(later); err != nil {
+				return err
+			}
+		}
+
+		attrs := []any{
+			slog.String("segment", name),
+			slog.Int64("offset", scan.valid),
+			slog.Int64("bytes", scan.size-scan.valid),
+			slog.Uint64("last_lsn", w.lastLSN),
+			slog.Any("err", scan.bad),
+		}
+		if torn {
+			w.log.Warn("wal torn tail truncated", attrs...)
+		} else {
+			w.log.Error("wal corrupted, truncated", append(attrs, slog.Int("segments", len(segments)-i-1))...)
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func scanSegment(path string) (segmentScan, error) {
+	file, err := os.Open(path) //nolint:gosec // path comes from the wal directory listing
+	if err != nil {
+		return segmentScan{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return segmentScan{}, err
+	}
+
+	scan := segmentScan{size: info.Size()}
+	reader := bufio.NewReader(file)
+	for {
+		record, n, err := readRecord(reader, scan.size-scan.valid)
+		switch {
+		case errors.Is(err, io.EOF):
+			return scan, nil
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			// a frame running past the end is a torn write only when nothing
+			// intact follows it; otherwise its length is corrupted
+			rest := make([]byte, scan.size-scan.valid)
+			if _, err := file.ReadAt(rest, scan.valid); err != nil {
+				return segmentScan{}, err
+			}
+			lastLSN := scan.lastLSN
+			if scan.valid == 0 {
+				lastLSN = segmentFirstLSN(path) - 1
+			}
+			scan.tail = !intactFrameAfter(rest, lastLSN)
+			if scan.tail {
+				scan.bad = fmt.Errorf("torn frame: %w", ErrCorruptedRecord)
+			} else {
+				scan.bad = fmt.Errorf("frame length: %w", ErrCorruptedRecord)
+			}
+			return scan, nil
+		case errors.Is(err, ErrCorruptedRecord):
+			scan.bad = err
+			scan.tail = scan.valid+n == scan.size
+			return scan, nil
+		case err != nil:
+			return segmentScan{}, err
+		}
+
+		scan.valid += n
+		scan.lastLSN = record.LSN
+	}
+}
+
+const (
+	// maxScanFrameSize bounds the frames intactFrameAfter looks for:
+	// records hold commands far smaller, a larger frame after a corrupted
+	// length is taken for a torn tail.
+	maxScanFrameSize = 1 << 20
+	// maxScanBytes bounds the payloads intactFrameAfter checksums in all,
+	// so that garbage claiming frames at every offset cannot hold up New.
+	maxScanBytes = 64 << 20
+)
+
+// intactFrameAfter reports whether a frame with a valid checksum and payload
+// starts anywhere in data past its first byte. Only frames of at most
+// maxScanFrameSize following the record at lastLSN are looked for, their
+// LSN being checked before the checksum, and the search gives up once it
+// checksummed maxScanBytes.
+func intactFrameAfter(data []byte, lastLSN uint64) bool {
+	budget := maxScanBytes
+	for offset := 1; offset+frameHeaderSize < len(data) && budget > 0; offset++ {
+		header := data[offset : offset+frameHeaderSize]
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		end := int64(offset) + frameHeaderSize + length
+		if length > maxScanFrameSize || end > int64(len(data)) {
+			continue
+		}
+
+		payload := data[offset+frameHeaderSize : end]
+		// the records that follow are no more than the bytes they take
+		lsn, n := binary.Uvarint(payload)
+		if n <= 0 || lsn <= lastLSN || lsn-lastLSN > uint64(len(data)) {
+			continue
+		}
+		budget -= len(payload)
+		if crc32.Checksum(payload, castagnoli) != binary.BigEndian.Uint32(header[4:]) {
+			continue
+		}
+		if _, err := decodePayload(payload); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateFile cuts a file to size and fsyncs it, so that the bytes cut off
+// do not come back after a crash.
+func truncateFile(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0) //nolint:gosec // path comes from the wal directory listing
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := file.Truncate(size); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	return file.Close()
+}
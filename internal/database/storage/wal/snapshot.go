@@ -0,0 +1,299 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"lesson1/internal/database/compression"
+)
+
+const (
+	snapshotPrefix = "snapshot_"
+	snapshotSuffix = ".snap"
+	snapshotMagic  = "KVSNAP01"
+)
+
+var (
+	ErrCorruptedSnapshot = errors.New("corrupted snapshot")
+	// ErrTruncated reports a position whose records were deleted after a
+	// snapshot covered them; the snapshot has to be loaded instead.
+	ErrTruncated = errors.New("wal position truncated")
+
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// WriteSnapshot stores a snapshot of the state reached at lsn, the records
+// that recreate it being produced by dump through emit. The snapshot is
+// written to a temporary file that is only renamed into place once fsynced,
+// so a crash leaves either the previous snapshot or the new one. Afterwards
+// the older snapshots and the segments covered by lsn are deleted.
+//
+// The file is the magic, the big-endian lsn, the record frames, each with its
+// own length and CRC-32C, an empty frame marking the end and the CRC-32C of
+// everything before it.
+func (w *WAL) WriteSnapshot(lsn uint64, dump func(emit func(command string, args ...string) error) error) error {
+	const op = "wal.WriteSnapshot"
+
+	path := filepath.Join(w.dir, snapshotName(lsn))
+	records, saved, err := writeSnapshotFile(path+".tmp", lsn, w.compressor, dump)
+	w.savedBytes.Add(saved)
+	if err != nil {
+		_ = os.Remove(path + ".tmp")
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := syncDir(w.dir); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	w.log.Info("snapshot written", slog.Uint64("lsn", lsn), slog.Int("records", records))
+
+	snapshots, err := w.snapshots()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, old := range snapshots {
+		if fileLSN(old, snapshotPrefix, snapshotSuffix) < lsn {
+			if err := os.Remove(old); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if err := w.Truncate(lsn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ReadSnapshot feeds the records of the newest snapshot to apply, all of
+// them carrying the LSN the snapshot covers, and returns that LSN, 0 when
+// there is no snapshot. The checksum is verified before the first record is
+// applied.
+func (w *WAL) ReadSnapshot(apply func(Record) error) (uint64, error) {
+	const op = "wal.ReadSnapshot"
+
+	snapshots, err := w.snapshots()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	path := snapshots[len(snapshots)-1]
+	if _, err := readSnapshotFile(path, nil); err != nil {
+		return 0, fmt.Errorf("%s: %s: %w", op, filepath.Base(path), err)
+	}
+	lsn, err := readSnapshotFile(path, apply)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s: %w", op, filepath.Base(path), err)
+	}
+	return lsn, nil
+}
+
+// Truncate deletes the segments whose records all have an LSN up to lsn.
+// The newest segment is always kept, it holds the position of the log.
+func (w *WAL) Truncate(lsn uint64) error {
+	const op = "wal.Truncate"
+
+	segments, err := w.segments()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var deleted int
+	for i := 0; i+1 < len(segments); i++ {
+		if segmentFirstLSN(segments[i+1]) > lsn+1 {
+			break
+		}
+		if err := os.Remove(segments[i]); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		deleted++
+	}
+
+	if deleted > 0 {
+		w.log.Info("wal truncated", slog.Uint64("lsn", lsn), slog.Int("segments", deleted))
+	}
+	return nil
+}
+
+func (w *WAL) snapshots() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isFileName(entry.Name(), snapshotPrefix, snapshotSuffix) {
+			continue
+		}
+		snapshots = append(snapshots, filepath.Join(w.dir, entry.Name()))
+	}
+	slices.Sort(snapshots)
+
+	return snapshots, nil
+}
+
+// SnapshotLSN returns the LSN covered by the newest snapshot, 0 without one.
+func (w *WAL) SnapshotLSN() (uint64, error) {
+	snapshots, err := w.snapshots()
+	if err != nil || len(snapshots) == 0 {
+		return 0, err
+	}
+	return fileLSN(snapshots[len(snapshots)-1], snapshotPrefix, snapshotSuffix), nil
+}
+
+// writeSnapshotFile writes the snapshot and returns how many records it
+// holds and how many bytes compression spared.
+func writeSnapshotFile(
+	path string, lsn uint64, c compression.Compressor, dump func(emit func(command string, args ...string) error) error,
+) (int, int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640) //nolint:gosec // path is built from the wal directory
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	checksum := crc32.New(castagnoli)
+	writer := bufio.NewWriter(io.MultiWriter(file, checksum))
+
+	header := binary.BigEndian.AppendUint64([]byte(snapshotMagic), lsn)
+	if _, err := writer.Write(header); err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		records int
+		saved   int64
+	)
+	err = dump(func(command string, args ...string) error {
+		records++
+		frame, n := Record{LSN: lsn, Command: command, Args: args}.encode(c)
+		saved += n
+		_, err := writer.Write(frame)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if _, err := writer.Write(make([]byte, 4)); err != nil {
+		return 0, 0, err
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, 0, err
+	}
+	if _, err := file.Write(binary.BigEndian.AppendUint32(nil, checksum.Sum32())); err != nil {
+		return 0, 0, err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, 0, err
+	}
+	return records, saved, file.Close()
+}
+
+// readSnapshotFile reads a snapshot, feeding its records to apply unless it
+// is nil, and returns the LSN it covers once the checksum matched.
+func readSnapshotFile(path string, apply func(Record) error) (uint64, error) {
+	file, err := os.Open(path) //nolint:gosec // path comes from the wal directory listing
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if size < int64(len(snapshotMagic))+8+4+4 {
+		return 0, fmt.Errorf("size: %w", ErrCorruptedSnapshot)
+	}
+
+	// the checksum covers everything but itself
+	checksum := crc32.New(castagnoli)
+	reader := bufio.NewReader(io.TeeReader(io.LimitReader(file, size-4), checksum))
+
+	header := make([]byte, len(snapshotMagic)+8)
+	if _, err := io.ReadFull(reader, header); err != nil || string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return 0, fmt.Errorf("header: %w", ErrCorruptedSnapshot)
+	}
+	lsn := binary.BigEndian.Uint64(header[len(snapshotMagic):])
+
+	remaining := size - 4 - int64(len(header))
+	for {
+		record, n, err := readSnapshotRecord(reader, remaining)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		remaining -= n
+		if record.LSN != lsn {
+			return 0, fmt.Errorf("record lsn: %w", ErrCorruptedSnapshot)
+		}
+		if apply != nil {
+			if err := apply(record); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if _, err := reader.ReadByte(); !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("trailing bytes: %w", ErrCorruptedSnapshot)
+	}
+	var trailer [4]byte
+	if _, err := file.ReadAt(trailer[:], size-4); err != nil {
+		return 0, err
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != checksum.Sum32() {
+		return 0, fmt.Errorf("checksum: %w", ErrCorruptedSnapshot)
+	}
+	return lsn, nil
+}
+
+// readSnapshotRecord reads one frame, returning io.EOF for the end marker.
+func readSnapshotRecord(reader *bufio.Reader, remaining int64) (Record, int64, error) {
+	header, err := reader.Peek(4)
+	if err != nil {
+		return Record{}, 0, fmt.Errorf("frame: %w", ErrCorruptedSnapshot)
+	}
+	if binary.BigEndian.Uint32(header) == 0 {
+		_, _ = reader.Discard(4)
+		return Record{}, 0, io.EOF
+	}
+
+	record, n, err := readRecord(reader, remaining)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrCorruptedRecord) {
+		return Record{}, 0, fmt.Errorf("frame: %w: %w", ErrCorruptedSnapshot, err)
+	}
+	return record, n, err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir) //nolint:gosec // dir is the wal directory
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+func snapshotName(lsn uint64) string {
+	return fmt.Sprintf("%s%020d%s", snapshotPrefix, lsn, snapshotSuffix)
+}
@@ -0,0 +1,676 @@
+package wal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lesson1/internal/database/compression"
+)
+
+const (
+	DefaultMaxSegmentSize = 10 << 20
+	DefaultBatchSize      = 100
+	DefaultBatchTimeout   = 10 * time.Millisecond
+
+	segmentPrefix = "wal_"
+	segmentSuffix = ".log"
+)
+
+var (
+	ErrClosed = errors.New("wal is closed")
+	ErrFailed = errors.New("wal failed")
+
+	errStopReading = errors.New("stop reading")
+)
+
+// WAL is an append-only log of mutations split into segment files. A segment
+// is named after the LSN of its first record, so lexical order of the file
+// names is the replay order.
+//
+// Every record is framed with its length and CRC-32C. New checks every frame
+// and repairs a torn tail, see recover.
+//
+// Arguments are compressed as the compressor selects them, see
+// WithCompression; the codec is stored with every argument, so the records
+// are read back whatever the current setting.
+//
+// Writes are group-committed: records from concurrent callers are collected
+// into a batch that is written and fsynced once, either when it reaches the
+// batch size or when the batch timeout elapses.
+//
+// A failed write or fsync stops the log: the records of the batch that are
+// not durable are cut off the segment, and every later record is rejected
+// with ErrFailed, so that the LSNs on disk never skip one.
+type WAL struct {
+	log            *slog.Logger
+	dir            string
+	maxSegmentSize int64
+	batchSize      int
+	batchTimeout   time.Duration
+
+	corruptionPolicy CorruptionPolicy
+	compressor       compression.Compressor
+
+	// savedBytes counts the bytes compression spared in the records and
+	// snapshots written since New.
+	savedBytes atomic.Int64
+
+	mu      sync.Mutex
+	batch   []pending
+	lastLSN uint64
+	closed  bool
+	// syncedLSN is the last record fsynced; synced is signalled when it
+	// moves, the log fails or it is closed.
+	syncedLSN uint64
+	synced    *sync.Cond
+	// failure is why the log stopped accepting records, see fail.
+	failure error
+
+	full    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+
+	// segment state is owned by the flusher goroutine
+	segment     *os.File
+	segmentSize int64
+}
+
+type pending struct {
+	record Record
+	done   chan error
+}
+
+type Option func(*WAL)
+
+func WithMaxSegmentSize(size int64) Option {
+	return func(w *WAL) {
+		if size > 0 {
+			w.maxSegmentSize = size
+		}
+	}
+}
+
+// WithBatchSize sets how many records trigger an immediate flush.
+func WithBatchSize(size int) Option {
+	return func(w *WAL) {
+		if size > 0 {
+			w.batchSize = size
+		}
+	}
+}
+
+// WithBatchTimeout sets how long a record may wait for its batch to fill up.
+func WithBatchTimeout(timeout time.Duration) Option {
+	return func(w *WAL) {
+		if timeout > 0 {
+			w.batchTimeout = timeout
+		}
+	}
+}
+
+// WithCompression compresses the record arguments the compressor selects,
+// in the segments and in the snapshots.
+func WithCompression(c compression.Compressor) Option {
+	return func(w *WAL) {
+		w.compressor = c
+	}
+}
+
+// New opens the log stored in dir, creating the directory if needed, and
+// positions it after the last record already on disk. A torn record at the
+// end of the log is cut off; a corrupted record before it fails New with
+// ErrCorruptedRecord unless the corruption policy is PolicyTruncate.
+func New(log *slog.Logger, dir string, opts ...Option) (*WAL, error) {
+	const op = "wal.New"
+
+	w := &WAL{
+		log:              log,
+		dir:              dir,
+		maxSegmentSize:   DefaultMaxSegmentSize,
+		batchSize:        DefaultBatchSize,
+		batchTimeout:     DefaultBatchTimeout,
+		corruptionPolicy: PolicyFail,
+		full:             make(chan struct{}, 1),
+		stop:             make(chan struct{}),
+		stopped:          make(chan struct{}),
+	}
+	w.synced = sync.NewCond(&w.mu)
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := w.recover(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the segments a snapshot covered may all be gone
+	snapshotLSN, err := w.SnapshotLSN()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	w.lastLSN = max(w.lastLSN, snapshotLSN)
+	w.syncedLSN = w.lastLSN
+
+	go w.flushLoop()
+
+	return w, nil
+}
+
+// LastLSN returns the sequence number of the most recently written record.
+func (w *WAL) LastLSN() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.lastLSN
+}
+
+// WaitSynced blocks until the records up to lsn are fsynced. It fails with
+// ErrFailed once the log failed, and with ErrClosed once it is closed,
+// before they are.
+func (w *WAL) WaitSynced(lsn uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.syncedLSN < lsn {
+		if w.failure != nil {
+			return fmt.Errorf("%w: %w", ErrFailed, w.failure)
+		}
+		select {
+		case <-w.stopped:
+			return ErrClosed
+		default:
+		}
+		w.synced.Wait()
+	}
+	return nil
+}
+
+// SavedBytes returns how many bytes compression spared in the records and
+// snapshots written since New.
+func (w *WAL) SavedBytes() int64 {
+	return w.savedBytes.Load()
+}
+
+// Append durably writes one record. It returns only after the batch holding
+// the record has been fsynced, so a nil error means the mutation survives a
+// crash.
+func (w *WAL) Append(ctx context.Context, command string, args ...string) error {
+	const op = "wal.Append"
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := Wait(ctx, w.Submit(command, args...)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Submit assigns the next LSN to a record and queues it for the next batch
+// without waiting. The returned channel receives the outcome of the fsync.
+// Callers that need the log order to match some external order must call
+// Submit while holding the lock that defines that order.
+func (w *WAL) Submit(command string, args ...string) <-chan error {
+	done := make(chan error, 1)
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		done <- ErrClosed
+		return done
+	}
+	if w.failure != nil {
+		err := fmt.Errorf("%w: %w", ErrFailed, w.failure)
+		w.mu.Unlock()
+		done <- err
+		return done
+	}
+
+	w.lastLSN++
+	w.batch = append(w.batch, pending{
+		record: Record{LSN: w.lastLSN, Command: command, Args: args},
+		done:   done,
+	})
+	full := len(w.batch) >= w.batchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return done
+}
+
+// Wait blocks until a submitted record is durable or ctx is done. A cancelled
+// wait does not withdraw the record.
+func Wait(ctx context.Context, done <-chan error) error {
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Replay feeds the state on disk to apply in LSN order: the records of the
+// newest snapshot, then the records logged after it. The segments the
+// snapshot covers are deleted afterwards.
+func (w *WAL) Replay(apply func(Record) error) error {
+	return w.ReplayAfter(0, apply)
+}
+
+// ReplayAfter is Replay for a state that already holds the records up to
+// afterLSN: only the records logged after it are fed to apply, and the
+// snapshot only when it covers more. The caller clears its state first when
+// SnapshotLSN is past afterLSN, as the snapshot replaces it whole.
+func (w *WAL) ReplayAfter(afterLSN uint64, apply func(Record) error) error {
+	const op = "wal.Replay"
+
+	snapshotLSN, err := w.SnapshotLSN()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if snapshotLSN > afterLSN {
+		if snapshotLSN, err = w.ReadSnapshot(apply); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	skipLSN := max(snapshotLSN, afterLSN)
+
+	segments, err := w.segments()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var replayed int
+	for i, path := range segments {
+		if i+1 < len(segments) && segmentFirstLSN(segments[i+1]) <= skipLSN+1 {
+			continue
+		}
+		err := readSegment(path, func(record Record) error {
+			if record.LSN <= skipLSN {
+				return nil
+			}
+			replayed++
+			return apply(record)
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", op, filepath.Base(path), err)
+		}
+	}
+
+	w.log.Info("wal replayed",
+		slog.Uint64("snapshot_lsn", snapshotLSN),
+		slog.Int("segments", len(segments)),
+		slog.Int("records", replayed),
+	)
+
+	if err := w.Truncate(snapshotLSN); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ReadFrom returns up to limit records with an LSN greater than afterLSN, in
+// LSN order. Only complete frames are returned: a partially written frame at
+// the tail of the newest segment is treated as the end of the log. It fails
+// with ErrTruncated when the records following afterLSN were deleted.
+func (w *WAL) ReadFrom(afterLSN uint64, limit int) ([]Record, error) {
+	const op = "wal.ReadFrom"
+
+	segments, err := w.segments()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the log starts after the snapshot when no segment is left
+	firstLSN, err := w.SnapshotLSN()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	firstLSN++
+	if len(segments) > 0 {
+		firstLSN = segmentFirstLSN(segments[0])
+	}
+	if firstLSN > afterLSN+1 {
+		return nil, fmt.Errorf("%s: %w", op, ErrTruncated)
+	}
+
+	start := 0
+	for i, path := range segments {
+		if segmentFirstLSN(path) <= afterLSN+1 {
+			start = i
+		}
+	}
+
+	var records []Record
+	for i, path := range segments[start:] {
+		err := readSegment(path, func(record Record) error {
+			if record.LSN <= afterLSN {
+				return nil
+			}
+			records = append(records, record)
+			if len(records) >= limit {
+				return errStopReading
+			}
+			return nil
+		})
+		switch {
+		case errors.Is(err, errStopReading):
+			return records, nil
+		case errors.Is(err, io.ErrUnexpectedEOF) && start+i == len(segments)-1:
+			return records, nil
+		case err != nil:
+			return nil, fmt.Errorf("%s: %s: %w", op, filepath.Base(path), err)
+		}
+	}
+
+	return records, nil
+}
+
+// Close flushes the records that are still queued and closes the active
+// segment. Submitting after Close fails with ErrClosed.
+func (w *WAL) Close() error {
+	const op = "wal.Close"
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.stopped
+
+	w.mu.Lock()
+	w.synced.Broadcast()
+	w.mu.Unlock()
+
+	if w.segment == nil {
+		return nil
+	}
+	if err := w.segment.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (w *WAL) flushLoop() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.batchTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.full:
+		case <-ticker.C:
+		case <-w.stop:
+			w.flush()
+			return
+		}
+		w.flush()
+	}
+}
+
+// flush writes the queued batch with a single fsync and reports the result to
+// every caller waiting on it.
+func (w *WAL) flush() {
+	const op = "wal.flush"
+
+	w.mu.Lock()
+	batch := w.batch
+	w.batch = nil
+	failure := w.failure
+	w.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	var (
+		durable int
+		err     error
+	)
+	if failure != nil {
+		err = fmt.Errorf("%w: %w", ErrFailed, failure)
+	} else {
+		durable, err = w.writeBatch(batch)
+	}
+	if err != nil {
+		w.log.Error("wal flush failed", slog.String("operation", op), slog.Int("records", len(batch)), slog.Any("err", err))
+	}
+
+	w.mu.Lock()
+	if durable > 0 {
+		w.syncedLSN = batch[durable-1].record.LSN
+	}
+	w.synced.Broadcast()
+	w.mu.Unlock()
+
+	for i, p := range batch {
+		if i < durable {
+			p.done <- nil
+		} else {
+			p.done <- err
+		}
+	}
+}
+
+// writeBatch writes and fsyncs the batch and returns how many of its records
+// are durable, all of them unless it fails. A failure cuts the records not
+// fsynced yet off the segment and stops the log: they hold LSNs that no
+// later record may follow.
+func (w *WAL) writeBatch(batch []pending) (int, error) {
+	var (
+		durable int
+		// start is where the records not fsynced yet begin in the active
+		// segment, -1 before the first of them
+		start int64 = -1
+	)
+	for i, p := range batch {
+		offset, rotated, err := w.write(p.record)
+		if rotated {
+			durable, start = i, -1
+		}
+		if start < 0 {
+			start = offset
+		}
+		if err != nil {
+			return durable, w.fail(w.cut(start, err))
+		}
+	}
+	// the pages a failed fsync left behind may or may not reach the disk
+	if err := w.segment.Sync(); err != nil {
+		return durable, w.fail(err)
+	}
+	return len(batch), nil
+}
+
+// cut truncates the active segment back to size after err, unless size is
+// negative.
+func (w *WAL) cut(size int64, err error) error {
+	if size < 0 || w.segment == nil {
+		return err
+	}
+	if truncErr := w.segment.Truncate(size); truncErr != nil {
+		return fmt.Errorf("%w: truncate: %w", err, truncErr)
+	}
+	w.segmentSize = size
+	return err
+}
+
+// write appends a record to the active segment, rotating first when the
+// record would push a non-empty segment past the size limit. It returns the
+// offset of the frame, -1 when it was not reached, and whether the previous
+// segment was fsynced and closed.
+func (w *WAL) write(record Record) (int64, bool, error) {
+	frame, saved := record.encode(w.compressor)
+	w.savedBytes.Add(saved)
+
+	rotated := false
+	if w.segment != nil && w.segmentSize > 0 && w.segmentSize+int64(len(frame)) > w.maxSegmentSize {
+		if err := w.segment.Sync(); err != nil {
+			return -1, false, err
+		}
+		err := w.segment.Close()
+		w.segment = nil
+		if err != nil {
+			return -1, true, err
+		}
+		rotated = true
+	}
+
+	if w.segment == nil {
+		if err := w.openSegment(record.LSN); err != nil {
+			return -1, rotated, err
+		}
+	}
+
+	offset := w.segmentSize
+	n, err := w.segment.Write(frame)
+	w.segmentSize += int64(n)
+	return offset, rotated, err
+}
+
+// fail makes the log reject every later record with ErrFailed and returns
+// err.
+func (w *WAL) fail(err error) error {
+	w.mu.Lock()
+	if w.failure == nil {
+		w.failure = err
+	}
+	w.mu.Unlock()
+	return err
+}
+
+// openSegment reopens the newest segment if it still has room, otherwise it
+// starts a new one named after the LSN of the record about to be written.
+func (w *WAL) openSegment(nextLSN uint64) error {
+	if w.segmentSize == 0 {
+		segments, err := w.segments()
+		if err != nil {
+			return err
+		}
+		if len(segments) > 0 {
+			last := segments[len(segments)-1]
+			info, err := os.Stat(last)
+			if err != nil {
+				return err
+			}
+			if info.Size() < w.maxSegmentSize {
+				return w.openFile(last, info.Size())
+			}
+		}
+	}
+
+	return w.openFile(filepath.Join(w.dir, segmentName(nextLSN)), 0)
+}
+
+func (w *WAL) openFile(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+
+	w.segment = file
+	w.segmentSize = size
+	w.log.Debug("wal segment opened", slog.String("segment", filepath.Base(path)))
+	return nil
+}
+
+func (w *WAL) segments() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isSegmentName(entry.Name()) {
+			continue
+		}
+		segments = append(segments, filepath.Join(w.dir, entry.Name()))
+	}
+	slices.Sort(segments)
+
+	return segments, nil
+}
+
+func segmentName(firstLSN uint64) string {
+	return fmt.Sprintf("%s%020d%s", segmentPrefix, firstLSN, segmentSuffix)
+}
+
+func segmentFirstLSN(path string) uint64 {
+	return fileLSN(path, segmentPrefix, segmentSuffix)
+}
+
+func isSegmentName(name string) bool {
+	return isFileName(name, segmentPrefix, segmentSuffix)
+}
+
+// fileLSN returns the LSN in the name of a segment or a snapshot.
+func fileLSN(path, prefix, suffix string) uint64 {
+	name := filepath.Base(path)
+	lsn, _ := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix), 10, 64)
+	return lsn
+}
+
+func isFileName(name, prefix, suffix string) bool {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return false
+	}
+	_, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix), 10, 64)
+	return err == nil
+}
+
+func readSegment(path string, apply func(Record) error) error {
+	file, err := os.Open(path) //nolint:gosec // path comes from the wal directory listing
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	remaining := info.Size()
+	reader := bufio.NewReader(file)
+	for {
+		record, n, err := readRecord(reader, remaining)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		remaining -= n
+		if err := apply(record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,637 @@
+package wal_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/database/compression"
+	"lesson1/internal/database/storage/wal"
+	"lesson1/internal/lib/logger/slogdiscard"
+)
+
+func openWAL(t *testing.T, dir string, opts ...wal.Option) *wal.WAL {
+	t.Helper()
+
+	w, err := wal.New(slogdiscard.NewDiscardLogger(), dir, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	return w
+}
+
+func replayAll(t *testing.T, w *wal.WAL) []wal.Record {
+	t.Helper()
+
+	var records []wal.Record
+	require.NoError(t, w.Replay(func(record wal.Record) error {
+		records = append(records, record)
+		return nil
+	}))
+	return records
+}
+
+func TestWALAppendAndReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w := openWAL(t, t.TempDir())
+
+	require.NoError(t, w.Append(ctx, "SET", "k", "v"))
+	require.NoError(t, w.Append(ctx, "SET", "empty", ""))
+	require.NoError(t, w.Append(ctx, "DEL", "k"))
+
+	assert.Equal(t, uint64(3), w.LastLSN())
+	assert.Equal(t, []wal.Record{
+		{LSN: 1, Command: "SET", Args: []string{"k", "v"}},
+		{LSN: 2, Command: "SET", Args: []string{"empty", ""}},
+		{LSN: 3, Command: "DEL", Args: []string{"k"}},
+	}, replayAll(t, w))
+}
+
+func TestWALSegmentRotation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := openWAL(t, dir, wal.WithMaxSegmentSize(64))
+
+	const total = 20
+	for i := range total {
+		require.NoError(t, w.Append(ctx, "SET", "key"+strconv.Itoa(i), "value"))
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	require.NoError(t, err)
+	assert.Greater(t, len(segments), 1)
+
+	for _, segment := range segments {
+		info, err := os.Stat(segment)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(64))
+	}
+
+	records := replayAll(t, w)
+	require.Len(t, records, total)
+	for i, record := range records {
+		assert.Equal(t, uint64(i+1), record.LSN)
+		assert.Equal(t, "key"+strconv.Itoa(i), record.Args[0])
+	}
+}
+
+func TestWALReopenContinuesSequence(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := openWAL(t, dir, wal.WithMaxSegmentSize(64))
+	for i := range 5 {
+		require.NoError(t, first.Append(ctx, "SET", "k", strconv.Itoa(i)))
+	}
+	require.NoError(t, first.Close())
+	require.ErrorIs(t, first.Append(ctx, "SET", "k", "late"), wal.ErrClosed)
+
+	second := openWAL(t, dir, wal.WithMaxSegmentSize(64))
+	assert.Equal(t, uint64(5), second.LastLSN())
+	require.NoError(t, second.Append(ctx, "DEL", "k"))
+
+	records := replayAll(t, second)
+	require.Len(t, records, 6)
+	assert.Equal(t, wal.Record{LSN: 6, Command: "DEL", Args: []string{"k"}}, records[5])
+}
+
+func TestWALReplayTornTail(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	w := openWAL(t, dir)
+	require.NoError(t, w.Append(ctx, "SET", "k", "v"))
+	require.NoError(t, w.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	info, err := os.Stat(segments[0])
+	require.NoError(t, err)
+
+	file, err := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0o640)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{0, 0, 0, 9, 1})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	reopened := openWAL(t, dir)
+	assert.Equal(t, uint64(1), reopened.LastLSN())
+
+	repaired, err := os.Stat(segments[0])
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), repaired.Size())
+
+	require.NoError(t, reopened.Append(ctx, "DEL", "k"))
+	assert.Equal(t, []wal.Record{
+		{LSN: 1, Command: "SET", Args: []string{"k", "v"}},
+		{LSN: 2, Command: "DEL", Args: []string{"k"}},
+	}, replayAll(t, reopened))
+}
+
+func TestWALCorruptedRecord(t *testing.T) {
+	t.Parallel()
+
+	// corrupt flips a byte of the segment at offset, counted from the end
+	// when negative.
+	corrupt := func(t *testing.T, offset int) string {
+		t.Helper()
+
+		ctx := context.Background()
+		dir := t.TempDir()
+
+		w := openWAL(t, dir)
+		require.NoError(t, w.Append(ctx, "SET", "a", "1"))
+		require.NoError(t, w.Append(ctx, "SET", "b", "2"))
+		require.NoError(t, w.Append(ctx, "SET", "c", "3"))
+		require.NoError(t, w.Close())
+
+		segments, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+		require.NoError(t, err)
+		require.Len(t, segments, 1)
+
+		data, err := os.ReadFile(segments[0])
+		require.NoError(t, err)
+		if offset < 0 {
+			offset += len(data)
+		}
+		data[offset] ^= 0xff
+		require.NoError(t, os.WriteFile(segments[0], data, 0o600))
+
+		return dir
+	}
+
+	t.Run("tail", func(t *testing.T) {
+		t.Parallel()
+
+		w := openWAL(t, corrupt(t, -1))
+		assert.Equal(t, uint64(2), w.LastLSN())
+		assert.Len(t, replayAll(t, w), 2)
+	})
+
+	t.Run("middle fails", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := wal.New(slogdiscard.NewDiscardLogger(), corrupt(t, 8))
+		require.ErrorIs(t, err, wal.ErrCorruptedRecord)
+	})
+
+	t.Run("length in the middle fails", func(t *testing.T) {
+		t.Parallel()
+
+		// the first frame claims to run past the end of the file, but the
+		// frames after it are intact: it is not a torn write
+		_, err := wal.New(slogdiscard.NewDiscardLogger(), corrupt(t, 0))
+		require.ErrorIs(t, err, wal.ErrCorruptedRecord)
+	})
+
+	t.Run("length in the middle truncated", func(t *testing.T) {
+		t.Parallel()
+
+		w := openWAL(t, corrupt(t, 0), wal.WithCorruptionPolicy(wal.PolicyTruncate))
+		assert.Zero(t, w.LastLSN())
+		assert.Empty(t, replayAll(t, w))
+	})
+
+	t.Run("middle truncated", func(t *testing.T) {
+		t.Parallel()
+
+		w := openWAL(t, corrupt(t, 8), wal.WithCorruptionPolicy(wal.PolicyTruncate))
+		assert.Zero(t, w.LastLSN())
+		assert.Empty(t, replayAll(t, w))
+
+		require.NoError(t, w.Append(context.Background(), "SET", "d", "4"))
+		assert.Equal(t, []wal.Record{{LSN: 1, Command: "SET", Args: []string{"d", "4"}}}, replayAll(t, w))
+	})
+}
+
+func TestWALCorruptedLengthInLargeSegment(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	w := openWAL(t, dir)
+	require.NoError(t, w.Append(ctx, "SET", "a", "1"))
+	require.NoError(t, w.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	// the first frame claims to run past the end of ten MiB of garbage,
+	// which claims frames of almost a MiB with a plausible LSN at every
+	// fourth offset
+	data, err := os.ReadFile(segments[0])
+	require.NoError(t, err)
+	data[0] ^= 0xff
+	garbage := bytes.Repeat([]byte{0x00, 0x0f, 0x0f, 0x0f}, 10<<20/4)
+	require.NoError(t, os.WriteFile(segments[0], append(data, garbage...), 0o600))
+
+	opened := make(chan *wal.WAL, 1)
+	go func() {
+		w, err := wal.New(slogdiscard.NewDiscardLogger(), dir)
+		assert.NoError(t, err)
+		opened <- w
+	}()
+
+	select {
+	case w := <-opened:
+		require.NotNil(t, w)
+		t.Cleanup(func() { _ = w.Close() })
+		assert.Zero(t, w.LastLSN())
+	case <-time.After(10 * time.Second):
+		t.Fatal("looking for an intact frame in the garbage took too long")
+	}
+}
+
+func TestParseCorruptionPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := wal.ParseCorruptionPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, wal.PolicyFail, policy)
+
+	policy, err = wal.ParseCorruptionPolicy("truncate")
+	require.NoError(t, err)
+	assert.Equal(t, wal.PolicyTruncate, policy)
+
+	_, err = wal.ParseCorruptionPolicy("ignore")
+	require.ErrorIs(t, err, wal.ErrUnknownCorruptionPolicy)
+}
+
+func TestWALAppendCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := openWAL(t, t.TempDir())
+	require.ErrorIs(t, w.Append(ctx, "SET", "k", "v"), context.Canceled)
+	assert.Empty(t, replayAll(t, w))
+}
+
+func TestWALBatchFlushesWhenFull(t *testing.T) {
+	t.Parallel()
+
+	w := openWAL(t, t.TempDir(), wal.WithBatchSize(3), wal.WithBatchTimeout(time.Hour))
+
+	first := w.Submit("SET", "a", "1")
+	second := w.Submit("SET", "b", "2")
+
+	select {
+	case err := <-first:
+		t.Fatalf("partial batch flushed early: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	third := w.Submit("SET", "c", "3")
+	for _, done := range []<-chan error{first, second, third} {
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("full batch was not flushed")
+		}
+	}
+	assert.Len(t, replayAll(t, w), 3)
+}
+
+func TestWALBatchFlushesOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	w := openWAL(t, t.TempDir(), wal.WithBatchSize(1000), wal.WithBatchTimeout(5*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, w.Append(ctx, "SET", "k", "v"))
+	assert.Len(t, replayAll(t, w), 1)
+}
+
+func TestWALStopsAfterFailedWrite(t *testing.T) {
+	t.Parallel()
+
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to fail the writes")
+	}
+
+	ctx := context.Background()
+
+	// a segment holds three records, the fourth one starts a new segment
+	scratch := t.TempDir()
+	require.NoError(t, openWAL(t, scratch).Append(ctx, "SET", "k", "1"))
+	segments, err := filepath.Glob(filepath.Join(scratch, "wal_*.log"))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	info, err := os.Stat(segments[0])
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	w := openWAL(t, dir, wal.WithMaxSegmentSize(3*info.Size()), wal.WithBatchSize(2), wal.WithBatchTimeout(time.Hour))
+	first := w.Submit("SET", "k", "1")
+	second := w.Submit("SET", "k", "2")
+	require.NoError(t, <-first)
+	require.NoError(t, <-second)
+
+	// the segment of the fourth record fails every write
+	failing := filepath.Join(dir, fmt.Sprintf("wal_%020d.log", 4))
+	require.NoError(t, os.Symlink("/dev/full", failing))
+
+	third := w.Submit("SET", "k", "3")
+	fourth := w.Submit("SET", "k", "4")
+	require.NoError(t, <-third)
+	require.Error(t, <-fourth)
+
+	// no record may follow the lost one
+	require.ErrorIs(t, w.Append(ctx, "SET", "k", "5"), wal.ErrFailed)
+	require.NoError(t, w.WaitSynced(3))
+	require.ErrorIs(t, w.WaitSynced(4), wal.ErrFailed)
+	require.NoError(t, w.Close())
+
+	require.NoError(t, os.Remove(failing))
+	records := replayAll(t, openWAL(t, dir))
+	require.Len(t, records, 3)
+	assert.Equal(t, uint64(3), records[2].LSN)
+}
+
+func TestWALCloseFlushesQueuedRecords(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w := openWAL(t, dir, wal.WithBatchSize(1000), wal.WithBatchTimeout(time.Hour))
+
+	done := w.Submit("SET", "k", "v")
+	require.NoError(t, w.Close())
+	require.NoError(t, <-done)
+	require.NoError(t, w.WaitSynced(1))
+	require.ErrorIs(t, w.WaitSynced(2), wal.ErrClosed)
+
+	assert.Len(t, replayAll(t, openWAL(t, dir)), 1)
+}
+
+func TestWALConcurrentAppend(t *testing.T) {
+	t.Parallel()
+
+	const (
+		writers = 16
+		perEach = 50
+	)
+
+	ctx := context.Background()
+	w := openWAL(t, t.TempDir(), wal.WithBatchSize(8), wal.WithBatchTimeout(time.Millisecond))
+
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range perEach {
+				assert.NoError(t, w.Append(ctx, "SET", strconv.Itoa(i), strconv.Itoa(j)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	records := replayAll(t, w)
+	require.Len(t, records, writers*perEach)
+	for i, record := range records {
+		assert.Equal(t, uint64(i+1), record.LSN)
+	}
+}
+
+func TestWALReadFrom(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w := openWAL(t, t.TempDir(), wal.WithMaxSegmentSize(64))
+
+	for i := range 10 {
+		require.NoError(t, w.Append(ctx, "SET", "k", strconv.Itoa(i)))
+	}
+
+	records, err := w.ReadFrom(0, 3)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, uint64(1), records[0].LSN)
+
+	records, err = w.ReadFrom(6, 100)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, uint64(7), records[0].LSN)
+	assert.Equal(t, uint64(10), records[3].LSN)
+
+	records, err = w.ReadFrom(10, 100)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func writeSnapshot(t *testing.T, w *wal.WAL, lsn uint64, records ...wal.Record) {
+	t.Helper()
+
+	require.NoError(t, w.WriteSnapshot(lsn, func(emit func(command string, args ...string) error) error {
+		for _, record := range records {
+			if err := emit(record.Command, record.Args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func TestWALSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := openWAL(t, dir, wal.WithMaxSegmentSize(64))
+
+	for i := range 10 {
+		require.NoError(t, w.Append(ctx, "SET", "k", strconv.Itoa(i)))
+	}
+	segments, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	require.NoError(t, err)
+	require.Greater(t, len(segments), 2)
+
+	writeSnapshot(t, w, 8, wal.Record{Command: "SET", Args: []string{"k", "7"}})
+	require.NoError(t, w.Append(ctx, "DEL", "k"))
+
+	// only the segments holding records after the snapshot are kept
+	kept, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	require.NoError(t, err)
+	assert.Less(t, len(kept), len(segments))
+
+	_, err = w.ReadFrom(0, 100)
+	require.ErrorIs(t, err, wal.ErrTruncated)
+	records, err := w.ReadFrom(8, 100)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, uint64(9), records[0].LSN)
+
+	assert.Equal(t, []wal.Record{
+		{LSN: 8, Command: "SET", Args: []string{"k", "7"}},
+		{LSN: 9, Command: "SET", Args: []string{"k", "8"}},
+		{LSN: 10, Command: "SET", Args: []string{"k", "9"}},
+		{LSN: 11, Command: "DEL", Args: []string{"k"}},
+	}, replayAll(t, w))
+
+	// a newer snapshot replaces the older one
+	writeSnapshot(t, w, 11)
+	snapshots, err := filepath.Glob(filepath.Join(dir, "snapshot_*.snap"))
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Empty(t, replayAll(t, w))
+}
+
+func TestWALReplayAfter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w := openWAL(t, t.TempDir())
+
+	for i := range 5 {
+		require.NoError(t, w.Append(ctx, "SET", "k", strconv.Itoa(i)))
+	}
+	writeSnapshot(t, w, 3, wal.Record{Command: "SET", Args: []string{"k", "2"}})
+
+	replayAfter := func(afterLSN uint64) []wal.Record {
+		var records []wal.Record
+		require.NoError(t, w.ReplayAfter(afterLSN, func(record wal.Record) error {
+			records = append(records, record)
+			return nil
+		}))
+		return records
+	}
+
+	snapshotLSN, err := w.SnapshotLSN()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), snapshotLSN)
+
+	// a state older than the snapshot gets it first
+	assert.Equal(t, []wal.Record{
+		{LSN: 3, Command: "SET", Args: []string{"k", "2"}},
+		{LSN: 4, Command: "SET", Args: []string{"k", "3"}},
+		{LSN: 5, Command: "SET", Args: []string{"k", "4"}},
+	}, replayAfter(1))
+
+	// a newer one only gets the records it misses
+	assert.Equal(t, []wal.Record{
+		{LSN: 5, Command: "SET", Args: []string{"k", "4"}},
+	}, replayAfter(4))
+	assert.Empty(t, replayAfter(5))
+}
+
+func TestWALCompression(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	large := strings.Repeat(`{"id":1,"tags":["a","b"]}`, 200)
+
+	w, err := wal.New(slogdiscard.NewDiscardLogger(), dir, wal.WithCompression(compression.NewCompressor(compression.Flate, 64)))
+	require.NoError(t, err)
+	require.NoError(t, w.Append(ctx, "SET", "k1", large))
+	require.NoError(t, w.Append(ctx, "SET", "k2", "small"))
+	logged := w.SavedBytes()
+	assert.Positive(t, logged)
+
+	segments, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	info, err := os.Stat(segments[0])
+	require.NoError(t, err)
+	assert.Less(t, info.Size(), int64(len(large)))
+
+	writeSnapshot(t, w, 1, wal.Record{Command: "SET", Args: []string{"k1", large}})
+	assert.Greater(t, w.SavedBytes(), logged)
+	require.NoError(t, w.Close())
+
+	// the codec is recorded with every argument, so the records read back
+	// once compression is turned off
+	reopened := openWAL(t, dir)
+	assert.Zero(t, reopened.SavedBytes())
+	assert.Equal(t, []wal.Record{
+		{LSN: 1, Command: "SET", Args: []string{"k1", large}},
+		{LSN: 2, Command: "SET", Args: []string{"k2", "small"}},
+	}, replayAll(t, reopened))
+}
+
+func TestWALSnapshotWithoutSegments(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := openWAL(t, dir)
+	require.NoError(t, first.Append(ctx, "SET", "k", "v"))
+	writeSnapshot(t, first, 1, wal.Record{Command: "SET", Args: []string{"k", "v"}})
+	require.NoError(t, first.Close())
+
+	// the position survives the loss of every segment
+	segments, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	require.NoError(t, err)
+	for _, segment := range segments {
+		require.NoError(t, os.Remove(segment))
+	}
+
+	second := openWAL(t, dir)
+	assert.Equal(t, uint64(1), second.LastLSN())
+	_, err = second.ReadFrom(0, 10)
+	require.ErrorIs(t, err, wal.ErrTruncated)
+	records, err := second.ReadFrom(1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	require.NoError(t, second.Append(ctx, "DEL", "k"))
+	assert.Equal(t, []wal.Record{
+		{LSN: 1, Command: "SET", Args: []string{"k", "v"}},
+		{LSN: 2, Command: "DEL", Args: []string{"k"}},
+	}, replayAll(t, second))
+}
+
+func TestWALCorruptedSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := openWAL(t, dir)
+
+	require.NoError(t, w.Append(ctx, "SET", "k", "v"))
+	writeSnapshot(t, w, 1, wal.Record{Command: "SET", Args: []string{"k", "v"}})
+
+	snapshots, err := filepath.Glob(filepath.Join(dir, "snapshot_*.snap"))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	data, err := os.ReadFile(snapshots[0])
+	require.NoError(t, err)
+	data[len(data)-9] ^= 0xff // the last byte of the record
+	require.NoError(t, os.WriteFile(snapshots[0], data, 0o600))
+
+	applied := 0
+	_, err = w.ReadSnapshot(func(wal.Record) error {
+		applied++
+		return nil
+	})
+	require.ErrorIs(t, err, wal.ErrCorruptedSnapshot)
+	assert.Zero(t, applied)
+}
@@ -0,0 +1,30 @@
+package fakeclock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a manually driven time source for tests. Its Now method can be
+// passed wherever a func() time.Time clock is injected.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func New(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
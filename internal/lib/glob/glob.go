@@ -0,0 +1,107 @@
+package glob
+
+import "unicode/utf8"
+
+// Match reports whether s matches a glob pattern in the style of Redis
+// MATCH: * matches any run of characters, ? any single character, [abc] and
+// [a-z] one of a set, [^abc] one outside of it, and a backslash makes the
+// next character literal. A malformed class is matched literally.
+func Match(pattern, s string) bool {
+	// star and resume remember the last * to backtrack to on a mismatch
+	star, resume := -1, 0
+
+	p, i := 0, 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				star, resume = p, i
+				p++
+				continue
+			case '?':
+				p++
+				i += size
+				continue
+			case '[':
+				if matched, width, ok := matchClass(pattern[p:], r); ok {
+					if matched {
+						p += width
+						i += size
+						continue
+					}
+					break
+				}
+				if r == '[' {
+					p++
+					i += size
+					continue
+				}
+			default:
+				lit, width := utf8.DecodeRuneInString(pattern[p:])
+				if lit == '\\' && p+1 < len(pattern) {
+					lit, width = utf8.DecodeRuneInString(pattern[p+1:])
+					width++
+				}
+				if lit == r {
+					p += width
+					i += size
+					continue
+				}
+			}
+		}
+
+		if star < 0 {
+			return false
+		}
+		// let the last * swallow one more character and retry
+		_, size = utf8.DecodeRuneInString(s[resume:])
+		resume += size
+		p, i = star+1, resume
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass matches r against the [...] class at the start of pattern and
+// returns the width of the class. ok is false when the class is not closed.
+func matchClass(pattern string, r rune) (matched bool, width int, ok bool) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		first = false
+
+		lo, size := utf8.DecodeRuneInString(pattern[i:])
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo, size = utf8.DecodeRuneInString(pattern[i:])
+		}
+		i += size
+
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size = utf8.DecodeRuneInString(pattern[i+1:])
+			i += 1 + size
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	return false, 0, false
+}
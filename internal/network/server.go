@@ -0,0 +1,338 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"lesson1/internal/command"
+)
+
+const (
+	DefaultMaxConnections = 100
+	DefaultIdleTimeout    = 5 * time.Minute
+	DefaultMaxMessageSize = 4096
+
+	// ErrorPrefix starts every response line that reports a failed command.
+	ErrorPrefix = command.ErrorPrefix
+)
+
+var (
+	ErrTooManyConnections = errors.New("too many connections")
+	ErrMessageTooLarge    = errors.New("message too large")
+	ErrPushOverflow       = errors.New("client dropped for not keeping up with pushed messages")
+)
+
+//go:generate go run github.com/vektra/mockery/v3@v3.6.1 --config ../../.mockery.yaml
+
+type CommandHandler interface {
+	ComputeHandler(ctx context.Context, raw string) (string, error)
+}
+
+// Pusher is implemented by handlers that send lines to a client outside of
+// the replies to its commands, such as pub/sub messages. Pushes is asked
+// after every command for the lines pending for the connection of ctx and
+// returns nil when there are none to wait for. The handler closes the
+// channel to drop a client that does not keep up.
+type Pusher interface {
+	Pushes(ctx context.Context) <-chan string
+}
+
+// Server is a TCP front-end for a CommandHandler. Every client sends
+// newline-delimited commands and receives exactly one response line per
+// command; failed commands are answered with an "ERROR " prefixed line.
+type Server struct {
+	log     *slog.Logger
+	handler CommandHandler
+	address string
+
+	maxConnections int
+	idleTimeout    time.Duration
+	maxMessageSize int
+	sessionContext func(ctx context.Context) context.Context
+
+	listener  net.Listener
+	semaphore chan struct{}
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+type Option func(*Server)
+
+func WithMaxConnections(count int) Option {
+	return func(s *Server) {
+		if count > 0 {
+			s.maxConnections = count
+		}
+	}
+}
+
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.idleTimeout = timeout
+		}
+	}
+}
+
+func WithMaxMessageSize(size int) Option {
+	return func(s *Server) {
+		if size > 0 {
+			s.maxMessageSize = size
+		}
+	}
+}
+
+// WithSessionContext derives the context of every connection, which lets the
+// handler keep per-connection state such as an open transaction.
+func WithSessionContext(fn func(ctx context.Context) context.Context) Option {
+	return func(s *Server) {
+		if fn != nil {
+			s.sessionContext = fn
+		}
+	}
+}
+
+func NewServer(log *slog.Logger, handler CommandHandler, address string, opts ...Option) *Server {
+	s := &Server{
+		log:            log,
+		handler:        handler,
+		address:        address,
+		maxConnections: DefaultMaxConnections,
+		idleTimeout:    DefaultIdleTimeout,
+		maxMessageSize: DefaultMaxMessageSize,
+		conns:          make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.semaphore = make(chan struct{}, s.maxConnections)
+
+	return s
+}
+
+// Start binds the listener and serves clients in the background. The returned
+// context is cancelled once the server stops accepting connections, and the
+// error channel is closed after every client connection has been drained.
+func (s *Server) Start(parent context.Context) (context.Context, <-chan error) {
+	const op = "network.Server.Start"
+
+	ctx, cancel := context.WithCancel(parent)
+	errCh := make(chan error, 1)
+
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		cancel()
+		errCh <- fmt.Errorf("%s: %w", op, err)
+		close(errCh)
+		return ctx, errCh
+	}
+	s.listener = listener
+
+	s.log.Info("server listening", slog.String("operation", op), slog.String("address", listener.Addr().String()))
+
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+		s.closeIdle()
+	}()
+
+	go func() {
+		defer close(errCh)
+		defer s.wg.Wait()
+		defer cancel()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+					errCh <- fmt.Errorf("%s: %w", op, err)
+				}
+				return
+			}
+
+			select {
+			case s.semaphore <- struct{}{}:
+			default:
+				s.log.Info("connection rejected", slog.String("remote", conn.RemoteAddr().String()))
+				s.writeError(conn, ErrTooManyConnections)
+				_ = conn.Close()
+				continue
+			}
+
+			s.track(conn)
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer func() { <-s.semaphore }()
+				defer s.untrack(conn)
+
+				s.serve(ctx, conn)
+			}()
+		}
+	}()
+
+	return ctx, errCh
+}
+
+// Addr returns the address the server is bound to, or nil before Start.
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// serve runs the commands of one client in order. Commands are read by a
+// separate goroutine so that the connection context is cancelled as soon as
+// the client goes away, which stops a blocking command waiting on its behalf.
+// The idle timeout only runs between commands, and not at all while the
+// handler has lines to push to the client; it also bounds every write, so a
+// client that stops reading is dropped.
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	const op = "network.Server.serve"
+
+	remote := conn.RemoteAddr().String()
+	s.log.Info("client connected", slog.String("operation", op), slog.String("remote", remote))
+
+	if s.sessionContext != nil {
+		ctx = s.sessionContext(ctx)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- s.read(ctx, cancel, conn, lines)
+	}()
+
+	idle := time.NewTimer(s.idleTimeout)
+	defer idle.Stop()
+
+	pusher, _ := s.handler.(Pusher)
+	var pushes <-chan string
+
+loop:
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok || ctx.Err() != nil {
+				break loop
+			}
+
+			result, err := s.handler.ComputeHandler(ctx, line)
+			if err != nil {
+				s.writeError(conn, err)
+			} else if err := s.writeLine(conn, result); err != nil {
+				s.log.Info("client write failed", slog.String("remote", remote), slog.Any("err", err))
+				break loop
+			}
+
+			if pusher != nil {
+				pushes = pusher.Pushes(ctx)
+			}
+			if pushes != nil {
+				idle.Stop()
+			} else {
+				idle.Reset(s.idleTimeout)
+			}
+		case line, ok := <-pushes:
+			if !ok {
+				s.log.Info("client dropped by the handler", slog.String("remote", remote))
+				s.writeError(conn, ErrPushOverflow)
+				break loop
+			}
+			if err := s.writeLine(conn, line); err != nil {
+				s.log.Info("client write failed", slog.String("remote", remote), slog.Any("err", err))
+				break loop
+			}
+		case <-idle.C:
+			s.log.Info("client idle", slog.String("remote", remote))
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	cancel()
+	_ = conn.SetReadDeadline(time.Now())
+	if errors.Is(<-readErr, bufio.ErrTooLong) {
+		s.writeError(conn, ErrMessageTooLarge)
+	}
+
+	s.log.Info("client disconnected", slog.String("operation", op), slog.String("remote", remote))
+}
+
+// read scans commands off the connection into lines until the client goes
+// away or the connection is interrupted, and then cancels the connection
+// context. A pipelined command waits in read until the one before it is
+// done, so a client that goes away while its commands are queued is only
+// noticed once the running command finishes.
+func (s *Server) read(ctx context.Context, cancel context.CancelFunc, conn net.Conn, lines chan<- string) error {
+	defer cancel()
+	defer close(lines)
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, min(s.maxMessageSize, bufio.MaxScanTokenSize)), s.maxMessageSize)
+
+	for scanner.Scan() {
+		select {
+		case lines <- scanner.Text():
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil && !errors.Is(err, bufio.ErrTooLong) && ctx.Err() == nil {
+		s.log.Info("client read failed", slog.String("remote", conn.RemoteAddr().String()), slog.Any("err", err))
+	}
+	return err
+}
+
+// writeLine writes one response line, failing once the client has not read
+// it within the idle timeout.
+func (s *Server) writeLine(conn net.Conn, line string) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(s.idleTimeout)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(conn, line)
+	return err
+}
+
+func (s *Server) writeError(conn net.Conn, err error) {
+	_ = s.writeLine(conn, ErrorPrefix+err.Error())
+}
+
+func (s *Server) track(conn net.Conn) {
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	_ = conn.Close()
+}
+
+// closeIdle interrupts pending reads so that every client goroutine finishes
+// the command it is running and then exits. A blocking command sees its
+// context cancelled and returns.
+func (s *Server) closeIdle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.SetReadDeadline(time.Now())
+	}
+}
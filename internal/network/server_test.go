@@ -0,0 +1,345 @@
+package network_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/lib/logger/slogdiscard"
+	"lesson1/internal/network"
+	network_test "lesson1/internal/network/mocks"
+)
+
+func startServer(
+	t *testing.T,
+	handler network.CommandHandler,
+	opts ...network.Option,
+) (*network.Server, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server := network.NewServer(slogdiscard.NewDiscardLogger(), handler, "127.0.0.1:0", opts...)
+	_, errCh := server.Start(ctx)
+	require.NotNil(t, server.Addr())
+
+	t.Cleanup(func() {
+		cancel()
+		for range errCh {
+		}
+	})
+
+	return server, cancel, errCh
+}
+
+func dial(t *testing.T, server *network.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn, bufio.NewReader(conn)
+}
+
+func roundTrip(t *testing.T, conn net.Conn, reader *bufio.Reader, line string) string {
+	t.Helper()
+
+	_, err := conn.Write([]byte(line + "\n"))
+	require.NoError(t, err)
+
+	resp, err := reader.ReadString('\n')
+	require.NoError(t, err)
+
+	return strings.TrimSuffix(resp, "\n")
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	handler := network_test.NewMockCommandHandler(t)
+	handler.EXPECT().ComputeHandler(mock.Anything, "SET k v").Return("OK", nil)
+	handler.EXPECT().ComputeHandler(mock.Anything, "GET k").Return("VALUE v", nil)
+	handler.EXPECT().ComputeHandler(mock.Anything, "BAD").Return("", assert.AnError)
+
+	server, _, _ := startServer(t, handler)
+	conn, reader := dial(t, server)
+
+	assert.Equal(t, "OK", roundTrip(t, conn, reader, "SET k v"))
+	assert.Equal(t, "VALUE v", roundTrip(t, conn, reader, "GET k"))
+	assert.Equal(t, "ERROR "+assert.AnError.Error(), roundTrip(t, conn, reader, "BAD"))
+}
+
+func TestServerManyClients(t *testing.T) {
+	t.Parallel()
+
+	handler := network_test.NewMockCommandHandler(t)
+	handler.EXPECT().ComputeHandler(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, raw string) (string, error) { return "ECHO " + raw, nil })
+
+	server, _, _ := startServer(t, handler)
+
+	const clients = 10
+	done := make(chan struct{}, clients)
+	for i := range clients {
+		conn, reader := dial(t, server)
+		go func() {
+			defer func() { done <- struct{}{} }()
+
+			line := "GET key" + string(rune('a'+i))
+			assert.Equal(t, "ECHO "+line, roundTrip(t, conn, reader, line))
+		}()
+	}
+	for range clients {
+		<-done
+	}
+}
+
+func TestServerMaxConnections(t *testing.T) {
+	t.Parallel()
+
+	handler := network_test.NewMockCommandHandler(t)
+	handler.EXPECT().ComputeHandler(mock.Anything, "GET k").Return("NOT_FOUND", nil)
+
+	server, _, _ := startServer(t, handler, network.WithMaxConnections(1))
+
+	first, firstReader := dial(t, server)
+	assert.Equal(t, "NOT_FOUND", roundTrip(t, first, firstReader, "GET k"))
+
+	_, secondReader := dial(t, server)
+	resp, err := secondReader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ERROR "+network.ErrTooManyConnections.Error()+"\n", resp)
+}
+
+func TestServerMaxMessageSize(t *testing.T) {
+	t.Parallel()
+
+	handler := network_test.NewMockCommandHandler(t)
+	server, _, _ := startServer(t, handler, network.WithMaxMessageSize(16))
+
+	conn, reader := dial(t, server)
+	assert.Equal(t, "ERROR "+network.ErrMessageTooLarge.Error(),
+		roundTrip(t, conn, reader, "SET key "+strings.Repeat("x", 32)))
+
+	_, err := reader.ReadString('\n')
+	require.Error(t, err, "connection must be closed after an oversized message")
+}
+
+func TestServerIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	handler := network_test.NewMockCommandHandler(t)
+	server, _, _ := startServer(t, handler, network.WithIdleTimeout(50*time.Millisecond))
+
+	_, reader := dial(t, server)
+
+	_, err := reader.ReadString('\n')
+	require.Error(t, err, "idle connection must be closed by the server")
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := network_test.NewMockCommandHandler(t)
+	handler.EXPECT().ComputeHandler(mock.Anything, "SET k v").
+		RunAndReturn(func(context.Context, string) (string, error) {
+			close(started)
+			<-release
+			return "OK", nil
+		})
+
+	server, cancel, errCh := startServer(t, handler)
+	conn, reader := dial(t, server)
+
+	_, err := conn.Write([]byte("SET k v\n"))
+	require.NoError(t, err)
+	<-started
+
+	cancel()
+	close(release)
+
+	resp, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "OK\n", resp, "in-flight command must complete during shutdown")
+
+	for err := range errCh {
+		require.NoError(t, err)
+	}
+
+	_, err = net.Dial("tcp", server.Addr().String())
+	require.Error(t, err, "listener must be closed after shutdown")
+}
+
+func TestServerCancelsCommandOnDisconnect(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	handler := network_test.NewMockCommandHandler(t)
+	handler.EXPECT().ComputeHandler(mock.Anything, "BLPOP q 0").
+		RunAndReturn(func(ctx context.Context, _ string) (string, error) {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			return "", ctx.Err()
+		})
+
+	server, _, _ := startServer(t, handler)
+	conn, _ := dial(t, server)
+
+	_, err := conn.Write([]byte("BLPOP q 0\n"))
+	require.NoError(t, err)
+	<-started
+
+	require.NoError(t, conn.Close())
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("command context was not cancelled when the client went away")
+	}
+}
+
+func TestServerIdleTimeoutSkipsRunningCommand(t *testing.T) {
+	t.Parallel()
+
+	handler := network_test.NewMockCommandHandler(t)
+	handler.EXPECT().ComputeHandler(mock.Anything, "BLPOP q 1").
+		RunAndReturn(func(context.Context, string) (string, error) {
+			time.Sleep(150 * time.Millisecond)
+			return "NOT_FOUND", nil
+		})
+
+	server, _, _ := startServer(t, handler, network.WithIdleTimeout(50*time.Millisecond))
+	conn, reader := dial(t, server)
+
+	assert.Equal(t, "NOT_FOUND", roundTrip(t, conn, reader, "BLPOP q 1"),
+		"a command outliving the idle timeout must still be answered")
+}
+
+// pushingHandler pushes the lines sent on pushes once a command was run.
+type pushingHandler struct {
+	*network_test.MockCommandHandler
+	pushes chan string
+}
+
+func (h pushingHandler) Pushes(context.Context) <-chan string {
+	return h.pushes
+}
+
+func TestServerPushes(t *testing.T) {
+	t.Parallel()
+
+	mockHandler := network_test.NewMockCommandHandler(t)
+	mockHandler.EXPECT().ComputeHandler(mock.Anything, "SUBSCRIBE c").Return("SUBSCRIBED 1", nil)
+	handler := pushingHandler{MockCommandHandler: mockHandler, pushes: make(chan string)}
+
+	server, _, _ := startServer(t, handler, network.WithIdleTimeout(50*time.Millisecond))
+	conn, reader := dial(t, server)
+
+	assert.Equal(t, "SUBSCRIBED 1", roundTrip(t, conn, reader, "SUBSCRIBE c"))
+
+	// a client waiting for pushes is not idle
+	time.Sleep(150 * time.Millisecond)
+	handler.pushes <- `MESSAGE "c" "hello"`
+
+	resp, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "MESSAGE \"c\" \"hello\"\n", resp)
+
+	// closing the pushes drops the client
+	close(handler.pushes)
+
+	resp, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ERROR "+network.ErrPushOverflow.Error()+"\n", resp)
+
+	_, err = reader.ReadString('\n')
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestServerDropsClientNotReading(t *testing.T) {
+	t.Parallel()
+
+	mockHandler := network_test.NewMockCommandHandler(t)
+	mockHandler.EXPECT().ComputeHandler(mock.Anything, "SUBSCRIBE c").Return("SUBSCRIBED 1", nil)
+	mockHandler.EXPECT().ComputeHandler(mock.Anything, "PING").Return("PONG", nil).Maybe()
+	handler := pushingHandler{MockCommandHandler: mockHandler, pushes: make(chan string)}
+
+	server, _, _ := startServer(t, handler,
+		network.WithMaxConnections(1),
+		network.WithIdleTimeout(50*time.Millisecond),
+	)
+	conn, reader := dial(t, server)
+	assert.Equal(t, "SUBSCRIBED 1", roundTrip(t, conn, reader, "SUBSCRIBE c"))
+
+	// the client stops reading while pushes pile up in the socket buffers
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		line := strings.Repeat("x", 64<<10)
+		for {
+			select {
+			case handler.pushes <- line:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// the blocked write times out and frees the only connection slot
+	require.Eventually(t, func() bool {
+		other, err := net.Dial("tcp", server.Addr().String())
+		if err != nil {
+			return false
+		}
+		defer other.Close()
+
+		if _, err := other.Write([]byte("PING\n")); err != nil {
+			return false
+		}
+		resp, err := bufio.NewReader(other).ReadString('\n')
+		return err == nil && resp == "PONG\n"
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+type sessionKey struct{}
+
+func TestServerSessionContext(t *testing.T) {
+	t.Parallel()
+
+	var sessions atomic.Int64
+	newSession := func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, sessionKey{}, strconv.FormatInt(sessions.Add(1), 10))
+	}
+
+	handler := network_test.NewMockCommandHandler(t)
+	handler.EXPECT().ComputeHandler(mock.Anything, "WHOAMI").
+		RunAndReturn(func(ctx context.Context, _ string) (string, error) {
+			return ctx.Value(sessionKey{}).(string), nil
+		})
+
+	server, _, _ := startServer(t, handler, network.WithSessionContext(newSession))
+	first, firstReader := dial(t, server)
+	assert.Equal(t, "1", roundTrip(t, first, firstReader, "WHOAMI"))
+
+	second, secondReader := dial(t, server)
+	assert.Equal(t, "2", roundTrip(t, second, secondReader, "WHOAMI"))
+
+	assert.Equal(t, "1", roundTrip(t, first, firstReader, "WHOAMI"), "a connection keeps its session")
+	assert.Equal(t, "2", roundTrip(t, second, secondReader, "WHOAMI"))
+}
@@ -0,0 +1,332 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"lesson1/internal/command"
+	"lesson1/internal/lib/glob"
+)
+
+type OverflowPolicy string
+
+const (
+	// PolicyDrop discards the messages that do not fit in the buffer of a
+	// slow subscriber.
+	PolicyDrop OverflowPolicy = "drop"
+	// PolicyDisconnect closes a subscriber whose buffer is full, which
+	// disconnects its client.
+	PolicyDisconnect OverflowPolicy = "disconnect"
+
+	DefaultBufferSize = 128
+)
+
+var ErrUnknownOverflowPolicy = errors.New("unknown overflow policy")
+
+func ParseOverflowPolicy(raw string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(raw); policy {
+	case PolicyDrop, PolicyDisconnect:
+		return policy, nil
+	case "":
+		return PolicyDisconnect, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownOverflowPolicy, raw)
+	}
+}
+
+// Broker routes published messages to the subscribers of a channel and of
+// the glob patterns matching it. Publishing never blocks: every subscriber
+// has a bounded buffer and the overflow policy decides what happens when a
+// subscriber does not keep up.
+type Broker struct {
+	bufferSize int
+	policy     OverflowPolicy
+
+	mu       sync.RWMutex
+	channels map[string]map[*Subscriber]struct{}
+	patterns map[string]map[*Subscriber]struct{}
+
+	dropped      atomic.Uint64
+	disconnected atomic.Uint64
+}
+
+type Option func(*Broker)
+
+// WithBufferSize sets how many messages a subscriber may have pending.
+func WithBufferSize(size int) Option {
+	return func(b *Broker) {
+		if size > 0 {
+			b.bufferSize = size
+		}
+	}
+}
+
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(b *Broker) {
+		if policy != "" {
+			b.policy = policy
+		}
+	}
+}
+
+func NewBroker(opts ...Option) *Broker {
+	b := &Broker{
+		bufferSize: DefaultBufferSize,
+		policy:     PolicyDisconnect,
+		channels:   make(map[string]map[*Subscriber]struct{}),
+		patterns:   make(map[string]map[*Subscriber]struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// NewSubscriber returns a subscriber without subscriptions. It must be
+// closed once it is no longer read.
+func (b *Broker) NewSubscriber() *Subscriber {
+	return &Subscriber{
+		broker:   b,
+		messages: make(chan string, b.bufferSize),
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+	}
+}
+
+// Publish sends message to the subscribers of channel and returns how many
+// of them received it. A subscriber matching the channel through several
+// subscriptions receives it once for each.
+func (b *Broker) Publish(channel, message string) int {
+	var (
+		received int
+		overflow []*Subscriber
+	)
+
+	deliver := func(subs map[*Subscriber]struct{}, line string) {
+		for sub := range subs {
+			switch sub.deliver(line) {
+			case deliveryOK:
+				received++
+			case deliveryFull:
+				if b.policy == PolicyDisconnect {
+					overflow = append(overflow, sub)
+				} else {
+					b.dropped.Add(1)
+				}
+			}
+		}
+	}
+
+	b.mu.RLock()
+	if subs, ok := b.channels[channel]; ok {
+		deliver(subs, formatMessage(channel, message))
+	}
+	for pattern, subs := range b.patterns {
+		if glob.Match(pattern, channel) {
+			deliver(subs, formatPatternMessage(pattern, channel, message))
+		}
+	}
+	b.mu.RUnlock()
+
+	// closing unregisters the subscriber, which needs the write lock
+	for _, sub := range overflow {
+		if sub.Close() {
+			b.disconnected.Add(1)
+		}
+	}
+
+	return received
+}
+
+// Stats reports how many messages were dropped and how many subscribers
+// were disconnected for a full buffer.
+func (b *Broker) Stats() (dropped, disconnected uint64) {
+	return b.dropped.Load(), b.disconnected.Load()
+}
+
+func (b *Broker) add(index map[string]map[*Subscriber]struct{}, name string, sub *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := index[name]
+	if !ok {
+		subs = make(map[*Subscriber]struct{})
+		index[name] = subs
+	}
+	subs[sub] = struct{}{}
+}
+
+func (b *Broker) remove(index map[string]map[*Subscriber]struct{}, name string, sub *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := index[name]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(index, name)
+	}
+}
+
+// Subscriber is the receiving end of one client. Its subscriptions are
+// changed by the client's own commands, one at a time, while any number of
+// publishers deliver to it.
+type Subscriber struct {
+	broker *Broker
+
+	// mu orders deliveries with Close so that nothing is sent on a closed
+	// channel.
+	mu       sync.Mutex
+	messages chan string
+	closed   bool
+
+	// subsMu guards the subscriptions. It is taken before the broker lock,
+	// which publishers hold while they take mu, so mu is never held when the
+	// broker lock is taken.
+	subsMu   sync.Mutex
+	channels map[string]struct{}
+	patterns map[string]struct{}
+}
+
+type delivery int
+
+const (
+	deliveryOK delivery = iota
+	deliveryFull
+	deliveryClosed
+)
+
+// Messages returns the formatted message lines: MESSAGE "channel"
+// "message" for a channel subscription and PMESSAGE "pattern" "channel"
+// "message" for a pattern one. The channel is closed by Close, including
+// when the broker disconnects a subscriber that fell behind.
+func (s *Subscriber) Messages() <-chan string {
+	return s.messages
+}
+
+// Subscribe adds channel subscriptions and returns the number of
+// subscriptions, channels and patterns, held afterwards.
+func (s *Subscriber) Subscribe(channels ...string) int {
+	return s.subscribe(s.channels, s.broker.channels, channels)
+}
+
+// PSubscribe adds glob pattern subscriptions, see glob.Match.
+func (s *Subscriber) PSubscribe(patterns ...string) int {
+	return s.subscribe(s.patterns, s.broker.patterns, patterns)
+}
+
+// Unsubscribe removes channel subscriptions, all of them when none is given,
+// and returns the number of subscriptions left.
+func (s *Subscriber) Unsubscribe(channels ...string) int {
+	return s.unsubscribe(s.channels, s.broker.channels, channels)
+}
+
+// PUnsubscribe removes pattern subscriptions, all of them when none is given.
+func (s *Subscriber) PUnsubscribe(patterns ...string) int {
+	return s.unsubscribe(s.patterns, s.broker.patterns, patterns)
+}
+
+// Count returns the number of subscriptions held.
+func (s *Subscriber) Count() int {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	return len(s.channels) + len(s.patterns)
+}
+
+// Closed reports whether the subscriber was closed, by Close or by the
+// broker for falling behind.
+func (s *Subscriber) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// Close removes every subscription and closes the message channel. It
+// reports whether this call closed the subscriber.
+func (s *Subscriber) Close() bool {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+	s.closed = true
+	close(s.messages)
+	s.mu.Unlock()
+
+	for channel := range s.channels {
+		s.broker.remove(s.broker.channels, channel, s)
+	}
+	for pattern := range s.patterns {
+		s.broker.remove(s.broker.patterns, pattern, s)
+	}
+	clear(s.channels)
+	clear(s.patterns)
+	return true
+}
+
+func (s *Subscriber) subscribe(own map[string]struct{}, index map[string]map[*Subscriber]struct{}, names []string) int {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	// closed only changes under subsMu too
+	if s.closed {
+		return 0
+	}
+	for _, name := range names {
+		if _, ok := own[name]; ok {
+			continue
+		}
+		own[name] = struct{}{}
+		s.broker.add(index, name, s)
+	}
+	return len(s.channels) + len(s.patterns)
+}
+
+func (s *Subscriber) unsubscribe(own map[string]struct{}, index map[string]map[*Subscriber]struct{}, names []string) int {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	if len(names) == 0 {
+		for name := range own {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		if _, ok := own[name]; !ok {
+			continue
+		}
+		delete(own, name)
+		s.broker.remove(index, name, s)
+	}
+	return len(s.channels) + len(s.patterns)
+}
+
+func (s *Subscriber) deliver(line string) delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return deliveryClosed
+	}
+	select {
+	case s.messages <- line:
+		return deliveryOK
+	default:
+		return deliveryFull
+	}
+}
+
+func formatMessage(channel, message string) string {
+	return "MESSAGE " + command.Quote(channel) + " " + command.Quote(message)
+}
+
+func formatPatternMessage(pattern, channel, message string) string {
+	return "PMESSAGE " + command.Quote(pattern) + " " + command.Quote(channel) + " " + command.Quote(message)
+}
@@ -0,0 +1,170 @@
+package pubsub_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/pubsub"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	t.Parallel()
+
+	b := pubsub.NewBroker()
+
+	news := b.NewSubscriber()
+	t.Cleanup(func() { news.Close() })
+	assert.Equal(t, 2, news.Subscribe("news", "sport"))
+
+	all := b.NewSubscriber()
+	t.Cleanup(func() { all.Close() })
+	assert.Equal(t, 1, all.PSubscribe("n*"))
+
+	assert.Equal(t, 2, b.Publish("news", "hello world"))
+	assert.Equal(t, `MESSAGE "news" "hello world"`, <-news.Messages())
+	assert.Equal(t, `PMESSAGE "n*" "news" "hello world"`, <-all.Messages())
+
+	assert.Equal(t, 1, b.Publish("sport", "goal"))
+	assert.Equal(t, `MESSAGE "sport" "goal"`, <-news.Messages())
+
+	assert.Zero(t, b.Publish("weather", "rain"))
+
+	assert.Equal(t, 1, news.Unsubscribe("news", "missing"))
+	assert.Equal(t, 1, b.Publish("news", "again"))
+	assert.Equal(t, `PMESSAGE "n*" "news" "again"`, <-all.Messages())
+	assert.Empty(t, news.Messages())
+
+	// without names every subscription of the kind goes
+	assert.Zero(t, news.Unsubscribe())
+	assert.Zero(t, all.PUnsubscribe())
+	assert.Zero(t, b.Publish("sport", "ignored"))
+}
+
+func TestBrokerOverflow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		policy           pubsub.OverflowPolicy
+		wantDropped      uint64
+		wantDisconnected uint64
+	}{
+		{name: "drop", policy: pubsub.PolicyDrop, wantDropped: 2},
+		{name: "disconnect", policy: pubsub.PolicyDisconnect, wantDisconnected: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := pubsub.NewBroker(pubsub.WithBufferSize(2), pubsub.WithOverflowPolicy(tc.policy))
+
+			slow := b.NewSubscriber()
+			slow.Subscribe("c")
+			fast := b.NewSubscriber()
+			fast.Subscribe("c")
+			t.Cleanup(func() { fast.Close() })
+
+			for i := range 4 {
+				b.Publish("c", strconv.Itoa(i))
+				<-fast.Messages()
+			}
+
+			// the slow subscriber keeps what fitted in its buffer
+			var got []string
+			for line := range slow.Messages() {
+				got = append(got, line)
+				if len(got) == 2 {
+					break
+				}
+			}
+			assert.Equal(t, []string{`MESSAGE "c" "0"`, `MESSAGE "c" "1"`}, got)
+
+			dropped, disconnected := b.Stats()
+			assert.Equal(t, tc.wantDropped, dropped)
+			assert.Equal(t, tc.wantDisconnected, disconnected)
+
+			if tc.policy == pubsub.PolicyDisconnect {
+				_, ok := <-slow.Messages()
+				assert.False(t, ok)
+				assert.Zero(t, slow.Count())
+				assert.Equal(t, 1, b.Publish("c", "after"))
+			} else {
+				assert.Equal(t, 1, slow.Count())
+				slow.Close()
+			}
+		})
+	}
+}
+
+func TestSubscriberClose(t *testing.T) {
+	t.Parallel()
+
+	b := pubsub.NewBroker()
+	sub := b.NewSubscriber()
+	sub.Subscribe("a")
+	sub.PSubscribe("*")
+
+	require.True(t, sub.Close())
+	assert.False(t, sub.Close())
+	assert.Zero(t, b.Publish("a", "m"))
+
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+
+	// a closed subscriber takes no new subscriptions
+	assert.Zero(t, sub.Subscribe("b"))
+	assert.Zero(t, b.Publish("b", "m"))
+}
+
+func TestBrokerConcurrentPublishAndClose(t *testing.T) {
+	t.Parallel()
+
+	b := pubsub.NewBroker(pubsub.WithBufferSize(4))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				b.Publish("c"+strconv.Itoa(i%3), "m")
+			}
+		}()
+	}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				sub := b.NewSubscriber()
+				sub.Subscribe("c0", "c1")
+				sub.PSubscribe("c*")
+				sub.Unsubscribe("c1")
+				sub.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Zero(t, b.Publish("c0", "m"))
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := pubsub.ParseOverflowPolicy("drop")
+	require.NoError(t, err)
+	assert.Equal(t, pubsub.PolicyDrop, policy)
+
+	policy, err = pubsub.ParseOverflowPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, pubsub.PolicyDisconnect, policy)
+
+	_, err = pubsub.ParseOverflowPolicy("block")
+	require.ErrorIs(t, err, pubsub.ErrUnknownOverflowPolicy)
+}
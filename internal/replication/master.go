@@ -0,0 +1,174 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"lesson1/internal/database/storage/wal"
+)
+
+const (
+	DefaultBatchSize = 1024
+
+	syncCommand     = "SYNC"
+	snapshotCommand = "SNAPSHOT"
+)
+
+var (
+	ErrInvalidRequest  = errors.New("invalid replication request")
+	ErrSnapshotChanged = errors.New("snapshot replaced during transfer")
+
+	errChunkFull = errors.New("chunk full")
+)
+
+type LogReader interface {
+	ReadFrom(afterLSN uint64, limit int) ([]wal.Record, error)
+	ReadSnapshot(apply func(wal.Record) error) (uint64, error)
+}
+
+// Master serves the WAL to replicas. It is plugged into a network.Server as
+// its command handler: a replica sends "SYNC <lsn>" and receives one JSON
+// line with the records logged after that position. When those records were
+// truncated after a snapshot, the line holds the first chunk of the snapshot
+// instead, and the replica asks for the following ones with
+// "SNAPSHOT <snapshot lsn> <offset>", one round trip each. It loads the
+// whole snapshot in place of its keys before it syncs on from the position
+// of the snapshot.
+type Master struct {
+	log       *slog.Logger
+	reader    LogReader
+	batchSize int
+}
+
+type syncResponse struct {
+	// SnapshotLSN is set when Records are a chunk of a snapshot; Next is
+	// the offset of the following chunk, 0 after the last one.
+	SnapshotLSN uint64       `json:"snapshot_lsn,omitempty"`
+	Next        int          `json:"next,omitempty"`
+	Records     []wal.Record `json:"records"`
+}
+
+func NewMaster(log *slog.Logger, reader LogReader) *Master {
+	return &Master{
+		log:       log,
+		reader:    reader,
+		batchSize: DefaultBatchSize,
+	}
+}
+
+func (m *Master) ComputeHandler(_ context.Context, raw string) (string, error) {
+	const op = "replication.Master.ComputeHandler"
+
+	fields := strings.Fields(raw)
+	switch {
+	case len(fields) == 2 && fields[0] == syncCommand:
+		afterLSN, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidRequest)
+		}
+		return m.serveLog(afterLSN)
+	case len(fields) == 3 && fields[0] == snapshotCommand:
+		snapshotLSN, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil || snapshotLSN == 0 {
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidRequest)
+		}
+		offset, err := strconv.Atoi(fields[2])
+		if err != nil || offset <= 0 {
+			return "", fmt.Errorf("%s: %w", op, ErrInvalidRequest)
+		}
+		return m.serveSnapshot(snapshotLSN-1, snapshotLSN, offset)
+	default:
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidRequest)
+	}
+}
+
+// serveLog answers with the records logged after afterLSN, or with the first
+// chunk of the newest snapshot when they were truncated.
+func (m *Master) serveLog(afterLSN uint64) (string, error) {
+	const op = "replication.Master.serveLog"
+
+	records, err := m.reader.ReadFrom(afterLSN, m.batchSize)
+	if errors.Is(err, wal.ErrTruncated) {
+		return m.serveSnapshot(afterLSN, 0, 0)
+	}
+	if err != nil {
+		m.log.Error("replication read failed", slog.Uint64("after_lsn", afterLSN), slog.Any("err", err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload, err := json.Marshal(syncResponse{Records: records})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(records) > 0 {
+		m.log.Debug("replication batch served",
+			slog.Uint64("after_lsn", afterLSN),
+			slog.Int("records", len(records)),
+		)
+	}
+	return string(payload), nil
+}
+
+// serveSnapshot answers with the chunk of the newest snapshot that starts at
+// record offset. The first chunk may come from any snapshot newer than
+// afterLSN; the following ones must come from the snapshot at snapshotLSN,
+// or the transfer fails with ErrSnapshotChanged and starts over. The
+// snapshot is read from its start for every chunk.
+func (m *Master) serveSnapshot(afterLSN, snapshotLSN uint64, offset int) (string, error) {
+	const op = "replication.Master.serveSnapshot"
+
+	var (
+		records []wal.Record
+		seen    int
+		current uint64
+	)
+	lsn, err := m.reader.ReadSnapshot(func(record wal.Record) error {
+		current = record.LSN
+		if seen >= offset {
+			if len(records) == m.batchSize {
+				return errChunkFull
+			}
+			records = append(records, record)
+		}
+		seen++
+		return nil
+	})
+
+	next := 0
+	if errors.Is(err, errChunkFull) {
+		lsn, next, err = current, offset+len(records), nil
+	}
+	switch {
+	case err != nil:
+	case snapshotLSN != 0 && lsn != snapshotLSN:
+		err = ErrSnapshotChanged
+	case lsn <= afterLSN:
+		err = wal.ErrTruncated
+	}
+	if err != nil {
+		m.log.Error("replication snapshot failed",
+			slog.Uint64("after_lsn", afterLSN),
+			slog.Int("offset", offset),
+			slog.Any("err", err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload, err := json.Marshal(syncResponse{SnapshotLSN: lsn, Next: next, Records: records})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.log.Info("replication snapshot chunk served",
+		slog.Uint64("snapshot_lsn", lsn),
+		slog.Int("offset", offset),
+		slog.Int("records", len(records)),
+	)
+	return string(payload), nil
+}
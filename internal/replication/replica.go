@@ -0,0 +1,217 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"lesson1/internal/database/storage/wal"
+	"lesson1/internal/network"
+)
+
+const (
+	DefaultSyncInterval = time.Second
+
+	// exchangeTimeout bounds one request/response round trip: a batch of
+	// the log or a chunk of a snapshot.
+	exchangeTimeout = 5 * time.Second
+)
+
+var (
+	ErrMasterError = errors.New("master returned an error")
+	ErrOutOfOrder  = errors.New("replication record out of order")
+)
+
+type Applier interface {
+	Apply(ctx context.Context, record wal.Record) error
+	Restore(ctx context.Context, records []wal.Record) error
+}
+
+// Replica periodically pulls the master's WAL from the last LSN it applied
+// and applies every record locally. The position lives in memory only, so a
+// restarted replica rebuilds its state from the beginning of the master log,
+// or from its newest snapshot once the master truncated its log.
+type Replica struct {
+	log           *slog.Logger
+	applier       Applier
+	masterAddress string
+	syncInterval  time.Duration
+
+	lastLSN atomic.Uint64
+
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewReplica(log *slog.Logger, applier Applier, masterAddress string, syncInterval time.Duration) *Replica {
+	if syncInterval <= 0 {
+		syncInterval = DefaultSyncInterval
+	}
+
+	return &Replica{
+		log:           log,
+		applier:       applier,
+		masterAddress: masterAddress,
+		syncInterval:  syncInterval,
+	}
+}
+
+// LastLSN returns the position of the last record applied from the master.
+func (r *Replica) LastLSN() uint64 {
+	return r.lastLSN.Load()
+}
+
+// Start runs the sync loop in the background until parent is cancelled.
+// Connection problems are logged and retried on the next tick; the error
+// channel is closed once the loop has exited.
+func (r *Replica) Start(parent context.Context) (context.Context, <-chan error) {
+	const op = "replication.Replica.Start"
+
+	ctx, cancel := context.WithCancel(parent)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(errCh)
+		defer cancel()
+		defer r.disconnect()
+
+		r.log.Info("replica started", slog.String("operation", op), slog.String("master", r.masterAddress))
+
+		ticker := time.NewTicker(r.syncInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.sync(ctx); err != nil && ctx.Err() == nil {
+				r.log.Error("replication sync failed", slog.String("operation", op), slog.Any("err", err))
+				r.disconnect()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ctx, errCh
+}
+
+// sync pulls batches until the master has nothing newer to send.
+func (r *Replica) sync(ctx context.Context) error {
+	for ctx.Err() == nil {
+		response, err := r.fetch(ctx, syncCommand+" "+strconv.FormatUint(r.LastLSN(), 10))
+		if err != nil {
+			return err
+		}
+
+		if response.SnapshotLSN > 0 {
+			if err := r.loadSnapshot(ctx, response); err != nil {
+				return err
+			}
+			continue
+		}
+
+		records := response.Records
+		if len(records) == 0 {
+			return nil
+		}
+
+		for _, record := range records {
+			if record.LSN != r.LastLSN()+1 {
+				return fmt.Errorf("%w: want %d, got %d", ErrOutOfOrder, r.LastLSN()+1, record.LSN)
+			}
+			if err := r.applier.Apply(ctx, record); err != nil {
+				return err
+			}
+			r.lastLSN.Store(record.LSN)
+		}
+
+		r.log.Debug("replication batch applied", slog.Int("records", len(records)), slog.Uint64("lsn", r.LastLSN()))
+	}
+	return nil
+}
+
+// loadSnapshot fetches the chunks of the snapshot that follow first, then
+// loads the whole snapshot in place of the local keys.
+func (r *Replica) loadSnapshot(ctx context.Context, first syncResponse) error {
+	records := first.Records
+	for next := first.Next; next > 0; {
+		request := snapshotCommand + " " + strconv.FormatUint(first.SnapshotLSN, 10) + " " + strconv.Itoa(next)
+		chunk, err := r.fetch(ctx, request)
+		if err != nil {
+			return err
+		}
+		if chunk.SnapshotLSN != first.SnapshotLSN {
+			return fmt.Errorf("%w: want %d, got %d", ErrSnapshotChanged, first.SnapshotLSN, chunk.SnapshotLSN)
+		}
+		records = append(records, chunk.Records...)
+		next = chunk.Next
+	}
+
+	if err := r.applier.Restore(ctx, records); err != nil {
+		return err
+	}
+	r.lastLSN.Store(first.SnapshotLSN)
+	r.log.Info("replication snapshot loaded", slog.Int("records", len(records)), slog.Uint64("lsn", r.LastLSN()))
+	return nil
+}
+
+// fetch sends one request line and decodes the response, within its own
+// exchangeTimeout.
+func (r *Replica) fetch(ctx context.Context, request string) (syncResponse, error) {
+	if r.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", r.masterAddress)
+		if err != nil {
+			return syncResponse{}, err
+		}
+		r.conn = conn
+		r.reader = bufio.NewReader(conn)
+	}
+
+	conn := r.conn
+	if err := conn.SetDeadline(time.Now().Add(exchangeTimeout)); err != nil {
+		return syncResponse{}, err
+	}
+
+	// interrupt a pending exchange as soon as the replica is stopped
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := conn.Write([]byte(request + "\n")); err != nil {
+		return syncResponse{}, err
+	}
+
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		return syncResponse{}, err
+	}
+	line = strings.TrimSuffix(line, "\n")
+
+	if msg, ok := strings.CutPrefix(line, network.ErrorPrefix); ok {
+		return syncResponse{}, fmt.Errorf("%w: %s", ErrMasterError, msg)
+	}
+
+	var response syncResponse
+	if err := json.Unmarshal([]byte(line), &response); err != nil {
+		return syncResponse{}, err
+	}
+	return response, nil
+}
+
+func (r *Replica) disconnect() {
+	if r.conn == nil {
+		return
+	}
+	_ = r.conn.Close()
+	r.conn, r.reader = nil, nil
+}
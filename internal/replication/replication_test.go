@@ -0,0 +1,294 @@
+package replication_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/compute"
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/database/storage"
+	"lesson1/internal/database/storage/engine"
+	"lesson1/internal/database/storage/wal"
+	"lesson1/internal/lib/logger/slogdiscard"
+	"lesson1/internal/network"
+	"lesson1/internal/replication"
+)
+
+const (
+	syncInterval = 10 * time.Millisecond
+	waitFor      = 2 * time.Second
+)
+
+type instance struct {
+	storage *storage.Storage
+	compute *compute.Compute
+}
+
+// startMaster runs a master with a WAL and a replication endpoint on a random
+// localhost port.
+func startMaster(t *testing.T, ctx context.Context, opts ...wal.Option) (instance, string) {
+	t.Helper()
+
+	logger := slogdiscard.NewDiscardLogger()
+
+	w, err := wal.New(logger, t.TempDir(), opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+
+	ctx, cancel := context.WithCancel(ctx)
+	server := network.NewServer(logger, replication.NewMaster(logger, w), "127.0.0.1:0")
+	_, errCh := server.Start(ctx)
+	require.NotNil(t, server.Addr())
+	t.Cleanup(func() {
+		cancel()
+		for range errCh {
+		}
+	})
+
+	return instance{storage: s, compute: compute.NewCompute(logger, s)}, server.Addr().String()
+}
+
+func startReplica(t *testing.T, ctx context.Context, masterAddress string) (instance, *replication.Replica) {
+	t.Helper()
+
+	logger := slogdiscard.NewDiscardLogger()
+	s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithReadOnly())
+
+	ctx, cancel := context.WithCancel(ctx)
+	replica := replication.NewReplica(logger, s, masterAddress, syncInterval)
+	_, errCh := replica.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		for range errCh {
+		}
+	})
+
+	return instance{storage: s, compute: compute.NewCompute(logger, s)}, replica
+}
+
+func TestReplicationAppliesMasterWrites(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	master, address := startMaster(t, ctx)
+	replica, r := startReplica(t, ctx, address)
+
+	for _, line := range []string{"SET a 1", "SET b 2", "SET a 3", "DEL b"} {
+		_, err := master.compute.ComputeHandler(ctx, line)
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool { return r.LastLSN() == 4 }, waitFor, syncInterval)
+
+	got, err := replica.compute.ComputeHandler(ctx, "GET a")
+	require.NoError(t, err)
+	assert.Equal(t, "VALUE 3", got)
+
+	got, err = replica.compute.ComputeHandler(ctx, "GET b")
+	require.NoError(t, err)
+	assert.Equal(t, "NOT_FOUND", got)
+}
+
+func TestReplicationCatchesUpInBatches(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	master, address := startMaster(t, ctx, wal.WithMaxSegmentSize(256), wal.WithBatchSize(1))
+
+	const total = replication.DefaultBatchSize + 500
+	for i := range total {
+		require.NoError(t, master.storage.Set(ctx, "key"+strconv.Itoa(i), strconv.Itoa(i)))
+	}
+
+	replica, r := startReplica(t, ctx, address)
+	require.Eventually(t, func() bool { return r.LastLSN() == total }, waitFor, syncInterval)
+
+	for _, i := range []int{0, total / 2, total - 1} {
+		got, err := replica.storage.Get(ctx, "key"+strconv.Itoa(i))
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(i), got)
+	}
+}
+
+func TestReplicationLoadsSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	master, address := startMaster(t, ctx, wal.WithMaxSegmentSize(64))
+
+	for _, line := range []string{"SET a 1", "SET b 2", "RPUSH l x y", "SET a 3"} {
+		_, err := master.compute.ComputeHandler(ctx, line)
+		require.NoError(t, err)
+	}
+	lsn, err := master.storage.Snapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), lsn)
+	_, err = master.compute.ComputeHandler(ctx, "DEL b")
+	require.NoError(t, err)
+
+	// the replica loads the snapshot in place of the truncated records, then
+	// follows the log from there
+	replica, r := startReplica(t, ctx, address)
+	require.Eventually(t, func() bool { return r.LastLSN() == 5 }, waitFor, syncInterval)
+
+	for line, want := range map[string]string{
+		"GET a":           "VALUE 3",
+		"GET b":           "NOT_FOUND",
+		`LRANGE l 0 "-1"`: `ITEMS "x" "y"`,
+	} {
+		got, err := replica.compute.ComputeHandler(ctx, line)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, line)
+	}
+}
+
+func TestReplicationLoadsSnapshotInChunks(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	master, address := startMaster(t, ctx, wal.WithMaxSegmentSize(256), wal.WithBatchSize(1))
+
+	const total = replication.DefaultBatchSize + 500
+	for i := range total {
+		require.NoError(t, master.storage.Set(ctx, "key"+strconv.Itoa(i), strconv.Itoa(i)))
+	}
+	_, err := master.storage.Snapshot(ctx)
+	require.NoError(t, err)
+	require.NoError(t, master.storage.Del(ctx, "key0"))
+
+	replica, r := startReplica(t, ctx, address)
+	require.Eventually(t, func() bool { return r.LastLSN() == total+1 }, waitFor, syncInterval)
+
+	_, err = replica.storage.Get(ctx, "key0")
+	require.ErrorIs(t, err, dberrors.ErrNotFound)
+	for _, i := range []int{1, replication.DefaultBatchSize, total - 1} {
+		got, err := replica.storage.Get(ctx, "key"+strconv.Itoa(i))
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(i), got)
+	}
+}
+
+func TestMasterServesSnapshotInChunks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	w, err := wal.New(logger, t.TempDir(), wal.WithMaxSegmentSize(256), wal.WithBatchSize(1))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	s := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+	const total = replication.DefaultBatchSize + 1
+	for i := range total {
+		require.NoError(t, s.Set(ctx, "key"+strconv.Itoa(i), "v"))
+	}
+	lsn, err := s.Snapshot(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.Set(ctx, "key", "v"))
+
+	master := replication.NewMaster(logger, w)
+	first, err := master.ComputeHandler(ctx, "SYNC 0")
+	require.NoError(t, err)
+	assert.Contains(t, first, `"next":`+strconv.Itoa(replication.DefaultBatchSize))
+
+	last, err := master.ComputeHandler(ctx, "SNAPSHOT "+strconv.FormatUint(lsn, 10)+" "+strconv.Itoa(replication.DefaultBatchSize))
+	require.NoError(t, err)
+	assert.NotContains(t, last, `"next"`)
+	assert.Contains(t, last, `"snapshot_lsn":`+strconv.FormatUint(lsn, 10))
+
+	_, err = master.ComputeHandler(ctx, "SNAPSHOT "+strconv.FormatUint(lsn+1, 10)+" 1")
+	require.ErrorIs(t, err, replication.ErrSnapshotChanged)
+}
+
+func TestReplicaRejectsWrites(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	_, address := startMaster(t, ctx)
+	replica, _ := startReplica(t, ctx, address)
+
+	for _, line := range []string{"SET k v", "DEL k"} {
+		got, err := replica.compute.ComputeHandler(ctx, line)
+		require.ErrorIs(t, err, dberrors.ErrReadOnly)
+		assert.Equal(t, dberrors.ErrReadOnly.Error(), err.Error())
+		assert.Empty(t, got)
+	}
+}
+
+func TestReplicaRetriesUntilMasterIsUp(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	logger := slogdiscard.NewDiscardLogger()
+	w, err := wal.New(logger, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	master := storage.NewStorage(logger, engine.NewEngine(logger), storage.WithWAL(w))
+	require.NoError(t, master.Set(ctx, "k", "v"))
+
+	// reserve an address, then start the replica before the master listens on it
+	probe := network.NewServer(logger, replication.NewMaster(logger, w), "127.0.0.1:0")
+	probeCtx, stopProbe := context.WithCancel(ctx)
+	_, probeErr := probe.Start(probeCtx)
+	address := probe.Addr().String()
+	stopProbe()
+	for range probeErr {
+	}
+
+	replica, r := startReplica(t, ctx, address)
+	time.Sleep(5 * syncInterval)
+	assert.Zero(t, r.LastLSN())
+
+	serverCtx, stopServer := context.WithCancel(ctx)
+	server := network.NewServer(logger, replication.NewMaster(logger, w), address)
+	_, errCh := server.Start(serverCtx)
+	t.Cleanup(func() {
+		stopServer()
+		for range errCh {
+		}
+	})
+
+	require.Eventually(t, func() bool { return r.LastLSN() == 1 }, waitFor, syncInterval)
+	got, err := replica.storage.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", got)
+}
+
+func TestMasterRejectsInvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	logger := slogdiscard.NewDiscardLogger()
+	w, err := wal.New(logger, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	master := replication.NewMaster(logger, w)
+	for _, raw := range []string{"", "SYNC", "SYNC x", "GET k", "SYNC 1 2", "SNAPSHOT 1", "SNAPSHOT 0 1", "SNAPSHOT 1 0", "SNAPSHOT x 1"} {
+		_, err := master.ComputeHandler(context.Background(), raw)
+		require.ErrorIs(t, err, replication.ErrInvalidRequest, raw)
+	}
+
+	got, err := master.ComputeHandler(context.Background(), "SYNC 0")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"records":null}`, got)
+}
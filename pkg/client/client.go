@@ -0,0 +1,598 @@
+// Package client is a Go client for the database TCP protocol: one command
+// per line, one response line per command.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/dberrors"
+	"lesson1/internal/network"
+)
+
+const (
+	DefaultPoolSize    = 4
+	DefaultDialTimeout = 3 * time.Second
+	DefaultCallTimeout = 5 * time.Second
+	DefaultMaxRetries  = 1
+
+	responseOK       = "OK"
+	responseNotSet   = "NOT_SET"
+	responseMismatch = "MISMATCH"
+	responseDeleted  = "DELETED"
+	responseNotFound = "NOT_FOUND"
+	responseValue    = "VALUE "
+	responseValues   = "VALUES"
+	responseKeys     = "KEYS"
+	responseNext     = "NEXT"
+	responseCount    = "COUNT "
+	responseCursor   = "CURSOR"
+)
+
+var (
+	// ErrNotFound is returned by Get for a missing key.
+	ErrNotFound = dberrors.ErrNotFound
+	// ErrReadOnly is returned for writes sent to a replica.
+	ErrReadOnly = dberrors.ErrReadOnly
+	// ErrOutOfMemory is returned for writes rejected by the memory limit.
+	ErrOutOfMemory = dberrors.ErrOutOfMemory
+	// ErrNotInteger and ErrNotFloat are returned for counters over values
+	// that are not numbers, ErrOverflow for results out of range.
+	ErrNotInteger = dberrors.ErrNotInteger
+	ErrNotFloat   = dberrors.ErrNotFloat
+	ErrOverflow   = dberrors.ErrOverflow
+	// ErrNotOrdered is returned for range queries against a server that does
+	// not run the ordered engine.
+	ErrNotOrdered = dberrors.ErrNotOrdered
+	// ErrInvalidCursor is returned by ScanCursor for a cursor the server did
+	// not hand out.
+	ErrInvalidCursor = dberrors.ErrInvalidCursor
+	// ErrWrongType is returned for commands of one data type, such as HSet,
+	// against a key holding another.
+	ErrWrongType = dberrors.ErrWrongType
+
+	ErrServer             = errors.New("server error")
+	ErrUnexpectedResponse = errors.New("unexpected response")
+	ErrClosed             = errors.New("client is closed")
+	ErrOddArguments       = errors.New("keys and values must come in pairs")
+)
+
+// Client is safe for concurrent use. Connections are dialled lazily, kept in
+// a bounded pool and replaced transparently when they break.
+type Client struct {
+	address     string
+	dialTimeout time.Duration
+	callTimeout time.Duration
+	maxRetries  int
+
+	pool *pool
+}
+
+type Option func(*Client)
+
+// WithPoolSize limits the number of open connections.
+func WithPoolSize(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.pool = newPool(size)
+		}
+	}
+}
+
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.dialTimeout = timeout
+		}
+	}
+}
+
+// WithCallTimeout bounds calls whose context has no deadline.
+func WithCallTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.callTimeout = timeout
+		}
+	}
+}
+
+// WithMaxRetries sets how many times a call is retried on a fresh connection
+// after a network failure. A call whose request may have reached the server
+// is only retried when all its commands just read, see roundTrip.
+func WithMaxRetries(retries int) Option {
+	return func(c *Client) {
+		if retries >= 0 {
+			c.maxRetries = retries
+		}
+	}
+}
+
+func New(address string, opts ...Option) *Client {
+	c := &Client{
+		address:     address,
+		dialTimeout: DefaultDialTimeout,
+		callTimeout: DefaultCallTimeout,
+		maxRetries:  DefaultMaxRetries,
+		pool:        newPool(DefaultPoolSize),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Client) Set(ctx context.Context, key, value string) error {
+	const op = "client.Set"
+
+	resp, err := c.Do(ctx, command.CommandSet, key, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return expect(op, resp, responseOK)
+}
+
+// SetNX stores the value only if the key does not exist, with an expiry
+// unless ttl is zero, and reports whether it did. It is the building block of
+// a lock.
+func (c *Client) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	const op = "client.SetNX"
+
+	args := []string{key, value, command.OptionNX}
+	if ttl > 0 {
+		args = append(args, command.OptionEX, strconv.FormatInt(int64((ttl+time.Second-1)/time.Second), 10))
+	}
+
+	resp, err := c.Do(ctx, command.CommandSet, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if resp == responseNotSet {
+		return false, nil
+	}
+	return true, expect(op, resp, responseOK)
+}
+
+// CompareAndSwap replaces the value of a key only if it currently equals
+// expected and reports whether it did. It returns ErrNotFound when the key
+// does not exist.
+func (c *Client) CompareAndSwap(ctx context.Context, key, expected, value string) (bool, error) {
+	const op = "client.CompareAndSwap"
+
+	resp, err := c.Do(ctx, command.CommandCAS, key, expected, value)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if resp == responseMismatch {
+		return false, nil
+	}
+	return true, expect(op, resp, responseOK)
+}
+
+// Get returns ErrNotFound when the key does not exist.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	const op = "client.Get"
+
+	resp, err := c.Do(ctx, command.CommandGet, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return parseValue(op, resp)
+}
+
+// Del deletes key; a missing key is not an error.
+func (c *Client) Del(ctx context.Context, key string) error {
+	const op = "client.Del"
+
+	resp, err := c.Do(ctx, command.CommandDel, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return expect(op, resp, responseDeleted)
+}
+
+// IncrBy atomically adds delta, which may be negative, to the integer stored
+// at key and returns the result. A missing key counts as 0.
+func (c *Client) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	const op = "client.IncrBy"
+
+	resp, err := c.Do(ctx, command.CommandIncrBy, key, strconv.FormatInt(delta, 10))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	value, err := parseValue(op, resp)
+	if err != nil {
+		return 0, err
+	}
+	result, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	return result, nil
+}
+
+// IncrByFloat atomically adds delta to the number stored at key and returns
+// the result. A missing key counts as 0.
+func (c *Client) IncrByFloat(ctx context.Context, key string, delta float64) (float64, error) {
+	const op = "client.IncrByFloat"
+
+	resp, err := c.Do(ctx, command.CommandIncrByFloat, key, strconv.FormatFloat(delta, 'f', -1, 64))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	value, err := parseValue(op, resp)
+	if err != nil {
+		return 0, err
+	}
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	return result, nil
+}
+
+// MSet stores key value pairs atomically: MSet(ctx, "k1", "v1", "k2", "v2").
+func (c *Client) MSet(ctx context.Context, keysAndValues ...string) error {
+	const op = "client.MSet"
+
+	if len(keysAndValues) == 0 || len(keysAndValues)%2 != 0 {
+		return fmt.Errorf("%s: %w", op, ErrOddArguments)
+	}
+
+	resp, err := c.Do(ctx, command.CommandMSet, keysAndValues...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return expect(op, resp, responseOK)
+}
+
+// MGet reads keys from one consistent snapshot and returns one result per
+// key; a missing key has ErrNotFound as its error.
+func (c *Client) MGet(ctx context.Context, keys ...string) ([]Result, error) {
+	const op = "client.MGet"
+
+	resp, err := c.Do(ctx, command.CommandMGet, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseValues(op, resp, len(keys))
+}
+
+// MDel deletes keys atomically and returns how many of them existed.
+func (c *Client) MDel(ctx context.Context, keys ...string) (int, error) {
+	const op = "client.MDel"
+
+	resp, err := c.Do(ctx, command.CommandMDel, keys...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	count, ok := strings.CutPrefix(resp, responseDeleted+" ")
+	if !ok {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	deleted, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	return deleted, nil
+}
+
+// Scan returns up to limit keys in [start, end) in lexicographic order, an
+// empty end being no upper bound, and the start of the next page, empty
+// after the last page. A non-positive limit uses the server's page size.
+func (c *Client) Scan(ctx context.Context, start, end string, limit int) ([]string, string, error) {
+	const op = "client.Scan"
+
+	args := []string{start, end}
+	if limit > 0 {
+		args = append(args, command.OptionLimit, strconv.Itoa(limit))
+	}
+
+	resp, err := c.Do(ctx, command.CommandScan, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	return parseKeys(op, resp)
+}
+
+// ScanCursor runs one step of a cursor walk over all keys, started with
+// cursor 0, and returns the keys of the step that match the glob pattern
+// match, every key when it is empty, and the cursor of the next step, 0 once
+// the walk is complete. A non-positive count uses the server's step size.
+func (c *Client) ScanCursor(ctx context.Context, cursor uint64, match string, count int) ([]string, uint64, error) {
+	const op = "client.ScanCursor"
+
+	args := []string{strconv.FormatUint(cursor, 10)}
+	if match != "" {
+		args = append(args, command.OptionMatch, match)
+	}
+	if count > 0 {
+		args = append(args, command.OptionCount, strconv.Itoa(count))
+	}
+
+	resp, err := c.Do(ctx, command.CommandScan, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := command.Tokenize(resp)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	if len(tokens) < 2 || tokens[0].Text != responseCursor || tokens[0].Quoted || tokens[1].Quoted {
+		return nil, 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	next, err := strconv.ParseUint(tokens[1].Text, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	keys := make([]string, 0, len(tokens)-2)
+	for _, token := range tokens[2:] {
+		if !token.Quoted {
+			return nil, 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+		}
+		keys = append(keys, token.Text)
+	}
+	return keys, next, nil
+}
+
+// Keys returns every key matching pattern, either prefix* or an exact key,
+// in lexicographic order.
+func (c *Client) Keys(ctx context.Context, pattern string) ([]string, error) {
+	const op = "client.Keys"
+
+	resp, err := c.Do(ctx, command.CommandKeys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	keys, _, err := parseKeys(op, resp)
+	return keys, err
+}
+
+// CountPrefix returns the number of keys that start with prefix.
+func (c *Client) CountPrefix(ctx context.Context, prefix string) (int, error) {
+	const op = "client.CountPrefix"
+
+	resp, err := c.Do(ctx, command.CommandCount, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	raw, ok := strings.CutPrefix(resp, responseCount)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	count, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	return count, nil
+}
+
+// Do sends one command with quoted arguments and returns its response line.
+// Error responses are converted to errors; other responses are returned
+// verbatim.
+func (c *Client) Do(ctx context.Context, name string, args ...string) (string, error) {
+	resps, err := c.roundTrip(ctx, []string{formatCommand(name, args)}, c.callTimeout)
+	if err != nil {
+		return "", err
+	}
+	return resps[0], parseError(resps[0])
+}
+
+// Pipeline returns a batch of commands sent in a single write and answered
+// in order on one connection.
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{client: c}
+}
+
+// Close closes idle connections; connections in use are closed when they
+// are returned. Calls after Close fail with ErrClosed.
+func (c *Client) Close() error {
+	c.pool.close()
+	return nil
+}
+
+// roundTrip writes all lines and reads one response per line, retrying on a
+// fresh connection when the network fails. Once any byte of the request was
+// written the server may have run it, so the request is only sent again when
+// every command in it is read-only. A context without a deadline is bounded
+// by callTimeout unless it is zero.
+func (c *Client) roundTrip(ctx context.Context, lines []string, callTimeout time.Duration) ([]string, error) {
+	if _, ok := ctx.Deadline(); !ok && callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, callTimeout)
+		defer cancel()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		conn, err := c.pool.get(ctx, c.dial)
+		if err != nil {
+			return nil, err
+		}
+
+		resps, sent, err := conn.exchange(ctx, lines)
+		if err == nil {
+			c.pool.put(conn)
+			return resps, nil
+		}
+
+		c.pool.discard(conn)
+		lastErr = err
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			// the connection deadline is the context's, it expires with it
+			<-ctx.Done()
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if sent && !readOnly(lines) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// readCommands are the commands that do not change the database, which are
+// safe to send again when their outcome is unknown.
+var readCommands = map[string]bool{
+	command.CommandGet:           true,
+	command.CommandMGet:          true,
+	command.CommandTTL:           true,
+	command.CommandStats:         true,
+	command.CommandScan:          true,
+	command.CommandKeys:          true,
+	command.CommandCount:         true,
+	command.CommandHGet:          true,
+	command.CommandHGetAll:       true,
+	command.CommandHLen:          true,
+	command.CommandHExists:       true,
+	command.CommandLRange:        true,
+	command.CommandLLen:          true,
+	command.CommandSMembers:      true,
+	command.CommandSIsMember:     true,
+	command.CommandSInter:        true,
+	command.CommandSUnion:        true,
+	command.CommandZScore:        true,
+	command.CommandZRange:        true,
+	command.CommandZRangeByScore: true,
+	command.CommandZRank:         true,
+}
+
+// readOnly reports whether every line sends a command of readCommands.
+func readOnly(lines []string) bool {
+	for _, line := range lines {
+		name, _, _ := strings.Cut(line, " ")
+		if !readCommands[name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := net.Dialer{Timeout: c.dialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(netConn), nil
+}
+
+// formatCommand quotes every argument so that keys and values may contain
+// spaces, quotes and any other byte.
+func formatCommand(name string, args []string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, arg := range args {
+		b.WriteByte(' ')
+		b.WriteString(command.Quote(arg))
+	}
+	return b.String()
+}
+
+// parseError turns an "ERROR ..." line into an error, mapping the messages of
+// well-known database errors back to their sentinels.
+func parseError(resp string) error {
+	msg, ok := strings.CutPrefix(resp, network.ErrorPrefix)
+	if !ok {
+		return nil
+	}
+
+	for _, known := range []error{ErrReadOnly, ErrOutOfMemory, ErrNotInteger, ErrNotFloat, ErrOverflow, ErrNotOrdered, ErrInvalidCursor, ErrWrongType} {
+		if msg == known.Error() {
+			return known
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrServer, msg)
+}
+
+func expect(op, resp, want string) error {
+	switch resp {
+	case want:
+		return nil
+	case responseNotFound:
+		return fmt.Errorf("%s: %w", op, ErrNotFound)
+	default:
+		return fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+}
+
+func parseValue(op, resp string) (string, error) {
+	if resp == responseNotFound {
+		return "", fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	value, ok := strings.CutPrefix(resp, responseValue)
+	if !ok {
+		return "", fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	value, err := command.Unquote(value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	return value, nil
+}
+
+// parseValues decodes "VALUES" followed by a quoted value or a bare NOT_FOUND
+// per key.
+func parseValues(op, resp string, want int) ([]Result, error) {
+	tokens, err := command.Tokenize(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	if len(tokens) != want+1 || tokens[0].Text != responseValues {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	results := make([]Result, 0, want)
+	for _, token := range tokens[1:] {
+		switch {
+		case token.Quoted:
+			results = append(results, Result{Value: token.Text})
+		case token.Text == responseNotFound:
+			results = append(results, Result{Err: ErrNotFound})
+		default:
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+		}
+	}
+	return results, nil
+}
+
+// parseKeys reads KEYS "k1" "k2" ... [NEXT "key"].
+func parseKeys(op, resp string) ([]string, string, error) {
+	tokens, err := command.Tokenize(resp)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	if len(tokens) == 0 || tokens[0].Text != responseKeys || tokens[0].Quoted {
+		return nil, "", fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	keys := make([]string, 0, len(tokens)-1)
+	for i := 1; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token.Quoted:
+			keys = append(keys, token.Text)
+		case token.Text == responseNext && i == len(tokens)-2 && tokens[i+1].Quoted:
+			return keys, tokens[i+1].Text, nil
+		default:
+			return nil, "", fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+		}
+	}
+	return keys, "", nil
+}
@@ -0,0 +1,748 @@
+package client_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"lesson1/internal/compute"
+	"lesson1/internal/database/notify"
+	"lesson1/internal/database/storage"
+	"lesson1/internal/database/storage/engine"
+	"lesson1/internal/lib/logger/slogdiscard"
+	"lesson1/internal/network"
+	"lesson1/internal/pubsub"
+	"lesson1/pkg/client"
+)
+
+// startServer runs a full in-process database on a random localhost port.
+func startServer(t *testing.T, storageOpts []storage.Option, opts ...network.Option) string {
+	t.Helper()
+
+	logger := slogdiscard.NewDiscardLogger()
+	return startServerWithEngine(t, engine.NewEngine(logger), storageOpts, opts...)
+}
+
+func startServerWithEngine(t *testing.T, eng *engine.Engine, storageOpts []storage.Option, opts ...network.Option) string {
+	t.Helper()
+
+	logger := slogdiscard.NewDiscardLogger()
+	s := storage.NewStorage(logger, eng, storageOpts...)
+	return serve(t, compute.NewCompute(logger, s), opts...)
+}
+
+func serve(t *testing.T, handler network.CommandHandler, opts ...network.Option) string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server := network.NewServer(slogdiscard.NewDiscardLogger(), handler, "127.0.0.1:0", opts...)
+	_, errCh := server.Start(ctx)
+	require.NotNil(t, server.Addr())
+	t.Cleanup(func() {
+		cancel()
+		for range errCh {
+		}
+	})
+
+	return server.Addr().String()
+}
+
+func newClient(t *testing.T, address string, opts ...client.Option) *client.Client {
+	t.Helper()
+
+	c := client.New(address, opts...)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// handlerFunc adapts a function to network.CommandHandler.
+type handlerFunc func(ctx context.Context, raw string) (string, error)
+
+func (f handlerFunc) ComputeHandler(ctx context.Context, raw string) (string, error) {
+	return f(ctx, raw)
+}
+
+func TestClientSetGetDel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	require.NoError(t, c.Set(ctx, "k", "v"))
+
+	value, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", value)
+
+	require.NoError(t, c.Del(ctx, "k"))
+
+	_, err = c.Get(ctx, "k")
+	require.ErrorIs(t, err, client.ErrNotFound)
+	// deleting a missing key succeeds
+	require.NoError(t, c.Del(ctx, "k"))
+}
+
+func TestClientArbitraryValues(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	for _, value := range []string{
+		"",
+		"hello world",
+		`{"id": 1, "tags": ["a", "b"]}`,
+		"https://example.com/path?q=1&x=2",
+		"line one\nline two\r\n",
+		`quote " and backslash \`,
+		"\x00\xff binary",
+		"значение",
+	} {
+		key := "key " + value
+		require.NoError(t, c.Set(ctx, key, value))
+
+		got, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, value, got)
+	}
+}
+
+func TestClientMultiKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	require.NoError(t, c.MSet(ctx, "a", "1", "b", "two words", "c", ""))
+	require.ErrorIs(t, c.MSet(ctx, "a"), client.ErrOddArguments)
+
+	results, err := c.MGet(ctx, "a", "missing", "b", "c")
+	require.NoError(t, err)
+	assert.Equal(t, []client.Result{
+		{Value: "1"},
+		{Err: client.ErrNotFound},
+		{Value: "two words"},
+		{Value: ""},
+	}, results)
+
+	deleted, err := c.MDel(ctx, "a", "b", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+}
+
+func TestClientConditionalWrites(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	ok, err := c.SetNX(ctx, "lock", "owner 1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.SetNX(ctx, "lock", "owner 2", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.CompareAndSwap(ctx, "lock", "owner 2", "owner 3")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.CompareAndSwap(ctx, "lock", "owner 1", "owner 3")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = c.CompareAndSwap(ctx, "missing", "a", "b")
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	value, err := c.Get(ctx, "lock")
+	require.NoError(t, err)
+	assert.Equal(t, "owner 3", value)
+}
+
+func TestClientCounters(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	n, err := c.IncrBy(ctx, "hits", 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), n)
+
+	n, err = c.IncrBy(ctx, "hits", -15)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-5), n)
+
+	f, err := c.IncrByFloat(ctx, "price", 2.5)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.5, f, 0)
+
+	f, err = c.IncrByFloat(ctx, "price", -0.25)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.25, f, 0)
+
+	require.NoError(t, c.Set(ctx, "name", "bob"))
+	_, err = c.IncrBy(ctx, "name", 1)
+	require.ErrorIs(t, err, client.ErrNotInteger)
+	_, err = c.IncrByFloat(ctx, "name", 1)
+	require.ErrorIs(t, err, client.ErrNotFloat)
+
+	require.NoError(t, c.Set(ctx, "max", "9223372036854775807"))
+	_, err = c.IncrBy(ctx, "max", 1)
+	require.ErrorIs(t, err, client.ErrOverflow)
+}
+
+func TestClientRangeQueries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	c := newClient(t, startServerWithEngine(t, engine.NewEngine(logger, engine.WithOrderedIndex()), nil))
+
+	for i := range 25 {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("user:%02d", i), "v"))
+	}
+	require.NoError(t, c.Set(ctx, "order:1", "v"))
+
+	var (
+		all   []string
+		start = "user:"
+	)
+	for start != "" {
+		keys, next, err := c.Scan(ctx, start, "user;", 10)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(keys), 10)
+		all = append(all, keys...)
+		start = next
+	}
+	require.Len(t, all, 25)
+	assert.True(t, slices.IsSorted(all))
+	assert.Equal(t, "user:00", all[0])
+
+	keys, err := c.Keys(ctx, "user:1*")
+	require.NoError(t, err)
+	assert.Len(t, keys, 10)
+
+	keys, err = c.Keys(ctx, "order:1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order:1"}, keys)
+
+	count, err := c.CountPrefix(ctx, "user:2")
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	unordered := newClient(t, startServer(t, nil))
+	_, err = unordered.CountPrefix(ctx, "user:")
+	require.ErrorIs(t, err, client.ErrNotOrdered)
+}
+
+func TestClientScanCursor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	for i := range 100 {
+		require.NoError(t, c.Set(ctx, "user:"+strconv.Itoa(i), "v"))
+		require.NoError(t, c.Set(ctx, "order:"+strconv.Itoa(i), "v"))
+	}
+
+	var (
+		users  []string
+		cursor uint64
+	)
+	for {
+		keys, next, err := c.ScanCursor(ctx, cursor, "user:*", 20)
+		require.NoError(t, err)
+		users = append(users, keys...)
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	assert.Len(t, users, 100)
+
+	_, _, err := c.ScanCursor(ctx, 1<<40, "", 0)
+	require.ErrorIs(t, err, client.ErrInvalidCursor)
+}
+
+func TestClientServerErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		opts    []storage.Option
+		call    func(c *client.Client) error
+		wantErr error
+	}{
+		{
+			name: "read only replica",
+			opts: []storage.Option{storage.WithReadOnly()},
+			call: func(c *client.Client) error {
+				return c.Set(ctx, "k", "v")
+			},
+			wantErr: client.ErrReadOnly,
+		},
+		{
+			name: "invalid command",
+			call: func(c *client.Client) error {
+				_, err := c.Do(ctx, "NOPE")
+				return err
+			},
+			wantErr: client.ErrServer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newClient(t, startServer(t, tt.opts))
+			require.ErrorIs(t, tt.call(c), tt.wantErr)
+		})
+	}
+}
+
+func TestClientPipeline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	p := c.Pipeline()
+	p.Set("a", "1")
+	p.Set("b", "2")
+	p.Get("a")
+	p.Del("b")
+	p.Get("b")
+	p.Del("missing")
+	require.Equal(t, 6, p.Len())
+
+	results, err := p.Exec(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+	assert.Zero(t, p.Len())
+
+	for _, i := range []int{0, 1, 3} {
+		require.NoError(t, results[i].Err, i)
+	}
+	require.NoError(t, results[2].Err)
+	assert.Equal(t, "1", results[2].Value)
+	require.ErrorIs(t, results[4].Err, client.ErrNotFound)
+	require.NoError(t, results[5].Err)
+
+	results, err = p.Exec(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestClientConcurrentPool(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil, network.WithMaxConnections(2)), client.WithPoolSize(2))
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			key := "k" + strconv.Itoa(i)
+			assert.NoError(t, c.Set(ctx, key, strconv.Itoa(i)))
+
+			value, err := c.Get(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(i), value)
+		})
+	}
+	wg.Wait()
+}
+
+func TestClientDeadline(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	address := serve(t, handlerFunc(func(ctx context.Context, _ string) (string, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return "OK", nil
+	}))
+	// cleanups run last in first out: unblock the handler before the server drains
+	t.Cleanup(func() { close(release) })
+	c := newClient(t, address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	require.Error(t, c.Set(ctx, "k", "v"))
+	assert.Less(t, time.Since(start), time.Second)
+
+	c = newClient(t, address, client.WithCallTimeout(50*time.Millisecond))
+	require.Error(t, c.Set(context.Background(), "k", "v"))
+}
+
+func TestClientCancel(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	address := serve(t, handlerFunc(func(_ context.Context, _ string) (string, error) {
+		<-release
+		return "OK", nil
+	}))
+	// cleanups run last in first out: unblock the handler before the server drains
+	t.Cleanup(func() { close(release) })
+	c := newClient(t, address)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	require.ErrorIs(t, c.Set(ctx, "k", "v"), context.Canceled)
+}
+
+func TestClientReconnect(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	idle := 50 * time.Millisecond
+	c := newClient(t, startServer(t, nil, network.WithIdleTimeout(idle)), client.WithPoolSize(1))
+
+	require.NoError(t, c.Set(ctx, "k", "v"))
+
+	// the server drops the pooled connection after the idle timeout
+	time.Sleep(3 * idle)
+
+	value, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", value)
+}
+
+func TestClientRetriesOnlyReads(t *testing.T) {
+	t.Parallel()
+
+	// the server reads every request and drops the connection unanswered
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			received <- strings.TrimSuffix(line, "\n")
+			_ = conn.Close()
+		}
+	}()
+
+	c := newClient(t, listener.Addr().String(), client.WithMaxRetries(2))
+	ctx := context.Background()
+
+	require.Error(t, c.Set(ctx, "k", "v"))
+	_, err = c.Get(ctx, "k")
+	require.Error(t, err)
+
+	var got []string
+	for range 4 {
+		got = append(got, <-received)
+	}
+	assert.Equal(t, []string{`SET "k" "v"`, `GET "k"`, `GET "k"`, `GET "k"`}, got)
+	assert.Empty(t, received)
+}
+
+func TestClientClosed(t *testing.T) {
+	t.Parallel()
+
+	c := client.New(startServer(t, nil))
+	require.NoError(t, c.Set(context.Background(), "k", "v"))
+	require.NoError(t, c.Close())
+
+	require.ErrorIs(t, c.Set(context.Background(), "k", "v"), client.ErrClosed)
+}
+
+func TestClientHashes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	added, err := c.HSet(ctx, "user:1", "name", "ann lee", "age", "30")
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = c.HSet(ctx, "user:1", "age", "31", "city", "paris")
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	_, err = c.HSet(ctx, "user:1", "name")
+	require.ErrorIs(t, err, client.ErrOddArguments)
+
+	name, err := c.HGet(ctx, "user:1", "name")
+	require.NoError(t, err)
+	assert.Equal(t, "ann lee", name)
+
+	_, err = c.HGet(ctx, "user:1", "missing")
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	fields, err := c.HGetAll(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "ann lee", "age": "31", "city": "paris"}, fields)
+
+	n, err := c.HLen(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	deleted, err := c.HDel(ctx, "user:1", "city", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	ok, err := c.HExists(ctx, "user:1", "city")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.HExists(ctx, "user:1", "age")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// a hash is not a string and a string is not a hash
+	_, err = c.Get(ctx, "user:1")
+	require.ErrorIs(t, err, client.ErrWrongType)
+
+	require.NoError(t, c.Set(ctx, "name", "bob"))
+	_, err = c.HSet(ctx, "name", "f", "v")
+	require.ErrorIs(t, err, client.ErrWrongType)
+
+	fields, err = c.HGetAll(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestClientLists(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	n, err := c.RPush(ctx, "jobs", "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = c.LPush(ctx, "jobs", "y", "z")
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	items, err := c.LRange(ctx, "jobs", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"z", "y", "a", "b"}, items)
+
+	value, err := c.LPop(ctx, "jobs")
+	require.NoError(t, err)
+	assert.Equal(t, "z", value)
+
+	value, err = c.RPop(ctx, "jobs")
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+
+	n, err = c.LLen(ctx, "jobs")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = c.LPop(ctx, "missing")
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	_, _, err = c.BLPop(ctx, 50*time.Millisecond, "missing")
+	require.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestClientSets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil))
+
+	added, err := c.SAdd(ctx, "a", "x", "y", "z")
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	_, err = c.SAdd(ctx, "b", "y", "z", "w")
+	require.NoError(t, err)
+
+	removed, err := c.SRem(ctx, "a", "z", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	members, err := c.SMembers(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, members)
+
+	ok, err := c.SIsMember(ctx, "a", "x")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	inter, err := c.SInter(ctx, "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"y"}, inter)
+
+	union, err := c.SUnion(ctx, "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"w", "x", "y", "z"}, union)
+
+	added, err = c.ZAdd(ctx, "board",
+		client.ScoredMember{Member: "ann", Score: 1.5},
+		client.ScoredMember{Member: "bob", Score: -2},
+		client.ScoredMember{Member: "cid", Score: 7},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	score, err := c.ZScore(ctx, "board", "ann")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.5, score, 0)
+
+	rank, err := c.ZRank(ctx, "board", "cid")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rank)
+
+	board, err := c.ZRange(ctx, "board", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []client.ScoredMember{{Member: "bob", Score: -2}, {Member: "ann", Score: 1.5}, {Member: "cid", Score: 7}}, board)
+
+	board, err = c.ZRangeByScore(ctx, "board", "(-2", "+inf", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []client.ScoredMember{{Member: "ann", Score: 1.5}}, board)
+
+	removed, err = c.ZRem(ctx, "board", "ann")
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = c.ZScore(ctx, "board", "ann")
+	require.ErrorIs(t, err, client.ErrNotFound)
+	_, err = c.ZRank(ctx, "a", "x")
+	require.ErrorIs(t, err, client.ErrWrongType)
+}
+
+func TestClientPubSub(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := newClient(t, startServer(t, nil, network.WithSessionContext(compute.NewSessionContext)))
+
+	sub, err := c.Subscribe(ctx, "news")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sub.Close() })
+
+	n, err := sub.PSubscribe(ctx, "user.*")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	receivers, err := c.Publish(ctx, "news", "hello world")
+	require.NoError(t, err)
+	assert.Equal(t, 1, receivers)
+	_, err = c.Publish(ctx, "user.1", "login")
+	require.NoError(t, err)
+
+	assert.Equal(t, client.Message{Channel: "news", Payload: "hello world"}, <-sub.Messages())
+	assert.Equal(t, client.Message{Pattern: "user.*", Channel: "user.1", Payload: "login"}, <-sub.Messages())
+
+	n, err = sub.Unsubscribe(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	receivers, err = c.Publish(ctx, "news", "ignored")
+	require.NoError(t, err)
+	assert.Zero(t, receivers)
+
+	require.NoError(t, sub.Close())
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+
+	_, err = sub.Subscribe(ctx, "news")
+	require.Error(t, err)
+}
+
+func TestClientKeyspaceNotifications(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slogdiscard.NewDiscardLogger()
+	broker := pubsub.NewBroker()
+	notifier := notify.NewNotifier(notify.WithBroker(broker))
+	notifyCtx, cancel := context.WithCancel(ctx)
+	_, errCh := notifier.Start(notifyCtx)
+	t.Cleanup(func() {
+		cancel()
+		<-errCh
+	})
+	eng := engine.NewEngine(logger, engine.WithListener(notifier.Notify))
+	handler := compute.NewCompute(logger, storage.NewStorage(logger, eng), compute.WithBroker(broker))
+	c := newClient(t, serve(t, handler, network.WithSessionContext(compute.NewSessionContext)))
+
+	sub, err := c.PSubscribe(ctx, notify.KeyspacePrefix+"*")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sub.Close() })
+
+	require.NoError(t, c.Set(ctx, "k", "v"))
+	require.NoError(t, c.Del(ctx, "k"))
+
+	pattern := notify.KeyspacePrefix + "*"
+	assert.Equal(t, client.Message{Pattern: pattern, Channel: notify.KeyspacePrefix + "k", Payload: "set"}, <-sub.Messages())
+	assert.Equal(t, client.Message{Pattern: pattern, Channel: notify.KeyspacePrefix + "k", Payload: "del"}, <-sub.Messages())
+}
+
+func TestClientBlockingPop(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	addr := startServer(t, nil)
+	worker := newClient(t, addr)
+	producer := newClient(t, addr)
+
+	type popped struct{ key, value string }
+	result := make(chan popped, 1)
+	go func() {
+		key, value, err := worker.BRPop(ctx, 0, "low", "high")
+		assert.NoError(t, err)
+		result <- popped{key, value}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	_, err := producer.LPush(ctx, "high", "job 1")
+	require.NoError(t, err)
+
+	select {
+	case got := <-result:
+		assert.Equal(t, popped{"high", "job 1"}, got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("blocked pop was not woken by the push")
+	}
+
+	// a waiter whose context is done is gone from the server and does not
+	// take the next job
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = worker.BLPop(waitCtx, 0, "queue")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	time.Sleep(50 * time.Millisecond)
+	_, err = producer.RPush(ctx, "queue", "job 2")
+	require.NoError(t, err)
+
+	n, err := producer.LLen(ctx, "queue")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"lesson1/internal/command"
+)
+
+const (
+	responseAdded  = "ADDED "
+	responseFields = "FIELDS"
+	responseExists = "EXISTS"
+)
+
+// HSet sets fields of the hash stored at key, creating it when needed, and
+// returns how many fields were added rather than updated. fieldsAndValues
+// alternates fields and their values. It returns ErrWrongType when key
+// holds a string.
+func (c *Client) HSet(ctx context.Context, key string, fieldsAndValues ...string) (int, error) {
+	const op = "client.HSet"
+
+	if len(fieldsAndValues) == 0 || len(fieldsAndValues)%2 != 0 {
+		return 0, fmt.Errorf("%s: %w", op, ErrOddArguments)
+	}
+
+	resp, err := c.Do(ctx, command.CommandHSet, append([]string{key}, fieldsAndValues...)...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseAdded)
+}
+
+// HGet returns ErrNotFound when the key or the field does not exist.
+func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
+	const op = "client.HGet"
+
+	resp, err := c.Do(ctx, command.CommandHGet, key, field)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return parseValue(op, resp)
+}
+
+// HDel removes fields from the hash stored at key and returns how many of
+// them existed. The key is deleted together with its last field.
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) (int, error) {
+	const op = "client.HDel"
+
+	resp, err := c.Do(ctx, command.CommandHDel, append([]string{key}, fields...)...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseDeleted+" ")
+}
+
+// HGetAll returns all fields of the hash stored at key, an empty map when
+// the key does not exist.
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	const op = "client.HGetAll"
+
+	resp, err := c.Do(ctx, command.CommandHGetAll, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := command.Tokenize(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	if len(tokens) == 0 || tokens[0].Text != responseFields || tokens[0].Quoted || len(tokens)%2 == 0 {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	fields := make(map[string]string, len(tokens)/2)
+	for i := 1; i < len(tokens); i += 2 {
+		if !tokens[i].Quoted || !tokens[i+1].Quoted {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+		}
+		fields[tokens[i].Text] = tokens[i+1].Text
+	}
+	return fields, nil
+}
+
+// HLen returns the number of fields of the hash stored at key, 0 when the key
+// does not exist.
+func (c *Client) HLen(ctx context.Context, key string) (int, error) {
+	const op = "client.HLen"
+
+	resp, err := c.Do(ctx, command.CommandHLen, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseCount)
+}
+
+func (c *Client) HExists(ctx context.Context, key, field string) (bool, error) {
+	const op = "client.HExists"
+
+	resp, err := c.Do(ctx, command.CommandHExists, key, field)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp {
+	case responseExists:
+		return true, nil
+	case responseNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+}
+
+// parseNumber reads the integer of a response made of prefix and a number.
+func parseNumber(op, resp, prefix string) (int, error) {
+	raw, ok := strings.CutPrefix(resp, prefix)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	return n, nil
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"lesson1/internal/command"
+)
+
+const (
+	responseItems  = "ITEMS"
+	responsePopped = "POPPED"
+)
+
+// LPush pushes values one by one to the head of the list stored at key, so
+// they end up in reverse order, and returns the length of the list.
+func (c *Client) LPush(ctx context.Context, key string, values ...string) (int, error) {
+	return c.push(ctx, "client.LPush", command.CommandLPush, key, values)
+}
+
+// RPush appends values to the tail of the list stored at key and returns the
+// length of the list.
+func (c *Client) RPush(ctx context.Context, key string, values ...string) (int, error) {
+	return c.push(ctx, "client.RPush", command.CommandRPush, key, values)
+}
+
+func (c *Client) push(ctx context.Context, op, name, key string, values []string) (int, error) {
+	resp, err := c.Do(ctx, name, append([]string{key}, values...)...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseCount)
+}
+
+// LPop removes and returns the head of the list stored at key. It returns
+// ErrNotFound when the list does not exist.
+func (c *Client) LPop(ctx context.Context, key string) (string, error) {
+	const op = "client.LPop"
+
+	resp, err := c.Do(ctx, command.CommandLPop, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return parseValue(op, resp)
+}
+
+// RPop removes and returns the tail of the list stored at key. It returns
+// ErrNotFound when the list does not exist.
+func (c *Client) RPop(ctx context.Context, key string) (string, error) {
+	const op = "client.RPop"
+
+	resp, err := c.Do(ctx, command.CommandRPop, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return parseValue(op, resp)
+}
+
+// BLPop pops the head of the first of keys that holds a list and returns the
+// key and the item. When none does it waits for a push for up to timeout, a
+// zero timeout waiting until ctx is done, and then returns ErrNotFound. The
+// call timeout only bounds the wait beyond timeout.
+func (c *Client) BLPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	return c.bpop(ctx, "client.BLPop", command.CommandBLPop, timeout, keys)
+}
+
+// BRPop is BLPop for the tails of the lists.
+func (c *Client) BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	return c.bpop(ctx, "client.BRPop", command.CommandBRPop, timeout, keys)
+}
+
+func (c *Client) bpop(ctx context.Context, op, name string, timeout time.Duration, keys []string) (string, string, error) {
+	var callTimeout time.Duration
+	if timeout > 0 {
+		callTimeout = timeout + c.callTimeout
+	}
+
+	args := append(keys[:len(keys):len(keys)], strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64))
+	resps, err := c.roundTrip(ctx, []string{formatCommand(name, args)}, callTimeout)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	resp := resps[0]
+	if err := parseError(resp); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if resp == responseNotFound {
+		return "", "", fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	tokens, err := command.Tokenize(resp)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	if len(tokens) != 3 || tokens[0].Text != responsePopped || !tokens[1].Quoted || !tokens[2].Quoted {
+		return "", "", fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	return tokens[1].Text, tokens[2].Text, nil
+}
+
+// LRange returns the items of the list stored at key from start to stop
+// inclusive; negative indexes count from the tail, -1 being the last item.
+func (c *Client) LRange(ctx context.Context, key string, start, stop int) ([]string, error) {
+	const op = "client.LRange"
+
+	resp, err := c.Do(ctx, command.CommandLRange, key, strconv.Itoa(start), strconv.Itoa(stop))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := command.Tokenize(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	if len(tokens) == 0 || tokens[0].Text != responseItems || tokens[0].Quoted {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	items := make([]string, 0, len(tokens)-1)
+	for _, token := range tokens[1:] {
+		if !token.Quoted {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+		}
+		items = append(items, token.Text)
+	}
+	return items, nil
+}
+
+// LLen returns the length of the list stored at key, 0 when the key does not
+// exist.
+func (c *Client) LLen(ctx context.Context, key string) (int, error) {
+	const op = "client.LLen"
+
+	resp, err := c.Do(ctx, command.CommandLLen, key)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseCount)
+}
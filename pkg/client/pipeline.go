@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"lesson1/internal/command"
+)
+
+// Pipeline queues commands and sends them together with Exec. A pipeline is
+// not safe for concurrent use and is not atomic: other clients' commands may
+// run between its commands.
+type Pipeline struct {
+	client   *Client
+	lines    []string
+	decoders []func(resp string) Result
+}
+
+// Result is the outcome of one pipelined command.
+type Result struct {
+	Value string
+	Err   error
+}
+
+func (p *Pipeline) Set(key, value string) {
+	p.queue(formatCommand(command.CommandSet, []string{key, value}), func(resp string) Result {
+		return Result{Err: expect("client.Pipeline.Set", resp, responseOK)}
+	})
+}
+
+func (p *Pipeline) Get(key string) {
+	p.queue(formatCommand(command.CommandGet, []string{key}), func(resp string) Result {
+		value, err := parseValue("client.Pipeline.Get", resp)
+		return Result{Value: value, Err: err}
+	})
+}
+
+func (p *Pipeline) Del(key string) {
+	p.queue(formatCommand(command.CommandDel, []string{key}), func(resp string) Result {
+		return Result{Err: expect("client.Pipeline.Del", resp, responseDeleted)}
+	})
+}
+
+// Len returns the number of queued commands.
+func (p *Pipeline) Len() int {
+	return len(p.lines)
+}
+
+// Exec sends the queued commands and returns one result per command in
+// queue order. The returned error reports transport failures only; command
+// failures are reported in the results. The pipeline is reset afterwards.
+func (p *Pipeline) Exec(ctx context.Context) ([]Result, error) {
+	const op = "client.Pipeline.Exec"
+
+	lines, decoders := p.lines, p.decoders
+	p.lines, p.decoders = nil, nil
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	resps, err := p.client.roundTrip(ctx, lines, p.client.callTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	results := make([]Result, len(resps))
+	for i, resp := range resps {
+		if err := parseError(resp); err != nil {
+			results[i] = Result{Err: err}
+			continue
+		}
+		results[i] = decoders[i](resp)
+	}
+	return results, nil
+}
+
+func (p *Pipeline) queue(line string, decode func(resp string) Result) {
+	p.lines = append(p.lines, line)
+	p.decoders = append(p.decoders, decode)
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pool hands out at most size connections. Idle connections are reused in
+// LIFO order; a caller that finds neither an idle connection nor a free slot
+// waits until one is returned or its context is done.
+type pool struct {
+	slots chan struct{}
+	idle  chan *conn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newPool(size int) *pool {
+	return &pool{
+		slots: make(chan struct{}, size),
+		idle:  make(chan *conn, size),
+	}
+}
+
+func (p *pool) get(ctx context.Context, dial func(context.Context) (*conn, error)) (*conn, error) {
+	if p.isClosed() {
+		return nil, ErrClosed
+	}
+
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+	}
+
+	select {
+	case c := <-p.idle:
+		return c, nil
+	case p.slots <- struct{}{}:
+		c, err := dial(ctx)
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *pool) put(c *conn) {
+	if p.isClosed() {
+		p.discard(c)
+		return
+	}
+	p.idle <- c
+}
+
+// discard closes a connection and frees its slot for a new dial.
+func (p *pool) discard(c *conn) {
+	_ = c.netConn.Close()
+	<-p.slots
+}
+
+func (p *pool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	for {
+		select {
+		case c := <-p.idle:
+			p.discard(c)
+		default:
+			return
+		}
+	}
+}
+
+func (p *pool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.closed
+}
+
+type conn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+}
+
+func newConn(netConn net.Conn) *conn {
+	return &conn{
+		netConn: netConn,
+		reader:  bufio.NewReader(netConn),
+	}
+}
+
+// exchange writes every line in one write and reads as many responses. The
+// context deadline bounds the whole exchange and cancelling the context
+// interrupts it; the connection must be discarded after any error. sent
+// tells whether any byte of the request may have reached the server.
+func (c *conn) exchange(ctx context.Context, lines []string) (resps []string, sent bool, err error) {
+	deadline, _ := ctx.Deadline()
+	if err := c.netConn.SetDeadline(deadline); err != nil {
+		return nil, false, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = c.netConn.SetDeadline(time.Now()) })
+	defer stop()
+
+	var request strings.Builder
+	for _, line := range lines {
+		request.WriteString(line)
+		request.WriteByte('\n')
+	}
+	if n, err := c.netConn.Write([]byte(request.String())); err != nil {
+		return nil, n > 0, err
+	}
+
+	resps = make([]string, 0, len(lines))
+	for range lines {
+		resp, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, true, err
+		}
+		resps = append(resps, strings.TrimSuffix(resp, "\n"))
+	}
+	return resps, true, nil
+}
@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"lesson1/internal/command"
+)
+
+const (
+	responseReceivers    = "RECEIVERS "
+	responseSubscribed   = "SUBSCRIBED "
+	responseUnsubscribed = "UNSUBSCRIBED "
+
+	pushMessage        = "MESSAGE"
+	pushPatternMessage = "PMESSAGE"
+
+	// subscriptionBuffer is how many messages a Subscription reads ahead of
+	// its consumer.
+	subscriptionBuffer = 64
+)
+
+// Publish sends message to the subscribers of channel and returns how many
+// of them received it.
+func (c *Client) Publish(ctx context.Context, channel, message string) (int, error) {
+	const op = "client.Publish"
+
+	resp, err := c.Do(ctx, command.CommandPublish, channel, message)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseReceivers)
+}
+
+// Message is a message received by a Subscription. Pattern is the pattern
+// that matched Channel, empty for a channel subscription.
+type Message struct {
+	Pattern string
+	Channel string
+	Payload string
+}
+
+// Subscription receives the messages published to its channels and
+// patterns on a connection of its own, outside of the pool. Its methods are
+// safe for concurrent use. Messages must be drained: the subscription
+// changes are answered in line with them, and a subscriber that falls
+// behind is dropped by the server.
+type Subscription struct {
+	conn        *conn
+	callTimeout time.Duration
+
+	messages chan Message
+	replies  chan string
+	closing  chan struct{}
+	done     chan struct{}
+
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+// Subscribe opens a Subscription to channels.
+func (c *Client) Subscribe(ctx context.Context, channels ...string) (*Subscription, error) {
+	return c.subscribe(ctx, "client.Subscribe", command.CommandSubscribe, channels)
+}
+
+// PSubscribe opens a Subscription to glob patterns of channels.
+func (c *Client) PSubscribe(ctx context.Context, patterns ...string) (*Subscription, error) {
+	return c.subscribe(ctx, "client.PSubscribe", command.CommandPSubscribe, patterns)
+}
+
+func (c *Client) subscribe(ctx context.Context, op, name string, names []string) (*Subscription, error) {
+	if c.pool.isClosed() {
+		return nil, fmt.Errorf("%s: %w", op, ErrClosed)
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s := &Subscription{
+		conn:        conn,
+		callTimeout: c.callTimeout,
+		messages:    make(chan Message, subscriptionBuffer),
+		replies:     make(chan string, 1),
+		closing:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go s.read()
+
+	if _, err := s.change(ctx, op, name, responseSubscribed, names); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Messages returns the received messages. The channel is closed once the
+// subscription is closed or its connection is lost.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Subscribe adds channels and returns the number of channels and patterns
+// subscribed to.
+func (s *Subscription) Subscribe(ctx context.Context, channels ...string) (int, error) {
+	return s.change(ctx, "client.Subscription.Subscribe", command.CommandSubscribe, responseSubscribed, channels)
+}
+
+func (s *Subscription) PSubscribe(ctx context.Context, patterns ...string) (int, error) {
+	return s.change(ctx, "client.Subscription.PSubscribe", command.CommandPSubscribe, responseSubscribed, patterns)
+}
+
+// Unsubscribe removes channels, all of them when none is given, and returns
+// the number of channels and patterns still subscribed to. The connection
+// stays open for later subscriptions.
+func (s *Subscription) Unsubscribe(ctx context.Context, channels ...string) (int, error) {
+	return s.change(ctx, "client.Subscription.Unsubscribe", command.CommandUnsubscribe, responseUnsubscribed, channels)
+}
+
+func (s *Subscription) PUnsubscribe(ctx context.Context, patterns ...string) (int, error) {
+	return s.change(ctx, "client.Subscription.PUnsubscribe", command.CommandPUnsubscribe, responseUnsubscribed, patterns)
+}
+
+// Close closes the connection and the Messages channel.
+func (s *Subscription) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		_ = s.conn.netConn.Close()
+	})
+	<-s.done
+	return nil
+}
+
+// change sends a subscription command and waits for its reply among the
+// messages. A failed exchange closes the subscription, since a late reply
+// would be taken for the reply of the next command.
+func (s *Subscription) change(ctx context.Context, op, name, prefix string, names []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := ctx.Deadline(); !ok && s.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.callTimeout)
+		defer cancel()
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := s.conn.netConn.SetWriteDeadline(deadline); err != nil {
+		_ = s.Close()
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := s.conn.netConn.Write([]byte(formatCommand(name, names) + "\n")); err != nil {
+		_ = s.Close()
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	select {
+	case resp, ok := <-s.replies:
+		if !ok {
+			return 0, fmt.Errorf("%s: %w", op, ErrClosed)
+		}
+		if err := parseError(resp); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return parseNumber(op, resp, prefix)
+	case <-ctx.Done():
+		_ = s.Close()
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
+
+// read sorts the lines of the connection into messages and replies until
+// the connection ends.
+func (s *Subscription) read() {
+	defer close(s.done)
+	defer close(s.replies)
+	defer close(s.messages)
+
+	for {
+		line, err := s.conn.reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		msg, ok := parseMessage(line)
+		if !ok {
+			// a line nobody waits for, such as the error the server sends
+			// before dropping a slow subscriber, is ignored
+			select {
+			case s.replies <- line:
+			default:
+			}
+			continue
+		}
+
+		select {
+		case s.messages <- msg:
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// parseMessage decodes MESSAGE "channel" "payload" and PMESSAGE "pattern"
+// "channel" "payload".
+func parseMessage(line string) (Message, bool) {
+	if !strings.HasPrefix(line, pushMessage) && !strings.HasPrefix(line, pushPatternMessage) {
+		return Message{}, false
+	}
+
+	tokens, err := command.Tokenize(line)
+	if err != nil || len(tokens) == 0 || tokens[0].Quoted {
+		return Message{}, false
+	}
+	for _, token := range tokens[1:] {
+		if !token.Quoted {
+			return Message{}, false
+		}
+	}
+
+	switch {
+	case tokens[0].Text == pushMessage && len(tokens) == 3:
+		return Message{Channel: tokens[1].Text, Payload: tokens[2].Text}, true
+	case tokens[0].Text == pushPatternMessage && len(tokens) == 4:
+		return Message{Pattern: tokens[1].Text, Channel: tokens[2].Text, Payload: tokens[3].Text}, true
+	default:
+		return Message{}, false
+	}
+}
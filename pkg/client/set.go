@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"lesson1/internal/command"
+	"lesson1/internal/database/kv"
+)
+
+const (
+	responseMembers = "MEMBERS"
+	responseScore   = "SCORE "
+	responseRank    = "RANK "
+)
+
+// ScoredMember is a member of a sorted set with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// SAdd adds members to the set stored at key, creating it when needed, and
+// returns how many of them were new.
+func (c *Client) SAdd(ctx context.Context, key string, members ...string) (int, error) {
+	const op = "client.SAdd"
+
+	resp, err := c.Do(ctx, command.CommandSAdd, append([]string{key}, members...)...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseAdded)
+}
+
+// SRem removes members from the set stored at key and returns how many of
+// them existed. The key is deleted together with its last member.
+func (c *Client) SRem(ctx context.Context, key string, members ...string) (int, error) {
+	const op = "client.SRem"
+
+	resp, err := c.Do(ctx, command.CommandSRem, append([]string{key}, members...)...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseDeleted+" ")
+}
+
+// SMembers returns the members of the set stored at key in lexicographic
+// order, none when the key does not exist.
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	const op = "client.SMembers"
+
+	resp, err := c.Do(ctx, command.CommandSMembers, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseMembers(op, resp)
+}
+
+func (c *Client) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	const op = "client.SIsMember"
+
+	resp, err := c.Do(ctx, command.CommandSIsMember, key, member)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp {
+	case responseExists:
+		return true, nil
+	case responseNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+}
+
+// SInter returns the members present in all sets stored at keys. A missing
+// key counts as an empty set.
+func (c *Client) SInter(ctx context.Context, keys ...string) ([]string, error) {
+	const op = "client.SInter"
+
+	resp, err := c.Do(ctx, command.CommandSInter, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseMembers(op, resp)
+}
+
+// SUnion returns the members present in any set stored at keys.
+func (c *Client) SUnion(ctx context.Context, keys ...string) ([]string, error) {
+	const op = "client.SUnion"
+
+	resp, err := c.Do(ctx, command.CommandSUnion, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseMembers(op, resp)
+}
+
+// ZAdd adds members to the sorted set stored at key, updating the score of
+// those already present, and returns how many of them were new.
+func (c *Client) ZAdd(ctx context.Context, key string, members ...ScoredMember) (int, error) {
+	const op = "client.ZAdd"
+
+	args := make([]string, 0, 1+2*len(members))
+	args = append(args, key)
+	for _, m := range members {
+		args = append(args, kv.FormatScore(m.Score), m.Member)
+	}
+
+	resp, err := c.Do(ctx, command.CommandZAdd, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseAdded)
+}
+
+// ZRem removes members from the sorted set stored at key and returns how
+// many of them existed.
+func (c *Client) ZRem(ctx context.Context, key string, members ...string) (int, error) {
+	const op = "client.ZRem"
+
+	resp, err := c.Do(ctx, command.CommandZRem, append([]string{key}, members...)...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseNumber(op, resp, responseDeleted+" ")
+}
+
+// ZScore returns ErrNotFound when the key or the member does not exist.
+func (c *Client) ZScore(ctx context.Context, key, member string) (float64, error) {
+	const op = "client.ZScore"
+
+	resp, err := c.Do(ctx, command.CommandZScore, key, member)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if resp == responseNotFound {
+		return 0, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	raw, ok := strings.CutPrefix(resp, responseScore)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	score, err := kv.ParseScore(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+	return score, nil
+}
+
+// ZRank returns the position of member in the sorted set stored at key,
+// counting from 0 at the lowest score. It returns ErrNotFound when the key or
+// the member does not exist.
+func (c *Client) ZRank(ctx context.Context, key, member string) (int, error) {
+	const op = "client.ZRank"
+
+	resp, err := c.Do(ctx, command.CommandZRank, key, member)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if resp == responseNotFound {
+		return 0, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+	return parseNumber(op, resp, responseRank)
+}
+
+// ZRange returns the members of the sorted set stored at key ranked from
+// start to stop inclusive, with their scores; negative ranks count from the
+// highest score, -1 being the last member.
+func (c *Client) ZRange(ctx context.Context, key string, start, stop int) ([]ScoredMember, error) {
+	const op = "client.ZRange"
+
+	resp, err := c.Do(ctx, command.CommandZRange, key, strconv.Itoa(start), strconv.Itoa(stop), command.OptionWithScores)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseScoredMembers(op, resp)
+}
+
+// ZRangeByScore returns the members of the sorted set stored at key with a
+// score between min and max, lowest first. Bounds are inclusive unless
+// prefixed with "(", and "-inf" and "+inf" leave a side unbounded. A positive
+// limit caps the number of members returned.
+func (c *Client) ZRangeByScore(ctx context.Context, key, min, max string, limit int) ([]ScoredMember, error) {
+	const op = "client.ZRangeByScore"
+
+	args := []string{key, min, max, command.OptionWithScores}
+	if limit > 0 {
+		args = append(args, command.OptionLimit, strconv.Itoa(limit))
+	}
+
+	resp, err := c.Do(ctx, command.CommandZRangeByScore, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return parseScoredMembers(op, resp)
+}
+
+func parseMembers(op, resp string) ([]string, error) {
+	tokens, err := command.Tokenize(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrUnexpectedResponse, err)
+	}
+	if len(tokens) == 0 || tokens[0].Text != responseMembers || tokens[0].Quoted {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	members := make([]string, 0, len(tokens)-1)
+	for _, token := range tokens[1:] {
+		if !token.Quoted {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+		}
+		members = append(members, token.Text)
+	}
+	return members, nil
+}
+
+func parseScoredMembers(op, resp string) ([]ScoredMember, error) {
+	raw, err := parseMembers(op, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw)%2 != 0 {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+	}
+
+	members := make([]ScoredMember, 0, len(raw)/2)
+	for i := 0; i < len(raw); i += 2 {
+		score, err := kv.ParseScore(raw[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrUnexpectedResponse, resp)
+		}
+		members = append(members, ScoredMember{Member: raw[i], Score: score})
+	}
+	return members, nil
+}